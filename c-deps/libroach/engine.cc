@@ -13,6 +13,7 @@
 // permissions and limitations under the License.
 
 #include "engine.h"
+#include <cstdlib>
 #include "db.h"
 #include "encoding.h"
 #include "fmt.h"
@@ -48,6 +49,7 @@ DBSSTable* DBEngine::GetSSTables(int* n) {
       DBString str = ToDBString(tmp);
       tables[i].end_key.key = DBSlice{str.data, str.len};
     }
+    tables[i].name = ToDBString(metadata[i].name);
   }
   return tables;
 }
@@ -169,6 +171,9 @@ DBStatus DBImpl::GetStats(DBStatsResult* stats) {
   rep->GetIntProperty("rocksdb.estimate-pending-compaction-bytes",
                       &pending_compaction_bytes_estimate);
 
+  std::string l0_file_count;
+  rep->GetProperty("rocksdb.num-files-at-level0", &l0_file_count);
+
   stats->block_cache_hits = (int64_t)s->getTickerCount(rocksdb::BLOCK_CACHE_HIT);
   stats->block_cache_misses = (int64_t)s->getTickerCount(rocksdb::BLOCK_CACHE_MISS);
   stats->block_cache_usage = (int64_t)block_cache->GetUsage();
@@ -182,6 +187,7 @@ DBStatus DBImpl::GetStats(DBStatsResult* stats) {
   stats->compactions = (int64_t)event_listener->GetCompactions();
   stats->table_readers_mem_estimate = table_readers_mem_estimate;
   stats->pending_compaction_bytes_estimate = pending_compaction_bytes_estimate;
+  stats->l0_file_count = std::atoi(l0_file_count.c_str());
   return kSuccess;
 }
 
@@ -277,6 +277,7 @@ typedef struct {
   int64_t compactions;
   int64_t table_readers_mem_estimate;
   int64_t pending_compaction_bytes_estimate;
+  int64_t l0_file_count;
 } DBStatsResult;
 
 DBStatus DBGetStats(DBEngine* db, DBStatsResult* stats);
@@ -287,11 +288,12 @@ typedef struct {
   uint64_t size;
   DBKey start_key;
   DBKey end_key;
+  DBString name;
 } DBSSTable;
 
 // Retrieve stats about all of the live sstables. Note that the tables
-// array must be freed along with the start_key and end_key of each
-// table.
+// array must be freed along with the start_key, end_key and name of
+// each table.
 DBSSTable* DBGetSSTables(DBEngine* db, int* n);
 
 // DBGetUserProperties fetches the user properties stored in each sstable's
@@ -79,9 +79,11 @@ type TestServerArgs struct {
 	ScanInterval             time.Duration
 	ScanMaxIdleTime          time.Duration
 	SSLCertsDir              string
+	SSLCAKey                 string
 	TimeSeriesQueryWorkerMax int
 	SQLMemoryPoolSize        int64
 	ListeningURLFile         string
+	SettingsFile             string
 
 	// If set, this will be appended to the Postgres URL by functions that
 	// automatically open a connection to the server. That's equivalent to running
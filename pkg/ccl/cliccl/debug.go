@@ -0,0 +1,105 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package cliccl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/cli"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+func init() {
+	encryptionStatusCmd := &cobra.Command{
+		Use:   "encryption-status <directory>",
+		Short: "show encryption status of a store",
+		Long: `
+Shows the encryption status of the store located in <directory>: the store
+keys and data keys known to its key registry, which of them are active, and
+the number of files and bytes written before the active data key was created.
+Those files are rewritten in the background with the active data key.
+
+Raw keys are never displayed.
+`,
+		RunE: cli.MaybeDecorateGRPCError(runEncryptionStatus),
+	}
+	cli.AddDebugCmd(encryptionStatusCmd)
+}
+
+func runEncryptionStatus(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("one argument required: dir")
+	}
+	status, err := engineccl.ReadEncryptionStatus(args[0])
+	if err != nil {
+		return err
+	}
+	return printEncryptionStatus(os.Stdout, status)
+}
+
+func printEncryptionStatus(w io.Writer, status *enginepbccl.EncryptionStatus) error {
+	fmt.Fprintf(w, "Active store key: %s\n", formatKeyID(status.ActiveStoreKey))
+	fmt.Fprintf(w, "Active data key: %s\n", formatKeyID(status.ActiveDataKey))
+
+	fmt.Fprintf(w, "\nStore keys:\n")
+	tw := tabwriter.NewWriter(w, 2, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "  ID\tType\tCreated\tSource\n")
+	for _, key := range status.StoreKeys {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n",
+			key.KeyId, key.EncryptionType, formatCreationTime(key.CreationTime), key.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nData keys:\n")
+	tw = tabwriter.NewWriter(w, 2, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "  ID\tType\tCreated\tParent\tExposed\n")
+	for _, key := range status.DataKeys {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%t\n",
+			key.KeyId, key.EncryptionType, formatCreationTime(key.CreationTime),
+			key.ParentKeyId, key.WasExposed)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nTotal: %d files, %s\n", status.TotalFiles, humanizeutil.IBytes(status.TotalBytes))
+	var fraction float64
+	if status.TotalBytes > 0 {
+		fraction = float64(status.StaleBytes) / float64(status.TotalBytes)
+	}
+	fmt.Fprintf(w, "Written before the active data key: %d files, %s (%.2f%%)\n",
+		status.StaleFiles, humanizeutil.IBytes(status.StaleBytes), 100*fraction)
+	return nil
+}
+
+func formatKeyID(key *enginepbccl.KeyInfo) string {
+	if key == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%s (%s)", key.KeyId, key.EncryptionType)
+}
+
+func formatCreationTime(secs int64) string {
+	if secs == 0 {
+		return ""
+	}
+	return timeutil.Unix(secs, 0).Format(time.RFC3339)
+}
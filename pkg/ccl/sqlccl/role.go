@@ -35,7 +35,26 @@ func createRolePlanHook(
 	}
 
 	// Call directly into the OSS code.
-	return p.CreateUserNode(ctx, createRole.Name, nil /* password */, createRole.IfNotExists, true /* isRole */, "CREATE ROLE")
+	return p.CreateUserNode(ctx, createRole.Name, createRole.Options, createRole.IfNotExists, true /* isRole */, "CREATE ROLE")
+}
+
+func alterRolePlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanNode, error) {
+	alterRole, ok := stmt.(*tree.AlterRole)
+	if !ok || !alterRole.IsRole {
+		return nil, nil
+	}
+
+	cfg := p.ExecCfg()
+	if err := utilccl.CheckEnterpriseEnabled(
+		cfg.Settings, cfg.ClusterID(), cfg.Organization(), "ALTER ROLE",
+	); err != nil {
+		return nil, err
+	}
+
+	// Call directly into the OSS code.
+	return p.AlterRoleNode(ctx, alterRole.Name, alterRole.Options, alterRole.IfExists, true /* isRole */, "ALTER ROLE")
 }
 
 func dropRolePlanHook(
@@ -72,12 +91,20 @@ func grantRolePlanHook(
 	}
 
 	if err := p.RequireSuperUser("grant role"); err != nil {
-		// Not a superuser: check permissions on each role.
+		// Not a superuser: check permissions on each role. The CREATEROLE role
+		// option allows managing the members of any role but admin.
+		hasCreateRole, err := p.HasRoleOption(ctx, tree.RoleOptCreateRole)
+		if err != nil {
+			return nil, err
+		}
 		allRoles, err := p.MemberOfWithAdminOption(ctx, p.User())
 		if err != nil {
 			return nil, err
 		}
 		for _, r := range grant.Roles {
+			if hasCreateRole && string(r) != sqlbase.AdminRole {
+				continue
+			}
 			if isAdmin, ok := allRoles[string(r)]; !ok || !isAdmin {
 				return nil, pgerror.NewErrorf(pgerror.CodeInsufficientPrivilegeError,
 					"%s is not a superuser or role admin for role %s", p.User(), r)
@@ -191,12 +218,20 @@ func revokeRolePlanHook(
 	}
 
 	if err := p.RequireSuperUser("revoke role"); err != nil {
-		// Not a superuser: check permissions on each role.
+		// Not a superuser: check permissions on each role. The CREATEROLE role
+		// option allows managing the members of any role but admin.
+		hasCreateRole, err := p.HasRoleOption(ctx, tree.RoleOptCreateRole)
+		if err != nil {
+			return nil, err
+		}
 		allRoles, err := p.MemberOfWithAdminOption(ctx, p.User())
 		if err != nil {
 			return nil, err
 		}
 		for _, r := range revoke.Roles {
+			if hasCreateRole && string(r) != sqlbase.AdminRole {
+				continue
+			}
 			if isAdmin, ok := allRoles[string(r)]; !ok || !isAdmin {
 				return nil, pgerror.NewErrorf(pgerror.CodeInsufficientPrivilegeError,
 					"%s is not a superuser or role admin for role %s", p.User(), r)
@@ -262,6 +297,7 @@ func revokeRolePlanHook(
 
 func init() {
 	sql.AddWrappedPlanHook(createRolePlanHook)
+	sql.AddWrappedPlanHook(alterRolePlanHook)
 	sql.AddWrappedPlanHook(dropRolePlanHook)
 	sql.AddWrappedPlanHook(grantRolePlanHook)
 	sql.AddWrappedPlanHook(revokeRolePlanHook)
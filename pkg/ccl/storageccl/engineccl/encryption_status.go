@@ -0,0 +1,140 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package engineccl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// KeyRegistryFilename is the name of the file in the store directory holding
+// the data keys registry. It must match kKeyRegistryFilename in
+// c-deps/libroach/ccl/key_manager.h.
+const KeyRegistryFilename = "COCKROACHDB_DATA_KEYS"
+
+func init() {
+	engine.EncryptionStatusHook = func(dir string) ([]byte, error) {
+		status, err := ReadEncryptionStatus(dir)
+		if err != nil {
+			return nil, err
+		}
+		return protoutil.Marshal(status)
+	}
+}
+
+// ReadEncryptionStatus builds the encryption status of the store in dir from
+// its data keys registry and the files in dir. The returned status never
+// includes raw keys.
+//
+// The store does not record which data key each file was written with, so the
+// status only tells apart the sstables written before the active data key was
+// created, which ReencryptStaleFiles rewrites.
+func ReadEncryptionStatus(dir string) (*enginepbccl.EncryptionStatus, error) {
+	registry, err := readKeyRegistry(filepath.Join(dir, KeyRegistryFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &enginepbccl.EncryptionStatus{}
+	for _, info := range registry.StoreKeys {
+		status.StoreKeys = append(status.StoreKeys, info)
+	}
+	for _, key := range registry.DataKeys {
+		if key.Info != nil {
+			status.DataKeys = append(status.DataKeys, key.Info)
+		}
+	}
+	sortKeyInfos(status.StoreKeys)
+	sortKeyInfos(status.DataKeys)
+	if registry.ActiveStoreKey != "" {
+		status.ActiveStoreKey = registry.StoreKeys[registry.ActiveStoreKey]
+	}
+	status.ActiveDataKey = activeDataKey(registry)
+
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Files may be removed by RocksDB out from under us.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() || info.Name() == KeyRegistryFilename {
+			return nil
+		}
+		status.TotalFiles++
+		status.TotalBytes += info.Size()
+		if filepath.Dir(path) == filepath.Clean(dir) && isStaleSSTable(info, status.ActiveDataKey) {
+			status.StaleFiles++
+			status.StaleBytes += info.Size()
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// activeDataKey returns the information about the active data key of the
+// registry, or nil if there is none.
+func activeDataKey(registry *enginepbccl.DataKeysRegistry) *enginepbccl.KeyInfo {
+	if registry.ActiveDataKey == "" {
+		return nil
+	}
+	if key := registry.DataKeys[registry.ActiveDataKey]; key != nil {
+		return key.Info
+	}
+	return nil
+}
+
+// isStaleSSTable returns whether the file is an sstable written before the
+// active data key was created. Sstables are never modified once written, so
+// such a file cannot be encrypted with the active key.
+func isStaleSSTable(info os.FileInfo, activeKey *enginepbccl.KeyInfo) bool {
+	if activeKey == nil || filepath.Ext(info.Name()) != ".sst" {
+		return false
+	}
+	return info.ModTime().Before(timeutil.Unix(activeKey.CreationTime, 0))
+}
+
+// readKeyRegistry reads the data keys registry at path. A missing registry is
+// not an error: it means encryption was never enabled on the store.
+func readKeyRegistry(path string) (*enginepbccl.DataKeysRegistry, error) {
+	registry := &enginepbccl.DataKeysRegistry{}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, err
+	}
+	if err := protoutil.Unmarshal(contents, registry); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse registry %s", path)
+	}
+	return registry, nil
+}
+
+// sortKeyInfos sorts keys by creation time, oldest first, breaking ties by ID.
+func sortKeyInfos(keys []*enginepbccl.KeyInfo) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreationTime != keys[j].CreationTime {
+			return keys[i].CreationTime < keys[j].CreationTime
+		}
+		return keys[i].KeyId < keys[j].KeyId
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package engineccl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+func TestReadEncryptionStatus(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	// A store without a key registry has no keys.
+	if err := ioutil.WriteFile(filepath.Join(dir, "000001.sst"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status, err := ReadEncryptionStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ActiveStoreKey != nil || status.ActiveDataKey != nil ||
+		len(status.StoreKeys) != 0 || len(status.DataKeys) != 0 {
+		t.Fatalf("expected no keys, got %+v", status)
+	}
+	if status.TotalFiles != 1 || status.TotalBytes != 100 {
+		t.Fatalf("expected 1 file of 100 bytes, got %d files of %d bytes",
+			status.TotalFiles, status.TotalBytes)
+	}
+
+	storeKey1 := &enginepbccl.KeyInfo{
+		EncryptionType: enginepbccl.EncryptionType_AES128_CTR, KeyId: "store1", CreationTime: 1,
+	}
+	storeKey2 := &enginepbccl.KeyInfo{
+		EncryptionType: enginepbccl.EncryptionType_AES256_CTR, KeyId: "store2", CreationTime: 2,
+	}
+	dataKey1 := &enginepbccl.KeyInfo{
+		EncryptionType: enginepbccl.EncryptionType_AES128_CTR, KeyId: "data1", CreationTime: 1,
+		ParentKeyId: "store1",
+	}
+	// The active data key was created after some of the files were written.
+	now := timeutil.Now()
+	dataKey2 := &enginepbccl.KeyInfo{
+		EncryptionType: enginepbccl.EncryptionType_AES256_CTR, KeyId: "data2",
+		CreationTime: now.Unix(), ParentKeyId: "store2",
+	}
+	registry := &enginepbccl.DataKeysRegistry{
+		StoreKeys: map[string]*enginepbccl.KeyInfo{"store1": storeKey1, "store2": storeKey2},
+		DataKeys: map[string]*enginepbccl.SecretKey{
+			"data1": {Info: dataKey1, Key: []byte("secret1")},
+			"data2": {Info: dataKey2, Key: []byte("secret2")},
+		},
+		ActiveStoreKey: "store2",
+		ActiveDataKey:  "data2",
+	}
+	contents, err := protoutil.Marshal(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, KeyRegistryFilename), contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first two sstables, and a file which isn't an sstable, were written
+	// before the active data key was created.
+	for name, size := range map[string]int{
+		"000002.sst": 200, "000003.sst": 300, "000004.sst": 400, "OPTIONS-000005": 500,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := now.Add(-time.Hour)
+	for _, name := range []string{"000001.sst", "000002.sst", "OPTIONS-000005"} {
+		if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err = ReadEncryptionStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ActiveStoreKey.KeyId != "store2" || status.ActiveDataKey.KeyId != "data2" {
+		t.Fatalf("unexpected active keys: store=%s, data=%s",
+			status.ActiveStoreKey.KeyId, status.ActiveDataKey.KeyId)
+	}
+	if len(status.StoreKeys) != 2 || status.StoreKeys[0].KeyId != "store1" ||
+		status.StoreKeys[1].KeyId != "store2" {
+		t.Fatalf("unexpected store keys: %+v", status.StoreKeys)
+	}
+	if len(status.DataKeys) != 2 || status.DataKeys[0].KeyId != "data1" ||
+		status.DataKeys[1].KeyId != "data2" {
+		t.Fatalf("unexpected data keys: %+v", status.DataKeys)
+	}
+	// The registry itself is not counted.
+	if status.TotalFiles != 5 || status.TotalBytes != 1500 {
+		t.Fatalf("expected 5 files of 1500 bytes, got %d files of %d bytes",
+			status.TotalFiles, status.TotalBytes)
+	}
+	if status.StaleFiles != 2 || status.StaleBytes != 300 {
+		t.Fatalf("expected 2 stale files of 300 bytes, got %d files of %d bytes",
+			status.StaleFiles, status.StaleBytes)
+	}
+	// Raw keys must never make it into the status.
+	statusBytes, err := protoutil.Marshal(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(statusBytes, []byte("secret")) {
+		t.Fatalf("encryption status contains raw keys")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package engineccl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func init() {
+	engine.ReencryptHook = ReencryptStaleFiles
+}
+
+// ReencryptStaleFiles rewrites the sstables of the engine, whose store is in
+// dir, which were written before the active data key was created. RocksDB only
+// rewrites files when it compacts them, so after a key rotation, data that is
+// rarely written would otherwise stay encrypted with the old key forever.
+// Each stale sstable is rewritten by compacting its key span, which writes new
+// files with the active key. It returns the number of sstables rewritten.
+func ReencryptStaleFiles(ctx context.Context, dir string, eng *engine.RocksDB) (int, error) {
+	registry, err := readKeyRegistry(filepath.Join(dir, KeyRegistryFilename))
+	if err != nil {
+		return 0, err
+	}
+	activeKey := activeDataKey(registry)
+	if activeKey == nil {
+		return 0, nil
+	}
+
+	var rewritten int
+	for _, sst := range eng.GetSSTables() {
+		if err := ctx.Err(); err != nil {
+			return rewritten, err
+		}
+		info, err := os.Stat(filepath.Join(dir, sst.Name))
+		if err != nil {
+			// The sstable was compacted away since the list was taken, possibly
+			// by the rewrite of an earlier one.
+			if os.IsNotExist(err) {
+				continue
+			}
+			return rewritten, err
+		}
+		if !isStaleSSTable(info, activeKey) {
+			continue
+		}
+		log.Infof(ctx, "rewriting %s, written before data key %s was created", sst.Name, activeKey.KeyId)
+		if err := eng.CompactRange(sst.Start.Key, sst.End.Key.PrefixEnd()); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package engineccl
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+func TestReencryptStaleFiles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	eng, err := engine.NewRocksDB(
+		engine.RocksDBConfig{
+			Settings: cluster.MakeTestingClusterSettings(),
+			Dir:      dir,
+		},
+		engine.RocksDBCache{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eng.Close()
+
+	for i := 0; i < 10; i++ {
+		key := engine.MakeMVCCMetadataKey(roachpb.Key(fmt.Sprintf("key-%d", i)))
+		if err := eng.Put(key, []byte("value")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := eng.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an active data key, there is nothing to rewrite.
+	if rewritten, err := ReencryptStaleFiles(ctx, dir, eng); err != nil {
+		t.Fatal(err)
+	} else if rewritten != 0 {
+		t.Fatalf("expected no file to be rewritten, got %d", rewritten)
+	}
+
+	now := timeutil.Now()
+	registry := &enginepbccl.DataKeysRegistry{
+		DataKeys: map[string]*enginepbccl.SecretKey{
+			"data1": {Info: &enginepbccl.KeyInfo{KeyId: "data1", CreationTime: now.Unix()}},
+		},
+		ActiveDataKey: "data1",
+	}
+	contents, err := protoutil.Marshal(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, KeyRegistryFilename), contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sstables predate the active data key.
+	old := now.Add(-time.Hour)
+	ssts := eng.GetSSTables()
+	if len(ssts) == 0 {
+		t.Fatal("expected sstables")
+	}
+	for _, sst := range ssts {
+		if err := os.Chtimes(filepath.Join(dir, sst.Name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rewritten, err := ReencryptStaleFiles(ctx, dir, eng); err != nil {
+		t.Fatal(err)
+	} else if rewritten == 0 {
+		t.Fatal("expected the stale sstables to be rewritten")
+	}
+
+	// The files written by the compactions are not stale.
+	status, err := ReadEncryptionStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.StaleFiles != 0 {
+		t.Fatalf("expected no stale files, got %d", status.StaleFiles)
+	}
+	if rewritten, err := ReencryptStaleFiles(ctx, dir, eng); err != nil {
+		t.Fatal(err)
+	} else if rewritten != 0 {
+		t.Fatalf("expected no file to be rewritten, got %d", rewritten)
+	}
+	for i := 0; i < 10; i++ {
+		key := engine.MakeMVCCMetadataKey(roachpb.Key(fmt.Sprintf("key-%d", i)))
+		if value, err := eng.Get(key); err != nil {
+			t.Fatal(err)
+		} else if string(value) != "value" {
+			t.Fatalf("%s: expected value, got %q", key, value)
+		}
+	}
+}
@@ -13,6 +13,5 @@ package allccl
 
 import (
 	// workloads
-	_ "github.com/cockroachdb/cockroach/pkg/testutils/workload/bank"
-	_ "github.com/cockroachdb/cockroach/pkg/testutils/workload/kv"
+	_ "github.com/cockroachdb/cockroach/pkg/workload/all"
 )
@@ -15,52 +15,103 @@ import (
 	"encoding/csv"
 	"fmt"
 	"net/url"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"cloud.google.com/go/storage"
 	"github.com/cockroachdb/cockroach/pkg/base"
-	"github.com/cockroachdb/cockroach/pkg/testutils/workload"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/workload"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 	"google.golang.org/api/iterator"
 )
 
 const (
-	fixtureGCSURIScheme = `gs`
+	fixtureGCSURIScheme       = `gs`
+	fixtureNodelocalURIScheme = `nodelocal`
+
+	// fixtureCSVFolder is the folder of a fixture holding the CSVs of its
+	// tables, in one subfolder per table.
+	fixtureCSVFolder = `csv`
+	// fixtureCSVRowsPerFile is the number of rows of each CSV file. Large
+	// tables are split into many files, so that IMPORT can process them in
+	// parallel.
+	fixtureCSVRowsPerFile = 100000
+	// fixtureCSVNull is the CSV representation of NULL.
+	fixtureCSVNull = `\N`
 )
 
-// FixtureStore describes a storage place for fixtures.
-type FixtureStore struct {
-	// GCSBucket is a Google Cloud Storage bucket.
-	GCSBucket string
+// FixtureConfig describes a storage place for fixtures.
+type FixtureConfig struct {
+	// StorageURI is the ExportStorage URI of the folder holding the fixtures,
+	// for example `gs://cockroach-fixtures/workload` or
+	// `nodelocal:///fixtures`.
+	StorageURI string
+
+	// ExternalIODir is the external IO directory of the nodes of the
+	// cluster. The CSVs of a fixture are written by the client, which
+	// resolves `nodelocal` URIs relative to this directory, so that the
+	// nodes find them. This requires the client to run on the nodes (or on
+	// a filesystem shared with them) and is ignored for other schemes.
+	ExternalIODir string
 
-	// GCSPrefix is a prefix to prepend to each Google Cloud Storage object
-	// path.
-	GCSPrefix string
+	// CSVOnly, if true, keeps the CSVs of the tables, which are then loaded
+	// with IMPORT, instead of converting them to backups, which are then
+	// loaded with RESTORE.
+	CSVOnly bool
 }
 
-func (s FixtureStore) objectPathToURI(folder string) string {
-	return (&url.URL{
-		Scheme: fixtureGCSURIScheme,
-		Host:   s.GCSBucket,
-		Path:   folder,
-	}).String()
+// objectPathToURI returns the URI of the given folder of the store.
+func (c FixtureConfig) objectPathToURI(folder string) (string, error) {
+	uri, err := url.Parse(c.StorageURI)
+	if err != nil {
+		return "", err
+	}
+	uri.Path = filepath.Join(uri.Path, folder)
+	return uri.String(), nil
+}
+
+// clientStorage returns an ExportStorage to read and write the given folder
+// of the store from the client. The caller is responsible for closing it.
+func (c FixtureConfig) clientStorage(
+	ctx context.Context, folder string,
+) (storageccl.ExportStorage, error) {
+	uri, err := url.Parse(c.StorageURI)
+	if err != nil {
+		return nil, err
+	}
+	uri.Path = filepath.Join(uri.Path, folder)
+	if uri.Scheme == fixtureNodelocalURIScheme && c.ExternalIODir != "" {
+		uri.Path = filepath.Join(c.ExternalIODir, uri.Path)
+	}
+	conf, err := storageccl.ExportStorageConfFromURI(uri.String())
+	if err != nil {
+		return nil, err
+	}
+	return storageccl.MakeExportStorage(ctx, conf, cluster.NoSettings)
 }
 
 // Fixture describes pre-computed data for a Generator, allowing quick
 // initialization of large clusters.
 type Fixture struct {
-	Store     FixtureStore
+	Config    FixtureConfig
 	Generator workload.Generator
 	Tables    []FixtureTable
 }
 
 // FixtureTable describes pre-computed data for a single table in a Generator,
-// allowing quick initializaiton of large clusters.
+// allowing quick initializaiton of large clusters. Exactly one of BackupURI
+// and CSVURIs is set.
 type FixtureTable struct {
 	TableName string
 	BackupURI string
+	CSVURIs   []string
 }
 
 // serializeOptions deterministically represents the configuration of a
@@ -77,147 +128,353 @@ func serializeOptions(gen workload.Generator) string {
 	return buf.String()
 }
 
-func generatorToGCSFolder(store FixtureStore, gen workload.Generator) string {
+// generatorToFolder returns the folder of the store holding the fixture of
+// the given generator.
+func generatorToFolder(gen workload.Generator) string {
 	meta := gen.Meta()
 	return filepath.Join(
-		store.GCSPrefix,
 		meta.Name,
 		fmt.Sprintf(`version=%s,%s`, meta.Version, serializeOptions(gen)),
 	)
 }
 
-// GetFixture returns a handle for pre-computed Generator data stored on GCS. It
-// is expected that the generator will have had Configure called on it.
-func GetFixture(
-	ctx context.Context, gcs *storage.Client, store FixtureStore, gen workload.Generator,
-) (Fixture, error) {
-	b := gcs.Bucket(store.GCSBucket)
+// csvFolder returns the folder of the store holding the CSVs of a table of
+// a fixture.
+func csvFolder(fixtureFolder string, table workload.Table) string {
+	return filepath.Join(fixtureFolder, fixtureCSVFolder, table.Name)
+}
 
-	fixtureFolder := generatorToGCSFolder(store, gen)
-	_, err := b.Objects(ctx, &storage.Query{Prefix: fixtureFolder, Delimiter: `/`}).Next()
-	if err == iterator.Done {
-		return Fixture{}, errors.Errorf(`fixture not found: %s`, fixtureFolder)
-	} else if err != nil {
-		return Fixture{}, err
+// csvFiles returns the names of the CSV files of a table, relative to its
+// CSV folder. There is always at least one file, which may be empty.
+func csvFiles(table workload.Table) []string {
+	numFiles := (table.InitialRowCount + fixtureCSVRowsPerFile - 1) / fixtureCSVRowsPerFile
+	if numFiles == 0 {
+		numFiles = 1
+	}
+	files := make([]string, numFiles)
+	for i := range files {
+		files[i] = fmt.Sprintf(`%s.%d.csv`, table.Name, i)
 	}
+	return files
+}
 
-	fixture := Fixture{Store: store, Generator: gen}
-	for _, table := range gen.Tables() {
-		tableFolder := filepath.Join(fixtureFolder, table.Name)
-		_, err := b.Objects(ctx, &storage.Query{Prefix: tableFolder, Delimiter: `/`}).Next()
-		if err == iterator.Done {
-			return Fixture{}, errors.Errorf(`fixture table not found: %s`, tableFolder)
-		} else if err != nil {
+// exists returns whether the given file exists in the store.
+func exists(ctx context.Context, es storageccl.ExportStorage, basename string) (bool, error) {
+	_, err := es.Size(ctx, basename)
+	if err == nil {
+		return true, nil
+	}
+	cause := errors.Cause(err)
+	if os.IsNotExist(cause) || cause == storage.ErrObjectNotExist {
+		return false, nil
+	}
+	switch es.Conf().Provider {
+	case roachpb.ExportStorageProvider_LocalFile, roachpb.ExportStorageProvider_GoogleCloud:
+		return false, err
+	default:
+		// The other providers don't tell missing files apart from other
+		// errors.
+		return false, nil
+	}
+}
+
+// GetFixture returns a handle for pre-computed Generator data stored in the
+// given store. It is expected that the generator will have had Configure
+// called on it.
+func GetFixture(
+	ctx context.Context, config FixtureConfig, gen workload.Generator,
+) (Fixture, error) {
+	fixtureFolder := generatorToFolder(gen)
+	fixture := Fixture{Config: config, Generator: gen}
+	for i, table := range gen.Tables() {
+		fixtureTable, err := getFixtureTable(ctx, config, fixtureFolder, table)
+		if err != nil {
 			return Fixture{}, err
 		}
-		fixture.Tables = append(fixture.Tables, FixtureTable{
-			TableName: table.Name,
-			BackupURI: store.objectPathToURI(tableFolder),
-		})
+		if fixtureTable == nil {
+			if i == 0 {
+				return Fixture{}, errors.Errorf(`fixture not found: %s`, fixtureFolder)
+			}
+			return Fixture{}, errors.Errorf(`fixture table not found: %s/%s`, fixtureFolder, table.Name)
+		}
+		fixture.Tables = append(fixture.Tables, *fixtureTable)
 	}
 	return fixture, nil
 }
 
-// writeCSVs creates a file on GCS in the specified folder that contains the
-// data for the given table. The GCS object path to the written file is
+// getFixtureTable returns the handle of the data of a table of a fixture, or
+// nil if it doesn't exist. The backup of the table is preferred to its CSVs.
+func getFixtureTable(
+	ctx context.Context, config FixtureConfig, fixtureFolder string, table workload.Table,
+) (*FixtureTable, error) {
+	tableFolder := filepath.Join(fixtureFolder, table.Name)
+	es, err := config.clientStorage(ctx, tableFolder)
+	if err != nil {
+		return nil, err
+	}
+	defer es.Close()
+	if ok, err := exists(ctx, es, `BACKUP`); err != nil {
+		return nil, err
+	} else if ok {
+		backupURI, err := config.objectPathToURI(tableFolder)
+		if err != nil {
+			return nil, err
+		}
+		return &FixtureTable{TableName: table.Name, BackupURI: backupURI}, nil
+	}
+
+	csvTableFolder := csvFolder(fixtureFolder, table)
+	csvES, err := config.clientStorage(ctx, csvTableFolder)
+	if err != nil {
+		return nil, err
+	}
+	defer csvES.Close()
+	files := csvFiles(table)
+	// The CSVs are written in order, so the table is complete iff the last
+	// one exists.
+	if ok, err := exists(ctx, csvES, files[len(files)-1]); err != nil || !ok {
+		return nil, err
+	}
+	fixtureTable := &FixtureTable{TableName: table.Name}
+	for _, file := range files {
+		csvURI, err := config.objectPathToURI(filepath.Join(csvTableFolder, file))
+		if err != nil {
+			return nil, err
+		}
+		fixtureTable.CSVURIs = append(fixtureTable.CSVURIs, csvURI)
+	}
+	return fixtureTable, nil
+}
+
+// numericLiteralRE matches the SQL numeric literals, which have the same
+// representation in CSV.
+var numericLiteralRE = regexp.MustCompile(`^[-+]?(\d+(\.\d*)?|\.\d+)([eE][-+]?\d+)?$`)
+
+// csvValue converts a value returned by the InitialRowFn of a table, which
+// is formatted for SQL, to its CSV representation. Only NULL, numeric
+// literals and standard string literals are supported: other forms, such
+// as escaped or byte strings and casts, return an error rather than being
+// written verbatim.
+func csvValue(datum string) (string, error) {
+	switch {
+	case datum == `NULL`:
+		return fixtureCSVNull, nil
+	case datum == `DEFAULT`:
+		return ``, errors.New(`DEFAULT values are not supported by fixtures`)
+	case numericLiteralRE.MatchString(datum):
+		return datum, nil
+	case len(datum) >= 2 && datum[0] == '\'' && datum[len(datum)-1] == '\'':
+		s := datum[1 : len(datum)-1]
+		if strings.Contains(strings.Replace(s, `''`, ``, -1), `'`) {
+			// The value is an expression containing several strings.
+			break
+		}
+		return strings.Replace(s, `''`, `'`, -1), nil
+	}
+	return ``, errors.Errorf(`value %s is not supported by fixtures`, datum)
+}
+
+// writeCSVs writes the CSV files that contain the data for the given table
+// to the specified folder of the store. The URIs of the written files are
 // returned.
 func writeCSVs(
-	ctx context.Context, gcs *storage.Client, table workload.Table, store FixtureStore, folder string,
-) (string, error) {
-	// TODO(dan): For large tables, break this up into multiple CSVs.
-	csvPath := filepath.Join(folder, table.Name+`.csv`)
-	const maxAttempts = 3
-	err := retry.WithMaxAttempts(ctx, base.DefaultRetryOptions(), maxAttempts, func() error {
-		w := gcs.Bucket(store.GCSBucket).Object(csvPath).NewWriter(ctx)
-		csvW := csv.NewWriter(w)
-		for rowIdx := 0; rowIdx < table.InitialRowCount; rowIdx++ {
+	ctx context.Context, config FixtureConfig, table workload.Table, folder string,
+) ([]string, error) {
+	es, err := config.clientStorage(ctx, folder)
+	if err != nil {
+		return nil, err
+	}
+	defer es.Close()
+
+	var csvURIs []string
+	for fileIdx, file := range csvFiles(table) {
+		var buf bytes.Buffer
+		csvW := csv.NewWriter(&buf)
+		rowStart := fileIdx * fixtureCSVRowsPerFile
+		rowEnd := rowStart + fixtureCSVRowsPerFile
+		if rowEnd > table.InitialRowCount {
+			rowEnd = table.InitialRowCount
+		}
+		for rowIdx := rowStart; rowIdx < rowEnd; rowIdx++ {
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				return nil, ctx.Err()
 			default:
 			}
-			if err := csvW.Write(table.InitialRowFn(rowIdx)); err != nil {
-				return err
+			row := table.InitialRowFn(rowIdx)
+			for i, datum := range row {
+				if row[i], err = csvValue(datum); err != nil {
+					return nil, errors.Wrapf(err, `table %s`, table.Name)
+				}
+			}
+			if err := csvW.Write(row); err != nil {
+				return nil, err
 			}
 		}
 		csvW.Flush()
 		if err := csvW.Error(); err != nil {
+			return nil, err
+		}
+
+		const maxAttempts = 3
+		if err := retry.WithMaxAttempts(ctx, base.DefaultRetryOptions(), maxAttempts, func() error {
+			return es.WriteFile(ctx, file, bytes.NewReader(buf.Bytes()))
+		}); err != nil {
+			return nil, errors.Wrapf(err, `writing %s`, file)
+		}
+		csvURI, err := config.objectPathToURI(filepath.Join(folder, file))
+		if err != nil {
+			return nil, err
+		}
+		csvURIs = append(csvURIs, csvURI)
+	}
+	return csvURIs, nil
+}
+
+// deleteCSVs removes the CSV files of the given table from the specified
+// folder of the store.
+func deleteCSVs(
+	ctx context.Context, config FixtureConfig, table workload.Table, folder string,
+) error {
+	es, err := config.clientStorage(ctx, folder)
+	if err != nil {
+		return err
+	}
+	defer es.Close()
+	for _, file := range csvFiles(table) {
+		if err := es.Delete(ctx, file); err != nil {
 			return err
 		}
-		return w.Close()
-	})
-	return csvPath, err
+	}
+	return nil
+}
+
+// csvDataPlaceholders returns the placeholders of the files of an `IMPORT
+// ... CSV DATA` statement, starting at the given index.
+func csvDataPlaceholders(start, n int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf(`$%d`, start+i)
+	}
+	return strings.Join(placeholders, `, `)
 }
 
-// MakeFixture regenerates a fixture, storing it to GCS. It is expected that the
-// generator will have had Configure called on it.
+// MakeFixture regenerates a fixture, storing it in the given store. It is
+// expected that the generator will have had Configure called on it.
 //
 // There's some ideal world in which we can generate backups (and thus
 // fixtures) directly from a Generator, but for now, we use `IMPORT ... CSV
-// DATA`. First a CSV file with the table data is written to GCS. `IMPORT
-// ... CSV DATA` works by turning a set of CSV files for a single table into a
-// backup file, then restoring that file into a cluster. The `transform` option
-// gives us only the first half (which is all we want for fixture generation).
+// DATA`. First the CSV files with the table data are written to the store.
+// `IMPORT ... CSV DATA` works by turning a set of CSV files for a single table
+// into a backup file, then restoring that file into a cluster. The
+// `transform` option gives us only the first half (which is all we want for
+// fixture generation). If the config is CSVOnly, the CSV files are the
+// fixture, and the backup is skipped.
 func MakeFixture(
-	ctx context.Context,
-	sqlDB *gosql.DB,
-	gcs *storage.Client,
-	store FixtureStore,
-	gen workload.Generator,
+	ctx context.Context, sqlDB *gosql.DB, config FixtureConfig, gen workload.Generator,
 ) (Fixture, error) {
-	fixtureFolder := generatorToGCSFolder(store, gen)
+	fixtureFolder := generatorToFolder(gen)
+
+	if !config.CSVOnly {
+		if _, err := sqlDB.ExecContext(
+			ctx, `SET CLUSTER SETTING experimental.importcsv.enabled = true`,
+		); err != nil {
+			return Fixture{}, err
+		}
+	}
 
-	// TODO(dan): Break up large tables into many CSV files and use the
-	// `distributed` option of `IMPORT` to do the backup generation work in
-	// parallel. Afterward, experiment with parallelizing the per-table work
-	// (IMPORT is pretty good at using cluster resources, so this is not as
-	// obvious of a win as it might seem).
+	// TODO(dan): Experiment with parallelizing the per-table work (IMPORT is
+	// pretty good at using cluster resources, so this is not as obvious of a
+	// win as it might seem).
 	for _, table := range gen.Tables() {
-		tableFolder := filepath.Join(fixtureFolder, table.Name)
-		csvPath, err := writeCSVs(ctx, gcs, table, store, tableFolder)
+		csvTableFolder := csvFolder(fixtureFolder, table)
+		csvURIs, err := writeCSVs(ctx, config, table, csvTableFolder)
 		if err != nil {
 			return Fixture{}, err
 		}
-		backupURI := store.objectPathToURI(tableFolder)
-		csvURI := store.objectPathToURI(csvPath)
+		if config.CSVOnly {
+			continue
+		}
 
-		if _, err := sqlDB.ExecContext(
-			ctx, `SET CLUSTER SETTING experimental.importcsv.enabled = true`,
-		); err != nil {
+		backupURI, err := config.objectPathToURI(filepath.Join(fixtureFolder, table.Name))
+		if err != nil {
 			return Fixture{}, err
 		}
 		importStmt := fmt.Sprintf(
-			`IMPORT TABLE %s %s CSV DATA ($1) WITH transform=$2`,
-			table.Name, table.Schema,
+			`IMPORT TABLE %s %s CSV DATA (%s) WITH transform=$1, nullif=$2`,
+			table.Name, table.Schema, csvDataPlaceholders(3, len(csvURIs)),
 		)
-		if _, err := sqlDB.ExecContext(ctx, importStmt, csvURI, backupURI); err != nil {
+		args := []interface{}{backupURI, fixtureCSVNull}
+		for _, csvURI := range csvURIs {
+			args = append(args, csvURI)
+		}
+		if _, err := sqlDB.ExecContext(ctx, importStmt, args...); err != nil {
 			return Fixture{}, errors.Wrapf(err, `creating backup for table %s`, table.Name)
 		}
+		if err := deleteCSVs(ctx, config, table, csvTableFolder); err != nil {
+			return Fixture{}, errors.Wrapf(err, `cleaning up CSVs for table %s`, table.Name)
+		}
 	}
-	// TODO(dan): Clean up the CSVs.
-	return GetFixture(ctx, gcs, store, gen)
+	return GetFixture(ctx, config, gen)
 }
 
-// RestoreFixture loads a fixture into a CockroachDB cluster. An enterprise
-// license is required to have been set in the cluster.
+// RestoreFixture loads a fixture into a CockroachDB cluster, with RESTORE
+// for the tables stored as backups and with IMPORT for the tables stored as
+// CSVs. An enterprise license is required to have been set in the cluster.
 func RestoreFixture(ctx context.Context, sqlDB *gosql.DB, fixture Fixture, database string) error {
+	schemas := make(map[string]string)
+	for _, table := range fixture.Generator.Tables() {
+		schemas[table.Name] = table.Schema
+	}
 	for _, table := range fixture.Tables {
-		// The IMPORT ... CSV DATA command generates a backup with the table in
-		// database `csv`.
-		importStmt := fmt.Sprintf(`RESTORE csv.%s FROM $1 WITH into_db=$2`, table.TableName)
-		if _, err := sqlDB.ExecContext(ctx, importStmt, table.BackupURI, database); err != nil {
+		if table.BackupURI != "" {
+			// The IMPORT ... CSV DATA command generates a backup with the table in
+			// database `csv`.
+			restoreStmt := fmt.Sprintf(`RESTORE csv.%s FROM $1 WITH into_db=$2`, table.TableName)
+			if _, err := sqlDB.ExecContext(ctx, restoreStmt, table.BackupURI, database); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := sqlDB.ExecContext(
+			ctx, `SET CLUSTER SETTING experimental.importcsv.enabled = true`,
+		); err != nil {
 			return err
 		}
+		importStmt := fmt.Sprintf(
+			`IMPORT TABLE %s %s CSV DATA (%s) WITH into_db=$1, nullif=$2`,
+			table.TableName, schemas[table.TableName], csvDataPlaceholders(3, len(table.CSVURIs)),
+		)
+		args := []interface{}{database, fixtureCSVNull}
+		for _, csvURI := range table.CSVURIs {
+			args = append(args, csvURI)
+		}
+		if _, err := sqlDB.ExecContext(ctx, importStmt, args...); err != nil {
+			return errors.Wrapf(err, `importing table %s`, table.TableName)
+		}
 	}
 	return nil
 }
 
-// ListFixtures returns the object paths to all fixtures stored in a FixtureStore.
-func ListFixtures(ctx context.Context, gcs *storage.Client, store FixtureStore) ([]string, error) {
-	b := gcs.Bucket(store.GCSBucket)
+// ListFixtures returns the object paths to all fixtures stored in a store.
+// Only Google Cloud Storage stores are supported, since listing is not part
+// of the ExportStorage interface.
+func ListFixtures(
+	ctx context.Context, gcs *storage.Client, config FixtureConfig,
+) ([]string, error) {
+	uri, err := url.Parse(config.StorageURI)
+	if err != nil {
+		return nil, err
+	}
+	if uri.Scheme != fixtureGCSURIScheme {
+		return nil, errors.Errorf(`listing fixtures is only supported on %s:// stores`,
+			fixtureGCSURIScheme)
+	}
+	b := gcs.Bucket(uri.Host)
 
 	var fixtures []string
-	gensPrefix := store.GCSPrefix + `/`
+	gensPrefix := strings.TrimPrefix(uri.Path, `/`) + `/`
 	for genIter := b.Objects(ctx, &storage.Query{Prefix: gensPrefix, Delimiter: `/`}); ; {
 		gen, err := genIter.Next()
 		if err == iterator.Done {
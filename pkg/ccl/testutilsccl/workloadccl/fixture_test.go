@@ -11,21 +11,15 @@ package workloadccl
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"os"
 	"strconv"
-	"strings"
 	"testing"
 
-	"cloud.google.com/go/storage"
 	_ "github.com/cockroachdb/cockroach/pkg/ccl/sqlccl"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
-	"github.com/cockroachdb/cockroach/pkg/testutils/workload"
+	"github.com/cockroachdb/cockroach/pkg/workload"
 	"github.com/spf13/pflag"
-	"golang.org/x/oauth2/google"
-	"google.golang.org/api/option"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -62,71 +56,82 @@ func TestFixture(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ctx := context.Background()
 
-	gcsBucket := os.Getenv(`GS_BUCKET`)
-	gcsKey := os.Getenv(`GS_JSONKEY`)
-	if gcsBucket == "" || gcsKey == "" {
-		t.Skip("GS_BUCKET and GS_JSONKEY env vars must be set")
-	}
-
-	// This prevents leaking an http conn goroutine.
-	http.DefaultTransport.(*http.Transport).DisableKeepAlives = true
-	source, err := google.JWTConfigFromJSON([]byte(gcsKey), storage.ScopeReadWrite)
-	if err != nil {
-		t.Fatalf(`%+v`, err)
-	}
-	gcs, err := storage.NewClient(ctx,
-		option.WithScopes(storage.ScopeReadWrite),
-		option.WithTokenSource(source.TokenSource(ctx)))
-	if err != nil {
-		t.Fatalf(`%+v`, err)
-	}
-	defer func() { _ = gcs.Close() }()
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
 
-	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{ExternalIODir: dir})
 	defer s.Stopper().Stop(ctx)
 	sqlDB := sqlutils.MakeSQLRunner(db)
-	sqlDB.Exec(t, `SET CLUSTER SETTING cloudstorage.gs.default.key = $1`, gcsKey)
-
-	gen := makeTestWorkload()
-	flag := fmt.Sprintf(`val=%d`, timeutil.Now().UnixNano())
-	if err := gen.Configure([]string{`--` + flag}); err != nil {
-		t.Fatalf(`%+v`, err)
-	}
-
-	store := FixtureStore{
-		GCSBucket: gcsBucket,
-		GCSPrefix: fmt.Sprintf(`TestFixture-%d`, timeutil.Now().UnixNano()),
-	}
 
-	if _, err := GetFixture(ctx, gcs, store, gen); !testutils.IsError(err, `fixture not found`) {
-		t.Fatalf(`expected "fixture not found" error but got: %+v`, err)
-	}
-
-	fixtures, err := ListFixtures(ctx, gcs, store)
-	if err != nil {
-		t.Fatalf(`%+v`, err)
-	}
-	if len(fixtures) != 0 {
-		t.Errorf(`expected no fixtures but got: %+v`, fixtures)
+	for _, csvOnly := range []bool{false, true} {
+		t.Run(fmt.Sprintf(`csvOnly=%t`, csvOnly), func(t *testing.T) {
+			gen := makeTestWorkload()
+			flag := fmt.Sprintf(`val=%d`, timeutil.Now().UnixNano())
+			if err := gen.Configure([]string{`--` + flag}); err != nil {
+				t.Fatalf(`%+v`, err)
+			}
+
+			config := FixtureConfig{
+				StorageURI:    fmt.Sprintf(`nodelocal:///TestFixture-%d`, timeutil.Now().UnixNano()),
+				ExternalIODir: dir,
+				CSVOnly:       csvOnly,
+			}
+
+			if _, err := GetFixture(ctx, config, gen); !testutils.IsError(err, `fixture not found`) {
+				t.Fatalf(`expected "fixture not found" error but got: %+v`, err)
+			}
+
+			fixture, err := MakeFixture(ctx, sqlDB.DB, config, gen)
+			if err != nil {
+				t.Fatalf(`%+v`, err)
+			}
+			if len(fixture.Tables) != 1 {
+				t.Fatalf(`expected exactly one table but got: %+v`, fixture.Tables)
+			}
+			if table := fixture.Tables[0]; csvOnly != (table.BackupURI == "") ||
+				csvOnly != (len(table.CSVURIs) > 0) {
+				t.Errorf(`unexpected table for csvOnly=%t: %+v`, csvOnly, table)
+			}
+
+			database := fmt.Sprintf(`test_csv_%t`, csvOnly)
+			sqlDB.Exec(t, `CREATE DATABASE `+database)
+			if err := RestoreFixture(ctx, sqlDB.DB, fixture, database); err != nil {
+				t.Fatalf(`%+v`, err)
+			}
+			sqlDB.CheckQueryResults(t,
+				`SELECT COUNT(*) FROM `+database+`.fx`, [][]string{{strconv.Itoa(fixtureTestGenRows)}})
+		})
 	}
+}
 
-	fixture, err := MakeFixture(ctx, sqlDB.DB, gcs, store, gen)
-	if err != nil {
-		t.Fatalf(`%+v`, err)
-	}
+func TestCSVValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
 
-	fixtures, err = ListFixtures(ctx, gcs, store)
-	if err != nil {
-		t.Fatalf(`%+v`, err)
+	for datum, expected := range map[string]string{
+		`1`:            `1`,
+		`-10.00`:       `-10.00`,
+		`1.5e-3`:       `1.5e-3`,
+		`NULL`:         fixtureCSVNull,
+		`''`:           ``,
+		`'abc'`:        `abc`,
+		`'it''s'`:      `it's`,
+		`'NULL'`:       `NULL`,
+		`'a,b"c'`:      `a,b"c`,
+		`'2006-01-02'`: `2006-01-02`,
+	} {
+		actual, err := csvValue(datum)
+		if err != nil {
+			t.Fatalf(`%s: %+v`, datum, err)
+		}
+		if actual != expected {
+			t.Errorf(`%s: expected %s got %s`, datum, expected, actual)
+		}
 	}
-	if len(fixtures) != 1 || !strings.Contains(fixtures[0], flag) {
-		t.Errorf(`expected exactly one %s fixture but got: %+v`, flag, fixtures)
-	}
-
-	sqlDB.Exec(t, `CREATE DATABASE test`)
-	if err := RestoreFixture(ctx, sqlDB.DB, fixture, `test`); err != nil {
-		t.Fatalf(`%+v`, err)
+	for _, datum := range []string{
+		`DEFAULT`, `b'abc'`, `e'it\'s'`, `'1'::INT`, `'a' || 'b'`, `true`, `NOW()`,
+	} {
+		if _, err := csvValue(datum); !testutils.IsError(err, `not supported`) {
+			t.Errorf(`expected an error for %s but got: %+v`, datum, err)
+		}
 	}
-	sqlDB.CheckQueryResults(t,
-		`SELECT COUNT(*) FROM test.fx`, [][]string{{strconv.Itoa(fixtureTestGenRows)}})
 }
@@ -27,6 +27,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/build"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	// Register the generators of `cockroach workload`.
+	_ "github.com/cockroachdb/cockroach/pkg/workload/all"
+	workloadcli "github.com/cockroachdb/cockroach/pkg/workload/cli"
 )
 
 // Main is the entry point for the cli, with a single line calling it intended
@@ -139,6 +142,7 @@ func init() {
 		genCmd,
 		versionCmd,
 		debugCmd,
+		workloadcli.WorkloadCmd(),
 	)
 }
 
@@ -147,6 +151,11 @@ func AddCmd(c *cobra.Command) {
 	cockroachCmd.AddCommand(c)
 }
 
+// AddDebugCmd adds a command to the debug commands of the cli.
+func AddDebugCmd(c *cobra.Command) {
+	debugCmd.AddCommand(c)
+}
+
 // Run ...
 func Run(args []string) error {
 	cockroachCmd.SetArgs(args)
@@ -1359,6 +1359,7 @@ Available Commands:
   gen         generate auxiliary files
   version     output version information
   debug       debugging commands
+  workload    generate data and query loads
   help        Help about any command
 
 Flags:
@@ -1784,6 +1785,7 @@ func TestJunkPositionalArguments(t *testing.T) {
 		"gen man junk",
 		"gen autocomplete junk",
 		"gen example-data file junk",
+		"gen settings-diff file junk",
 	} {
 		out, err := c.RunWithCapture(test)
 		if err != nil {
@@ -1796,6 +1798,51 @@ func TestJunkPositionalArguments(t *testing.T) {
 	}
 }
 
+func TestGenSettingsDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := newCLITest(cliTestParams{t: t})
+	defer c.cleanup()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "settings.yaml")
+	// Only the settings which differ from the cluster are listed.
+	contents := "kv.raft_log.synchronize: true\nserver.web_session_timeout: 24h\n"
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := c.RunWithCapture("gen settings-diff --format=csv " + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `gen settings-diff --format=csv ` + path + `
+variable,current_value,file_value
+server.web_session_timeout,168h0m0s,24h0m0s
+`
+	if out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	// Once the setting is changed, there is no drift left.
+	if _, err := c.RunWithCaptureArgs([]string{
+		"sql", "-e", "SET CLUSTER SETTING server.web_session_timeout = '24h'",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	out, err = c.RunWithCapture("gen settings-diff --format=csv " + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `gen settings-diff --format=csv ` + path + `
+variable,current_value,file_value
+`
+	if out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
 func TestZip(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1812,9 +1859,22 @@ writing ` + os.DevNull + `
   debug/events
   debug/liveness
   debug/settings
+  debug/jobs
+  debug/reports/problemranges
+  debug/crdb_internal.cluster_queries.txt
+  debug/crdb_internal.cluster_sessions.txt
+  debug/crdb_internal.cluster_settings.txt
+  debug/crdb_internal.gossip_liveness.txt
+  debug/crdb_internal.gossip_nodes.txt
+  debug/crdb_internal.kv_node_status.txt
+  debug/crdb_internal.kv_store_status.txt
+  debug/crdb_internal.leases.txt
+  debug/crdb_internal.node_statement_statistics.txt
+  debug/crdb_internal.schema_changes.txt
   debug/nodes/1/status
   debug/nodes/1/gossip
   debug/nodes/1/stacks
+  debug/nodes/1/heap.pprof
   debug/nodes/1/ranges/1
   debug/nodes/1/ranges/2
   debug/nodes/1/ranges/3
@@ -1835,6 +1895,7 @@ writing ` + os.DevNull + `
   debug/nodes/1/ranges/18
   debug/nodes/1/ranges/19
   debug/nodes/1/ranges/20
+  debug/nodes/1/ranges/21
   debug/schema/system@details
   debug/schema/system/descriptor
   debug/schema/system/eventlog
@@ -1844,6 +1905,7 @@ writing ` + os.DevNull + `
   debug/schema/system/namespace
   debug/schema/system/rangelog
   debug/schema/system/role_members
+  debug/schema/system/role_options
   debug/schema/system/settings
   debug/schema/system/table_statistics
   debug/schema/system/ui
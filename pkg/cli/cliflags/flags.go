@@ -232,6 +232,32 @@ Dumps the data as of the specified timestamp. Formats supported are the same
 as the timestamp type.`,
 	}
 
+	DumpFormat = FlagInfo{
+		Name: "dump-format",
+		Description: `
+How to dump the table data. "insert" (default) dumps INSERT statements.
+"copy" dumps COPY ... FROM stdin blocks, which load faster but require a
+client supporting COPY, such as psql.`,
+	}
+
+	DumpConcurrency = FlagInfo{
+		Name: "concurrency",
+		Description: `
+Number of table chunks to dump in parallel, each over its own SQL connection.
+Tables are split into chunks at their range boundaries. Values greater than 1
+require --output-dir.`,
+	}
+
+	DumpOutputDir = FlagInfo{
+		Name: "output-dir",
+		Description: `
+Write the dump to the specified directory instead of the standard output: the
+schema to schema.sql and the data of each table chunk to its own file, to be
+loaded in file name order. The progress of the dump is recorded in a manifest
+file in the directory; when run again with the same directory, an interrupted
+dump resumes where it stopped, at the same timestamp.`,
+	}
+
 	Execute = FlagInfo{
 		Name:      "execute",
 		Shorthand: "e",
@@ -348,6 +374,30 @@ After the CockroachDB node has started up successfully, it will
 write its process ID to the specified file.`,
 	}
 
+	SettingsFile = FlagInfo{
+		Name: "settings-file",
+		Description: `
+YAML file mapping cluster setting names to their desired values, for example:
+<PRE>
+
+  kv.raft_log.synchronize: true
+  server.time_until_store_dead: 10m
+
+</PRE>
+Once the node has started, the cluster settings which differ from the
+file are set to their value in the file, and each change is recorded in
+the event log. The node does not start if the file is invalid. Use
+'cockroach gen settings-diff' to preview the changes.`,
+	}
+
+	InitSettings = FlagInfo{
+		Name: "settings",
+		Description: `
+YAML file mapping cluster setting names to their values, which are set
+once the cluster is initialized. See the --settings-file flag of the
+start command for the format.`,
+	}
+
 	Socket = FlagInfo{
 		Name:   "socket",
 		EnvVar: "COCKROACH_SOCKET",
@@ -435,6 +485,16 @@ disabled by setting the environment variable COCKROACH_SKIP_KEY_PERMISSION_CHECK
 		Description: `Path to the CA key.`,
 	}
 
+	// Server version of the CA key flag, cannot be set through environment.
+	ServerCAKey = FlagInfo{
+		Name: "ca-key",
+		Description: `
+Path to the CA key. If specified, this node signs the renewed node and
+client certificates of the other nodes when the
+server.certificate_renewal.enabled cluster setting is set. Only one node
+in the cluster should be started with the CA key.`,
+	}
+
 	// TODO(tschottdorf): once clockless mode becomes non-experimental, explain it here:
 	// <PRE>
 	//
@@ -555,6 +615,22 @@ If left empty, defaults to the "extern" subdirectory of the first store director
 The value "disabled" will disable all local file I/O. `,
 	}
 
+	SQLAuditLogFileMaxSize = FlagInfo{
+		Name: "sql-audit-log-file-max-size",
+		Description: `
+Maximum size of each SQL audit log file. The SQL audit log records the
+statements that access tables with auditing enabled via ALTER TABLE ...
+EXPERIMENTAL_AUDIT SET. It is written to cockroach-sql-audit.*.log files
+in the log directory, separately from the main log files.`,
+	}
+
+	SQLAuditLogDirMaxSize = FlagInfo{
+		Name: "sql-audit-log-dir-max-size",
+		Description: `
+Maximum combined size of all the SQL audit log files. When this size is
+exceeded, the oldest audit log files are removed.`,
+	}
+
 	URL = FlagInfo{
 		Name:   "url",
 		EnvVar: "COCKROACH_URL",
@@ -586,6 +662,51 @@ Exclusive end key and format as [<format>:]<key>. Supported formats: raw,
 human, rangeID. The raw format supports escaped text. For example, "raw:\x01k"
 is the prefix for range local keys.`}
 
+	LogsFrom = FlagInfo{
+		Name: "from",
+		Description: `
+Only include the log entries at or after this time, specified as a SQL
+TIMESTAMP value in UTC (e.g. "2018-03-01 10:00:00").`,
+	}
+
+	LogsTo = FlagInfo{
+		Name: "to",
+		Description: `
+Only include the log entries at or before this time, specified as a SQL
+TIMESTAMP value in UTC (e.g. "2018-03-01 11:00:00").`,
+	}
+
+	MergeLogsFilter = FlagInfo{
+		Name: "filter",
+		Description: `
+Only include the log entries whose message matches this regular expression.`,
+	}
+
+	MergeLogsFileFilter = FlagInfo{
+		Name: "file-filter",
+		Description: `
+Only include the log entries whose location, formatted as file:line (e.g.
+"server/node.go:123"), matches this regular expression.`,
+	}
+
+	ZipRedact = FlagInfo{
+		Name: "redact",
+		Description: `
+Remove the values of SQL statements and keys from the logs, the events, the
+jobs, the crdb_internal tables and the range descriptors included in the zip
+file, and the function arguments from the stack traces. Unquoted numbers in
+log messages and errors are not removed, nor is the content of heap
+profiles.`,
+	}
+
+	ZipTimeout = FlagInfo{
+		Name: "timeout",
+		Description: `
+Maximum duration of each RPC request and SQL query to the cluster. The requests
+which time out are recorded as errors in the zip file and the collection
+continues. Zero disables the timeout.`,
+	}
+
 	Values = FlagInfo{
 		Name:        "values",
 		Description: `Print values along with their associated key.`,
@@ -616,6 +737,12 @@ If specified, print the system config contents. Beware that the output will be
 long and not particularly human-readable.`,
 	}
 
+	DeadStoreIDs = FlagInfo{
+		Name: "dead-store-ids",
+		Description: `
+Comma-separated list of the IDs of the stores which are permanently lost.`,
+	}
+
 	Decommission = FlagInfo{
 		Name: "decommission",
 		Description: `
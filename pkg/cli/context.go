@@ -79,6 +79,9 @@ func initCLIDefaults() {
 
 	dumpCtx.dumpMode = dumpBoth
 	dumpCtx.asOf = ""
+	dumpCtx.dumpFormat = dumpFormatInsert
+	dumpCtx.concurrency = 1
+	dumpCtx.outputDir = ""
 
 	debugCtx.startKey = engine.NilKey
 	debugCtx.endKey = engine.MVCCKeyMax
@@ -88,6 +91,17 @@ func initCLIDefaults() {
 	debugCtx.inputFile = ""
 	debugCtx.printSystemConfig = false
 	debugCtx.maxResults = 1000
+	debugCtx.deadStoreIDs = nil
+
+	zipCtx.logsFrom = time.Time{}
+	zipCtx.logsTo = time.Time{}
+	zipCtx.redact = false
+	zipCtx.timeout = time.Minute
+
+	mergeLogsCtx.from = time.Time{}
+	mergeLogsCtx.to = time.Time{}
+	mergeLogsCtx.filter = ""
+	mergeLogsCtx.fileFilter = ""
 
 	zoneCtx.zoneConfig = ""
 	zoneCtx.zoneDisableReplication = false
@@ -95,14 +109,18 @@ func initCLIDefaults() {
 	serverCfg.SocketFile = ""
 	serverCfg.ListeningURLFile = ""
 	serverCfg.PIDFile = ""
+	serverCfg.SettingsFile = ""
 	startCtx.serverInsecure = baseCfg.Insecure
 	startCtx.serverSSLCertsDir = base.DefaultCertsDirectory
+	startCtx.serverSSLCAKey = ""
 	startCtx.serverConnHost = ""
 	startCtx.tempDir = ""
 	startCtx.externalIODir = ""
 
 	quitCtx.serverDecommission = false
 
+	initCtx.settingsFile = ""
+
 	nodeCtx.nodeDecommissionWait = nodeDecommissionWaitAll
 	nodeCtx.statusShowRanges = false
 	nodeCtx.statusShowStats = false
@@ -179,6 +197,16 @@ var dumpCtx struct {
 
 	// asOf determines the time stamp at which the dump should be taken.
 	asOf string
+
+	// dumpFormat determines how the table data is dumped.
+	dumpFormat dumpFormat
+
+	// concurrency is the number of table chunks dumped in parallel.
+	concurrency int
+
+	// outputDir, if set, is the directory where the dump is written,
+	// along with its manifest.
+	outputDir string
 }
 
 // debugCtx captures the command-line parameters of the `debug` command.
@@ -191,6 +219,29 @@ var debugCtx struct {
 	inputFile         string
 	printSystemConfig bool
 	maxResults        int64
+	deadStoreIDs      []string
+}
+
+// zipCtx captures the command-line parameters of the `debug zip`
+// command. Defaults set by InitCLIDefaults() above.
+var zipCtx struct {
+	// logsFrom and logsTo restrict the log entries included, if set.
+	logsFrom, logsTo time.Time
+	// redact indicates whether to remove the values of SQL statements
+	// and keys from the data.
+	redact bool
+	// timeout bounds each RPC request, if nonzero.
+	timeout time.Duration
+}
+
+// mergeLogsCtx captures the command-line parameters of the `debug
+// merge-logs` command. Defaults set by InitCLIDefaults() above.
+var mergeLogsCtx struct {
+	// from and to restrict the log entries merged, if set.
+	from, to time.Time
+	// filter and fileFilter are regular expressions which the message
+	// and the file:line location of the entries must match, if set.
+	filter, fileFilter string
 }
 
 // zoneCtx captures the command-line parameters of the `zone` command.
@@ -206,6 +257,7 @@ var startCtx struct {
 	// server-specific values of some flags.
 	serverInsecure    bool
 	serverSSLCertsDir string
+	serverSSLCAKey    string
 	serverConnHost    string
 
 	// temporary directory to use to spill computation results to disk.
@@ -221,6 +273,12 @@ var quitCtx struct {
 	serverDecommission bool
 }
 
+// initCtx captures the command-line parameters of the `init` command.
+// Defaults set by InitCLIDefaults() above.
+var initCtx struct {
+	settingsFile string
+}
+
 // nodeCtx captures the command-line parameters of the `node` command.
 // Defaults set by InitCLIDefaults() above.
 var nodeCtx struct {
@@ -956,7 +956,7 @@ func parseGossipValues(gossipInfo *gossip.InfoStatus) (string, error) {
 				return "", errors.Wrapf(err, "failed to parse value for key %q", key)
 			}
 			output = append(output, fmt.Sprintf("%q: %v", key, desc))
-		} else if gossip.IsNodeIDKey(key) {
+		} else if gossip.IsNodeIDKey(key) || key == gossip.KeyCertificateSigner {
 			var desc roachpb.NodeDescriptor
 			if err := protoutil.Unmarshal(bytes, &desc); err != nil {
 				return "", errors.Wrapf(err, "failed to parse value for key %q", key)
@@ -1032,6 +1032,7 @@ var debugCmds = []*cobra.Command{
 	debugRaftLogCmd,
 	debugGCCmd,
 	debugCheckStoreCmd,
+	debugRemoveDeadReplicasCmd,
 	debugRocksDBCmd,
 	debugCompactCmd,
 	debugSSTablesCmd,
@@ -1039,6 +1040,7 @@ var debugCmds = []*cobra.Command{
 	debugSyncTestCmd,
 	debugEnvCmd,
 	debugZipCmd,
+	debugMergeLogsCmd,
 }
 
 var debugCmd = &cobra.Command{
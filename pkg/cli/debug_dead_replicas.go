@@ -0,0 +1,225 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/stateloader"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+var debugRemoveDeadReplicasCmd = &cobra.Command{
+	Use:   "unsafe-remove-dead-replicas --dead-store-ids=[store ID,...] [directory]",
+	Short: "unsafe: remove the dead replicas of the ranges which lost quorum",
+	Long: `
+Rewrites the range descriptors of the ranges which lost a majority of their
+replicas, so that the ranges can be used again. This command must be run,
+with the same list of dead stores, on every surviving store while all the
+nodes of the cluster are stopped.
+
+A range lost quorum if a majority of its replicas are on the dead stores. The
+descriptor of such a range is rewritten so that the surviving replica on the
+store with the lowest ID becomes the sole replica of the range. The range is
+then up-replicated by the cluster once the nodes are restarted.
+
+The plan of the changes is printed, and must be confirmed before the store is
+modified.
+
+This command is UNSAFE: the surviving replica may not have received the most
+recent writes to the range, which are lost, and transactions may observe
+inconsistent data. Ongoing changes to the rewritten descriptors are aborted.
+Use it only as a last resort when the dead stores cannot be recovered.
+`,
+	RunE: MaybeDecorateGRPCError(runDebugRemoveDeadReplicas),
+}
+
+func runDebugRemoveDeadReplicas(cmd *cobra.Command, args []string) error {
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.Background())
+
+	ctx := context.Background()
+
+	if len(args) != 1 {
+		return errors.New("one argument required: dir")
+	}
+	deadStoreIDs, err := parseStoreIDs(debugCtx.deadStoreIDs)
+	if err != nil {
+		return err
+	}
+	if len(deadStoreIDs) == 0 {
+		return errors.New("--dead-store-ids must list at least one store")
+	}
+
+	db, err := openExistingStore(args[0], stopper)
+	if err != nil {
+		return err
+	}
+	storeIdent, err := storage.ReadStoreIdent(ctx, db)
+	if err != nil {
+		return err
+	}
+	if _, ok := deadStoreIDs[storeIdent.StoreID]; ok {
+		return errors.Errorf("store %d is listed as dead", storeIdent.StoreID)
+	}
+
+	rewrites, err := planRemoveDeadReplicas(ctx, db, storeIdent.StoreID, deadStoreIDs)
+	if err != nil {
+		return err
+	}
+	if len(rewrites) == 0 {
+		fmt.Printf("no range of store %d lost quorum\n", storeIdent.StoreID)
+		return nil
+	}
+	fmt.Printf("the descriptors of %d ranges of store %d will be rewritten:\n",
+		len(rewrites), storeIdent.StoreID)
+	for _, rw := range rewrites {
+		fmt.Printf("  %s\n    -> %s\n", rw.old, rw.new)
+	}
+
+	fmt.Print("Proceed? [y/N] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return errors.Wrap(err, "while reading the confirmation")
+	}
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return errors.New("aborted, the store was not modified")
+	}
+
+	batch := db.NewBatch()
+	defer batch.Close()
+	clock := hlc.NewClock(hlc.UnixNano, 0)
+	if err := applyRemoveDeadReplicas(ctx, batch, clock.Now(), rewrites); err != nil {
+		return err
+	}
+	if err := batch.Commit(true /* sync */); err != nil {
+		return err
+	}
+	fmt.Printf("rewrote the descriptors of %d ranges\n", len(rewrites))
+	return nil
+}
+
+// parseStoreIDs parses a list of store IDs.
+func parseStoreIDs(args []string) (map[roachpb.StoreID]struct{}, error) {
+	storeIDs := make(map[roachpb.StoreID]struct{}, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 32)
+		if err != nil || id < 1 {
+			return nil, errors.Errorf("invalid store ID: %q", arg)
+		}
+		storeIDs[roachpb.StoreID(id)] = struct{}{}
+	}
+	return storeIDs, nil
+}
+
+// rangeDescRewrite is the rewrite of the descriptor of a range which
+// lost quorum.
+type rangeDescRewrite struct {
+	old, new roachpb.RangeDescriptor
+}
+
+// planRemoveDeadReplicas returns the rewrites of the descriptors of the
+// ranges of the store which lost quorum: the new descriptors only
+// contain the surviving replica on the store with the lowest ID. Every
+// surviving store computes the same descriptors, so that the replicas
+// agree on the replica which takes over the range.
+func planRemoveDeadReplicas(
+	ctx context.Context,
+	db engine.Reader,
+	storeID roachpb.StoreID,
+	deadStoreIDs map[roachpb.StoreID]struct{},
+) ([]rangeDescRewrite, error) {
+	var rewrites []rangeDescRewrite
+	err := storage.IterateRangeDescriptors(ctx, db, func(desc roachpb.RangeDescriptor) (bool, error) {
+		if _, ok := desc.GetReplicaDescriptor(storeID); !ok {
+			return false, nil
+		}
+		var survivor roachpb.ReplicaDescriptor
+		numLive := 0
+		for _, rep := range desc.Replicas {
+			if _, ok := deadStoreIDs[rep.StoreID]; ok {
+				continue
+			}
+			if numLive == 0 || rep.StoreID < survivor.StoreID {
+				survivor = rep
+			}
+			numLive++
+		}
+		if numLive > len(desc.Replicas)/2 {
+			// The range still has a quorum.
+			return false, nil
+		}
+		newDesc := desc
+		newDesc.Replicas = []roachpb.ReplicaDescriptor{survivor}
+		rewrites = append(rewrites, rangeDescRewrite{old: desc, new: newDesc})
+		return false, nil
+	})
+	return rewrites, err
+}
+
+// applyRemoveDeadReplicas writes the rewritten range descriptors at the
+// given timestamp. The intents on the descriptors, left by changes
+// which cannot complete without the dead replicas, are aborted. The
+// MVCC stats of the ranges are updated accordingly.
+//
+// The copies of the descriptors in the meta ranges are not rewritten:
+// they still list the surviving replicas, and are updated by the next
+// change of the replicas of the ranges.
+func applyRemoveDeadReplicas(
+	ctx context.Context, batch engine.ReadWriter, ts hlc.Timestamp, rewrites []rangeDescRewrite,
+) error {
+	for _, rw := range rewrites {
+		var ms enginepb.MVCCStats
+		key := keys.RangeDescriptorKey(rw.new.StartKey)
+		err := engine.MVCCPutProto(ctx, batch, &ms, key, ts, nil /* txn */, &rw.new)
+		if wiErr, ok := err.(*roachpb.WriteIntentError); ok {
+			for _, intent := range wiErr.Intents {
+				intent.Status = roachpb.ABORTED
+				if err := engine.MVCCResolveWriteIntent(ctx, batch, &ms, intent); err != nil {
+					return err
+				}
+			}
+			err = engine.MVCCPutProto(ctx, batch, &ms, key, ts, nil /* txn */, &rw.new)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "while rewriting the descriptor of r%d", rw.new.RangeID)
+		}
+
+		sl := stateloader.Make(serverCfg.Settings, rw.new.RangeID)
+		rangeMS, err := sl.LoadMVCCStats(ctx, batch)
+		if err != nil {
+			return err
+		}
+		rangeMS.Add(ms)
+		if err := sl.SetMVCCStats(ctx, batch, rangeMS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
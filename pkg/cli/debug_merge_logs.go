@@ -0,0 +1,377 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+var debugMergeLogsCmd = &cobra.Command{
+	Use:   "merge-logs [zip file | directory | log file]...",
+	Short: "merge the log files of multiple nodes",
+	Long: `
+Merges the log entries of multiple nodes by timestamp, and prefixes every line
+of the output with the node which wrote the entry.
+
+The arguments are zip files created by 'cockroach debug zip', directories,
+which are searched recursively for log files, and log files. The entries of
+the log files in a 'nodes/<node ID>' directory, as in a debug zip file, are
+attributed to the node with this ID, and the entries of the other log files
+to the directory given as argument or, for log files given as arguments, to
+their directory. For example:
+
+  cockroach debug merge-logs debug.zip
+  cockroach debug merge-logs node1/logs node2/logs node3/logs
+
+The entries can be restricted to a time window with --from and --to, and to the
+entries whose message or file:line match regular expressions with --filter and
+--file-filter.
+`,
+	RunE: MaybeDecorateGRPCError(runDebugMergeLogs),
+}
+
+func runDebugMergeLogs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return errors.New("at least one zip file, directory or log file is required")
+	}
+	filter := logFilter{from: mergeLogsCtx.from, to: mergeLogsCtx.to}
+	var err error
+	if mergeLogsCtx.filter != "" {
+		if filter.message, err = regexp.Compile(mergeLogsCtx.filter); err != nil {
+			return errors.Wrap(err, "invalid --filter")
+		}
+	}
+	if mergeLogsCtx.fileFilter != "" {
+		if filter.fileLine, err = regexp.Compile(mergeLogsCtx.fileFilter); err != nil {
+			return errors.Wrap(err, "invalid --file-filter")
+		}
+	}
+
+	sources, closer, err := findLogSources(args)
+	defer closer()
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	if err := mergeLogs(out, sources, filter); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// logSource is a log file to merge.
+type logSource struct {
+	// node identifies the node which wrote the log file in the output.
+	node string
+	// name is the name of the file, used in errors.
+	name string
+	open func() (io.ReadCloser, error)
+	// created is the time at which the file was created, as indicated
+	// by its name, or zero if the name does not indicate it.
+	created time.Time
+}
+
+// nodeDirRE matches the directories of the nodes in a debug zip file.
+var nodeDirRE = regexp.MustCompile(`(?:^|/)nodes/(\d+)/`)
+
+// logSourceNode returns the node which wrote the log file of the given
+// path, given the default node of the files which are not in the
+// directory of a node.
+func logSourceNode(path, defaultNode string) string {
+	if m := nodeDirRE.FindStringSubmatch(filepath.ToSlash(path)); m != nil {
+		return "n" + m[1]
+	}
+	return defaultNode
+}
+
+// logFileCreation returns the creation time of a log file, as indicated
+// by its name, and whether the file is a log file.
+func logFileCreation(name string) (time.Time, bool) {
+	details, err := log.ParseLogFilename(name)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timeutil.Unix(0, details.Time), true
+}
+
+// findLogSources returns the log files in the given zip files,
+// directories and log files. The returned closer must be called once
+// the log files are no longer used, even if an error is returned.
+func findLogSources(args []string) ([]logSource, func(), error) {
+	var sources []logSource
+	var zips []*zip.ReadCloser
+	closer := func() {
+		for _, z := range zips {
+			_ = z.Close()
+		}
+	}
+
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, closer, err
+		}
+
+		switch {
+		case info.IsDir():
+			node := filepath.Clean(arg)
+			if err := filepath.Walk(arg, func(p string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				// Symlinks, which point to the latest log files, are skipped
+				// by the walk and by the file name check.
+				if !info.Mode().IsRegular() {
+					return nil
+				}
+				created, ok := logFileCreation(info.Name())
+				if !ok {
+					return nil
+				}
+				sources = append(sources, logSource{
+					node:    logSourceNode(p, node),
+					name:    p,
+					open:    func() (io.ReadCloser, error) { return os.Open(p) },
+					created: created,
+				})
+				return nil
+			}); err != nil {
+				return nil, closer, err
+			}
+
+		case strings.HasSuffix(arg, ".zip"):
+			z, err := zip.OpenReader(arg)
+			if err != nil {
+				return nil, closer, err
+			}
+			zips = append(zips, z)
+			for _, f := range z.File {
+				created, ok := logFileCreation(filepath.Base(filepath.FromSlash(f.Name)))
+				if !ok {
+					continue
+				}
+				sources = append(sources, logSource{
+					node:    logSourceNode(f.Name, arg),
+					name:    arg + ":" + f.Name,
+					open:    f.Open,
+					created: created,
+				})
+			}
+
+		default:
+			created, _ := logFileCreation(info.Name())
+			p := arg
+			sources = append(sources, logSource{
+				node:    logSourceNode(p, filepath.Dir(p)),
+				name:    p,
+				open:    func() (io.ReadCloser, error) { return os.Open(p) },
+				created: created,
+			})
+		}
+	}
+	return sources, closer, nil
+}
+
+// logFilter selects the log entries to merge. The zero value selects
+// all the entries.
+type logFilter struct {
+	from, to time.Time
+	// message and fileLine, if set, must match the message of the
+	// entries and their file:line location.
+	message, fileLine *regexp.Regexp
+}
+
+func (f logFilter) match(e *log.Entry) bool {
+	if !f.from.IsZero() && e.Time < f.from.UnixNano() {
+		return false
+	}
+	if !f.to.IsZero() && e.Time > f.to.UnixNano() {
+		return false
+	}
+	if f.message != nil && !f.message.MatchString(e.Message) {
+		return false
+	}
+	if f.fileLine != nil && !f.fileLine.MatchString(fmt.Sprintf("%s:%d", e.File, e.Line)) {
+		return false
+	}
+	return true
+}
+
+// logStream reads the entries of a log file in order.
+type logStream struct {
+	src     logSource
+	idx     int
+	r       io.ReadCloser
+	decoder *log.EntryDecoder
+	// entry is the next entry of the stream.
+	entry log.Entry
+}
+
+// logFileCreationSlack is how long before the creation time of a log
+// file, as indicated by its name, its first entries may have been
+// logged: the time of an entry is taken before it is written, possibly
+// to a file rotated in between.
+const logFileCreationSlack = time.Minute
+
+// open opens the log file of the stream and reads its first entry. It
+// returns false if the file can't be read or has no entries.
+func (s *logStream) open() bool {
+	r, err := s.src.open()
+	if err != nil {
+		fmt.Fprintf(stderr, "warning: skipping %s: %v\n", s.src.name, err)
+		return false
+	}
+	s.r = r
+	s.decoder = log.NewEntryDecoder(r)
+	if !s.next() {
+		_ = r.Close()
+		return false
+	}
+	return true
+}
+
+// next reads the next entry of the stream, and returns false at the end
+// of the stream. Malformed entries are skipped, and an error reading the
+// file ends the stream, with a warning.
+func (s *logStream) next() bool {
+	for {
+		s.entry = log.Entry{}
+		err := s.decoder.Decode(&s.entry)
+		switch err.(type) {
+		case nil:
+			return true
+		case *time.ParseError, *strconv.NumError:
+			fmt.Fprintf(stderr, "warning: skipping malformed entry in %s: %v\n", s.src.name, err)
+			continue
+		}
+		if err != io.EOF {
+			fmt.Fprintf(stderr, "warning: skipping the rest of %s: %v\n", s.src.name, err)
+		}
+		return false
+	}
+}
+
+// logStreamHeap orders the streams by the time of their next entry.
+// Entries with the same time are ordered by the order of their files.
+type logStreamHeap []*logStream
+
+func (h logStreamHeap) Len() int { return len(h) }
+func (h logStreamHeap) Less(i, j int) bool {
+	if h[i].entry.Time != h[j].entry.Time {
+		return h[i].entry.Time < h[j].entry.Time
+	}
+	return h[i].idx < h[j].idx
+}
+func (h logStreamHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *logStreamHeap) Push(x interface{}) { *h = append(*h, x.(*logStream)) }
+func (h *logStreamHeap) Pop() interface{} {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// mergeLogs writes the entries of the log files selected by the filter,
+// ordered by time. Every line of the output is prefixed with the node
+// which wrote the entry, including the continuation lines of multi-line
+// entries. The files which can't be read and the malformed entries are
+// skipped with a warning.
+//
+// The files overlapping in time are read concurrently, so that the
+// entries of the log files of a node which overlap in time, such as the
+// main and secondary log files or the files rotated while the node was
+// writing to them, are interleaved correctly. The files are opened in
+// the order of their creation, once the merge reaches the time at which
+// they were created, so that only these files are open at once.
+func mergeLogs(w io.Writer, sources []logSource, filter logFilter) error {
+	var pending []*logStream
+	for i, src := range sources {
+		if !filter.to.IsZero() && !src.created.IsZero() && src.created.After(filter.to) {
+			// The file only contains entries after the window.
+			continue
+		}
+		pending = append(pending, &logStream{src: src, idx: i})
+	}
+	// The files whose creation time is unknown are opened first.
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].src.created.Before(pending[j].src.created)
+	})
+
+	var h logStreamHeap
+	defer func() {
+		for _, s := range h {
+			_ = s.r.Close()
+		}
+	}()
+
+	var buf bytes.Buffer
+	for len(h) > 0 || len(pending) > 0 {
+		// Open the files which may contain entries preceding the next one.
+		for len(pending) > 0 && (len(h) == 0 || !pending[0].src.created.After(
+			timeutil.Unix(0, h[0].entry.Time).Add(logFileCreationSlack))) {
+			if s := pending[0]; s.open() {
+				heap.Push(&h, s)
+			}
+			pending = pending[1:]
+		}
+		if len(h) == 0 {
+			continue
+		}
+
+		s := h[0]
+		if filter.match(&s.entry) {
+			buf.Reset()
+			if err := s.entry.Format(&buf); err != nil {
+				return err
+			}
+			prefix := s.src.node + "> "
+			for _, line := range strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+				if _, err := io.WriteString(w, prefix+line); err != nil {
+					return err
+				}
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+
+		if s.next() {
+			heap.Fix(&h, 0)
+		} else {
+			_ = s.r.Close()
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
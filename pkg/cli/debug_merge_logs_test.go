@@ -0,0 +1,234 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestMergeLogs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	// Node 1 rotated its log file while a multi-line entry was written.
+	files := map[string]string{
+		"debug/nodes/1/logs/cockroach.host1.user.2018-03-01T10_00_00Z.000001.log": `I180301 10:00:00.000000 1 server/node.go:10  node 1 started
+W180301 10:00:02.500000 5 storage/store.go:20  slow
+  second line
+`,
+		"debug/nodes/1/logs/cockroach.host1.user.2018-03-01T10_00_02Z.000001.log": `I180301 10:00:02.000000 1 server/node.go:11  rotated
+`,
+		"debug/nodes/2/logs/cockroach.host2.user.2018-03-01T10_00_00Z.000002.log": `I180301 10:00:01.000000 1 server/node.go:10  node 2 started
+E180301 10:00:04.000000 7 sql/exec.go:30  boom
+`,
+		"debug/nodes/1/stacks.txt": "not a log file\n",
+	}
+	zipName := filepath.Join(dir, "debug.zip")
+	zf, err := os.Create(zipName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := zip.NewWriter(zf)
+	for name, contents := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w, err := z.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The symlink to the latest log file is not merged.
+	node1Logs := filepath.Join(dir, "debug", "nodes", "1", "logs")
+	if err := os.Symlink(
+		filepath.Join(node1Logs, "cockroach.host1.user.2018-03-01T10_00_02Z.000001.log"),
+		filepath.Join(node1Logs, "cockroach.log"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	const all = `n1> I180301 10:00:00.000000 1 server/node.go:10  node 1 started
+n2> I180301 10:00:01.000000 1 server/node.go:10  node 2 started
+n1> I180301 10:00:02.000000 1 server/node.go:11  rotated
+n1> W180301 10:00:02.500000 5 storage/store.go:20  slow
+n1>   second line
+n2> E180301 10:00:04.000000 7 sql/exec.go:30  boom
+`
+
+	ts := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	node2File := filepath.Join(
+		dir, "debug", "nodes", "2", "logs", "cockroach.host2.user.2018-03-01T10_00_00Z.000002.log")
+	stacksFile := filepath.Join(dir, "debug", "nodes", "1", "stacks.txt")
+
+	testData := []struct {
+		name     string
+		args     []string
+		filter   logFilter
+		expected string
+	}{
+		{"zip", []string{zipName}, logFilter{}, all},
+		{"dir", []string{filepath.Join(dir, "debug")}, logFilter{}, all},
+		{"files", []string{node2File, stacksFile}, logFilter{}, `n2> I180301 10:00:01.000000 1 server/node.go:10  node 2 started
+n2> E180301 10:00:04.000000 7 sql/exec.go:30  boom
+`},
+		{"window", []string{zipName}, logFilter{
+			from: ts("2018-03-01 10:00:01"), to: ts("2018-03-01 10:00:02"),
+		}, `n2> I180301 10:00:01.000000 1 server/node.go:10  node 2 started
+n1> I180301 10:00:02.000000 1 server/node.go:11  rotated
+`},
+		{"message", []string{zipName}, logFilter{
+			message: regexp.MustCompile(`started|second`),
+		}, `n1> I180301 10:00:00.000000 1 server/node.go:10  node 1 started
+n2> I180301 10:00:01.000000 1 server/node.go:10  node 2 started
+n1> W180301 10:00:02.500000 5 storage/store.go:20  slow
+n1>   second line
+`},
+		{"file", []string{zipName}, logFilter{
+			fileLine: regexp.MustCompile(`^(sql/|server/node\.go:11$)`),
+		}, `n1> I180301 10:00:02.000000 1 server/node.go:11  rotated
+n2> E180301 10:00:04.000000 7 sql/exec.go:30  boom
+`},
+	}
+
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			sources, closer, err := findLogSources(d.args)
+			defer closer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := mergeLogs(&buf, sources, d.filter); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != d.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", d.expected, buf.String())
+			}
+		})
+	}
+}
+
+// memLogFile is an in-memory log file, which keeps count of the open
+// files.
+type memLogFile struct {
+	*strings.Reader
+	open *int
+}
+
+func (f memLogFile) Close() error {
+	*f.open--
+	return nil
+}
+
+func TestMergeLogsOpensFilesLazily(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var open, maxOpen int
+	source := func(node, name string, created time.Time, contents string) logSource {
+		return logSource{
+			node: node,
+			name: name,
+			open: func() (io.ReadCloser, error) {
+				open++
+				if open > maxOpen {
+					maxOpen = open
+				}
+				return memLogFile{Reader: strings.NewReader(contents), open: &open}, nil
+			},
+			created: created,
+		}
+	}
+
+	// The files are listed in the reverse order of their creation, an hour
+	// apart.
+	var sources []logSource
+	for i := 3; i >= 0; i-- {
+		sources = append(sources, source(
+			fmt.Sprintf("n%d", i+1), fmt.Sprintf("file%d", i),
+			time.Date(2018, 3, 1, 10+i, 0, 0, 0, time.UTC),
+			fmt.Sprintf("I180301 %d:00:01.000000 1 server/node.go:%d  entry %d\n", 10+i, i, i),
+		))
+	}
+	// The files which can't be read and the malformed entries are skipped.
+	sources = append(sources, logSource{
+		node: "n5",
+		name: "missing",
+		open: func() (io.ReadCloser, error) { return nil, errors.New("no such file") },
+	})
+	sources = append(sources, source("n6", "malformed", time.Time{}, `I180301 10:30:00.000000 1 server/node.go:1  good
+I180301 99:99:99.000000 1 server/node.go:2  bad time
+I180301 10:30:02.000000 1 server/node.go:99999999999999999999  bad line
+I180301 10:30:03.000000 1 server/node.go:4  good again
+`))
+
+	var buf bytes.Buffer
+	if err := mergeLogs(&buf, sources, logFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	const expected = `n1> I180301 10:00:01.000000 1 server/node.go:0  entry 0
+n6> I180301 10:30:00.000000 1 server/node.go:1  good
+n6> I180301 10:30:03.000000 1 server/node.go:4  good again
+n2> I180301 11:00:01.000000 1 server/node.go:1  entry 1
+n3> I180301 12:00:01.000000 1 server/node.go:2  entry 2
+n4> I180301 13:00:01.000000 1 server/node.go:3  entry 3
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+	// The file whose creation time is unknown is open along with each file
+	// created an hour apart, which are opened one at a time.
+	if maxOpen != 2 {
+		t.Errorf("expected at most 2 open files, got %d", maxOpen)
+	}
+	if open != 0 {
+		t.Errorf("expected all the files to be closed, got %d open", open)
+	}
+}
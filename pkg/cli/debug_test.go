@@ -18,11 +18,16 @@ package cli
 import (
 	"context"
 	"path/filepath"
+	"reflect"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 )
@@ -74,3 +79,109 @@ func TestOpenExistingStore(t *testing.T) {
 		}
 	}
 }
+
+func TestRemoveDeadReplicas(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	db := engine.NewInMem(roachpb.Attributes{}, 1<<20)
+	defer db.Close()
+
+	makeDesc := func(rangeID int, start, end string, storeIDs ...int) roachpb.RangeDescriptor {
+		desc := roachpb.RangeDescriptor{
+			RangeID:       roachpb.RangeID(rangeID),
+			StartKey:      roachpb.RKey(start),
+			EndKey:        roachpb.RKey(end),
+			NextReplicaID: roachpb.ReplicaID(len(storeIDs) + 1),
+		}
+		for i, id := range storeIDs {
+			desc.Replicas = append(desc.Replicas, roachpb.ReplicaDescriptor{
+				NodeID:    roachpb.NodeID(id),
+				StoreID:   roachpb.StoreID(id),
+				ReplicaID: roachpb.ReplicaID(i + 1),
+			})
+		}
+		return desc
+	}
+	descs := []roachpb.RangeDescriptor{
+		makeDesc(1, "a", "b", 1, 2, 3),
+		makeDesc(2, "b", "c", 1, 4, 5),
+		makeDesc(3, "c", "d", 5, 2, 3),
+		makeDesc(4, "d", "e", 3, 2, 5, 1, 4),
+	}
+	ts := hlc.Timestamp{WallTime: 1}
+	for _, desc := range descs {
+		desc := desc
+		key := keys.RangeDescriptorKey(desc.StartKey)
+		if err := engine.MVCCPutProto(ctx, db, nil, key, ts, nil, &desc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An unfinished change of the descriptor of r1 left an intent.
+	txn := roachpb.MakeTransaction(
+		"test", roachpb.Key("a"), roachpb.NormalUserPriority, enginepb.SERIALIZABLE, ts.Next(), 0,
+	)
+	change := makeDesc(1, "a", "b", 1, 2, 3, 4)
+	if err := engine.MVCCPutProto(
+		ctx, db, nil, keys.RangeDescriptorKey(change.StartKey), txn.Timestamp, &txn, &change,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	deadStoreIDs, err := parseStoreIDs([]string{"2", "3", "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewritten := func(storeID roachpb.StoreID) map[roachpb.RangeID]roachpb.StoreID {
+		rewrites, err := planRemoveDeadReplicas(ctx, db, storeID, deadStoreIDs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := make(map[roachpb.RangeID]roachpb.StoreID)
+		for _, rw := range rewrites {
+			if len(rw.new.Replicas) != 1 {
+				t.Fatalf("expected a single replica, got %s", rw.new)
+			}
+			res[rw.new.RangeID] = rw.new.Replicas[0].StoreID
+		}
+		return res
+	}
+	// r2 still has a quorum. r3 has no replica on store 1, and the
+	// replica of r4 on store 1 takes over on both surviving stores.
+	if exp, res := map[roachpb.RangeID]roachpb.StoreID{1: 1, 4: 1}, rewritten(1); !reflect.DeepEqual(exp, res) {
+		t.Errorf("expected %v, got %v", exp, res)
+	}
+	if exp, res := map[roachpb.RangeID]roachpb.StoreID{3: 5, 4: 1}, rewritten(5); !reflect.DeepEqual(exp, res) {
+		t.Errorf("expected %v, got %v", exp, res)
+	}
+
+	rewrites, err := planRemoveDeadReplicas(ctx, db, 1, deadStoreIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := db.NewBatch()
+	defer batch.Close()
+	if err := applyRemoveDeadReplicas(ctx, batch, ts.Add(10, 0), rewrites); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(false /* sync */); err != nil {
+		t.Fatal(err)
+	}
+	for _, rw := range rewrites {
+		var desc roachpb.RangeDescriptor
+		if _, err := engine.MVCCGetProto(
+			ctx, db, keys.RangeDescriptorKey(rw.new.StartKey), hlc.MaxTimestamp, true, nil, &desc,
+		); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(desc, rw.new) {
+			t.Errorf("expected %s, got %s", rw.new, desc)
+		}
+	}
+
+	for _, ids := range [][]string{{"0"}, {"x"}, {"-1"}} {
+		if _, err := parseStoreIDs(ids); !testutils.IsError(err, "invalid store ID") {
+			t.Errorf("%v: unexpected error: %v", ids, err)
+		}
+	}
+}
@@ -15,12 +15,14 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"database/sql/driver"
 	"fmt"
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,6 +41,10 @@ var dumpCmd = &cobra.Command{
 	Long: `
 Dump SQL tables of a cockroach database. If the table name
 is omitted, dump all tables in the database.
+
+With --output-dir, the dump is written to files in the specified
+directory, table chunks can be dumped in parallel with --concurrency,
+and an interrupted dump is resumed when the command is run again.
 `,
 	RunE: MaybeDecorateGRPCError(runDump),
 }
@@ -48,6 +54,13 @@ func runDump(cmd *cobra.Command, args []string) error {
 		return usageAndError(cmd)
 	}
 
+	if dumpCtx.concurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
+	}
+	if dumpCtx.concurrency > 1 && dumpCtx.outputDir == "" {
+		return errors.New("--concurrency requires --output-dir")
+	}
+
 	conn, err := getPasswordAndMakeSQLClient()
 	if err != nil {
 		return err
@@ -60,7 +73,25 @@ func runDump(cmd *cobra.Command, args []string) error {
 		tableNames = args[1:]
 	}
 
-	mds, ts, err := getDumpMetadata(conn, dbName, tableNames, dumpCtx.asOf)
+	// An existing manifest in the output directory means that we are
+	// resuming an interrupted dump: keep dumping at its timestamp.
+	asOf := dumpCtx.asOf
+	var manifest *dumpManifest
+	if dumpCtx.outputDir != "" {
+		manifest, err = readDumpManifest(dumpCtx.outputDir)
+		if err != nil {
+			return err
+		}
+		if manifest != nil {
+			if err := manifest.checkResume(dbName, tableNames); err != nil {
+				return err
+			}
+			asOf = manifest.Timestamp
+			tableNames = manifest.Tables
+		}
+	}
+
+	mds, ts, err := getDumpMetadata(conn, dbName, tableNames, asOf)
 	if err != nil {
 		return err
 	}
@@ -95,6 +126,10 @@ func runDump(cmd *cobra.Command, args []string) error {
 		return collectOrder[mds[i].ID] < collectOrder[mds[j].ID]
 	})
 
+	if dumpCtx.outputDir != "" {
+		return dumpToDir(dumpCtx.outputDir, conn, dbName, mds, ts, manifest)
+	}
+
 	w := os.Stdout
 
 	if dumpCtx.dumpMode != dumpDataOnly {
@@ -112,7 +147,7 @@ func runDump(cmd *cobra.Command, args []string) error {
 			if md.isView {
 				continue
 			}
-			if err := dumpTableData(w, conn, ts, md); err != nil {
+			if err := dumpTableData(w, conn, ts, md, dumpChunk{}); err != nil {
 				return err
 			}
 		}
@@ -159,9 +194,13 @@ func getDumpMetadata(
 		}
 		clusterTS = string(vals[0].([]byte))
 	} else {
-		// Validate the timestamp. This prevents SQL injection.
+		// Validate the timestamp. This prevents SQL injection. Decimal
+		// cluster timestamps, as recorded in dump manifests, are accepted
+		// too.
 		if _, err := tree.ParseDTimestamp(asOf, time.Nanosecond); err != nil {
-			return nil, "", err
+			if _, decErr := tree.ParseDDecimal(asOf); decErr != nil {
+				return nil, "", err
+			}
 		}
 		clusterTS = asOf
 	}
@@ -346,11 +385,16 @@ const (
 	insertRows = 100
 )
 
-// dumpTableData dumps the data of the specified table to w.
-func dumpTableData(w io.Writer, conn *sqlConn, clusterTS string, md tableMetadata) error {
-	bs := fmt.Sprintf("SELECT * FROM %s AS OF SYSTEM TIME '%s' ORDER BY PRIMARY KEY %[1]s",
+// dumpTableData dumps the data of the specified chunk of a table to w, in
+// the format selected by --dump-format. The zero dumpChunk designates the
+// whole table.
+func dumpTableData(
+	w io.Writer, conn *sqlConn, clusterTS string, md tableMetadata, chunk dumpChunk,
+) error {
+	bs := fmt.Sprintf("SELECT * FROM %s AS OF SYSTEM TIME '%s'%s ORDER BY PRIMARY KEY %[1]s",
 		md.name,
 		clusterTS,
+		chunk.predicate(),
 	)
 	inserts := make([]string, 0, insertRows)
 	rows, err := conn.Query(bs, nil)
@@ -392,95 +436,29 @@ func dumpTableData(w io.Writer, conn *sqlConn, clusterTS string, md tableMetadat
 		}
 	})
 	g.Go(func() error {
-		// Convert SQL rows into VALUE strings.
+		// Convert SQL rows into VALUE strings or COPY lines.
 		defer close(stringsCh)
 		f := tree.NewFmtCtxWithBuf(tree.FmtParsable)
 		defer f.Close()
 		for vals := range valsCh {
 			f.Reset()
-			// Values need to be correctly encoded for INSERT statements in a text file.
 			for si, sv := range vals {
+				if dumpCtx.dumpFormat == dumpFormatCopy {
+					if si > 0 {
+						f.WriteByte('\t')
+					}
+					if err := formatCopyValue(f.Buffer, md.columnTypes[cols[si]], sv); err != nil {
+						return errors.Wrapf(err, "column %s", cols[si])
+					}
+					continue
+				}
+				// Values need to be correctly encoded for INSERT statements in a text file.
 				if si > 0 {
 					f.WriteString(", ")
 				}
-				var d tree.Datum
-				switch t := sv.(type) {
-				case nil:
-					d = tree.DNull
-				case bool:
-					d = tree.MakeDBool(tree.DBool(t))
-				case int64:
-					d = tree.NewDInt(tree.DInt(t))
-				case float64:
-					d = tree.NewDFloat(tree.DFloat(t))
-				case string:
-					d = tree.NewDString(t)
-				case []byte:
-					switch ct := md.columnTypes[cols[si]]; ct {
-					case "INTERVAL":
-						d, err = tree.ParseDInterval(string(t))
-						if err != nil {
-							return err
-						}
-					case "BYTES":
-						d = tree.NewDBytes(tree.DBytes(t))
-					case "UUID":
-						d, err = tree.ParseDUuidFromString(string(t))
-						if err != nil {
-							return err
-						}
-					case "INET":
-						d, err = tree.ParseDIPAddrFromINetString(string(t))
-						if err != nil {
-							return err
-						}
-					case "JSON":
-						d, err = tree.ParseDJSON(string(t))
-						if err != nil {
-							return err
-						}
-					default:
-						// STRING and DECIMAL types can have optional length
-						// suffixes, so only examine the prefix of the type.
-						// In addition, we can only observe ARRAY types by their [] suffix.
-						if strings.HasSuffix(md.columnTypes[cols[si]], "[]") {
-							typ := strings.TrimRight(md.columnTypes[cols[si]], "[]")
-							elemType, err := tree.StringToColType(typ)
-							if err != nil {
-								return err
-							}
-							d, err = tree.ParseDArrayFromString(tree.NewTestingEvalContext(), string(t), elemType)
-							if err != nil {
-								return err
-							}
-						} else if strings.HasPrefix(md.columnTypes[cols[si]], "STRING") {
-							d = tree.NewDString(string(t))
-						} else if strings.HasPrefix(md.columnTypes[cols[si]], "DECIMAL") {
-							d, err = tree.ParseDDecimal(string(t))
-							if err != nil {
-								return err
-							}
-						} else {
-							return errors.Errorf("unknown []byte type: %s, %v: %s", t, cols[si], md.columnTypes[cols[si]])
-						}
-					}
-				case time.Time:
-					ct := md.columnTypes[cols[si]]
-					switch ct {
-					case "DATE":
-						d = tree.NewDDateFromTime(t, time.UTC)
-					case "TIME":
-						// pq awkwardly represents TIME as a time.Time with date 0000-01-01.
-						d = tree.MakeDTime(timeofday.FromTime(t))
-					case "TIMESTAMP":
-						d = tree.MakeDTimestamp(t, time.Nanosecond)
-					case "TIMESTAMP WITH TIME ZONE":
-						d = tree.MakeDTimestampTZ(t, time.Nanosecond)
-					default:
-						return errors.Errorf("unknown timestamp type: %s, %v: %s", t, cols[si], md.columnTypes[cols[si]])
-					}
-				default:
-					return errors.Errorf("unknown field type: %T (%s)", t, cols[si])
+				d, err := datumFromDriverValue(md.columnTypes[cols[si]], sv)
+				if err != nil {
+					return errors.Wrapf(err, "column %s", cols[si])
 				}
 				d.Format(&f.FmtCtx)
 			}
@@ -493,6 +471,9 @@ func dumpTableData(w io.Writer, conn *sqlConn, clusterTS string, md tableMetadat
 		return nil
 	})
 	g.Go(func() error {
+		if dumpCtx.dumpFormat == dumpFormatCopy {
+			return writeCopy(w, md, stringsCh)
+		}
 		// Batch SQL strings into groups and write to output.
 		for s := range stringsCh {
 			inserts = append(inserts, s)
@@ -510,6 +491,66 @@ func dumpTableData(w io.Writer, conn *sqlConn, clusterTS string, md tableMetadat
 	return g.Wait()
 }
 
+// datumFromDriverValue converts a value received from the driver for a
+// column of the given type to a datum.
+func datumFromDriverValue(colType string, v driver.Value) (tree.Datum, error) {
+	switch t := v.(type) {
+	case nil:
+		return tree.DNull, nil
+	case bool:
+		return tree.MakeDBool(tree.DBool(t)), nil
+	case int64:
+		return tree.NewDInt(tree.DInt(t)), nil
+	case float64:
+		return tree.NewDFloat(tree.DFloat(t)), nil
+	case string:
+		return tree.NewDString(t), nil
+	case []byte:
+		switch colType {
+		case "INTERVAL":
+			return tree.ParseDInterval(string(t))
+		case "BYTES":
+			return tree.NewDBytes(tree.DBytes(t)), nil
+		case "UUID":
+			return tree.ParseDUuidFromString(string(t))
+		case "INET":
+			return tree.ParseDIPAddrFromINetString(string(t))
+		case "JSON":
+			return tree.ParseDJSON(string(t))
+		}
+		// STRING and DECIMAL types can have optional length
+		// suffixes, so only examine the prefix of the type.
+		// In addition, we can only observe ARRAY types by their [] suffix.
+		if strings.HasSuffix(colType, "[]") {
+			typ := strings.TrimRight(colType, "[]")
+			elemType, err := tree.StringToColType(typ)
+			if err != nil {
+				return nil, err
+			}
+			return tree.ParseDArrayFromString(tree.NewTestingEvalContext(), string(t), elemType)
+		} else if strings.HasPrefix(colType, "STRING") {
+			return tree.NewDString(string(t)), nil
+		} else if strings.HasPrefix(colType, "DECIMAL") {
+			return tree.ParseDDecimal(string(t))
+		}
+		return nil, errors.Errorf("unknown []byte type: %s, %s", t, colType)
+	case time.Time:
+		switch colType {
+		case "DATE":
+			return tree.NewDDateFromTime(t, time.UTC), nil
+		case "TIME":
+			// pq awkwardly represents TIME as a time.Time with date 0000-01-01.
+			return tree.MakeDTime(timeofday.FromTime(t)), nil
+		case "TIMESTAMP":
+			return tree.MakeDTimestamp(t, time.Nanosecond), nil
+		case "TIMESTAMP WITH TIME ZONE":
+			return tree.MakeDTimestampTZ(t, time.Nanosecond), nil
+		}
+		return nil, errors.Errorf("unknown timestamp type: %s, %s", t, colType)
+	}
+	return nil, errors.Errorf("unknown field type: %T", v)
+}
+
 func writeInserts(w io.Writer, md tableMetadata, inserts []string) {
 	fmt.Fprintf(w, "\nINSERT INTO %s (%s) VALUES", &md.name.TableName, md.columnNames)
 	for idx, values := range inserts {
@@ -520,3 +561,109 @@ func writeInserts(w io.Writer, md tableMetadata, inserts []string) {
 	}
 	fmt.Fprintln(w, ";")
 }
+
+// writeCopy writes the COPY lines received on lines to w, in a single
+// COPY ... FROM stdin block. Nothing is written if there are no lines.
+func writeCopy(w io.Writer, md tableMetadata, lines <-chan string) error {
+	n := 0
+	for line := range lines {
+		if n == 0 {
+			fmt.Fprintf(w, "\nCOPY %s (%s) FROM stdin;\n", &md.name.TableName, md.columnNames)
+		}
+		n++
+		fmt.Fprintln(w, line)
+	}
+	if n > 0 {
+		fmt.Fprintln(w, `\.`)
+	}
+	return nil
+}
+
+// formatCopyValue writes the text representation of a value received
+// from the driver for a column of the given type, as a field of a COPY
+// line understood by the COPY implementation of the server (see
+// sql/copy.go). The server only unescapes the fields of the types it
+// expects to contain arbitrary characters; the text of the other types
+// is used verbatim and thus can't contain the field or line delimiters.
+func formatCopyValue(buf *bytes.Buffer, colType string, v driver.Value) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString(`\N`)
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case int64:
+		buf.WriteString(strconv.FormatInt(t, 10))
+	case float64:
+		buf.WriteString(strconv.FormatFloat(t, 'g', -1, 64))
+	case string:
+		encodeCopyString(buf, t)
+	case []byte:
+		switch {
+		case colType == "BYTES":
+			encodeCopyBytes(buf, t)
+		case colType == "INTERVAL", colType == "UUID", colType == "INET",
+			strings.HasPrefix(colType, "STRING") && !strings.HasSuffix(colType, "[]"):
+			encodeCopyString(buf, string(t))
+		default:
+			// DECIMAL, JSON and ARRAY values.
+			if bytes.ContainsAny(t, "\t\n\r") {
+				return errors.Errorf("%s value %q can't be dumped in the COPY format", colType, t)
+			}
+			buf.Write(t)
+		}
+	case time.Time:
+		switch colType {
+		case "DATE":
+			buf.WriteString(t.Format("2006-01-02"))
+		case "TIME":
+			// pq awkwardly represents TIME as a time.Time with date 0000-01-01.
+			buf.WriteString(t.Format("15:04:05.999999"))
+		case "TIMESTAMP":
+			buf.WriteString(t.Format("2006-01-02 15:04:05.999999999"))
+		case "TIMESTAMP WITH TIME ZONE":
+			buf.WriteString(t.Format("2006-01-02 15:04:05.999999999-07:00"))
+		default:
+			return errors.Errorf("unknown timestamp type: %s, %s", t, colType)
+		}
+	default:
+		return errors.Errorf("unknown field type: %T", v)
+	}
+	return nil
+}
+
+// encodeCopyString escapes the backslashes and delimiters of s for a
+// COPY field.
+func encodeCopyString(buf *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
+
+// encodeCopyBytes escapes b for a COPY field, using hexadecimal escapes
+// for all the bytes that are not printable ASCII characters.
+func encodeCopyBytes(buf *bytes.Buffer, b []byte) {
+	const hex = "0123456789abcdef"
+	for _, c := range b {
+		switch {
+		case c == '\\':
+			buf.WriteString(`\\`)
+		case c < 0x20 || c > 0x7e:
+			buf.WriteString(`\x`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// A dump to an output directory consists of the following files:
+//
+// - schema.sql contains the CREATE statements of the dumped tables.
+// - data-TTTT-CCCCC.sql contains the data of the chunk CCCCC of the
+//   table TTTT, in dump order. Tables are split into chunks at their
+//   range boundaries, so that chunks can be dumped in parallel.
+// - manifest.json records the timestamp of the dump, its chunks and
+//   which of them are done.
+//
+// All the files are written atomically. Running the dump again with an
+// existing manifest dumps the chunks that are not done yet, at the
+// timestamp of the manifest.
+
+const (
+	dumpManifestFile = "manifest.json"
+	dumpSchemaFile   = "schema.sql"
+)
+
+// dumpManifest records the progress of a dump to an output directory.
+type dumpManifest struct {
+	Database  string   `json:"database"`
+	Timestamp string   `json:"timestamp"`
+	Mode      string   `json:"mode"`
+	Format    string   `json:"format"`
+	Tables    []string `json:"tables"`
+	// Chunks lists the chunks of all the dumped tables, in dump order.
+	Chunks []dumpChunk `json:"chunks"`
+}
+
+// dumpChunk is a part of the data of a table, delimited by bounds on
+// its first primary key column.
+type dumpChunk struct {
+	Table string `json:"table"`
+	File  string `json:"file"`
+	// Column is the first primary key column of the table. It is only
+	// set if the chunk has bounds.
+	Column string `json:"column,omitempty"`
+	// Lower and Upper are the inclusive lower bound and exclusive upper
+	// bound of the chunk, if any.
+	Lower *int64 `json:"lower,omitempty"`
+	Upper *int64 `json:"upper,omitempty"`
+	Done  bool   `json:"done"`
+}
+
+// predicate returns the WHERE clause selecting the rows of the chunk.
+func (c dumpChunk) predicate() string {
+	var conds []string
+	col := tree.NameString(c.Column)
+	if c.Lower != nil {
+		conds = append(conds, fmt.Sprintf("%s >= %d", col, *c.Lower))
+	}
+	if c.Upper != nil {
+		conds = append(conds, fmt.Sprintf("%s < %d", col, *c.Upper))
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// readDumpManifest reads the manifest of the dump in the given directory.
+// It returns nil if there is none.
+func readDumpManifest(dir string) (*dumpManifest, error) {
+	path := filepath.Join(dir, dumpManifestFile)
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var m dumpManifest
+	if err := json.Unmarshal(contents, &m); err != nil {
+		return nil, errors.Wrapf(err, "could not parse dump manifest %s", path)
+	}
+	return &m, nil
+}
+
+// checkResume verifies that the dump recorded in the manifest is the one
+// requested on the command line.
+func (m *dumpManifest) checkResume(dbName string, tableNames []string) error {
+	if m.Database != dbName {
+		return errors.Errorf("%s contains a dump of database %s", dumpCtx.outputDir, m.Database)
+	}
+	if tableNames != nil {
+		requested := append([]string(nil), tableNames...)
+		recorded := append([]string(nil), m.Tables...)
+		sort.Strings(requested)
+		sort.Strings(recorded)
+		if strings.Join(requested, ",") != strings.Join(recorded, ",") {
+			return errors.Errorf("%s contains a dump of tables %s",
+				dumpCtx.outputDir, strings.Join(m.Tables, ", "))
+		}
+	}
+	if dumpCtx.asOf != "" && dumpCtx.asOf != m.Timestamp {
+		return errors.Errorf("%s contains a dump as of %s", dumpCtx.outputDir, m.Timestamp)
+	}
+	if mode := dumpCtx.dumpMode.String(); mode != m.Mode {
+		return errors.Errorf("%s contains a dump with --dump-mode=%s, not %s",
+			dumpCtx.outputDir, m.Mode, mode)
+	}
+	if format := dumpCtx.dumpFormat.String(); format != m.Format {
+		return errors.Errorf("%s contains a dump with --dump-format=%s, not %s",
+			dumpCtx.outputDir, m.Format, format)
+	}
+	return nil
+}
+
+// write atomically replaces the manifest in the given directory.
+func (m *dumpManifest) write(dir string) error {
+	contents, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeDumpFile(dir, dumpManifestFile, func(w io.Writer) error {
+		_, err := w.Write(contents)
+		return err
+	})
+}
+
+// writeDumpFile writes a file in the given directory using fn. The file
+// is written to a temporary file first and renamed once complete, so
+// that it is never seen partially written.
+func writeDumpFile(dir, name string, fn func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(dir, "."+name+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	err = func() error {
+		defer f.Close()
+		w := bufio.NewWriter(f)
+		if err := fn(w); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return f.Sync()
+	}()
+	if err == nil {
+		err = os.Rename(tmpPath, filepath.Join(dir, name))
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
+
+// dumpToDir dumps the specified tables to the given directory. If
+// manifest is not nil, the dump it records is resumed.
+func dumpToDir(
+	dir string,
+	conn *sqlConn,
+	dbName string,
+	mds []tableMetadata,
+	clusterTS string,
+	manifest *dumpManifest,
+) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if manifest == nil {
+		manifest = &dumpManifest{
+			Database:  dbName,
+			Timestamp: clusterTS,
+			Mode:      dumpCtx.dumpMode.String(),
+			Format:    dumpCtx.dumpFormat.String(),
+		}
+		for i, md := range mds {
+			manifest.Tables = append(manifest.Tables, string(md.name.TableName))
+			if dumpCtx.dumpMode == dumpSchemaOnly || md.isView {
+				continue
+			}
+			chunks, err := makeDumpChunks(conn, i, md)
+			if err != nil {
+				return err
+			}
+			manifest.Chunks = append(manifest.Chunks, chunks...)
+		}
+		if err := manifest.write(dir); err != nil {
+			return err
+		}
+	}
+
+	if dumpCtx.dumpMode != dumpDataOnly {
+		if err := writeDumpFile(dir, dumpSchemaFile, func(w io.Writer) error {
+			for i, md := range mds {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				if err := dumpCreateTable(w, md); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	byName := make(map[string]tableMetadata)
+	for _, md := range mds {
+		byName[string(md.name.TableName)] = md
+	}
+	var pending []int
+	for i, c := range manifest.Chunks {
+		if !c.Done {
+			pending = append(pending, i)
+		}
+	}
+
+	// The chunks are dumped by dumpCtx.concurrency workers, each with its
+	// own connection. The manifest is updated as soon as a chunk is done.
+	var mu syncutil.Mutex
+	g, ctx := errgroup.WithContext(context.Background())
+	work := make(chan int)
+	g.Go(func() error {
+		defer close(work)
+		for _, i := range pending {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case work <- i:
+			}
+		}
+		return nil
+	})
+	for worker := 0; worker < dumpCtx.concurrency; worker++ {
+		workerConn := conn
+		if worker > 0 {
+			workerConn = makeSQLConn(conn.url)
+			defer workerConn.Close()
+		}
+		g.Go(func() error {
+			for i := range work {
+				chunk := manifest.Chunks[i]
+				md, ok := byName[chunk.Table]
+				if !ok {
+					return errors.Errorf("table %s of chunk %s not found", chunk.Table, chunk.File)
+				}
+				if err := writeDumpFile(dir, chunk.File, func(w io.Writer) error {
+					return dumpTableData(w, workerConn, clusterTS, md, chunk)
+				}); err != nil {
+					return errors.Wrapf(err, "could not dump %s", chunk.File)
+				}
+
+				mu.Lock()
+				manifest.Chunks[i].Done = true
+				err := manifest.write(dir)
+				mu.Unlock()
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// makeDumpChunks splits the data of the table at position tableIdx in
+// the dump into chunks. Tables are only split when dumping with more
+// than one worker, at the range boundaries of their primary index, if
+// the first column of their primary key is an integer. Any set of bounds
+// splits the table correctly; the range boundaries only make for chunks
+// of similar sizes.
+func makeDumpChunks(conn *sqlConn, tableIdx int, md tableMetadata) ([]dumpChunk, error) {
+	chunkFile := func(chunkIdx int) string {
+		return fmt.Sprintf("data-%04d-%05d.sql", tableIdx, chunkIdx)
+	}
+	table := string(md.name.TableName)
+	whole := []dumpChunk{{Table: table, File: chunkFile(0)}}
+	if dumpCtx.concurrency == 1 {
+		return whole, nil
+	}
+
+	col, err := getFirstPrimaryKeyColumn(conn, md)
+	if err != nil {
+		return nil, err
+	}
+	// The hidden rowid column is not listed in the column types.
+	if typ, ok := md.columnTypes[col]; col == "" || (ok && typ != "INT") {
+		return whole, nil
+	}
+	splits, err := getTableSplits(conn, md)
+	if err != nil {
+		return nil, err
+	}
+	if len(splits) == 0 {
+		return whole, nil
+	}
+
+	chunks := make([]dumpChunk, len(splits)+1)
+	for i := range chunks {
+		chunks[i] = dumpChunk{Table: table, File: chunkFile(i), Column: col}
+		if i > 0 {
+			chunks[i].Lower = &splits[i-1]
+		}
+		if i < len(splits) {
+			chunks[i].Upper = &splits[i]
+		}
+	}
+	return chunks, nil
+}
+
+// getFirstPrimaryKeyColumn retrieves the name of the first column of the
+// primary key of the specified table.
+func getFirstPrimaryKeyColumn(conn *sqlConn, md tableMetadata) (string, error) {
+	vals, err := conn.QueryRow(`
+		SELECT k.COLUMN_NAME
+		FROM "".information_schema.key_column_usage AS k
+		JOIN "".information_schema.table_constraints AS c
+			ON k.TABLE_SCHEMA = c.TABLE_SCHEMA
+			AND k.TABLE_NAME = c.TABLE_NAME
+			AND k.CONSTRAINT_NAME = c.CONSTRAINT_NAME
+		WHERE k.TABLE_SCHEMA = $1
+			AND k.TABLE_NAME = $2
+			AND c.CONSTRAINT_TYPE = 'PRIMARY KEY'
+			AND k.ORDINAL_POSITION = 1
+		`, []driver.Value{string(md.name.DatabaseName), string(md.name.TableName)})
+	if err != nil {
+		if err == io.EOF {
+			return "", nil
+		}
+		return "", err
+	}
+	name, ok := vals[0].(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected value: %T", vals[0])
+	}
+	return name, nil
+}
+
+// getTableSplits retrieves the values of the first primary key column at
+// the range boundaries of the primary index of the specified table, in
+// increasing order. The values that are not integers are skipped.
+func getTableSplits(conn *sqlConn, md tableMetadata) ([]int64, error) {
+	rows, err := conn.Query(
+		fmt.Sprintf(`SHOW TESTING_RANGES FROM TABLE %s`, md.name), nil)
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]driver.Value, len(rows.Columns()))
+	seen := make(map[int64]bool)
+	var splits []int64
+	for {
+		if err := rows.Next(vals); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// The start key is NULL for the first range, and is otherwise
+		// printed as /<first column value>/<other column values>...
+		startKey, ok := vals[0].(string)
+		if !ok || !strings.HasPrefix(startKey, "/") {
+			continue
+		}
+		first := strings.SplitN(startKey[1:], "/", 2)[0]
+		v, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || seen[v] {
+			continue
+		}
+		seen[v] = true
+		splits = append(splits, v)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	sort.Slice(splits, func(i, j int) bool { return splits[i] < splits[j] })
+	return splits, nil
+}
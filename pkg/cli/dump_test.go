@@ -19,8 +19,12 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -30,6 +34,7 @@ import (
 
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
@@ -277,7 +282,7 @@ func dumpSingleTable(w io.Writer, conn *sqlConn, dbName string, tName string) er
 	if err := dumpCreateTable(w, mds[0]); err != nil {
 		return err
 	}
-	return dumpTableData(w, conn, ts, mds[0])
+	return dumpTableData(w, conn, ts, mds[0], dumpChunk{})
 }
 
 func TestDumpBytes(t *testing.T) {
@@ -968,3 +973,162 @@ INSERT INTO t (id, next_id) VALUES
 		t.Fatalf("expected: %s\ngot: %s", expect, out)
 	}
 }
+
+func TestDumpCopy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := newCLITest(cliTestParams{t: t})
+	defer c.cleanup()
+
+	const create = `
+	CREATE DATABASE d;
+	CREATE TABLE d.t (
+		i INT PRIMARY KEY,
+		s STRING,
+		b BYTES,
+		ts TIMESTAMP,
+		j JSON,
+		n INTERVAL,
+		e DECIMAL
+	);
+	INSERT INTO d.t VALUES
+		(1, e'a\tb\nc\\d', b'\x00ab\\', '2016-01-25 10:10:10', '{"a":"b"}', '2h30m30s', 1.5),
+		(2, NULL, NULL, NULL, NULL, NULL, NULL);
+	CREATE TABLE d.empty (i INT);
+`
+	c.RunWithArgs([]string{"sql", "-e", create})
+
+	out, err := c.RunWithCapture("dump d t empty --dump-mode=data --dump-format=copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `dump d t empty --dump-mode=data --dump-format=copy
+
+COPY t (i, s, b, ts, j, n, e) FROM stdin;
+1	a\tb\nc\\d	\x00ab\\	2016-01-25 10:10:10	{"a":"b"}	2h30m30s	1.5
+2	\N	\N	\N	\N	\N	\N
+\.
+`
+	if out != expected {
+		t.Fatalf("expected %s\ngot: %s", expected, out)
+	}
+}
+
+func TestDumpOutputDir(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := newCLITest(cliTestParams{t: t})
+	defer c.cleanup()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	url, urlCleanup := sqlutils.PGUrl(t, c.ServingAddr(), t.Name(), url.User(security.RootUser))
+	defer urlCleanup()
+	conn := makeSQLConn(url.String())
+	defer conn.Close()
+
+	if err := conn.Exec(`
+		CREATE DATABASE d;
+		CREATE TABLE d.t (i INT PRIMARY KEY, s STRING);
+		INSERT INTO d.t SELECT i, i::STRING FROM generate_series(1, 99, 2) AS g(i);
+		ALTER TABLE d.t SPLIT AT VALUES (25), (50), (75);
+	`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := c.RunWithCaptureArgs([]string{"dump", "d", "t", "--concurrency=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "--concurrency requires --output-dir") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	dump := []string{"dump", "d", "t", "--output-dir", dir, "--concurrency=3"}
+	if out, err := c.RunWithCaptureArgs(dump); err != nil {
+		t.Fatal(err)
+	} else if strings.Count(out, "\n") != 1 {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	// The table is split at its range boundaries.
+	m, err := readDumpManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bounds []string
+	for _, chunk := range m.Chunks {
+		if !chunk.Done {
+			t.Fatalf("chunk %s not done", chunk.File)
+		}
+		bounds = append(bounds, chunk.predicate())
+	}
+	expectedBounds := []string{
+		" WHERE i < 25",
+		" WHERE i >= 25 AND i < 50",
+		" WHERE i >= 50 AND i < 75",
+		" WHERE i >= 75",
+	}
+	if !reflect.DeepEqual(bounds, expectedBounds) {
+		t.Fatalf("expected chunks %q, got %q", expectedBounds, bounds)
+	}
+
+	// Simulate an interrupted dump: the third chunk is not done. The
+	// resumed dump uses the original timestamp, so it does not see rows
+	// inserted since.
+	m.Chunks[2].Done = false
+	if err := m.write(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, m.Chunks[2].File)); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Exec(`INSERT INTO d.t VALUES (52, '52')`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := c.RunWithCaptureArgs(append(dump, "--dump-format=copy")); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(out, "contains a dump with --dump-format=insert, not copy") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if _, err := c.RunWithCaptureArgs(dump); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = readDumpManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Chunks[2].Done {
+		t.Fatalf("chunk %s not done", m.Chunks[2].File)
+	}
+
+	// Load the dump in another database.
+	if err := conn.Exec(`
+		CREATE DATABASE o;
+		SET DATABASE = o;
+	`, nil); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "data-*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	for _, file := range append([]string{filepath.Join(dir, dumpSchemaFile)}, files...) {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.Exec(string(contents), nil); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+	}
+	vals, err := conn.QueryRow(`SELECT count(*), sum(i) FROM o.t`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, sum := vals[0].(int64), string(vals[1].([]byte)); count != 50 || sum != "2500" {
+		t.Fatalf("expected 50 rows summing to 2500, got %d rows summing to %s", count, sum)
+	}
+}
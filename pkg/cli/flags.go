@@ -25,7 +25,9 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/cli/cliflags"
+	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/log/logflags"
 )
@@ -223,6 +225,8 @@ func init() {
 
 		StringFlag(f, &serverCfg.PIDFile, cliflags.PIDFile, serverCfg.PIDFile)
 
+		StringFlag(f, &serverCfg.SettingsFile, cliflags.SettingsFile, serverCfg.SettingsFile)
+
 		// Use a separate variable to store the value of ServerInsecure.
 		// We share the default with the ClientInsecure flag.
 		BoolFlag(f, &startCtx.serverInsecure, cliflags.ServerInsecure, startCtx.serverInsecure)
@@ -230,6 +234,10 @@ func init() {
 		// Certificates directory. Use a server-specific flag and value to ignore environment
 		// variables, but share the same default.
 		StringFlag(f, &startCtx.serverSSLCertsDir, cliflags.ServerCertsDir, startCtx.serverSSLCertsDir)
+		// The CA key makes this node sign the renewed certificates of the
+		// other nodes. Like the certificates directory, use a server-specific
+		// flag to ignore the environment variable of the cert commands.
+		StringFlag(f, &startCtx.serverSSLCAKey, cliflags.ServerCAKey, startCtx.serverSSLCAKey)
 
 		// Cluster joining flags.
 		VarFlag(f, &serverCfg.JoinList, cliflags.Join)
@@ -243,6 +251,10 @@ func init() {
 		VarFlag(f, diskTempStorageSizeValue, cliflags.SQLTempStorage)
 		StringFlag(f, &startCtx.tempDir, cliflags.TempDir, startCtx.tempDir)
 		StringFlag(f, &startCtx.externalIODir, cliflags.ExternalIODir, startCtx.externalIODir)
+
+		// SQL audit log flags.
+		VarFlag(f, humanizeutil.NewBytesValue(&sql.AuditLogFileMaxSize), cliflags.SQLAuditLogFileMaxSize)
+		VarFlag(f, humanizeutil.NewBytesValue(&sql.AuditLogFilesCombinedMaxSize), cliflags.SQLAuditLogDirMaxSize)
 	}
 
 	for _, cmd := range certCmds {
@@ -279,6 +291,7 @@ func init() {
 		debugZipCmd,
 		dumpCmd,
 		genHAProxyCmd,
+		genSettingsDiffCmd,
 		quitCmd,
 		sqlShellCmd,
 		/* StartCmd is covered above */
@@ -301,6 +314,7 @@ func init() {
 	timeoutCmds := []*cobra.Command{
 		statusNodeCmd,
 		lsNodesCmd,
+		drainNodeCmd,
 		// If you add something here, make sure the actual implementation
 		// of the command uses `cmdTimeoutContext(.)` or it will ignore
 		// the timeout.
@@ -325,6 +339,9 @@ func init() {
 	// Quit command.
 	BoolFlag(quitCmd.Flags(), &quitCtx.serverDecommission, cliflags.Decommission, quitCtx.serverDecommission)
 
+	// Init command.
+	StringFlag(initCmd.Flags(), &initCtx.settingsFile, cliflags.InitSettings, initCtx.settingsFile)
+
 	zf := setZoneCmd.Flags()
 	StringFlag(zf, &zoneCtx.zoneConfig, cliflags.ZoneConfig, zoneCtx.zoneConfig)
 	BoolFlag(zf, &zoneCtx.zoneDisableReplication, cliflags.ZoneDisableReplication, zoneCtx.zoneDisableReplication)
@@ -334,9 +351,12 @@ func init() {
 
 	VarFlag(dumpCmd.Flags(), &dumpCtx.dumpMode, cliflags.DumpMode)
 	StringFlag(dumpCmd.Flags(), &dumpCtx.asOf, cliflags.DumpTime, dumpCtx.asOf)
+	VarFlag(dumpCmd.Flags(), &dumpCtx.dumpFormat, cliflags.DumpFormat)
+	IntFlag(dumpCmd.Flags(), &dumpCtx.concurrency, cliflags.DumpConcurrency, dumpCtx.concurrency)
+	StringFlag(dumpCmd.Flags(), &dumpCtx.outputDir, cliflags.DumpOutputDir, dumpCtx.outputDir)
 
 	// Commands that establish a SQL connection.
-	sqlCmds := []*cobra.Command{sqlShellCmd, dumpCmd}
+	sqlCmds := []*cobra.Command{sqlShellCmd, dumpCmd, genSettingsDiffCmd}
 	sqlCmds = append(sqlCmds, zoneCmds...)
 	sqlCmds = append(sqlCmds, userCmds...)
 	for _, cmd := range sqlCmds {
@@ -351,7 +371,7 @@ func init() {
 	}
 
 	// Commands that print tables.
-	tableOutputCommands := []*cobra.Command{sqlShellCmd}
+	tableOutputCommands := []*cobra.Command{sqlShellCmd, genSettingsDiffCmd}
 	tableOutputCommands = append(tableOutputCommands, userCmds...)
 	tableOutputCommands = append(tableOutputCommands, nodeCmds...)
 
@@ -373,6 +393,13 @@ func init() {
 		BoolFlag(f, &debugCtx.values, cliflags.Values, debugCtx.values)
 		BoolFlag(f, &debugCtx.sizes, cliflags.Sizes, debugCtx.sizes)
 	}
+	{
+		f := debugZipCmd.Flags()
+		VarFlag(f, (*timestampValue)(&zipCtx.logsFrom), cliflags.LogsFrom)
+		VarFlag(f, (*timestampValue)(&zipCtx.logsTo), cliflags.LogsTo)
+		BoolFlag(f, &zipCtx.redact, cliflags.ZipRedact, zipCtx.redact)
+		DurationFlag(f, &zipCtx.timeout, cliflags.ZipTimeout, zipCtx.timeout)
+	}
 	{
 		f := debugRangeDataCmd.Flags()
 		BoolFlag(f, &debugCtx.replicated, cliflags.Replicated, debugCtx.replicated)
@@ -382,6 +409,17 @@ func init() {
 		StringFlag(f, &debugCtx.inputFile, cliflags.GossipInputFile, debugCtx.inputFile)
 		BoolFlag(f, &debugCtx.printSystemConfig, cliflags.PrintSystemConfig, debugCtx.printSystemConfig)
 	}
+	{
+		f := debugMergeLogsCmd.Flags()
+		VarFlag(f, (*timestampValue)(&mergeLogsCtx.from), cliflags.LogsFrom)
+		VarFlag(f, (*timestampValue)(&mergeLogsCtx.to), cliflags.LogsTo)
+		StringFlag(f, &mergeLogsCtx.filter, cliflags.MergeLogsFilter, mergeLogsCtx.filter)
+		StringFlag(f, &mergeLogsCtx.fileFilter, cliflags.MergeLogsFileFilter, mergeLogsCtx.fileFilter)
+	}
+	{
+		f := debugRemoveDeadReplicasCmd.Flags()
+		StringSliceFlag(f, &debugCtx.deadStoreIDs, cliflags.DeadStoreIDs, debugCtx.deadStoreIDs)
+	}
 }
 
 func extraServerFlagInit() {
@@ -20,10 +20,12 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	humanize "github.com/dustin/go-humanize"
@@ -91,6 +93,40 @@ func (m *dumpMode) Set(s string) error {
 	return nil
 }
 
+type dumpFormat int
+
+const (
+	dumpFormatInsert dumpFormat = iota
+	dumpFormatCopy
+)
+
+// Type implements the pflag.Value interface.
+func (f *dumpFormat) Type() string { return "string" }
+
+// String implements the pflag.Value interface.
+func (f *dumpFormat) String() string {
+	switch *f {
+	case dumpFormatInsert:
+		return "insert"
+	case dumpFormatCopy:
+		return "copy"
+	}
+	return ""
+}
+
+// Set implements the pflag.Value interface.
+func (f *dumpFormat) Set(s string) error {
+	switch s {
+	case "insert":
+		*f = dumpFormatInsert
+	case "copy":
+		*f = dumpFormatCopy
+	default:
+		return fmt.Errorf("invalid value for --dump-format: %s", s)
+	}
+	return nil
+}
+
 type mvccKey engine.MVCCKey
 
 // Type implements the pflag.Value interface.
@@ -226,6 +262,32 @@ func (s *nodeDecommissionWaitType) Set(value string) error {
 	return nil
 }
 
+// timestampValue is an implementation of pflag.Value for timestamps,
+// in any of the formats of SQL TIMESTAMP literals. Timestamps without a
+// time zone are in UTC.
+type timestampValue time.Time
+
+// Type implements the pflag.Value interface.
+func (t *timestampValue) Type() string { return "timestamp" }
+
+// String implements the pflag.Value interface.
+func (t *timestampValue) String() string {
+	if time.Time(*t).IsZero() {
+		return ""
+	}
+	return time.Time(*t).Format(time.RFC3339Nano)
+}
+
+// Set implements the pflag.Value interface.
+func (t *timestampValue) Set(value string) error {
+	d, err := tree.ParseDTimestamp(value, time.Nanosecond)
+	if err != nil {
+		return err
+	}
+	*t = timestampValue(d.Time)
+	return nil
+}
+
 type tableDisplayFormat int
 
 const (
@@ -118,6 +118,7 @@ var genCmds = []*cobra.Command{
 	genAutocompleteCmd,
 	genExamplesCmd,
 	genHAProxyCmd,
+	genSettingsDiffCmd,
 }
 
 func init() {
@@ -19,9 +19,11 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/settingsfile"
 )
 
 var initCmd = &cobra.Command{
@@ -37,6 +39,9 @@ must appear in the --join flags of other nodes.
 
 A node started without the --join flag initializes itself as a
 single-node cluster, so the init command is not used in that case.
+
+With --settings, the cluster settings of the given settings file are
+set once the cluster is initialized.
 `,
 	RunE: MaybeShoutError(MaybeDecorateGRPCError(runInit)),
 }
@@ -45,6 +50,16 @@ func runInit(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Validate the settings file before initializing the cluster, which
+	// cannot be undone.
+	var settingsFile settingsfile.File
+	if initCtx.settingsFile != "" {
+		var err error
+		if settingsFile, err = settingsfile.Read(initCtx.settingsFile); err != nil {
+			return err
+		}
+	}
+
 	conn, _, finish, err := getClientGRPCConn(ctx)
 	if err != nil {
 		return err
@@ -58,5 +73,27 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Fprintln(os.Stdout, "Cluster successfully initialized")
+
+	if len(settingsFile) == 0 {
+		return nil
+	}
+	return applyInitSettings(settingsFile)
+}
+
+// applyInitSettings sets the cluster settings of the settings file through
+// SQL, like an operator would right after initializing the cluster.
+func applyInitSettings(settingsFile settingsfile.File) error {
+	conn, err := getPasswordAndMakeSQLClient()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, s := range settingsFile {
+		if err := conn.Exec(s.SetStatement(), nil); err != nil {
+			return errors.Wrapf(err, "setting cluster setting %s", s.Name)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Applied %d cluster settings from %s\n", len(settingsFile), initCtx.settingsFile)
 	return nil
 }
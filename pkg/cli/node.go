@@ -15,19 +15,23 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server"
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/server/status"
 	"github.com/cockroachdb/cockroach/pkg/util/retry"
@@ -483,12 +487,172 @@ func runRecommissionNode(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var drainNodeColumnHeaders = []string{
+	"draining",
+	"sql_conns",
+	"leases",
+	"is_drained",
+}
+
+var drainNodeCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "drains the node without shutting it down",
+	Long: `
+Drains the node: it refuses new SQL clients and closes the existing ones
+between transactions with an admin shutdown error, on which they can
+reconnect to another node, and moves its range leases to other nodes.
+Waits until the node holds neither SQL clients nor leases, and returns.
+
+Unlike 'quit', the node keeps running (and draining) until it is stopped,
+for example by an orchestrator during a rolling upgrade. The drain progress
+can be monitored through the /_admin/v1/drain endpoint.
+
+Fails if the number of SQL clients and leases stops decreasing for a while,
+for example when no other node has a replica of the ranges, and lists the
+ranges whose leases could not be moved. --timeout bounds the total wait.
+`,
+	RunE: MaybeDecorateGRPCError(runDrainNode),
+}
+
+func runDrainNode(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageAndError(cmd)
+	}
+
+	ctx, cancel := cmdTimeoutContext(context.Background())
+	defer cancel()
+
+	c, finish, err := getAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer finish()
+
+	onModes := make([]int32, len(server.GracefulDrainModes))
+	for i, m := range server.GracefulDrainModes {
+		onModes[i] = int32(m)
+	}
+	minSQLConns, minLeaseCount := int64(math.MaxInt64), int64(math.MaxInt64)
+	opts := retry.Options{
+		InitialBackoff: 5 * time.Millisecond,
+		Multiplier:     2,
+		MaxBackoff:     20 * time.Second,
+		// The retries are reset on progress. Without progress, give up
+		// after about 80 seconds.
+		MaxRetries: 15,
+	}
+
+	var prevResponse serverpb.DrainStatusResponse
+	for r := retry.StartWithCtx(ctx, opts); r.Next(); {
+		// Draining is idempotent, and draining again retries the transfer of
+		// the leases which could not be moved away so far.
+		if err := drainNode(ctx, c, onModes); err != nil {
+			fmt.Fprintln(stderr)
+			return errors.Wrap(err, "while trying to drain")
+		}
+		resp, err := c.DrainStatus(ctx, &serverpb.DrainStatusRequest{})
+		if err != nil {
+			fmt.Fprintln(stderr)
+			return errors.Wrap(err, "while retrieving the drain status")
+		}
+
+		if !reflect.DeepEqual(&prevResponse, resp) {
+			fmt.Fprintln(stderr)
+			if err := printDrainStatus(*resp); err != nil {
+				return err
+			}
+			prevResponse = *resp
+		} else {
+			fmt.Fprintf(stderr, ".")
+		}
+		if resp.Drained {
+			fmt.Fprintln(os.Stdout, "\nNode drained. It keeps running until it is stopped.")
+			return nil
+		}
+		if resp.SQLConns < minSQLConns || resp.LeaseCount < minLeaseCount {
+			if resp.SQLConns < minSQLConns {
+				minSQLConns = resp.SQLConns
+			}
+			if resp.LeaseCount < minLeaseCount {
+				minLeaseCount = resp.LeaseCount
+			}
+			r.Reset()
+		}
+	}
+	fmt.Fprintln(stderr)
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "node not drained (%s)", drainRemainder(prevResponse))
+	}
+	return errors.Errorf("node not drained, no progress after %d attempts (%s)",
+		opts.MaxRetries, drainRemainder(prevResponse))
+}
+
+// drainRemainder describes what prevents a node from being drained.
+func drainRemainder(resp serverpb.DrainStatusResponse) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d SQL connections and %d leases left", resp.SQLConns, resp.LeaseCount)
+	if len(resp.LeaseRangeIDs) > 0 {
+		buf.WriteString(", leases of ranges")
+		for i, rangeID := range resp.LeaseRangeIDs {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(&buf, " r%d", rangeID)
+		}
+		if int64(len(resp.LeaseRangeIDs)) < resp.LeaseCount {
+			buf.WriteString(", ...")
+		}
+	}
+	return buf.String()
+}
+
+// drainNode activates the given drain modes on the node, without shutting
+// it down.
+func drainNode(ctx context.Context, c serverpb.AdminClient, onModes []int32) error {
+	stream, err := c.Drain(ctx, &serverpb.DrainRequest{
+		On:       onModes,
+		Shutdown: false,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// drainResponseValueToRows converts a DrainStatusResponse to SQL-like result
+// rows, so that we can pretty-print them.
+func drainResponseValueToRows(resp serverpb.DrainStatusResponse) [][]string {
+	modes := make([]string, len(resp.On))
+	for i, m := range resp.On {
+		modes[i] = serverpb.DrainMode(m).String()
+	}
+	return [][]string{{
+		strings.Join(modes, ","),
+		strconv.FormatInt(resp.SQLConns, 10),
+		strconv.FormatInt(resp.LeaseCount, 10),
+		strconv.FormatBool(resp.Drained),
+	}}
+}
+
+func printDrainStatus(resp serverpb.DrainStatusResponse) error {
+	return printQueryOutput(os.Stdout, drainNodeColumnHeaders,
+		newRowSliceIter(drainResponseValueToRows(resp), "lrrc"))
+}
+
 // Sub-commands for node command.
 var nodeCmds = []*cobra.Command{
 	lsNodesCmd,
 	statusNodeCmd,
 	decommissionNodeCmd,
 	recommissionNodeCmd,
+	drainNodeCmd,
 }
 
 var nodeCmd = &cobra.Command{
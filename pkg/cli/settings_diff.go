@@ -0,0 +1,77 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/settingsfile"
+)
+
+var genSettingsDiffCmd = &cobra.Command{
+	Use:   "settings-diff <settings-file>",
+	Short: "compare a settings file to the cluster settings of the connected cluster",
+	Long: `
+Compares a settings file, as used by the --settings-file flag of the start
+command, to the cluster settings of the cluster reached through the client
+flags. Lists the settings whose value in the cluster differs from the file,
+which are the ones a node started with the file would change.
+`,
+	RunE: MaybeDecorateGRPCError(runGenSettingsDiff),
+}
+
+var settingsDiffColumnHeaders = []string{
+	"variable",
+	"current_value",
+	"file_value",
+}
+
+func runGenSettingsDiff(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageAndError(cmd)
+	}
+	settingsFile, err := settingsfile.Read(args[0])
+	if err != nil {
+		return err
+	}
+
+	conn, err := getPasswordAndMakeSQLClient()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, rows, err := runQuery(conn, makeQuery(`SELECT name, value FROM system.settings`), false)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]string, len(rows))
+	for _, row := range rows {
+		current[row[0]] = row[1]
+	}
+	drift, err := settingsFile.Diff(context.Background(), current)
+	if err != nil {
+		return err
+	}
+
+	rows = make([][]string, len(drift))
+	for i, d := range drift {
+		rows[i] = []string{d.Name, d.Current, d.Value}
+	}
+	return printQueryOutput(os.Stdout, settingsDiffColumnHeaders, newRowSliceIter(rows, "lll"))
+}
@@ -91,6 +91,19 @@ type cliState struct {
 	// The current prompt, either fullPrompt or continuePrompt.
 	currentPrompt string
 
+	// outFile, if set, receives the query results instead of the
+	// standard output. See \o.
+	outFile *os.File
+	// unexpandedFormat is the display format restored when the expanded
+	// display is turned off. See \x.
+	unexpandedFormat tableDisplayFormat
+	// includeDepth is the number of nested \i commands being processed.
+	includeDepth int
+
+	// completionCache retains the names retrieved from the server for
+	// tab completion. Cleared every time a statement is run.
+	completionCache map[completionKey][]string
+
 	// State
 	//
 	// lastInputLine is the last valid line obtained from readline.
@@ -181,10 +194,22 @@ Type:
   \set [NAME]       set a client-side flag or (without argument) print the current settings.
   \unset NAME       unset a flag.
   \show             during a multi-line statement or transaction, show the SQL entered so far.
+  \i FILE           run the SQL statements and client-side commands of a file.
+  \o [FILE]         send query results to a file or (without argument) to standard output.
+  \x [on|off]       toggle the expanded display of query results.
+  \timing [on|off]  toggle the display of execution times.
+  \l                list the databases.
+  \dn               list the schemas.
+  \d [PATTERN]      list the tables, views and sequences, or describe a relation.
+  \dt [PATTERN]     list the tables.
+  \di [PATTERN]     list the indexes.
+  \du               list the users and roles.
   \? or "help"      print this help.
   \h [NAME]         help on syntax of SQL commands.
   \hf [NAME]        help on SQL built-in functions.
 
+Press Tab to complete keywords and names, and Ctrl+R to search the history.
+
 More documentation about our SQL dialect and the CLI shell is available online:
 %s
 %s`,
@@ -248,11 +273,7 @@ var options = map[string]struct {
 			return cliCtx.tableDisplayFormat.Set(args[0])
 		},
 		func(_ *cliState) error {
-			displayFormat := tableDisplayTSV
-			if cliCtx.terminalOutput {
-				displayFormat = tableDisplayPretty
-			}
-			cliCtx.tableDisplayFormat = displayFormat
+			cliCtx.tableDisplayFormat = defaultTableDisplayFormat()
 			return nil
 		},
 		func(_ *cliState) string { return cliCtx.tableDisplayFormat.String() },
@@ -299,6 +320,15 @@ var options = map[string]struct {
 	},
 }
 
+// defaultTableDisplayFormat returns the display format used when none
+// is specified.
+func defaultTableDisplayFormat() tableDisplayFormat {
+	if cliCtx.terminalOutput {
+		return tableDisplayPretty
+	}
+	return tableDisplayTSV
+}
+
 // optionNames retains the names of every option in the map above in sorted
 // order. We want them sorted to ensure the output of \? is deterministic.
 var optionNames = func() []string {
@@ -435,6 +465,152 @@ func execSyscmd(command string) (string, error) {
 
 var errInvalidSyntax = errors.New("invalid syntax")
 
+// output returns the writer receiving the query results.
+func (c *cliState) output() io.Writer {
+	if c.outFile != nil {
+		return c.outFile
+	}
+	return os.Stdout
+}
+
+// parseToggle parses the optional on/off argument of the client-side
+// commands toggling a setting. Without argument, the current value is
+// inverted.
+func parseToggle(args []string, cur bool) (bool, error) {
+	if len(args) == 0 {
+		return !cur, nil
+	}
+	if len(args) > 1 {
+		return false, errors.New("too many arguments")
+	}
+	switch strings.ToLower(args[0]) {
+	case "on", "true":
+		return true, nil
+	case "off", "false":
+		return false, nil
+	}
+	return false, errors.Errorf("invalid value: %q", args[0])
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// handleExpanded supports the \x command. The expanded display uses
+// the records display format; turning it off restores the format used
+// previously.
+func (c *cliState) handleExpanded(args []string, nextState, errState cliStateEnum) cliStateEnum {
+	expanded := cliCtx.tableDisplayFormat == tableDisplayRecords
+	on, err := parseToggle(args, expanded)
+	if err != nil {
+		return c.invalidSyntax(errState, `\x: %v`, err)
+	}
+	if on && !expanded {
+		c.unexpandedFormat = cliCtx.tableDisplayFormat
+		cliCtx.tableDisplayFormat = tableDisplayRecords
+	} else if !on && expanded {
+		cliCtx.tableDisplayFormat = c.unexpandedFormat
+	}
+	fmt.Printf("Expanded display is %s.\n", onOff(on))
+	return nextState
+}
+
+// handleTiming supports the \timing command.
+func (c *cliState) handleTiming(args []string, nextState, errState cliStateEnum) cliStateEnum {
+	on, err := parseToggle(args, cliCtx.showTimes)
+	if err != nil {
+		return c.invalidSyntax(errState, `\timing: %v`, err)
+	}
+	cliCtx.showTimes = on
+	fmt.Printf("Timing is %s.\n", onOff(on))
+	return nextState
+}
+
+// handleOutput supports the \o command: query results are sent to the
+// given file, truncated first, or back to the standard output.
+func (c *cliState) handleOutput(args []string, nextState, errState cliStateEnum) cliStateEnum {
+	if len(args) > 1 {
+		return c.invalidSyntax(errState, `\o: too many arguments`)
+	}
+	var f *os.File
+	if len(args) == 1 {
+		var err error
+		f, err = os.Create(args[0])
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			c.exitErr = err
+			return errState
+		}
+	}
+	if err := c.closeOutput(); err != nil {
+		fmt.Fprintln(stderr, err)
+		c.exitErr = err
+	}
+	c.outFile = f
+	return nextState
+}
+
+// closeOutput closes the file set by \o, if any.
+func (c *cliState) closeOutput() error {
+	if c.outFile == nil {
+		return nil
+	}
+	err := c.outFile.Close()
+	c.outFile = nil
+	return err
+}
+
+// maxIncludeDepth bounds the nesting of \i commands, to stop
+// files including themselves.
+const maxIncludeDepth = 10
+
+// runInclude supports the \i command: the statements and client-side
+// commands of the file are processed as if they were input
+// non-interactively, with the current options.
+func (c *cliState) runInclude(args []string, nextState, errState cliStateEnum) cliStateEnum {
+	if len(args) != 1 {
+		return c.invalidSyntax(errState, `\i: expected a file name`)
+	}
+	if c.includeDepth >= maxIncludeDepth {
+		fmt.Fprintf(stderr, "\\i: maximum include depth %d exceeded\n", maxIncludeDepth)
+		c.exitErr = errInvalidSyntax
+		return errState
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		c.exitErr = err
+		return errState
+	}
+	defer f.Close()
+
+	sub := cliState{
+		conn:             c.conn,
+		ins:              noLineEditor,
+		buf:              bufio.NewReader(f),
+		errExit:          c.errExit,
+		outFile:          c.outFile,
+		unexpandedFormat: c.unexpandedFormat,
+		includeDepth:     c.includeDepth + 1,
+		partialLines:     []string{},
+	}
+	for state := cliStartLine; state != cliStop; {
+		state = sub.doNextState(state)
+	}
+	// The included file may have changed the output.
+	c.outFile = sub.outFile
+	c.unexpandedFormat = sub.unexpandedFormat
+
+	if sub.exitErr != nil {
+		c.exitErr = sub.exitErr
+		return errState
+	}
+	return nextState
+}
+
 // runSyscmd runs system commands on the interactive CLI.
 func (c *cliState) runSyscmd(line string, nextState, errState cliStateEnum) cliStateEnum {
 	command := strings.Trim(line[2:], " \r\n\t\f")
@@ -601,29 +777,6 @@ func endsWithIncompleteTxn(stmts []string) bool {
 
 var cmdHistFile = envutil.EnvOrDefaultString("COCKROACH_SQL_CLI_HISTORY", ".cockroachsql_history")
 
-// GetCompletions implements the readline.CompletionGenerator interface.
-func (c *cliState) GetCompletions(_ string) []string {
-	sql, _ := c.ins.GetLineInfo()
-
-	if !strings.HasSuffix(sql, "??") {
-		fmt.Fprintf(c.ins.Stdout(),
-			"\ntab completion not supported; append '??' and press tab for contextual help\n\n%s", sql)
-		return nil
-	}
-
-	_, pgErr := c.serverSideParse(sql)
-	if pgErr != nil {
-		if pgErr.Message == "help token in input" && strings.HasPrefix(pgErr.Hint, "help:") {
-			fmt.Fprintf(c.ins.Stdout(), "\nSuggestion:\n%s\n", pgErr.Hint[6:])
-		} else {
-			fmt.Fprintf(c.ins.Stdout(), "\n%v\n", pgErr)
-			maybeShowErrorDetails(c.ins.Stdout(), pgErr, false)
-		}
-		fmt.Fprint(c.ins.Stdout(), c.currentPrompt, sql)
-	}
-	return nil
-}
-
 func (c *cliState) doStart(nextState cliStateEnum) cliStateEnum {
 	// Common initialization.
 	c.partialLines = []string{}
@@ -767,8 +920,9 @@ func (c *cliState) doReadLine(nextState cliStateEnum) cliStateEnum {
 	case io.EOF:
 		c.atEOF = true
 
-		if cliCtx.isInteractive {
-			// In interactive mode, EOF terminates.
+		if cliCtx.isInteractive && c.includeDepth == 0 {
+			// In interactive mode, EOF terminates. Included files are
+			// processed like non-interactive input.
 			// exitErr is left to be whatever has set it previously.
 			return cliStop
 		}
@@ -863,10 +1017,25 @@ func (c *cliState) doHandleCliCmd(loopState, nextState cliStateEnum) cliStateEnu
 	case `\hf`:
 		return c.handleFunctionHelp(cmd[1:], loopState, errState)
 
+	case `\l`, `\dn`, `\d`, `\dt`, `\di`, `\du`:
+		return c.handleCatalogCmd(cmd, loopState, errState)
+
+	case `\x`:
+		return c.handleExpanded(cmd[1:], loopState, errState)
+
+	case `\timing`:
+		return c.handleTiming(cmd[1:], loopState, errState)
+
+	case `\o`:
+		return c.handleOutput(cmd[1:], loopState, errState)
+
+	case `\i`:
+		return c.runInclude(cmd[1:], loopState, errState)
+
 	default:
 		if strings.HasPrefix(cmd[0], `\d`) {
 			// Unrecognized command for now, but we want to be helpful.
-			fmt.Fprint(stderr, "Suggestion: use the SQL SHOW statement to inspect your schema.\n")
+			fmt.Fprint(stderr, "Suggestion: use \\d, \\dt, \\di or the SQL SHOW statement to inspect your schema.\n")
 		}
 		return c.invalidSyntax(errState, `%s. Try \? for help.`, c.lastInputLine)
 	}
@@ -979,9 +1148,11 @@ func (c *cliState) doRunStatement(nextState cliStateEnum) cliStateEnum {
 	// Once we send something to the server, the txn status may change arbitrarily.
 	// Clear the known state so that further entries do not assume anything.
 	c.lastKnownTxnStatus = " ?"
+	// Likewise, the statement may change the names to complete.
+	c.completionCache = nil
 
 	// Now run the statement/query.
-	c.exitErr = runQueryAndFormatResults(c.conn, os.Stdout, makeQuery(c.concatLines))
+	c.exitErr = runQueryAndFormatResults(c.conn, c.output(), makeQuery(c.concatLines))
 	if c.exitErr != nil {
 		fmt.Fprintln(stderr, c.exitErr)
 		maybeShowErrorDetails(stderr, c.exitErr, false)
@@ -1078,44 +1249,56 @@ func runInteractive(conn *sqlConn) (exitErr error) {
 
 			state = c.doStart(cliRefreshPrompts)
 
-		case cliRefreshPrompts:
-			state = c.doRefreshPrompts(cliStartLine)
+		default:
+			state = c.doNextState(state)
+		}
+	}
 
-		case cliStartLine:
-			state = c.doStartLine(cliReadLine)
+	if err := c.closeOutput(); err != nil && c.exitErr == nil {
+		c.exitErr = err
+	}
+	return c.exitErr
+}
 
-		case cliContinueLine:
-			state = c.doContinueLine(cliReadLine)
+// doNextState runs the step of the state machine for the given state,
+// other than cliStart, and returns the next state.
+func (c *cliState) doNextState(state cliStateEnum) cliStateEnum {
+	switch state {
+	case cliRefreshPrompts:
+		return c.doRefreshPrompts(cliStartLine)
 
-		case cliReadLine:
-			state = c.doReadLine(cliDecidePath)
+	case cliStartLine:
+		return c.doStartLine(cliReadLine)
 
-		case cliDecidePath:
-			state = c.doDecidePath()
+	case cliContinueLine:
+		return c.doContinueLine(cliReadLine)
 
-		case cliProcessFirstLine:
-			state = c.doProcessFirstLine(cliRefreshPrompts, cliHandleCliCmd)
+	case cliReadLine:
+		return c.doReadLine(cliDecidePath)
 
-		case cliHandleCliCmd:
-			state = c.doHandleCliCmd(cliReadLine, cliPrepareStatementLine)
+	case cliDecidePath:
+		return c.doDecidePath()
 
-		case cliPrepareStatementLine:
-			state = c.doPrepareStatementLine(
-				cliRefreshPrompts, cliContinueLine, cliCheckStatement, cliRunStatement,
-			)
+	case cliProcessFirstLine:
+		return c.doProcessFirstLine(cliRefreshPrompts, cliHandleCliCmd)
 
-		case cliCheckStatement:
-			state = c.doCheckStatement(cliRefreshPrompts, cliContinueLine, cliRunStatement)
+	case cliHandleCliCmd:
+		return c.doHandleCliCmd(cliReadLine, cliPrepareStatementLine)
 
-		case cliRunStatement:
-			state = c.doRunStatement(cliRefreshPrompts)
+	case cliPrepareStatementLine:
+		return c.doPrepareStatementLine(
+			cliRefreshPrompts, cliContinueLine, cliCheckStatement, cliRunStatement,
+		)
 
-		default:
-			panic(fmt.Sprintf("unknown state: %d", state))
-		}
-	}
+	case cliCheckStatement:
+		return c.doCheckStatement(cliRefreshPrompts, cliContinueLine, cliRunStatement)
 
-	return c.exitErr
+	case cliRunStatement:
+		return c.doRunStatement(cliRefreshPrompts)
+
+	default:
+		panic(fmt.Sprintf("unknown state: %d", state))
+	}
 }
 
 // runOneStatement executes one statement and terminates
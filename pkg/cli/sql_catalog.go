@@ -0,0 +1,216 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// The psql-style catalog commands (\l, \dn, \d, \dt, \di, \du) are
+// implemented by queries against information_schema and crdb_internal.
+// Their results are formatted like the results of any other query,
+// using the current display format.
+
+// virtualSchemas lists the schemas which are not databases.
+const virtualSchemas = `('crdb_internal', 'information_schema', 'pg_catalog')`
+
+// handleCatalogCmd supports the client-side commands listing and
+// describing the objects of the catalog. All the commands take an
+// optional pattern argument restricting the objects listed.
+func (c *cliState) handleCatalogCmd(cmd []string, nextState, errState cliStateEnum) cliStateEnum {
+	if len(cmd) > 2 {
+		return c.invalidSyntax(errState, `%s. Try \? for help.`, strings.Join(cmd, " "))
+	}
+	pattern := ""
+	if len(cmd) == 2 {
+		pattern = cmd[1]
+	}
+
+	var query string
+	var args []driver.Value
+	switch cmd[0] {
+	case `\l`:
+		cond, condArgs := catalogFilter("", "SCHEMA_NAME", pattern)
+		query = `
+			SELECT SCHEMA_NAME AS database_name
+			FROM "".information_schema.schemata
+			WHERE SCHEMA_NAME NOT IN ` + virtualSchemas + cond + `
+			ORDER BY 1`
+		args = condArgs
+
+	case `\dn`:
+		// Databases also act as schemas, along with the virtual schemas.
+		cond, condArgs := catalogFilter("", "SCHEMA_NAME", pattern)
+		query = `
+			SELECT SCHEMA_NAME AS schema_name
+			FROM "".information_schema.schemata
+			WHERE true` + cond + `
+			ORDER BY 1`
+		args = condArgs
+
+	case `\d`:
+		if pattern != "" && !strings.ContainsAny(pattern, "*?") {
+			return c.describeRelation(pattern, nextState, errState)
+		}
+		tableCond, condArgs := catalogFilter("TABLE_SCHEMA", "TABLE_NAME", pattern)
+		// Both conditions have the same arguments.
+		seqCond, _ := catalogFilter("SEQUENCE_SCHEMA", "SEQUENCE_NAME", pattern)
+		query = `
+			SELECT TABLE_SCHEMA AS schema_name, TABLE_NAME AS name,
+				CASE TABLE_TYPE WHEN 'VIEW' THEN 'view' ELSE 'table' END AS type
+			FROM "".information_schema.tables
+			WHERE TABLE_TYPE IN ('BASE TABLE', 'VIEW')` + tableCond + `
+			UNION ALL
+			SELECT SEQUENCE_SCHEMA, SEQUENCE_NAME, 'sequence'
+			FROM "".information_schema.sequences
+			WHERE true` + seqCond + `
+			ORDER BY 1, 2`
+		args = condArgs
+
+	case `\dt`:
+		cond, condArgs := catalogFilter("TABLE_SCHEMA", "TABLE_NAME", pattern)
+		query = `
+			SELECT TABLE_SCHEMA AS schema_name, TABLE_NAME AS table_name
+			FROM "".information_schema.tables
+			WHERE TABLE_TYPE = 'BASE TABLE'` + cond + `
+			ORDER BY 1, 2`
+		args = condArgs
+
+	case `\di`:
+		cond, condArgs := catalogFilter("t.database_name", "i.index_name", pattern)
+		query = `
+			SELECT t.database_name AS schema_name, i.descriptor_name AS table_name,
+				i.index_name, i.index_type, i.is_unique
+			FROM "".crdb_internal.table_indexes AS i
+			JOIN "".crdb_internal.tables AS t ON i.descriptor_id = t.table_id
+			WHERE t.state = 'PUBLIC'` + cond + `
+			ORDER BY 1, 2, 3`
+		args = condArgs
+
+	case `\du`:
+		cond, condArgs := catalogFilter("", "username", pattern)
+		query = `
+			SELECT username AS user_name, "isRole" AS is_role
+			FROM system.users
+			WHERE true` + cond + `
+			ORDER BY 1`
+		args = condArgs
+
+	default:
+		panic(fmt.Sprintf("unknown catalog command: %s", cmd[0]))
+	}
+
+	return c.runCatalogQueries(nextState, errState, makeQuery(query, args...))
+}
+
+// describeRelation supports the \d command with the name of a table,
+// view or sequence: it shows its columns and indexes.
+func (c *cliState) describeRelation(name string, nextState, errState cliStateEnum) cliStateEnum {
+	cond, args := catalogFilter("TABLE_SCHEMA", "TABLE_NAME", name)
+	vals, err := c.conn.QueryRow(`
+		SELECT count(*)
+		FROM "".information_schema.columns
+		WHERE true`+cond, args)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		c.exitErr = err
+		return errState
+	}
+	if vals[0].(int64) == 0 {
+		fmt.Fprintf(stderr, "did not find any relation named %q\n", name)
+		c.exitErr = errInvalidSyntax
+		return errState
+	}
+
+	columns := makeQuery(`
+		SELECT COLUMN_NAME AS column_name, DATA_TYPE AS data_type,
+			IS_NULLABLE AS is_nullable, COLUMN_DEFAULT AS column_default
+		FROM "".information_schema.columns
+		WHERE true`+cond+`
+		ORDER BY ORDINAL_POSITION`, args...)
+	indexes := makeQuery(`
+		SELECT INDEX_NAME AS index_name, NON_UNIQUE AS non_unique, SEQ_IN_INDEX AS seq_in_index,
+			COLUMN_NAME AS column_name, DIRECTION AS direction, STORING AS storing
+		FROM "".information_schema.statistics
+		WHERE IMPLICIT = 'NO'`+cond+`
+		ORDER BY 1, 3`, args...)
+	return c.runCatalogQueries(nextState, errState, columns, indexes)
+}
+
+// runCatalogQueries runs the queries of a catalog command and formats
+// their results.
+func (c *cliState) runCatalogQueries(
+	nextState, errState cliStateEnum, queries ...queryFunc,
+) cliStateEnum {
+	for _, q := range queries {
+		if err := runQueryAndFormatResults(c.conn, c.output(), q); err != nil {
+			fmt.Fprintln(stderr, err)
+			maybeShowErrorDetails(stderr, err, false)
+			c.exitErr = err
+			return errState
+		}
+	}
+	return nextState
+}
+
+// catalogFilter returns the conditions selecting the catalog objects
+// matching a psql-style pattern of the form [DATABASE.]NAME, in which
+// `*` matches any sequence of characters and `?` any single character,
+// along with their arguments. The conditions start with AND. If dbCol
+// is set and the pattern does not specify the database, only the
+// objects of the current database are selected.
+func catalogFilter(dbCol, nameCol, pattern string) (string, []driver.Value) {
+	var buf bytes.Buffer
+	var args []driver.Value
+	if dbCol != "" {
+		dbPattern := ""
+		if i := strings.IndexByte(pattern, '.'); i >= 0 {
+			dbPattern, pattern = pattern[:i], pattern[i+1:]
+		}
+		if dbPattern == "" {
+			fmt.Fprintf(&buf, " AND %s = current_database()", dbCol)
+		} else {
+			args = append(args, patternToLike(dbPattern))
+			fmt.Fprintf(&buf, " AND %s LIKE $%d", dbCol, len(args))
+		}
+	}
+	if pattern != "" {
+		args = append(args, patternToLike(pattern))
+		fmt.Fprintf(&buf, " AND %s LIKE $%d", nameCol, len(args))
+	}
+	return buf.String(), args
+}
+
+// patternToLike converts a psql-style pattern to a LIKE pattern.
+func patternToLike(pattern string) string {
+	var buf bytes.Buffer
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			buf.WriteByte('%')
+		case '?':
+			buf.WriteByte('_')
+		case '%', '_', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,250 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// Tab completion in the interactive shell proposes SQL keywords, taken
+// from the grammar, and the names of the databases, tables and columns,
+// fetched from the server when first needed. The names are cached until
+// the next statement is run, since the statement may change the schema
+// or the current database.
+
+// completionNameKind identifies a kind of name proposed by the
+// completion.
+type completionNameKind int
+
+const (
+	completeDatabases completionNameKind = iota
+	completeTables
+	completeColumns
+)
+
+// completionKey identifies a list of names in the completion cache.
+type completionKey struct {
+	kind completionNameKind
+	// database restricts the tables to those of the given database. If
+	// empty, the current database is used.
+	database string
+}
+
+// completionKeywords is the sorted list of the SQL keywords.
+var completionKeywords = func() []string {
+	kws := make([]string, 0, len(lex.Keywords))
+	for kw := range lex.Keywords {
+		kws = append(kws, kw)
+	}
+	sort.Strings(kws)
+	return kws
+}()
+
+// keywordsByToken maps the token of each SQL keyword to the keyword.
+var keywordsByToken = func() map[int]string {
+	m := make(map[int]string, len(lex.Keywords))
+	for kw, k := range lex.Keywords {
+		m[k.Tok] = kw
+	}
+	return m
+}()
+
+// tableKeywords are the keywords followed by a table name.
+var tableKeywords = map[string]struct{}{
+	"from":     {},
+	"into":     {},
+	"join":     {},
+	"table":    {},
+	"truncate": {},
+	"update":   {},
+}
+
+// GetCompletions implements the readline.CompletionGenerator interface.
+func (c *cliState) GetCompletions(word string) []string {
+	sql, pos := c.ins.GetLineInfo()
+
+	if strings.HasSuffix(sql, "??") {
+		_, pgErr := c.serverSideParse(sql)
+		if pgErr != nil {
+			if pgErr.Message == "help token in input" && strings.HasPrefix(pgErr.Hint, "help:") {
+				fmt.Fprintf(c.ins.Stdout(), "\nSuggestion:\n%s\n", pgErr.Hint[6:])
+			} else {
+				fmt.Fprintf(c.ins.Stdout(), "\n%v\n", pgErr)
+				maybeShowErrorDetails(c.ins.Stdout(), pgErr, false)
+			}
+			fmt.Fprint(c.ins.Stdout(), c.currentPrompt, sql)
+		}
+		return nil
+	}
+
+	// The context of the word is the input entered before it, including
+	// the previous lines of a multi-line statement.
+	if pos >= 0 && pos <= len(sql) {
+		sql = sql[:pos]
+	}
+	lines := append(append([]string(nil), c.partialLines...), strings.TrimSuffix(sql, word))
+	return completeWord(strings.Join(lines, "\n"), word, c.getCompletionNames)
+}
+
+// completeWord returns the completions of word, given the input that
+// precedes it. getNames retrieves the names of the objects of the
+// given kind.
+func completeWord(
+	prefix, word string, getNames func(completionKey) []string,
+) []string {
+	if strings.HasPrefix(strings.TrimSpace(prefix), `\`) || strings.HasPrefix(word, `\`) {
+		// Client-side commands are not completed.
+		return nil
+	}
+
+	var candidates []string
+	if i := strings.IndexByte(word, '.'); i >= 0 {
+		// A qualified name: propose the tables of the database.
+		db := word[:i]
+		for _, name := range getNames(completionKey{kind: completeTables, database: db}) {
+			candidates = append(candidates, db+"."+tree.NameString(name))
+		}
+		return filterCompletions(candidates, word)
+	}
+
+	addNames := func(kind completionNameKind) {
+		for _, name := range getNames(completionKey{kind: kind}) {
+			candidates = append(candidates, tree.NameString(name))
+		}
+	}
+	lastKw := lastKeyword(prefix)
+	switch {
+	case lastKw == "database":
+		addNames(completeDatabases)
+
+	case isTableKeyword(lastKw):
+		addNames(completeTables)
+		addNames(completeDatabases)
+
+	default:
+		if word == "" {
+			// Too many candidates to be useful.
+			return nil
+		}
+		upper := strings.ToUpper(word) == word
+		for _, kw := range completionKeywords {
+			if upper {
+				kw = strings.ToUpper(kw)
+			}
+			candidates = append(candidates, kw)
+		}
+		addNames(completeColumns)
+		addNames(completeTables)
+	}
+	return filterCompletions(candidates, word)
+}
+
+func isTableKeyword(kw string) bool {
+	_, ok := tableKeywords[kw]
+	return ok
+}
+
+// lastKeyword returns the last token of the input if it is a
+// keyword, or an empty string. The input is split into tokens by the
+// SQL scanner, so that keywords in literals and comments are ignored.
+// Equal signs are skipped, so that e.g. `SET DATABASE =` is followed
+// by a database name.
+func lastKeyword(input string) string {
+	last := 0
+	sc := parser.MakeScanner(input)
+	sc.Tokens(func(t int) {
+		if t != '=' {
+			last = t
+		}
+	})
+	return keywordsByToken[last]
+}
+
+// filterCompletions returns the sorted, deduplicated candidates that
+// start with word. Keywords are matched regardless of case.
+func filterCompletions(candidates []string, word string) []string {
+	lowerWord := strings.ToLower(word)
+	var res []string
+	for _, cand := range candidates {
+		if strings.HasPrefix(cand, word) || strings.HasPrefix(strings.ToLower(cand), lowerWord) {
+			res = append(res, cand)
+		}
+	}
+	sort.Strings(res)
+	j := 0
+	for i := range res {
+		if i == 0 || res[i] != res[i-1] {
+			res[j] = res[i]
+			j++
+		}
+	}
+	return res[:j]
+}
+
+// getCompletionNames retrieves the names of the databases, tables or
+// columns from the server, or from the cache if they were retrieved
+// since the last statement was run. No names are retrieved while a
+// transaction is open, as the queries would run in it and could abort
+// it. Errors silently result in no names, so as not to disrupt the line
+// being edited.
+func (c *cliState) getCompletionNames(key completionKey) []string {
+	if names, ok := c.completionCache[key]; ok {
+		return names
+	}
+	_, rows, err := runQuery(c.conn, makeQuery(`SHOW TRANSACTION STATUS`), false /* showMoreChars */)
+	if err != nil || len(rows) != 1 || rows[0][0] != sql.NoTxn.String() {
+		return nil
+	}
+
+	var query string
+	var args []driver.Value
+	dbCond := "current_database()"
+	if key.database != "" {
+		dbCond = "$1"
+		args = append(args, key.database)
+	}
+	switch key.kind {
+	case completeDatabases:
+		query = `SELECT SCHEMA_NAME FROM "".information_schema.schemata`
+	case completeTables:
+		query = `SELECT TABLE_NAME FROM "".information_schema.tables WHERE TABLE_SCHEMA = ` + dbCond
+	case completeColumns:
+		query = `SELECT DISTINCT COLUMN_NAME FROM "".information_schema.columns WHERE TABLE_SCHEMA = ` + dbCond
+	default:
+		panic(fmt.Sprintf("unknown completion kind: %d", key.kind))
+	}
+
+	_, rows, err = runQuery(c.conn, makeQuery(query, args...), true /* showMoreChars */)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(rows))
+	for i, row := range rows {
+		names[i] = row[0]
+	}
+	if c.completionCache == nil {
+		c.completionCache = make(map[completionKey][]string)
+	}
+	c.completionCache[key] = names
+	return names
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestCompleteWord(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	getNames := func(key completionKey) []string {
+		switch key.kind {
+		case completeDatabases:
+			return []string{"defaultdb", "system", "shop"}
+		case completeTables:
+			switch key.database {
+			case "":
+				return []string{"orders", "order items", "customers"}
+			case "system":
+				return []string{"users", "settings"}
+			}
+		case completeColumns:
+			return []string{"order_id", "customer_id"}
+		}
+		return nil
+	}
+
+	testData := []struct {
+		prefix, word string
+		expected     []string
+	}{
+		// Keywords, in the case of the word, and columns.
+		{"", "SELE", []string{"SELECT"}},
+		{"", "sele", []string{"select"}},
+		{"SELECT ", "ord", []string{"order", "order_id", "orders", "ordinality"}},
+		// Tables and databases after keywords followed by tables.
+		{"SELECT * FROM ", "", []string{"\"order items\"", "customers", "defaultdb", "orders", "shop", "system"}},
+		{"SELECT * FROM orders JOIN ", "cu", []string{"customers"}},
+		{"INSERT INTO ", "s", []string{"shop", "system"}},
+		// Qualified names.
+		{"SELECT * FROM ", "system.u", []string{"system.users"}},
+		// Databases.
+		{"SET DATABASE = ", "s", []string{"shop", "system"}},
+		{"CREATE DATABASE ", "d", []string{"defaultdb"}},
+		// Keywords in string literals do not count.
+		{"SELECT 'from', ", "cus", []string{"customer_id", "customers"}},
+		// No completion of client-side commands, nor of empty words out of
+		// context.
+		{`\d `, "or", nil},
+		{"", `\d`, nil},
+		{"SELECT ", "", nil},
+	}
+
+	for _, d := range testData {
+		t.Run(d.prefix+"|"+d.word, func(t *testing.T) {
+			res := completeWord(d.prefix, d.word, getNames)
+			if !reflect.DeepEqual(res, d.expected) {
+				t.Errorf("expected %q, got %q", d.expected, res)
+			}
+		})
+	}
+}
@@ -15,9 +15,11 @@
 package cli
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
@@ -152,6 +154,120 @@ select '''
 	}
 }
 
+// TestSQLMetaCommands tests the psql-style client-side commands of the
+// sql subcommand.
+func TestSQLMetaCommands(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := newCLITest(cliTestParams{t: t})
+	defer c.cleanup()
+
+	pgurl, cleanup := sqlutils.PGUrl(t, c.ServingAddr(), t.Name(), url.User(security.RootUser))
+	defer cleanup()
+
+	conn := makeSQLConn(pgurl.String())
+	defer conn.Close()
+
+	if err := conn.Exec(`
+CREATE DATABASE t;
+CREATE TABLE t.a (id INT PRIMARY KEY, v STRING);
+CREATE VIEW t.a_view AS SELECT v FROM t.a;
+CREATE SEQUENCE t.a_seq;
+CREATE TABLE t.b (x INT);
+`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "TestSQLMetaCommands")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+		stdin = os.Stdin
+	}()
+
+	inclFile := filepath.Join(dir, "incl.sql")
+	if err := ioutil.WriteFile(inclFile, []byte("SELECT 3 AS w;\n\\x on\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outFile := filepath.Join(dir, "out.txt")
+	inFile := filepath.Join(dir, "in.sql")
+	in := fmt.Sprintf(`SET DATABASE = t;
+\dt
+\d a*
+\x
+SELECT 1 AS x, 'b' AS y;
+\x off
+SELECT 1 AS x;
+\o %s
+SELECT 2 AS z;
+\o
+\i %s
+SELECT 4 AS u;
+`, outFile, inclFile)
+	if err := ioutil.WriteFile(inFile, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Override the standard input for runInteractive().
+	stdin = f
+
+	setCLIDefaultsForTests()
+	cliCtx.tableDisplayFormat = tableDisplayTSV
+	defer setCLIDefaultsForTests()
+
+	out, err := captureOutput(func() {
+		if err := runInteractive(conn); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SET
+schema_name	table_name
+t	a
+t	b
+# 2 rows
+schema_name	name	type
+t	a	table
+t	a_seq	sequence
+t	a_view	view
+# 3 rows
+Expanded display is on.
+-[ RECORD 1 ]
+x | 1
+y | b
+Expanded display is off.
+x
+1
+# 1 row
+w
+3
+# 1 row
+Expanded display is on.
+-[ RECORD 1 ]
+u | 4
+`
+	if out != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	// The results of the query run between \o commands went to the file.
+	contents, err := ioutil.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "z\n2\n# 1 row\n"; string(contents) != expected {
+		t.Fatalf("expected %q in %s, got %q", expected, outFile, contents)
+	}
+}
+
 func TestIsEndOfStatement(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
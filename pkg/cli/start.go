@@ -432,6 +432,7 @@ func runStart(cmd *cobra.Command, args []string) error {
 	// flags specified for the command.
 	serverCfg.Insecure = startCtx.serverInsecure
 	serverCfg.SSLCertsDir = startCtx.serverSSLCertsDir
+	serverCfg.SSLCAKey = startCtx.serverSSLCAKey
 	serverCfg.User = security.NodeUser
 	// As well as derived temporary/auxiliary directory specifications.
 	if serverCfg.Settings.ExternalIODir, err = initExternalIODir(ctx, serverCfg.Stores.Specs[0]); err != nil {
@@ -967,6 +968,8 @@ var quitCmd = &cobra.Command{
 Shutdown the server. The first stage is drain, where any new requests
 will be ignored by the server. When all extant requests have been
 completed, the server exits.
+
+To drain the server without shutting it down, see 'node drain'.
 `,
 	RunE: MaybeDecorateGRPCError(runQuit),
 }
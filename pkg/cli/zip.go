@@ -21,12 +21,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 )
 
 var debugZipCmd = &cobra.Command{
@@ -35,12 +45,23 @@ var debugZipCmd = &cobra.Command{
 	Long: `
 
 Gather cluster debug data into a zip file. Data includes cluster events, node
-liveness, node status, range status, node stack traces, log files, and SQL
+liveness, cluster settings, jobs, problem ranges, crdb_internal tables, node
+status, range status, node stack traces and heap profiles, log files, and SQL
 schema.
 
 Retrieval of per-node details (status, stack traces, range status) requires the
 node to be live and operating properly. Retrieval of SQL data requires the
-cluster to be live.
+cluster to be live. Each RPC request and SQL query is bounded by --timeout: the
+requests which fail or time out are recorded as errors in the zip file, which
+contains the data that could be retrieved.
+
+The log entries can be restricted to a time window with --from and --to. With
+--redact, the values of SQL statements and keys are removed from the logs, the
+events, the jobs, the crdb_internal tables and the range descriptors, and the
+arguments of the functions are removed from the stack traces. In free-form text
+such as log messages and errors, only quoted strings and key values are
+recognized as values: unquoted numbers are kept. Heap profiles are not
+redacted.
 `,
 	RunE: MaybeDecorateGRPCError(runDebugZip),
 }
@@ -94,14 +115,55 @@ func (z *zipper) createError(name string, e error) error {
 	return nil
 }
 
+// zipInternalTables lists the crdb_internal tables included in the zip
+// file, along with their columns containing SQL statements or free-form
+// text, which are redacted when --redact is specified.
+var zipInternalTables = []struct {
+	name         string
+	redactedCols []string
+}{
+	{"crdb_internal.cluster_queries", []string{"query"}},
+	{"crdb_internal.cluster_sessions", []string{"active_queries", "last_active_query"}},
+	{"crdb_internal.cluster_settings", nil},
+	{"crdb_internal.gossip_liveness", nil},
+	{"crdb_internal.gossip_nodes", nil},
+	{"crdb_internal.kv_node_status", nil},
+	{"crdb_internal.kv_store_status", nil},
+	{"crdb_internal.leases", nil},
+	{"crdb_internal.node_statement_statistics", []string{"last_error"}},
+	{"crdb_internal.schema_changes", nil},
+}
+
+// zipRequest runs a request to the cluster, bounded by the --timeout
+// duration.
+func zipRequest(ctx context.Context, fn func(ctx context.Context) error) error {
+	if zipCtx.timeout == 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, zipCtx.timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// createJSONOrError creates a file with the result of a request, or with
+// its error.
+func (z *zipper) createJSONOrError(name string, m interface{}, e error) error {
+	if e != nil {
+		return z.createError(name, e)
+	}
+	return z.createJSON(name, m)
+}
+
 func runDebugZip(cmd *cobra.Command, args []string) error {
 	const (
-		base         = "debug"
-		eventsName   = base + "/events"
-		livenessName = base + "/liveness"
-		nodesPrefix  = base + "/nodes"
-		schemaPrefix = base + "/schema"
-		settingsName = base + "/settings"
+		base          = "debug"
+		eventsName    = base + "/events"
+		jobsName      = base + "/jobs"
+		livenessName  = base + "/liveness"
+		nodesPrefix   = base + "/nodes"
+		reportsPrefix = base + "/reports"
+		schemaPrefix  = base + "/schema"
+		settingsName  = base + "/settings"
 	)
 
 	if len(args) != 1 {
@@ -130,37 +192,72 @@ func runDebugZip(cmd *cobra.Command, args []string) error {
 	z := newZipper(out)
 	defer z.close()
 
-	if events, err := admin.Events(ctx, &serverpb.EventsRequest{}); err != nil {
-		if err := z.createError(eventsName, err); err != nil {
-			return err
-		}
-	} else {
-		if err := z.createJSON(eventsName, events); err != nil {
-			return err
+	var events *serverpb.EventsResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		events, err = admin.Events(ctx, &serverpb.EventsRequest{})
+		return err
+	})
+	if err == nil && zipCtx.redact {
+		for i := range events.Events {
+			events.Events[i].Info = redactEventInfo(events.Events[i].Info)
 		}
 	}
+	if err := z.createJSONOrError(eventsName, events, err); err != nil {
+		return err
+	}
 
-	if liveness, err := admin.Liveness(ctx, &serverpb.LivenessRequest{}); err != nil {
-		if err := z.createError(livenessName, err); err != nil {
-			return err
-		}
-	} else {
-		if err := z.createJSON(livenessName, liveness); err != nil {
-			return err
-		}
+	var liveness *serverpb.LivenessResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		liveness, err = admin.Liveness(ctx, &serverpb.LivenessRequest{})
+		return err
+	})
+	if err := z.createJSONOrError(livenessName, liveness, err); err != nil {
+		return err
 	}
 
-	if settings, err := admin.Settings(ctx, &serverpb.SettingsRequest{}); err != nil {
-		if err := z.createError(settingsName, err); err != nil {
-			return err
-		}
-	} else {
-		if err := z.createJSON(settingsName, settings); err != nil {
-			return err
+	var settings *serverpb.SettingsResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		settings, err = admin.Settings(ctx, &serverpb.SettingsRequest{})
+		return err
+	})
+	if err := z.createJSONOrError(settingsName, settings, err); err != nil {
+		return err
+	}
+
+	var jobs *serverpb.JobsResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		jobs, err = admin.Jobs(ctx, &serverpb.JobsRequest{})
+		return err
+	})
+	if err == nil && zipCtx.redact {
+		for i := range jobs.Jobs {
+			jobs.Jobs[i].Description = redactSQL(jobs.Jobs[i].Description)
+			jobs.Jobs[i].Error = redactText(jobs.Jobs[i].Error)
 		}
 	}
+	if err := z.createJSONOrError(jobsName, jobs, err); err != nil {
+		return err
+	}
 
-	if nodes, err := status.Nodes(ctx, &serverpb.NodesRequest{}); err != nil {
+	var problemRanges *serverpb.ProblemRangesResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		problemRanges, err = status.ProblemRanges(ctx, &serverpb.ProblemRangesRequest{})
+		return err
+	})
+	if err := z.createJSONOrError(reportsPrefix+"/problemranges", problemRanges, err); err != nil {
+		return err
+	}
+
+	if err := zipInternalTablesData(ctx, z, base); err != nil {
+		return err
+	}
+
+	var nodes *serverpb.NodesResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		nodes, err = status.Nodes(ctx, &serverpb.NodesRequest{})
+		return err
+	})
+	if err != nil {
 		if err := z.createError(nodesPrefix, err); err != nil {
 			return err
 		}
@@ -171,105 +268,345 @@ func runDebugZip(cmd *cobra.Command, args []string) error {
 			if err := z.createJSON(prefix+"/status", node); err != nil {
 				return err
 			}
-
-			if gossip, err := status.Gossip(ctx, &serverpb.GossipRequest{NodeId: id}); err != nil {
-				if err := z.createError(prefix+"/gossip", err); err != nil {
-					return err
-				}
-			} else if err := z.createJSON(prefix+"/gossip", gossip); err != nil {
+			if err := zipNode(ctx, z, status, id, prefix); err != nil {
 				return err
 			}
+		}
+	}
 
-			if stacks, err := status.Stacks(ctx, &serverpb.StacksRequest{NodeId: id}); err != nil {
-				if err := z.createError(prefix+"/stacks", err); err != nil {
-					return err
-				}
-			} else if err := z.createRaw(prefix+"/stacks", stacks.Data); err != nil {
+	var databases *serverpb.DatabasesResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		databases, err = admin.Databases(ctx, &serverpb.DatabasesRequest{})
+		return err
+	})
+	if err != nil {
+		if err := z.createError(schemaPrefix, err); err != nil {
+			return err
+		}
+	} else {
+		for _, dbName := range databases.Databases {
+			prefix := schemaPrefix + "/" + dbName
+			var database *serverpb.DatabaseDetailsResponse
+			err := zipRequest(ctx, func(ctx context.Context) (err error) {
+				database, err = admin.DatabaseDetails(
+					ctx, &serverpb.DatabaseDetailsRequest{Database: dbName})
 				return err
-			}
-
-			if logs, err := status.LogFilesList(
-				ctx, &serverpb.LogFilesListRequest{NodeId: id}); err != nil {
-				if err := z.createError(prefix+"/logs", err); err != nil {
+			})
+			if err != nil {
+				if err := z.createError(prefix, err); err != nil {
 					return err
 				}
-			} else {
-				for _, file := range logs.Files {
-					name := prefix + "/logs/" + file.Name
-					entries, err := status.LogFile(
-						ctx, &serverpb.LogFileRequest{NodeId: id, File: file.Name})
-					if err != nil {
-						if err := z.createError(name, err); err != nil {
-							return err
-						}
-						continue
-					}
-					logOut, err := z.create(name)
-					if err != nil {
-						return err
-					}
-					for _, e := range entries.Entries {
-						if err := e.Format(logOut); err != nil {
-							return err
-						}
-					}
-				}
+				continue
+			}
+			if err := z.createJSON(prefix+"@details", database); err != nil {
+				return err
 			}
 
-			if ranges, err := status.Ranges(ctx, &serverpb.RangesRequest{NodeId: id}); err != nil {
-				if err := z.createError(prefix+"/ranges", err); err != nil {
+			for _, tableName := range database.TableNames {
+				name := prefix + "/" + tableName
+				var table *serverpb.TableDetailsResponse
+				err := zipRequest(ctx, func(ctx context.Context) (err error) {
+					table, err = admin.TableDetails(
+						ctx, &serverpb.TableDetailsRequest{Database: dbName, Table: tableName})
 					return err
-				}
-			} else {
-				sort.Slice(ranges.Ranges, func(i, j int) bool {
-					return ranges.Ranges[i].State.Desc.RangeID <
-						ranges.Ranges[j].State.Desc.RangeID
 				})
-				for _, r := range ranges.Ranges {
-					name := fmt.Sprintf("%s/ranges/%s", prefix, r.State.Desc.RangeID)
-					if err := z.createJSON(name, r); err != nil {
-						return err
-					}
+				if err := z.createJSONOrError(name, table, err); err != nil {
+					return err
 				}
 			}
 		}
 	}
 
-	if databases, err := admin.Databases(ctx, &serverpb.DatabasesRequest{}); err != nil {
-		if err := z.createError(schemaPrefix, err); err != nil {
+	return nil
+}
+
+// zipNode adds the details of a node to the zip file: its gossip
+// information, stack traces, heap profile, logs and ranges.
+func zipNode(
+	ctx context.Context, z *zipper, status serverpb.StatusClient, id, prefix string,
+) error {
+	var gossipInfo *gossip.InfoStatus
+	err := zipRequest(ctx, func(ctx context.Context) (err error) {
+		gossipInfo, err = status.Gossip(ctx, &serverpb.GossipRequest{NodeId: id})
+		return err
+	})
+	if err := z.createJSONOrError(prefix+"/gossip", gossipInfo, err); err != nil {
+		return err
+	}
+
+	var stacks *serverpb.JSONResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		stacks, err = status.Stacks(ctx, &serverpb.StacksRequest{NodeId: id})
+		return err
+	})
+	if err != nil {
+		if err := z.createError(prefix+"/stacks", err); err != nil {
 			return err
 		}
 	} else {
-		for _, dbName := range databases.Databases {
-			prefix := schemaPrefix + "/" + dbName
-			database, err := admin.DatabaseDetails(
-				ctx, &serverpb.DatabaseDetailsRequest{Database: dbName})
+		if zipCtx.redact {
+			stacks.Data = redactStacks(stacks.Data)
+		}
+		if err := z.createRaw(prefix+"/stacks", stacks.Data); err != nil {
+			return err
+		}
+	}
+
+	var heap *serverpb.JSONResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		heap, err = status.Profile(ctx, &serverpb.ProfileRequest{
+			NodeId: id, Type: serverpb.ProfileRequest_HEAP,
+		})
+		return err
+	})
+	if err != nil {
+		if err := z.createError(prefix+"/heap.pprof", err); err != nil {
+			return err
+		}
+	} else if err := z.createRaw(prefix+"/heap.pprof", heap.Data); err != nil {
+		return err
+	}
+
+	var logs *serverpb.LogFilesListResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		logs, err = status.LogFilesList(ctx, &serverpb.LogFilesListRequest{NodeId: id})
+		return err
+	})
+	if err != nil {
+		if err := z.createError(prefix+"/logs", err); err != nil {
+			return err
+		}
+	} else {
+		for _, file := range logs.Files {
+			// Skip the files which were not written to during the time
+			// window: the files are appended to from their creation time
+			// until their last modification time.
+			if !zipCtx.logsFrom.IsZero() && file.ModTimeNanos < zipCtx.logsFrom.UnixNano() {
+				continue
+			}
+			if !zipCtx.logsTo.IsZero() && file.Details.Time > zipCtx.logsTo.UnixNano() {
+				continue
+			}
+			name := prefix + "/logs/" + file.Name
+			var entries *serverpb.LogEntriesResponse
+			err := zipRequest(ctx, func(ctx context.Context) (err error) {
+				entries, err = status.LogFile(
+					ctx, &serverpb.LogFileRequest{NodeId: id, File: file.Name})
+				return err
+			})
 			if err != nil {
-				if err := z.createError(prefix, err); err != nil {
+				if err := z.createError(name, err); err != nil {
 					return err
 				}
 				continue
 			}
-			if err := z.createJSON(prefix+"@details", database); err != nil {
+			logOut, err := z.create(name)
+			if err != nil {
 				return err
 			}
-
-			for _, tableName := range database.TableNames {
-				name := prefix + "/" + tableName
-				table, err := admin.TableDetails(
-					ctx, &serverpb.TableDetailsRequest{Database: dbName, Table: tableName})
-				if err != nil {
-					if err := z.createError(name, err); err != nil {
-						return err
-					}
+			for _, e := range entries.Entries {
+				if !zipCtx.logsFrom.IsZero() && e.Time < zipCtx.logsFrom.UnixNano() {
+					continue
+				}
+				if !zipCtx.logsTo.IsZero() && e.Time > zipCtx.logsTo.UnixNano() {
 					continue
 				}
-				if err := z.createJSON(name, table); err != nil {
+				if zipCtx.redact {
+					e.Message = redactText(e.Message)
+				}
+				if err := e.Format(logOut); err != nil {
 					return err
 				}
 			}
 		}
 	}
 
+	var ranges *serverpb.RangesResponse
+	err = zipRequest(ctx, func(ctx context.Context) (err error) {
+		ranges, err = status.Ranges(ctx, &serverpb.RangesRequest{NodeId: id})
+		return err
+	})
+	if err != nil {
+		return z.createError(prefix+"/ranges", err)
+	}
+	sort.Slice(ranges.Ranges, func(i, j int) bool {
+		return ranges.Ranges[i].State.Desc.RangeID <
+			ranges.Ranges[j].State.Desc.RangeID
+	})
+	for _, r := range ranges.Ranges {
+		name := fmt.Sprintf("%s/ranges/%s", prefix, r.State.Desc.RangeID)
+		if zipCtx.redact {
+			redactRangeInfo(&r)
+		}
+		if err := z.createJSON(name, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zipInternalTablesData adds the contents of the crdb_internal tables
+// to the zip file, in the TSV format. Each query is bounded by --timeout.
+func zipInternalTablesData(ctx context.Context, z *zipper, prefix string) error {
+	defer func(f tableDisplayFormat) { cliCtx.tableDisplayFormat = f }(cliCtx.tableDisplayFormat)
+	cliCtx.tableDisplayFormat = tableDisplayTSV
+
+	sqlConn, err := makeSQLClient(url.User(security.RootUser))
+	if err != nil {
+		return err
+	}
+	defer sqlConn.Close()
+
+	for _, table := range zipInternalTables {
+		name := prefix + "/" + table.name + ".txt"
+		var cols []string
+		var rows [][]string
+		err := zipRequest(ctx, func(ctx context.Context) (err error) {
+			cols, rows, err = runZipQuery(ctx, sqlConn, "SELECT * FROM "+table.name)
+			return err
+		})
+		if err != nil {
+			if err := z.createError(name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if zipCtx.redact {
+			for _, col := range table.redactedCols {
+				for i := range cols {
+					if cols[i] != col {
+						continue
+					}
+					for _, row := range rows {
+						row[i] = redactSQL(row[i])
+					}
+				}
+			}
+		}
+		w, err := z.create(name)
+		if err != nil {
+			return err
+		}
+		if err := printQueryOutput(w, cols, newRowSliceIter(rows, "")); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// runZipQuery runs the query and returns its columns and rows, or the
+// error of the context if it is done first. The server can't be asked to
+// cancel a query, so in that case the connection is closed to abandon
+// it; the next query opens a new connection.
+func runZipQuery(ctx context.Context, conn *sqlConn, query string) ([]string, [][]string, error) {
+	if err := conn.ensureConn(); err != nil {
+		return nil, nil, err
+	}
+	driverConn := conn.conn
+
+	type result struct {
+		cols []string
+		rows [][]string
+		err  error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		var res result
+		res.cols, res.rows, res.err = runQuery(conn, makeQuery(query), false)
+		resCh <- res
+	}()
+	select {
+	case res := <-resCh:
+		return res.cols, res.rows, res.err
+	case <-ctx.Done():
+		// Closing the connection unblocks the query.
+		_ = driverConn.Close()
+		<-resCh
+		conn.Close()
+		return nil, nil, ctx.Err()
+	}
+}
+
+// redactedMarker replaces the redacted values.
+const redactedMarker = "‹redacted›"
+
+var (
+	// redactKeyRE matches the values in pretty-printed table keys, such
+	// as /1/"a" in /Table/51/1/1/"a".
+	redactKeyRE = regexp.MustCompile(`(/Table/\d+/\d+)(/[^\s,\]\)]+)+`)
+	// redactQuotedRE matches quoted strings.
+	redactQuotedRE = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"`)
+	// redactStackArgsRE matches the argument words of the function calls
+	// in goroutine stack traces, such as (0xc420010000, 0x5, ...).
+	redactStackArgsRE = regexp.MustCompile(`(?m)\((?:0x[0-9a-f]+|\.\.\.)(?:, (?:0x[0-9a-f]+|\.\.\.))*\)$`)
+)
+
+// redactText removes the values of the pretty-printed keys and the
+// quoted strings of a free-form text, like a log message.
+func redactText(s string) string {
+	s = redactKeyRE.ReplaceAllString(s, "$1/"+redactedMarker)
+	return redactQuotedRE.ReplaceAllString(s, redactedMarker)
+}
+
+// redactStacks removes the arguments of the function calls in goroutine
+// stack traces, which may be values of the data.
+func redactStacks(stacks []byte) []byte {
+	return redactStackArgsRE.ReplaceAll(stacks, []byte("("+redactedMarker+")"))
+}
+
+// redactSQL removes the constants of SQL statements. If the statements
+// cannot be parsed, they are redacted like free-form text.
+func redactSQL(s string) string {
+	stmts, err := parser.Parse(s)
+	if err != nil {
+		return redactText(s)
+	}
+	strs := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		strs[i] = tree.AsStringWithFlags(stmt, tree.FmtHideConstants)
+	}
+	return strings.Join(strs, "; ")
+}
+
+// redactEventInfo removes the constants of the SQL statement recorded
+// in the JSON details of an event.
+func redactEventInfo(info string) string {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(info), &m); err != nil {
+		return redactText(info)
+	}
+	if stmt, ok := m["Statement"].(string); ok {
+		m["Statement"] = redactSQL(stmt)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return redactText(info)
+	}
+	return string(b)
+}
+
+// redactKey truncates a table key to its table and index prefix. Other
+// keys are returned unchanged.
+func redactKey(key roachpb.RKey) roachpb.RKey {
+	rest, tableID, err := keys.DecodeTablePrefix(roachpb.Key(key))
+	if err != nil {
+		return key
+	}
+	rest, indexID, err := encoding.DecodeUvarintAscending(rest)
+	if err != nil || len(rest) == 0 {
+		return key
+	}
+	return roachpb.RKey(encoding.EncodeUvarintAscending(keys.MakeTablePrefix(uint32(tableID)), indexID))
+}
+
+// redactRangeInfo removes the values of the keys of a range.
+func redactRangeInfo(r *serverpb.RangeInfo) {
+	r.Span.StartKey = redactText(r.Span.StartKey)
+	r.Span.EndKey = redactText(r.Span.EndKey)
+	if desc := r.State.Desc; desc != nil {
+		descCopy := *desc
+		descCopy.StartKey = redactKey(desc.StartKey)
+		descCopy.EndKey = redactKey(desc.EndKey)
+		r.State.Desc = &descCopy
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestZipRedact(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	textTests := []struct {
+		in, expected string
+	}{
+		{`split at /Table/51/1/1/"foo" done`, `split at /Table/51/1/‹redacted› done`},
+		{`[n1] /Table/51/2/"a"/3, /Table/52/1`, `[n1] /Table/51/2/‹redacted›, /Table/52/1`},
+		{`value 'secret' and "it\"s"`, `value ‹redacted› and ‹redacted›`},
+		{`no values in r12`, `no values in r12`},
+	}
+	for _, test := range textTests {
+		if out := redactText(test.in); out != test.expected {
+			t.Errorf("redactText(%q): expected %q, got %q", test.in, test.expected, out)
+		}
+	}
+
+	sqlTests := []struct {
+		in, expected string
+	}{
+		{`SELECT * FROM t WHERE a = 'secret' AND b > 3`, `SELECT * FROM t WHERE (a = _) AND (b > _)`},
+		{`INSERT INTO t VALUES (1, 'x'); DELETE FROM t`, `INSERT INTO t VALUES (_, _); DELETE FROM t`},
+		{`not sql 'secret'`, `not sql ‹redacted›`},
+	}
+	for _, test := range sqlTests {
+		if out := redactSQL(test.in); out != test.expected {
+			t.Errorf("redactSQL(%q): expected %q, got %q", test.in, test.expected, out)
+		}
+	}
+
+	const stacks = `goroutine 1 [running]:
+main.(*T).foo(0xc420010000, 0x5, 0x5, ...)
+	/go/src/main.go:12 +0x39
+main.main()
+	/go/src/main.go:20 +0x2a
+`
+	const expectedStacks = `goroutine 1 [running]:
+main.(*T).foo(‹redacted›)
+	/go/src/main.go:12 +0x39
+main.main()
+	/go/src/main.go:20 +0x2a
+`
+	if out := redactStacks([]byte(stacks)); string(out) != expectedStacks {
+		t.Errorf("expected\n%s\ngot\n%s", expectedStacks, out)
+	}
+
+	const info = `{"TableName":"t","Statement":"CREATE TABLE t (a INT DEFAULT 42)","User":"root"}`
+	const expectedInfo = `{"Statement":"CREATE TABLE t (a INT DEFAULT _)","TableName":"t","User":"root"}`
+	if out := redactEventInfo(info); out != expectedInfo {
+		t.Errorf("expected %s, got %s", expectedInfo, out)
+	}
+
+	indexPrefix := encoding.EncodeUvarintAscending(keys.MakeTablePrefix(51), 1)
+	rowKey := encoding.EncodeStringAscending(append([]byte(nil), indexPrefix...), "secret")
+	if out := redactKey(roachpb.RKey(rowKey)); !bytes.Equal(out, indexPrefix) {
+		t.Errorf("expected %s, got %s", roachpb.Key(indexPrefix), roachpb.Key(out))
+	}
+	for _, key := range []roachpb.RKey{
+		roachpb.RKey(indexPrefix), roachpb.RKey(keys.MakeTablePrefix(51)), roachpb.RKey(keys.Meta2Prefix),
+	} {
+		if out := redactKey(key); !bytes.Equal(out, key) {
+			t.Errorf("expected %s to be unchanged, got %s", key, out)
+		}
+	}
+}
+
+func TestRunZipQueryTimeout(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := newCLITest(cliTestParams{t: t})
+	defer c.cleanup()
+
+	url, cleanup := sqlutils.PGUrl(t, c.ServingAddr(), t.Name(), url.User(security.RootUser))
+	defer cleanup()
+
+	blocker := makeSQLConn(url.String())
+	defer blocker.Close()
+	conn := makeSQLConn(url.String())
+	defer conn.Close()
+
+	for _, stmt := range []string{
+		`CREATE DATABASE d`,
+		`CREATE TABLE d.t (k INT PRIMARY KEY)`,
+		// The intent of the open transaction blocks the reads of the table.
+		`BEGIN`,
+		`INSERT INTO d.t VALUES (1)`,
+	} {
+		if err := blocker.Exec(stmt, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, _, err := runZipQuery(ctx, conn, `SELECT * FROM d.t`); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// The next query uses a new connection.
+	if err := blocker.Exec(`ROLLBACK`, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, rows, err := runZipQuery(context.Background(), conn, `SELECT * FROM d.t`); err != nil {
+		t.Fatal(err)
+	} else if len(rows) != 0 {
+		t.Fatalf("expected no rows, got %v", rows)
+	}
+}
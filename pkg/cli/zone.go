@@ -181,7 +181,8 @@ specified zone-config from the given file ("-" for stdin).
 The zone config format has the following YAML schema:
 
   num_replicas: <num>
-  constraints: [comma-separated attribute list]
+  constraints: [comma-separated attribute list] or {comma-separated attribute list: <num>, ...}
+  lease_preferences: [[comma-separated attribute list], ...]
   range_min_bytes: <size-in-bytes>
   range_max_bytes: <size-in-bytes>
   gc:
@@ -193,6 +194,21 @@ num_replicas: 3
 constraints: [ssd, -mem]
 EOF
 
+Lease preferences are ordered from most to least preferred. For example, to
+keep the leases of the db.t table in us-east when possible, falling back to
+us-west, run:
+$ cockroach zone set db.t -f - << EOF
+lease_preferences: [[+region=us-east], [+region=us-west]]
+EOF
+
+Constraints can also be specified per-replica as a map from comma-separated
+attribute lists to the number of replicas that must satisfy them. For example,
+to place two replicas of the db.t table in us-east and one in us-west, run:
+$ cockroach zone set db.t -f - << EOF
+num_replicas: 3
+constraints: {+region=us-east: 2, +region=us-west: 1}
+EOF
+
 Note that the specified zone config is merged with the existing zone config for
 the database or table.
 `,
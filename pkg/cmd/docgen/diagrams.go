@@ -417,6 +417,13 @@ var specs = []stmtSpec{
 		unlink:  []string{"index_name", "table_name", "column_name"},
 		nosplit: true,
 	},
+	{
+		name:    "create_policy_stmt",
+		inline:  []string{"opt_policy_command", "opt_policy_roles", "opt_policy_using", "opt_policy_with_check", "name_list"},
+		replace: map[string]string{"'POLICY' name": "'POLICY' policy_name", "qualified_name": "table_name", "a_expr": "condition", "( name (": "( role_name (", "',' name": "',' role_name"},
+		unlink:  []string{"policy_name", "table_name", "condition", "role_name"},
+		nosplit: true,
+	},
 	{
 		name:    "create_sequence_stmt",
 		inline:  []string{"opt_sequence_option_list", "sequence_option_list", "sequence_option_elem"},
@@ -433,13 +440,11 @@ var specs = []stmtSpec{
 		relink:  map[string]string{"view_name": "any_name", "column_list": "name_list"},
 	},
 	{name: "create_user_stmt",
-		inline: []string{"opt_with", "opt_password"},
+		inline: []string{"opt_with", "opt_role_options", "role_option_list"},
 		replace: map[string]string{
-			"'PASSWORD' string_or_placeholder": "'PASSWORD' password",
-			"'USER' string_or_placeholder":     "'USER' name",
-			"'EXISTS' string_or_placeholder":   "'EXISTS' name",
+			"'USER' string_or_placeholder":   "'USER' name",
+			"'EXISTS' string_or_placeholder": "'EXISTS' name",
 		},
-		unlink: []string{"password"},
 	},
 	{
 		name: "default_value_column_level",
@@ -485,6 +490,11 @@ var specs = []stmtSpec{
 		inline:  []string{"opt_drop_behavior", "table_name_with_index_list", "table_name_with_index"},
 		replace: map[string]string{"qualified_name": "table_name", "'@' name": "'@' index_name"}, unlink: []string{"table_name", "index_name"},
 	},
+	{
+		name:    "drop_policy_stmt",
+		replace: map[string]string{"'POLICY' name": "'POLICY' policy_name", "'EXISTS' name": "'EXISTS' policy_name", "qualified_name": "table_name"},
+		unlink:  []string{"policy_name", "table_name"},
+	},
 	{
 		name:    "drop_sequence_stmt",
 		inline:  []string{"table_name_list", "opt_drop_behavior"},
@@ -26,26 +26,22 @@ import (
 	"google.golang.org/api/option"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/testutilsccl/workloadccl"
-	"github.com/cockroachdb/cockroach/pkg/testutils/workload"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/workload"
+	workloadcli "github.com/cockroachdb/cockroach/pkg/workload/cli"
 )
 
-var useast1bFixtures = workloadccl.FixtureStore{
-	// TODO(dan): Keep fixtures in more than one region to better support
-	// geo-distributed clusters.
-	GCSBucket: `cockroach-fixtures`,
-	GCSPrefix: `workload`,
-}
-
 var fixturesCmd = &cobra.Command{Use: `fixtures`}
 var fixturesListCmd = &cobra.Command{
 	Use:   `list`,
 	Short: `List all fixtures stored on GCS`,
 	RunE:  fixturesList,
 }
-var fixturesStoreCmd = &cobra.Command{
-	Use:   `store`,
-	Short: `Regenerate and store a fixture on GCS`,
+var fixturesMakeCmd = &cobra.Command{
+	Use:     `make`,
+	Aliases: []string{`store`},
+	Short: `Regenerate and store a fixture. ` +
+		`The tables are stored as backups, unless --csv is set.`,
 }
 var fixturesLoadCmd = &cobra.Command{
 	Use: `load`,
@@ -53,6 +49,21 @@ var fixturesLoadCmd = &cobra.Command{
 		`An enterprise license is required.`,
 }
 
+// TODO(dan): Keep fixtures in more than one region to better support
+// geo-distributed clusters.
+const defaultFixturesStorage = `gs://cockroach-fixtures/workload`
+
+var fixturesStorage = fixturesCmd.PersistentFlags().String(
+	`storage`, defaultFixturesStorage,
+	`URI of the storage holding the fixtures, e.g. gs://, s3:// or nodelocal:///`)
+var fixturesExternalIODir = fixturesCmd.PersistentFlags().String(
+	`external-io-dir`, ``,
+	`external IO directory of the nodes, used to resolve nodelocal:/// storage `+
+		`(the fixture must be made on a node)`)
+
+var fixturesMakeCSV = fixturesMakeCmd.PersistentFlags().Bool(
+	`csv`, false, `store the tables as CSVs, which are loaded with IMPORT, instead of backups`)
+
 var fixturesLoadDB = fixturesLoadCmd.PersistentFlags().String(
 	`into_db`, `workload`, `SQL database to load fixture into`)
 
@@ -63,31 +74,44 @@ const storageError = `failed to create google cloud client ` +
 // getStorage returns a GCS client using "application default" credentials. The
 // caller is responsible for closing it.
 func getStorage(ctx context.Context) (*storage.Client, error) {
-	// TODO(dan): Right now, we don't need all the complexity of
-	// storageccl.ExportStorage, but if we start supporting more than just GCS,
-	// this should probably be switched to it.
 	g, err := storage.NewClient(ctx, option.WithScopes(storage.ScopeReadWrite))
 	return g, errors.Wrap(err, storageError)
 }
 
+// describeFixtureTable returns where the data of a fixture table is stored.
+func describeFixtureTable(table workloadccl.FixtureTable) string {
+	if table.BackupURI != "" {
+		return table.BackupURI
+	}
+	return fmt.Sprintf(`%s (%d CSV files)`, table.TableName, len(table.CSVURIs))
+}
+
+func fixtureConfig() workloadccl.FixtureConfig {
+	return workloadccl.FixtureConfig{
+		StorageURI:    *fixturesStorage,
+		ExternalIODir: *fixturesExternalIODir,
+		CSVOnly:       *fixturesMakeCSV,
+	}
+}
+
 func init() {
 	for _, meta := range workload.Registered() {
 		gen := meta.New()
 		genFlags := gen.Flags()
 
-		genStoreCmd := &cobra.Command{
+		genMakeCmd := &cobra.Command{
 			Use:  meta.Name + ` [CRDB URI]`,
 			Args: cobra.RangeArgs(0, 1),
 		}
-		genStoreCmd.Flags().AddFlagSet(genFlags)
-		genStoreCmd.RunE = func(cmd *cobra.Command, args []string) error {
-			crdb := crdbDefaultURI
+		genMakeCmd.Flags().AddFlagSet(genFlags)
+		genMakeCmd.RunE = func(cmd *cobra.Command, args []string) error {
+			crdb := workloadcli.CRDBDefaultURI
 			if len(args) > 0 {
 				crdb = args[0]
 			}
-			return fixturesStore(cmd, gen, crdb)
+			return fixturesMake(cmd, gen, crdb)
 		}
-		fixturesStoreCmd.AddCommand(genStoreCmd)
+		fixturesMakeCmd.AddCommand(genMakeCmd)
 
 		genLoadCmd := &cobra.Command{
 			Use:  meta.Name + ` [CRDB URI]`,
@@ -95,7 +119,7 @@ func init() {
 		}
 		genLoadCmd.Flags().AddFlagSet(genFlags)
 		genLoadCmd.RunE = func(cmd *cobra.Command, args []string) error {
-			crdb := crdbDefaultURI
+			crdb := workloadcli.CRDBDefaultURI
 			if len(args) > 0 {
 				crdb = args[0]
 			}
@@ -104,7 +128,7 @@ func init() {
 		fixturesLoadCmd.AddCommand(genLoadCmd)
 	}
 	fixturesCmd.AddCommand(fixturesListCmd)
-	fixturesCmd.AddCommand(fixturesStoreCmd)
+	fixturesCmd.AddCommand(fixturesMakeCmd)
 	fixturesCmd.AddCommand(fixturesLoadCmd)
 	rootCmd.AddCommand(fixturesCmd)
 }
@@ -116,7 +140,7 @@ func fixturesList(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 	defer func() { _ = gcs.Close() }()
-	fixtures, err := workloadccl.ListFixtures(ctx, gcs, useast1bFixtures)
+	fixtures, err := workloadccl.ListFixtures(ctx, gcs, fixtureConfig())
 	if err != nil {
 		return err
 	}
@@ -126,37 +150,25 @@ func fixturesList(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
-func fixturesStore(cmd *cobra.Command, gen workload.Generator, crdbURI string) error {
+func fixturesMake(cmd *cobra.Command, gen workload.Generator, crdbURI string) error {
 	ctx := context.Background()
-	gcs, err := getStorage(ctx)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = gcs.Close() }()
-
 	sqlDB, err := gosql.Open(`postgres`, crdbURI)
 	if err != nil {
 		return err
 	}
 
-	fixture, err := workloadccl.MakeFixture(ctx, sqlDB, gcs, useast1bFixtures, gen)
+	fixture, err := workloadccl.MakeFixture(ctx, sqlDB, fixtureConfig(), gen)
 	if err != nil {
 		return err
 	}
 	for _, table := range fixture.Tables {
-		log.Infof(ctx, `stored %s`, table.BackupURI)
+		log.Infof(ctx, `stored %s`, describeFixtureTable(table))
 	}
 	return nil
 }
 
 func fixturesLoad(cmd *cobra.Command, gen workload.Generator, crdbURI string) error {
 	ctx := context.Background()
-	gcs, err := getStorage(ctx)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = gcs.Close() }()
-
 	sqlDB, err := gosql.Open(`postgres`, crdbURI)
 	if err != nil {
 		return err
@@ -165,7 +177,7 @@ func fixturesLoad(cmd *cobra.Command, gen workload.Generator, crdbURI string) er
 		return err
 	}
 
-	fixture, err := workloadccl.GetFixture(ctx, gcs, useast1bFixtures, gen)
+	fixture, err := workloadccl.GetFixture(ctx, fixtureConfig(), gen)
 	if err != nil {
 		return errors.Wrap(err, `finding fixture`)
 	}
@@ -173,7 +185,7 @@ func fixturesLoad(cmd *cobra.Command, gen workload.Generator, crdbURI string) er
 		return errors.Wrap(err, `restoring fixture`)
 	}
 	for _, table := range fixture.Tables {
-		log.Infof(ctx, `loaded %s`, table.BackupURI)
+		log.Infof(ctx, `loaded %s`, describeFixtureTable(table))
 	}
 	return nil
 }
@@ -16,14 +16,13 @@
 package main
 
 import (
-	"github.com/spf13/cobra"
-
 	_ "github.com/cockroachdb/cockroach/pkg/ccl/testutilsccl/workloadccl/allccl"
+	workloadcli "github.com/cockroachdb/cockroach/pkg/workload/cli"
 )
 
-var rootCmd = &cobra.Command{
-	Use: `workload`,
-}
+// rootCmd is initialized after the generators are registered by the
+// imported packages.
+var rootCmd = workloadcli.WorkloadCmd()
 
 func main() {
 	_ = rootCmd.Execute()
@@ -201,6 +201,15 @@ func (s SystemConfig) GetLargestObjectID(maxID uint32) (uint32, error) {
 // GetZoneConfigForKey looks up the zone config for the range containing 'key'.
 // It is the caller's responsibility to ensure that the range does not need to be split.
 func (s SystemConfig) GetZoneConfigForKey(key roachpb.RKey) (ZoneConfig, error) {
+	objectID, keySuffix := ObjectIDForKey(key)
+	return s.getZoneConfigForID(objectID, keySuffix)
+}
+
+// ObjectIDForKey returns the ID of the object (database, table, or named zone)
+// whose zone config is consulted first for the range containing 'key', along
+// with the suffix of the key after the object's prefix. Note that the object
+// may inherit its zone config from one of its ancestors.
+func ObjectIDForKey(key roachpb.RKey) (uint32, []byte) {
 	objectID, keySuffix, ok := DecodeObjectID(key)
 	if !ok {
 		// Not in the structured data namespace.
@@ -225,8 +234,7 @@ func (s SystemConfig) GetZoneConfigForKey(key roachpb.RKey) (ZoneConfig, error)
 			objectID = keys.SystemRangesID
 		}
 	}
-
-	return s.getZoneConfigForID(objectID, keySuffix)
+	return objectID, keySuffix
 }
 
 // getZoneConfigForID looks up the zone config for the object (table or database)
@@ -17,6 +17,7 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
@@ -229,8 +230,22 @@ func (c *Constraint) FromString(short string) error {
 var _ yaml.Marshaler = Constraints{}
 var _ yaml.Unmarshaler = &Constraints{}
 
-// MarshalYAML implements yaml.Marshaler.
+// MarshalYAML implements yaml.Marshaler. Constraints that apply to every
+// replica are marshaled as a list of constraint shorthands, while per-replica
+// constraints are marshaled as a map from a comma-separated list of constraint
+// shorthands to the number of replicas that must satisfy them.
 func (c Constraints) MarshalYAML() (interface{}, error) {
+	if len(c.PerReplica) > 0 {
+		perReplica := make(map[string]int32, len(c.PerReplica))
+		for _, rc := range c.PerReplica {
+			short := make([]string, len(rc.Constraints))
+			for i, c := range rc.Constraints {
+				short[i] = c.String()
+			}
+			perReplica[strings.Join(short, ",")] = rc.NumReplicas
+		}
+		return perReplica, nil
+	}
 	short := make([]string, len(c.Constraints))
 	for i, c := range c.Constraints {
 		short[i] = c.String()
@@ -242,7 +257,13 @@ func (c Constraints) MarshalYAML() (interface{}, error) {
 func (c *Constraints) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var shortConstraints []string
 	if err := unmarshal(&shortConstraints); err != nil {
-		return err
+		// Fall back to the per-replica form, e.g.
+		// {+region=us-east: 2, +region=us-west: 1}.
+		var perReplica map[string]int32
+		if mapErr := unmarshal(&perReplica); mapErr != nil {
+			return err
+		}
+		return c.fromPerReplicaShorthand(perReplica)
 	}
 	constraints := make([]Constraint, len(shortConstraints))
 	for i, short := range shortConstraints {
@@ -251,6 +272,64 @@ func (c *Constraints) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		}
 	}
 	c.Constraints = constraints
+	c.PerReplica = nil
+	return nil
+}
+
+// fromPerReplicaShorthand populates the per-replica constraints from a map of
+// comma-separated constraint shorthands to replica counts. Keys are processed
+// in sorted order so that the result is deterministic.
+func (c *Constraints) fromPerReplicaShorthand(perReplica map[string]int32) error {
+	shorthands := make([]string, 0, len(perReplica))
+	for k := range perReplica {
+		shorthands = append(shorthands, k)
+	}
+	sort.Strings(shorthands)
+	c.Constraints = nil
+	c.PerReplica = make([]ReplicaConstraints, len(shorthands))
+	for i, k := range shorthands {
+		rc := &c.PerReplica[i]
+		rc.NumReplicas = perReplica[k]
+		for _, short := range strings.Split(k, ",") {
+			short = strings.TrimSpace(short)
+			if short == "" {
+				return errors.Errorf("empty constraint in per-replica constraints %q", k)
+			}
+			var constraint Constraint
+			if err := constraint.FromString(short); err != nil {
+				return err
+			}
+			rc.Constraints = append(rc.Constraints, constraint)
+		}
+	}
+	return nil
+}
+
+var _ yaml.Marshaler = LeasePreference{}
+var _ yaml.Unmarshaler = &LeasePreference{}
+
+// MarshalYAML implements yaml.Marshaler.
+func (l LeasePreference) MarshalYAML() (interface{}, error) {
+	short := make([]string, len(l.Constraints))
+	for i, c := range l.Constraints {
+		short[i] = c.String()
+	}
+	return short, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *LeasePreference) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var shortConstraints []string
+	if err := unmarshal(&shortConstraints); err != nil {
+		return err
+	}
+	constraints := make([]Constraint, len(shortConstraints))
+	for i, short := range shortConstraints {
+		if err := constraints[i].FromString(short); err != nil {
+			return err
+		}
+	}
+	l.Constraints = constraints
 	return nil
 }
 
@@ -326,6 +405,43 @@ func (z *ZoneConfig) Validate() error {
 		return fmt.Errorf("RangeMinBytes %d is greater than or equal to RangeMaxBytes %d",
 			z.RangeMinBytes, z.RangeMaxBytes)
 	}
+	if len(z.Constraints.PerReplica) > 0 {
+		if len(z.Constraints.Constraints) > 0 {
+			return fmt.Errorf("constraints must either apply to all replicas or be " +
+				"specified per-replica, not both")
+		}
+		var numConstrained int32
+		for _, rc := range z.Constraints.PerReplica {
+			if rc.NumReplicas <= 0 {
+				return fmt.Errorf("per-replica constraints must apply to at least one replica")
+			}
+			if len(rc.Constraints) == 0 {
+				return fmt.Errorf("per-replica constraints must include at least one constraint")
+			}
+			for _, constraint := range rc.Constraints {
+				if constraint.Type == Constraint_POSITIVE {
+					return fmt.Errorf("per-replica constraints must either be required " +
+						"(e.g. '+region=us-east') or prohibited (e.g. '-region=us-east')")
+				}
+			}
+			numConstrained += rc.NumReplicas
+		}
+		if numConstrained > z.NumReplicas {
+			return fmt.Errorf("the number of replicas specified in per-replica constraints (%d) "+
+				"cannot be greater than the number of replicas (%d)", numConstrained, z.NumReplicas)
+		}
+	}
+	for _, leasePref := range z.LeasePreferences {
+		if len(leasePref.Constraints) == 0 {
+			return fmt.Errorf("every lease preference must include at least one constraint")
+		}
+		for _, constraint := range leasePref.Constraints {
+			if constraint.Type == Constraint_POSITIVE {
+				return fmt.Errorf("lease preference constraints must either be required " +
+					"(e.g. '+region=us-east') or prohibited (e.g. '-region=us-east')")
+			}
+		}
+	}
 	return nil
 }
 
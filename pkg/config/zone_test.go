@@ -63,6 +63,108 @@ func TestZoneConfigValidate(t *testing.T) {
 			},
 			"is greater than or equal to RangeMaxBytes",
 		},
+		{
+			config.ZoneConfig{
+				NumReplicas:      1,
+				RangeMaxBytes:    config.DefaultZoneConfig().RangeMaxBytes,
+				LeasePreferences: []config.LeasePreference{{}},
+			},
+			"every lease preference must include at least one constraint",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   1,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				LeasePreferences: []config.LeasePreference{
+					{Constraints: []config.Constraint{{Value: "a", Type: config.Constraint_POSITIVE}}},
+				},
+			},
+			"lease preference constraints must either be required",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   1,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				LeasePreferences: []config.LeasePreference{
+					{Constraints: []config.Constraint{{Value: "a", Type: config.Constraint_REQUIRED}}},
+					{Constraints: []config.Constraint{{Value: "b", Type: config.Constraint_PROHIBITED}}},
+				},
+			},
+			"",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				Constraints: config.Constraints{
+					Constraints: []config.Constraint{{Value: "a", Type: config.Constraint_REQUIRED}},
+					PerReplica: []config.ReplicaConstraints{
+						{NumReplicas: 1, Constraints: []config.Constraint{{Value: "b", Type: config.Constraint_REQUIRED}}},
+					},
+				},
+			},
+			"constraints must either apply to all replicas or be specified per-replica",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				Constraints: config.Constraints{
+					PerReplica: []config.ReplicaConstraints{
+						{NumReplicas: 0, Constraints: []config.Constraint{{Value: "a", Type: config.Constraint_REQUIRED}}},
+					},
+				},
+			},
+			"per-replica constraints must apply to at least one replica",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				Constraints: config.Constraints{
+					PerReplica: []config.ReplicaConstraints{{NumReplicas: 1}},
+				},
+			},
+			"per-replica constraints must include at least one constraint",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				Constraints: config.Constraints{
+					PerReplica: []config.ReplicaConstraints{
+						{NumReplicas: 1, Constraints: []config.Constraint{{Value: "a", Type: config.Constraint_POSITIVE}}},
+					},
+				},
+			},
+			"per-replica constraints must either be required",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				Constraints: config.Constraints{
+					PerReplica: []config.ReplicaConstraints{
+						{NumReplicas: 2, Constraints: []config.Constraint{{Value: "a", Type: config.Constraint_REQUIRED}}},
+						{NumReplicas: 2, Constraints: []config.Constraint{{Value: "b", Type: config.Constraint_REQUIRED}}},
+					},
+				},
+			},
+			"the number of replicas specified in per-replica constraints \\(4\\) cannot be greater",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				Constraints: config.Constraints{
+					PerReplica: []config.ReplicaConstraints{
+						{NumReplicas: 2, Constraints: []config.Constraint{{Value: "a", Type: config.Constraint_REQUIRED}}},
+						{NumReplicas: 1, Constraints: []config.Constraint{{Value: "b", Type: config.Constraint_PROHIBITED}}},
+					},
+				},
+			},
+			"",
+		},
 	}
 	for i, c := range testCases {
 		err := c.cfg.Validate()
@@ -215,6 +317,137 @@ constraints: [foo, +duck=foo, -duck=foo]
 	}
 }
 
+// TestZoneConfigLeasePreferencesYAML makes sure that lease preferences are
+// correctly marshaled to YAML and back.
+func TestZoneConfigLeasePreferencesYAML(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	original := config.ZoneConfig{
+		RangeMinBytes: 1,
+		RangeMaxBytes: 1,
+		GC: config.GCPolicy{
+			TTLSeconds: 1,
+		},
+		NumReplicas: 1,
+		LeasePreferences: []config.LeasePreference{
+			{
+				Constraints: []config.Constraint{
+					{
+						Type:  config.Constraint_REQUIRED,
+						Key:   "region",
+						Value: "us-east",
+					},
+					{
+						Type:  config.Constraint_PROHIBITED,
+						Value: "ssd",
+					},
+				},
+			},
+			{
+				Constraints: []config.Constraint{
+					{
+						Type:  config.Constraint_REQUIRED,
+						Key:   "region",
+						Value: "us-west",
+					},
+				},
+			},
+		},
+	}
+
+	expected := `range_min_bytes: 1
+range_max_bytes: 1
+gc:
+  ttlseconds: 1
+num_replicas: 1
+constraints: []
+lease_preferences: [[+region=us-east, -ssd], [+region=us-west]]
+`
+
+	body, err := yaml.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != expected {
+		t.Fatalf("yaml.Marshal(%+v) = %s; not %s", original, body, expected)
+	}
+
+	var unmarshaled config.ZoneConfig
+	if err := yaml.UnmarshalStrict(body, &unmarshaled); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&unmarshaled, &original) {
+		t.Errorf("yaml.UnmarshalStrict(%q) = %+v; not %+v", body, unmarshaled, original)
+	}
+}
+
+// TestZoneConfigPerReplicaConstraintsYAML makes sure that per-replica
+// constraints are correctly marshaled to YAML and back.
+func TestZoneConfigPerReplicaConstraintsYAML(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	original := config.ZoneConfig{
+		RangeMinBytes: 1,
+		RangeMaxBytes: 1,
+		GC: config.GCPolicy{
+			TTLSeconds: 1,
+		},
+		NumReplicas: 3,
+		Constraints: config.Constraints{
+			PerReplica: []config.ReplicaConstraints{
+				{
+					NumReplicas: 2,
+					Constraints: []config.Constraint{
+						{
+							Type:  config.Constraint_REQUIRED,
+							Key:   "region",
+							Value: "us-east",
+						},
+					},
+				},
+				{
+					NumReplicas: 1,
+					Constraints: []config.Constraint{
+						{
+							Type:  config.Constraint_REQUIRED,
+							Key:   "region",
+							Value: "us-west",
+						},
+						{
+							Type:  config.Constraint_PROHIBITED,
+							Value: "ssd",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := `range_min_bytes: 1
+range_max_bytes: 1
+gc:
+  ttlseconds: 1
+num_replicas: 3
+constraints: {+region=us-east: 2, '+region=us-west,-ssd': 1}
+`
+
+	body, err := yaml.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != expected {
+		t.Fatalf("yaml.Marshal(%+v) = %s; not %s", original, body, expected)
+	}
+
+	var unmarshaled config.ZoneConfig
+	if err := yaml.UnmarshalStrict(body, &unmarshaled); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&unmarshaled, &original) {
+		t.Errorf("yaml.UnmarshalStrict(%q) = %+v; not %+v", body, unmarshaled, original)
+	}
+}
+
 func TestZoneSpecifiers(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
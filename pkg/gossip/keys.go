@@ -76,6 +76,11 @@ const (
 	// KeyDistSQLNodeVersionKeyPrefix is key prefix for each node's DistSQL
 	// version.
 	KeyDistSQLNodeVersionKeyPrefix = "distsql-version"
+
+	// KeyCertificateSigner is the key under which the node started with
+	// the CA key gossips its roachpb.NodeDescriptor. The other nodes send
+	// it the certificate signing requests of their renewed certificates.
+	KeyCertificateSigner = "cert-signer"
 )
 
 // MakeKey creates a canonical key under which to gossip a piece of
@@ -328,4 +328,5 @@ const (
 	LocationsTableID       = 21
 	LivenessRangesID       = 22
 	RoleMembersTableID     = 23
+	RoleOptionsTableID     = 24
 )
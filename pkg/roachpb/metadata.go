@@ -20,6 +20,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/pkg/errors"
@@ -264,11 +265,14 @@ func (p Percentiles) String() string {
 
 // String returns a string representation of the StoreCapacity.
 func (sc StoreCapacity) String() string {
-	return fmt.Sprintf("disk (capacity=%s, available=%s, used=%s, logicalBytes=%s), "+
+	return fmt.Sprintf("disk (capacity=%s, available=%s, used=%s, logicalBytes=%s, "+
+		"growth=%s/s, l0Files=%d, pendingCompaction=%s), "+
 		"ranges=%d, leases=%d, writes=%.2f, "+
 		"bytesPerReplica={%s}, writesPerReplica={%s}",
 		humanizeutil.IBytes(sc.Capacity), humanizeutil.IBytes(sc.Available),
 		humanizeutil.IBytes(sc.Used), humanizeutil.IBytes(sc.LogicalBytes),
+		humanizeutil.IBytes(int64(sc.UsedBytesPerSecond)), sc.L0FileCount,
+		humanizeutil.IBytes(sc.PendingCompactionBytes),
 		sc.RangeCount, sc.LeaseCount, sc.WritesPerSecond,
 		sc.BytesPerReplica, sc.WritesPerReplica)
 }
@@ -291,6 +295,26 @@ func (sc StoreCapacity) FractionUsed() float64 {
 	return float64(sc.Used) / float64(sc.Available+sc.Used)
 }
 
+// ProjectedFractionUsed computes the fraction of storage capacity that will be
+// in use after the given duration if the store's disk usage keeps growing at
+// its recent rate. Shrinking disk usage is not extrapolated, so the result is
+// never less than FractionUsed.
+func (sc StoreCapacity) ProjectedFractionUsed(horizon time.Duration) float64 {
+	if sc.Capacity == 0 || sc.UsedBytesPerSecond <= 0 {
+		return sc.FractionUsed()
+	}
+	growth := int64(sc.UsedBytesPerSecond * horizon.Seconds())
+	if growth > sc.Available {
+		growth = sc.Available
+	}
+	projected := sc
+	projected.Available -= growth
+	if projected.Used != 0 {
+		projected.Used += growth
+	}
+	return projected.FractionUsed()
+}
+
 // String returns a string representation of the Tier.
 func (t Tier) String() string {
 	return fmt.Sprintf("%s=%s", t.Key, t.Value)
@@ -19,6 +19,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -104,6 +105,26 @@ func TestPercentilesFromData(t *testing.T) {
 	}
 }
 
+func TestStoreCapacityProjectedFractionUsed(t *testing.T) {
+	testCases := []struct {
+		capacity StoreCapacity
+		horizon  time.Duration
+		expected float64
+	}{
+		{StoreCapacity{}, time.Hour, 0},
+		{StoreCapacity{Capacity: 100, Available: 50}, time.Hour, 0.5},
+		{StoreCapacity{Capacity: 100, Available: 50, UsedBytesPerSecond: -1}, time.Hour, 0.5},
+		{StoreCapacity{Capacity: 100, Available: 50, UsedBytesPerSecond: 1}, 10 * time.Second, 0.6},
+		{StoreCapacity{Capacity: 100, Available: 50, UsedBytesPerSecond: 1}, time.Hour, 1},
+		{StoreCapacity{Capacity: 100, Available: 60, Used: 20, UsedBytesPerSecond: 2}, 10 * time.Second, 0.5},
+	}
+	for i, tc := range testCases {
+		if actual := tc.capacity.ProjectedFractionUsed(tc.horizon); actual != tc.expected {
+			t.Errorf("%d: ProjectedFractionUsed(%s) got %f, want %f", i, tc.horizon, actual, tc.expected)
+		}
+	}
+}
+
 func TestRangeDescriptorFindReplica(t *testing.T) {
 	desc := RangeDescriptor{
 		Replicas: []ReplicaDescriptor{
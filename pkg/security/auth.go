@@ -15,6 +15,7 @@
 package security
 
 import (
+	"context"
 	"crypto/tls"
 
 	"github.com/pkg/errors"
@@ -120,6 +121,42 @@ func UserAuthCertHook(insecureMode bool, tlsState *tls.ConnectionState) (UserAut
 // UserAuthPasswordHook builds an authentication hook based on the security
 // mode, password, and its potentially matching hash.
 func UserAuthPasswordHook(insecureMode bool, password string, hashedPassword []byte) UserAuthHook {
+	return userAuthPasswordHook(insecureMode, func() error {
+		// If the requested user has an empty password, disallow authentication.
+		if len(password) == 0 || CompareHashAndPassword(hashedPassword, password) != nil {
+			return errors.New("invalid password")
+		}
+		return nil
+	})
+}
+
+// UserAuthSCRAMHook builds an authentication hook based on the security mode
+// and the outcome of a SCRAM exchange, as returned by
+// SCRAMConversation.ServerFinal.
+func UserAuthSCRAMHook(insecureMode bool, exchangeErr error) UserAuthHook {
+	return userAuthPasswordHook(insecureMode, func() error {
+		if exchangeErr != nil {
+			return errors.New("invalid password")
+		}
+		return nil
+	})
+}
+
+// UserAuthExternalHook builds an authentication hook based on the security
+// mode, password, and an external authenticator to verify it with.
+func UserAuthExternalHook(
+	ctx context.Context, insecureMode bool, authenticator ExternalAuthenticator, password string,
+) UserAuthHook {
+	return func(requestedUser string, clientConnection bool) error {
+		return userAuthPasswordHook(insecureMode, func() error {
+			return authenticator.Authenticate(ctx, requestedUser, password)
+		})(requestedUser, clientConnection)
+	}
+}
+
+// userAuthPasswordHook builds an authentication hook for the password-based
+// methods, which verify the password using the given function.
+func userAuthPasswordHook(insecureMode bool, verify func() error) UserAuthHook {
 	return func(requestedUser string, clientConnection bool) error {
 		if len(requestedUser) == 0 {
 			return errors.New("user is missing")
@@ -137,11 +174,6 @@ func UserAuthPasswordHook(insecureMode bool, password string, hashedPassword []b
 			return errors.Errorf("user %s must use certificate authentication instead of password authentication", RootUser)
 		}
 
-		// If the requested user has an empty password, disallow authentication.
-		if len(password) == 0 || CompareHashAndPassword(hashedPassword, password) != nil {
-			return errors.New("invalid password")
-		}
-
-		return nil
+		return verify()
 	}
 }
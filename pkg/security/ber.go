@@ -0,0 +1,154 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"encoding/asn1"
+
+	"github.com/pkg/errors"
+)
+
+// berElement is an ASN.1 element decoded from its BER encoding (ITU-T X.690,
+// section 8): its identifier and its content octets.
+type berElement struct {
+	Class       int
+	Tag         int
+	Constructed bool
+	Content     []byte
+}
+
+// parseBERElement decodes the BER element at the start of data, and returns
+// it along with the bytes following it.
+//
+// encoding/asn1 only accepts DER, but LDAP servers are free to use any BER
+// encoding (RFC 4511, section 5.1), and some, like Active Directory and
+// OpenLDAP, send lengths in a non-minimal long form such as 0x84 0x00 0x00
+// 0x00 0x0c. Those are accepted here. Indefinite lengths and tags larger than
+// 30, which LDAP does not use, are not.
+func parseBERElement(data []byte) (berElement, []byte, error) {
+	if len(data) < 2 {
+		return berElement{}, nil, errors.New("truncated BER element")
+	}
+	id := data[0]
+	e := berElement{
+		Class:       int(id >> 6),
+		Tag:         int(id & 0x1f),
+		Constructed: id&0x20 != 0,
+	}
+	if e.Tag == 0x1f {
+		return berElement{}, nil, errors.Errorf("unsupported BER tag encoding 0x%x", id)
+	}
+	length := int(data[1])
+	data = data[2:]
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 {
+			return berElement{}, nil, errors.New("unsupported BER indefinite length")
+		}
+		if n > len(data) {
+			return berElement{}, nil, errors.New("truncated BER length")
+		}
+		length = 0
+		for _, b := range data[:n] {
+			if length > ldapMaxMessageSize {
+				return berElement{}, nil, errors.New("BER length too large")
+			}
+			length = length<<8 | int(b)
+		}
+		data = data[n:]
+	}
+	if length > len(data) {
+		return berElement{}, nil, errors.Errorf("truncated BER element: %d bytes left, %d expected",
+			len(data), length)
+	}
+	e.Content = data[:length]
+	return e, data[length:], nil
+}
+
+// parseBERInt decodes the content of a BER INTEGER or ENUMERATED, which is a
+// big-endian two's complement number.
+func parseBERInt(content []byte) (int64, error) {
+	if len(content) == 0 || len(content) > 8 {
+		return 0, errors.Errorf("unsupported BER integer length %d", len(content))
+	}
+	// Sign-extend from the first byte.
+	v := int64(int8(content[0]))
+	for _, b := range content[1:] {
+		v = v<<8 | int64(b)
+	}
+	return v, nil
+}
+
+// expectBER decodes the BER element at the start of data and checks that it
+// has the given class and tag.
+func expectBER(data []byte, class, tag int, what string) (berElement, []byte, error) {
+	e, rest, err := parseBERElement(data)
+	if err != nil {
+		return berElement{}, nil, errors.Wrapf(err, "malformed %s", what)
+	}
+	if e.Class != class || e.Tag != tag {
+		return berElement{}, nil, errors.Errorf("malformed %s: unexpected tag %d of class %d",
+			what, e.Tag, e.Class)
+	}
+	return e, rest, nil
+}
+
+// ldapResult is the result of an LDAP operation, carried by the responses to
+// most requests (RFC 4511, section 4.1.9).
+type ldapResult struct {
+	MessageID         int64
+	ResultCode        int64
+	DiagnosticMessage string
+}
+
+// parseLDAPResult decodes an LDAPMessage carrying a response whose protocol
+// operation has the given application tag and starts with an LDAPResult, such
+// as a BindResponse or an ExtendedResponse.
+func parseLDAPResult(msg []byte, appTag int) (ldapResult, error) {
+	var res ldapResult
+	seq, _, err := expectBER(msg, asn1.ClassUniversal, asn1.TagSequence, "LDAP message")
+	if err != nil {
+		return res, err
+	}
+	id, rest, err := expectBER(seq.Content, asn1.ClassUniversal, asn1.TagInteger, "LDAP message ID")
+	if err != nil {
+		return res, err
+	}
+	if res.MessageID, err = parseBERInt(id.Content); err != nil {
+		return res, err
+	}
+	// Controls may follow the protocol operation; they are ignored.
+	op, _, err := expectBER(rest, asn1.ClassApplication, appTag, "LDAP response")
+	if err != nil {
+		return res, err
+	}
+	code, rest, err := expectBER(op.Content, asn1.ClassUniversal, asn1.TagEnum, "LDAP result code")
+	if err != nil {
+		return res, err
+	}
+	if res.ResultCode, err = parseBERInt(code.Content); err != nil {
+		return res, err
+	}
+	_, rest, err = expectBER(rest, asn1.ClassUniversal, asn1.TagOctetString, "LDAP matched DN")
+	if err != nil {
+		return res, err
+	}
+	diag, _, err := expectBER(rest, asn1.ClassUniversal, asn1.TagOctetString, "LDAP diagnostic message")
+	if err != nil {
+		return res, err
+	}
+	res.DiagnosticMessage = string(diag.Content)
+	return res, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(strings.Replace(s, " ", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseLDAPResult(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testData := []struct {
+		name     string
+		msg      string
+		expected ldapResult
+		err      string
+	}{
+		{
+			// A successful BindResponse captured from Active Directory, which uses
+			// four-byte long form lengths that DER does not allow.
+			name:     "long form lengths",
+			msg:      "30 84 00 00 00 10 02 01 01 61 84 00 00 00 07 0a 01 00 04 00 04 00",
+			expected: ldapResult{MessageID: 1},
+		},
+		{
+			name:     "short form lengths",
+			msg:      "30 0c 02 01 01 61 07 0a 01 00 04 00 04 00",
+			expected: ldapResult{MessageID: 1},
+		},
+		{
+			name: "invalid credentials",
+			msg: "30 84 00 00 00 23 02 01 02 61 84 00 00 00 1a 0a 01 31 04 00 04 13" +
+				hex.EncodeToString([]byte("invalid credentials")),
+			expected: ldapResult{MessageID: 2, ResultCode: 49, DiagnosticMessage: "invalid credentials"},
+		},
+		{
+			name:     "controls are ignored",
+			msg:      "30 10 02 01 01 61 07 0a 01 00 04 00 04 00 a0 02 30 00",
+			expected: ldapResult{MessageID: 1},
+		},
+		{
+			name: "truncated message",
+			msg:  "30 84 00 00 00 10 02 01 01 61 84 00 00 00 07 0a 01 00 04 00",
+			err:  "truncated BER element",
+		},
+		{
+			name: "truncated length",
+			msg:  "30 84 00 00",
+			err:  "truncated BER length",
+		},
+		{
+			name: "indefinite length",
+			msg:  "30 80 02 01 01 61 07 0a 01 00 04 00 04 00 00 00",
+			err:  "indefinite length",
+		},
+		{
+			name: "length too large",
+			msg:  "30 88 7f ff ff ff ff ff ff ff",
+			err:  "BER length too large",
+		},
+		{
+			name: "wrong operation",
+			msg:  "30 0c 02 01 01 78 07 0a 01 00 04 00 04 00",
+			err:  "malformed LDAP response: unexpected tag 24",
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			res, err := parseLDAPResult(mustDecodeHex(t, d.msg), ldapApplicationTagBindResponse)
+			if d.err != "" {
+				if !isError(err, d.err) {
+					t.Fatalf("expected error %q, got %v", d.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != d.expected {
+				t.Fatalf("expected %+v, got %+v", d.expected, res)
+			}
+		})
+	}
+}
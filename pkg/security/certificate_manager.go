@@ -15,12 +15,15 @@
 package security
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
@@ -63,13 +66,40 @@ type CertificateManager struct {
 	caCert      *CertInfo
 	nodeCert    *CertInfo
 	clientCerts map[string]*CertInfo
+	// Certificate revocation lists. Swapped in during Load(), and never
+	// mutated afterwards.
+	crls crlsByIssuer
+
+	// Settings controlling the OCSP checks. Nil until SetTLSSettings is
+	// called, in which case OCSP is not used.
+	tlsSettings TLSSettings
 
 	// TLS configs. Initialized lazily. Wiped on every successful Load().
 	// Server-side config.
 	serverConfig *tls.Config
+	// The OCSP staple of the server config.
+	serverConfigStaple []byte
 	// Client-side config for the cockroach node.
 	// All other client tls.Config objects are built as requested and not cached.
 	clientConfig *tls.Config
+
+	// ocspMu protects the cache of OCSP responses, keyed by certificate
+	// issuer and serial number, and the OCSP staple of the node
+	// certificate. It is separate from mu as the responders are queried
+	// without holding mu.
+	ocspMu struct {
+		syncutil.Mutex
+		cache map[string]ocspCacheEntry
+		// The staple is fetched by the OCSP stapler for the node
+		// certificate in stapleCert, and is valid until stapleExpiry if
+		// non-zero.
+		staple       []byte
+		stapleCert   *CertInfo
+		stapleExpiry time.Time
+	}
+	// stapleRefresh wakes up the OCSP stapler when the certificates are
+	// reloaded.
+	stapleRefresh chan struct{}
 }
 
 // CertificateMetrics holds metrics about the various certificates.
@@ -87,6 +117,8 @@ func makeCertificateManager(certsDir string) *CertificateManager {
 		CAExpiration:   metric.NewGauge(metaCAExpiration),
 		NodeExpiration: metric.NewGauge(metaNodeExpiration),
 	}
+	cm.ocspMu.cache = make(map[string]ocspCacheEntry)
+	cm.stapleRefresh = make(chan struct{}, 1)
 	return cm
 }
 
@@ -109,6 +141,15 @@ func NewCertificateManagerFirstRun(certsDir string) (*CertificateManager, error)
 	return cm, cm.LoadCertificates()
 }
 
+// SetTLSSettings sets the settings controlling the OCSP checks of the
+// certificates of TLS peers. Certificate revocation lists are always
+// checked.
+func (cm *CertificateManager) SetTLSSettings(tlsSettings TLSSettings) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.tlsSettings = tlsSettings
+}
+
 // Metrics returns the metrics struct.
 func (cm *CertificateManager) Metrics() CertificateMetrics {
 	return cm.certMetrics
@@ -198,6 +239,9 @@ func (cm *CertificateManager) ClientCerts() map[string]*CertInfo {
 // LoadCertificates creates a CertificateLoader to load all certs and keys.
 // Upon success, it swaps the existing certificates for the new ones.
 func (cm *CertificateManager) LoadCertificates() error {
+	if err := recoverStagedCertificates(cm.certsDir); err != nil {
+		return errors.Wrapf(err, "problem recovering renewed certificates in %s", cm.certsDir)
+	}
 	cl := NewCertificateLoader(cm.certsDir)
 	if err := cl.Load(); err != nil {
 		return errors.Wrapf(err, "problem loading certs directory %s", cm.certsDir)
@@ -205,15 +249,26 @@ func (cm *CertificateManager) LoadCertificates() error {
 
 	var caCert, nodeCert *CertInfo
 	clientCerts := make(map[string]*CertInfo)
+	// The certificates that may sign revocation lists: the CA certificates
+	// and the intermediate certificates following the leaf certificates.
+	var issuers []*x509.Certificate
 	for _, ci := range cl.Certificates() {
 		switch ci.FileUsage {
 		case CAPem:
 			caCert = ci
+			issuers = append(issuers, ci.ParsedCertificates...)
 		case NodePem:
 			nodeCert = ci
 		case ClientPem:
 			clientCerts[ci.Name] = ci
 		}
+		if ci.FileUsage != CAPem && len(ci.ParsedCertificates) > 1 {
+			issuers = append(issuers, ci.ParsedCertificates[1:]...)
+		}
+	}
+	crls, err := loadCRLs(cm.certsDir, issuers)
+	if err != nil {
+		return errors.Wrapf(err, "problem loading revocation lists in certs directory %s", cm.certsDir)
 	}
 
 	cm.mu.Lock()
@@ -232,11 +287,25 @@ func (cm *CertificateManager) LoadCertificates() error {
 	cm.caCert = caCert
 	cm.nodeCert = nodeCert
 	cm.clientCerts = clientCerts
+	cm.crls = crls
 	cm.initialized = true
 
 	cm.serverConfig = nil
 	cm.clientConfig = nil
 
+	// The cached OCSP responses may predate a revocation the operator is
+	// reloading certificates for, and the staple may be for the previous
+	// node certificate.
+	cm.ocspMu.Lock()
+	cm.ocspMu.cache = make(map[string]ocspCacheEntry)
+	cm.ocspMu.staple = nil
+	cm.ocspMu.stapleCert = nil
+	cm.ocspMu.Unlock()
+	select {
+	case cm.stapleRefresh <- struct{}{}:
+	default:
+	}
+
 	cm.updateMetricsLocked()
 	return nil
 }
@@ -289,7 +358,20 @@ func (cm *CertificateManager) GetEmbeddedServerTLSConfig(
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
+	var staple []byte
+	if cm.tlsSettings != nil && cm.tlsSettings.ocspStapling() {
+		staple = cm.ocspStapleLocked()
+	}
 	if cm.serverConfig != nil {
+		if !bytes.Equal(staple, cm.serverConfigStaple) {
+			// Handshakes in progress may still be using the current config,
+			// so the new staple goes into a copy of it.
+			cfg := cm.serverConfig.Clone()
+			cfg.Certificates = append([]tls.Certificate(nil), cfg.Certificates...)
+			cfg.Certificates[0].OCSPStaple = staple
+			cm.serverConfig = cfg
+			cm.serverConfigStaple = staple
+		}
 		return cm.serverConfig, nil
 	}
 
@@ -307,7 +389,10 @@ func (cm *CertificateManager) GetEmbeddedServerTLSConfig(
 	if err != nil {
 		return nil, err
 	}
+	cfg.VerifyPeerCertificate = cm.verifyPeerCertificate
+	cfg.Certificates[0].OCSPStaple = staple
 
+	cm.serverConfigStaple = staple
 	cm.serverConfig = cfg
 	return cfg, nil
 }
@@ -358,6 +443,7 @@ func (cm *CertificateManager) GetClientTLSConfig(user string) (*tls.Config, erro
 		if err != nil {
 			return nil, err
 		}
+		cfg.VerifyPeerCertificate = cm.verifyPeerCertificate
 
 		return cfg, nil
 	}
@@ -380,6 +466,7 @@ func (cm *CertificateManager) GetClientTLSConfig(user string) (*tls.Config, erro
 	if err != nil {
 		return nil, err
 	}
+	cfg.VerifyPeerCertificate = cm.verifyPeerCertificate
 
 	// Cache the config.
 	cm.clientConfig = cfg
@@ -0,0 +1,334 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+
+	"github.com/pkg/errors"
+)
+
+// Certificates are renewed by a designated signer node holding the CA
+// key. The other nodes send it a certificate signing request with a
+// fresh public key for each certificate about to expire, and replace
+// the certificate and its key with the result.
+
+// CertificateSigner issues certificates signed by the CA.
+type CertificateSigner struct {
+	caCert *x509.Certificate
+	caKey  crypto.PrivateKey
+}
+
+// NewCertificateSigner loads the CA certificate and key. If the CA
+// certificate file contains multiple certificates, the first one is
+// used.
+func NewCertificateSigner(caCertPath, caKeyPath string) (*CertificateSigner, error) {
+	caCert, caKey, err := loadCACertAndKey(caCertPath, caKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateSigner{caCert: caCert, caKey: caKey}, nil
+}
+
+// Sign issues a certificate for the given DER-encoded certificate
+// signing request. The common name of the request is the user of the
+// certificate: requests for the node user get node certificates valid
+// for the hosts listed in the request, others get client certificates.
+// Returns the DER-encoded certificate.
+//
+// Any certificate can be requested, so the caller must only sign
+// requests from the nodes.
+func (s *CertificateSigner) Sign(csrDER []byte, lifetime time.Duration) ([]byte, error) {
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse certificate signing request")
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, errors.Wrap(err, "invalid certificate signing request")
+	}
+	user := csr.Subject.CommonName
+	if user != NodeUser {
+		return GenerateClientCert(s.caCert, s.caKey, csr.PublicKey, lifetime, user)
+	}
+	hosts := append([]string(nil), csr.DNSNames...)
+	for _, ip := range csr.IPAddresses {
+		hosts = append(hosts, ip.String())
+	}
+	if len(hosts) == 0 {
+		return nil, errors.New("no hosts specified in node certificate signing request")
+	}
+	return GenerateServerCert(s.caCert, s.caKey, csr.PublicKey, lifetime, hosts)
+}
+
+// CertificatesExpiringBefore returns the node and client certificates
+// that have a key in the certs directory and expire before the given
+// time.
+func (cm *CertificateManager) CertificatesExpiringBefore(t time.Time) []*CertInfo {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	candidates := make([]*CertInfo, 0, 1+len(cm.clientCerts))
+	if cm.nodeCert != nil {
+		candidates = append(candidates, cm.nodeCert)
+	}
+	for _, ci := range cm.clientCerts {
+		candidates = append(candidates, ci)
+	}
+
+	var ret []*CertInfo
+	for _, ci := range candidates {
+		if ci.Error != nil || len(ci.KeyFileContents) == 0 {
+			continue
+		}
+		if ci.ParsedCertificates[0].NotAfter.Before(t) {
+			ret = append(ret, ci)
+		}
+	}
+	return ret
+}
+
+// CreateRenewalRequest generates a new key for the given certificate,
+// of the same type and size as its current key, and a certificate
+// signing request for it with the same user and hosts. Returns the
+// DER-encoded request and the new key.
+func CreateRenewalRequest(ci *CertInfo) ([]byte, crypto.PrivateKey, error) {
+	oldKey, err := PEMToPrivateKey(ci.KeyFileContents)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not parse key %s", ci.KeyFilename)
+	}
+	var key crypto.Signer
+	switch k := oldKey.(type) {
+	case *rsa.PrivateKey:
+		key, err = rsa.GenerateKey(rand.Reader, k.N.BitLen())
+	case *ecdsa.PrivateKey:
+		key, err = ecdsa.GenerateKey(k.Curve, rand.Reader)
+	default:
+		return nil, nil, errors.Errorf("unsupported key type %T in %s", oldKey, ci.KeyFilename)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not generate key")
+	}
+
+	cert := ci.ParsedCertificates[0]
+	template := &x509.CertificateRequest{
+		Subject:     cert.Subject,
+		DNSNames:    cert.DNSNames,
+		IPAddresses: cert.IPAddresses,
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return csr, key, nil
+}
+
+// stagedExtension is appended to the names of the certificate and key
+// files being installed.
+const stagedExtension = ".staged"
+
+// InstallRenewedCertificate checks that the given DER-encoded
+// certificate is signed by the CA and matches the given key, replaces
+// the certificate and key files of ci with them and reloads the
+// certificates.
+//
+// Two files can't be replaced atomically, so the new key and certificate
+// are first staged next to the files they replace, the key first. Once
+// the staged certificate exists, the pair is complete and both files
+// are renamed in place. If the process dies before then, the next call
+// to LoadCertificates discards the staged key; if it dies after, it
+// completes the installation. Either way, the certificate and key in
+// use match.
+func (cm *CertificateManager) InstallRenewedCertificate(
+	ci *CertInfo, certDER []byte, key crypto.PrivateKey,
+) error {
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return errors.Wrap(err, "could not parse renewed certificate")
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return errors.Errorf("unsupported key type %T", key)
+	}
+	certPub, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	keyPub, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(certPub, keyPub) {
+		return errors.New("renewed certificate does not match the key")
+	}
+	if cert.Subject.CommonName != ci.ParsedCertificates[0].Subject.CommonName {
+		return errors.Errorf("renewed certificate is for %q, expected %q",
+			cert.Subject.CommonName, ci.ParsedCertificates[0].Subject.CommonName)
+	}
+
+	cm.mu.RLock()
+	caCert := cm.caCert
+	cm.mu.RUnlock()
+	if err := checkCertIsValid(caCert); err != nil {
+		return errors.Wrap(err, "problem with CA certificate")
+	}
+	roots := x509.NewCertPool()
+	for _, ca := range caCert.ParsedCertificates {
+		roots.AddCert(ca)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return errors.Wrap(err, "renewed certificate is not signed by the CA")
+	}
+
+	keyBlock, err := PrivateKeyToPEM(key)
+	if err != nil {
+		return err
+	}
+	keyPath := filepath.Join(cm.certsDir, ci.KeyFilename)
+	if err := writePEMToFileAtomically(keyPath+stagedExtension, keyFileMode, keyBlock); err != nil {
+		return errors.Wrapf(err, "could not write key %s", keyPath)
+	}
+	// The staged key must be on disk before the staged certificate.
+	if err := syncDir(cm.certsDir); err != nil {
+		return err
+	}
+	certPath := filepath.Join(cm.certsDir, ci.Filename)
+	if err := writePEMToFileAtomically(
+		certPath+stagedExtension, certFileMode, &pem.Block{Type: "CERTIFICATE", Bytes: certDER},
+	); err != nil {
+		return errors.Wrapf(err, "could not write certificate %s", certPath)
+	}
+	if err := installStagedCertificate(certPath, keyPath); err != nil {
+		return err
+	}
+	return cm.LoadCertificates()
+}
+
+// installStagedCertificate renames the staged certificate and key in
+// place of the given files. The key may have been renamed already, by
+// an installation interrupted before the certificate was renamed.
+func installStagedCertificate(certPath, keyPath string) error {
+	if err := os.Rename(keyPath+stagedExtension, keyPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "could not install key %s", keyPath)
+	}
+	if err := os.Rename(certPath+stagedExtension, certPath); err != nil {
+		return errors.Wrapf(err, "could not install certificate %s", certPath)
+	}
+	return syncDir(filepath.Dir(certPath))
+}
+
+// recoverStagedCertificates completes or discards the installations of
+// renewed certificates interrupted by a crash, as described in
+// InstallRenewedCertificate.
+func recoverStagedCertificates(certsDir string) error {
+	fileInfos, err := ioutil.ReadDir(certsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	staged := make(map[string]bool)
+	for _, info := range fileInfos {
+		if name := info.Name(); strings.HasSuffix(name, stagedExtension) {
+			staged[name] = true
+		}
+	}
+	if len(staged) == 0 {
+		return nil
+	}
+	for name := range staged {
+		switch base := strings.TrimSuffix(name, stagedExtension); {
+		case isCertificateFile(base):
+			certPath := filepath.Join(certsDir, base)
+			keyPath := filepath.Join(certsDir, strings.TrimSuffix(base, certExtension)+keyExtension)
+			log.Warningf(context.Background(), "completing interrupted installation of %s", certPath)
+			if err := installStagedCertificate(certPath, keyPath); err != nil {
+				return err
+			}
+		case strings.HasSuffix(base, keyExtension):
+			certName := strings.TrimSuffix(base, keyExtension) + certExtension + stagedExtension
+			if staged[certName] {
+				continue
+			}
+			path := filepath.Join(certsDir, name)
+			log.Warningf(context.Background(), "removing incomplete renewed key %s", path)
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return syncDir(certsDir)
+}
+
+// syncDir syncs the directory, to make the renames and removals of the
+// files in it durable.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return errors.Wrapf(err, "could not sync directory %s", dir)
+	}
+	return nil
+}
+
+// writePEMToFileAtomically writes PEM blocks to a temporary file next to
+// path, syncs it and renames it to path, so that readers never see a
+// partially written file.
+func writePEMToFileAtomically(path string, mode os.FileMode, blocks ...*pem.Block) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	err = func() error {
+		defer f.Close()
+		for _, p := range blocks {
+			if err := pem.Encode(f, p); err != nil {
+				return errors.Errorf("could not encode PEM block: %v", err)
+			}
+		}
+		if err := f.Chmod(mode); err != nil {
+			return err
+		}
+		return f.Sync()
+	}()
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
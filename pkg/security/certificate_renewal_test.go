@@ -0,0 +1,126 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security_test
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestRecoverStagedCertificate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	certsDir, _, _, cleanup := setUpRevocationTest(t)
+	defer cleanup()
+
+	cm, err := security.NewCertificateManager(certsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := security.NewCertificateSigner(
+		filepath.Join(certsDir, security.EmbeddedCACert),
+		filepath.Join(certsDir, security.EmbeddedCAKey),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeCert := cm.NodeCert()
+	oldSerial := nodeCert.ParsedCertificates[0].SerialNumber
+	certPath := filepath.Join(certsDir, nodeCert.Filename)
+	keyPath := filepath.Join(certsDir, nodeCert.KeyFilename)
+
+	// stage writes a renewed node certificate and its key as an
+	// installation interrupted by a crash would have left them.
+	stage := func(withCert bool) {
+		csr, key, err := security.CreateRenewalRequest(nodeCert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certDER, err := signer.Sign(csr, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyBlock, err := security.PrivateKeyToPEM(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(
+			keyPath+".staged", pem.EncodeToMemory(keyBlock), 0600,
+		); err != nil {
+			t.Fatal(err)
+		}
+		if withCert {
+			if err := ioutil.WriteFile(
+				certPath+".staged", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0644,
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectStagedRemoved := func() {
+		t.Helper()
+		for _, path := range []string{certPath + ".staged", keyPath + ".staged"} {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("expected %s to be removed, got %v", path, err)
+			}
+		}
+	}
+
+	// Without a staged certificate, the staged key is discarded.
+	stage(false /* withCert */)
+	if err := cm.LoadCertificates(); err != nil {
+		t.Fatal(err)
+	}
+	expectStagedRemoved()
+	if serial := cm.NodeCert().ParsedCertificates[0].SerialNumber; serial.Cmp(oldSerial) != 0 {
+		t.Fatalf("expected the node certificate to be kept, got serial %s", serial)
+	}
+
+	// A staged pair is installed.
+	stage(true /* withCert */)
+	if err := cm.LoadCertificates(); err != nil {
+		t.Fatal(err)
+	}
+	expectStagedRemoved()
+	if serial := cm.NodeCert().ParsedCertificates[0].SerialNumber; serial.Cmp(oldSerial) == 0 {
+		t.Fatal("expected the renewed node certificate to be installed")
+	}
+	if _, _, err := handshake(t, cm, security.NodeUser); err != nil {
+		t.Fatalf("unexpected handshake error with the renewed certificate: %v", err)
+	}
+
+	// So is a staged certificate whose key was already installed.
+	oldSerial = cm.NodeCert().ParsedCertificates[0].SerialNumber
+	stage(true /* withCert */)
+	if err := os.Rename(keyPath+".staged", keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.LoadCertificates(); err != nil {
+		t.Fatal(err)
+	}
+	expectStagedRemoved()
+	if serial := cm.NodeCert().ParsedCertificates[0].SerialNumber; serial.Cmp(oldSerial) == 0 {
+		t.Fatal("expected the renewed node certificate to be installed")
+	}
+	if _, _, err := handshake(t, cm, security.NodeUser); err != nil {
+		t.Fatalf("unexpected handshake error with the renewed certificate: %v", err)
+	}
+}
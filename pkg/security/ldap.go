@@ -0,0 +1,358 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/asn1"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// ExternalAuthenticator verifies a user's password against an identity
+// provider outside of the cluster, so that the password does not need to be
+// stored in system.users.
+type ExternalAuthenticator interface {
+	// Authenticate returns nil if the password is valid for the user.
+	Authenticate(ctx context.Context, user, password string) error
+}
+
+// LDAPUserPlaceholder is replaced with the (escaped) user name in an
+// LDAPAuthenticator's bind DN template.
+const LDAPUserPlaceholder = "{user}"
+
+// DefaultLDAPTimeout is the timeout used by an LDAPAuthenticator if none is
+// specified.
+const DefaultLDAPTimeout = 10 * time.Second
+
+// LDAPAuthenticator is an ExternalAuthenticator that performs an LDAPv3
+// simple bind (RFC 4511, section 4.2) as the user: the password is valid if
+// the directory server accepts the bind.
+//
+// The password is only sent over TLS: ldaps connections use TLS from the
+// start, and ldap connections are upgraded with StartTLS (RFC 4511, section
+// 4.14) before binding, unless InsecureCleartext is set.
+type LDAPAuthenticator struct {
+	// URL is the address of the directory server, using either the ldap or
+	// the ldaps scheme, e.g. ldaps://ldap.example.com:636.
+	URL string
+	// BindDNTemplate is the DN to bind as, in which LDAPUserPlaceholder is
+	// replaced by the user name, e.g. uid={user},ou=people,dc=example,dc=com.
+	BindDNTemplate string
+	// TLSConfig is used for the TLS connection to the server. If nil, the
+	// server certificate is verified using the system roots.
+	TLSConfig *tls.Config
+	// InsecureCleartext disables StartTLS on ldap connections, so that
+	// passwords are sent to the server in cleartext.
+	InsecureCleartext bool
+	// Timeout bounds the duration of the whole exchange with the server.
+	Timeout time.Duration
+}
+
+var _ ExternalAuthenticator = &LDAPAuthenticator{}
+
+// ValidateLDAPURL returns an error if the given URL can't be used by an
+// LDAPAuthenticator.
+func ValidateLDAPURL(ldapURL string) error {
+	_, _, err := parseLDAPURL(ldapURL)
+	return err
+}
+
+func parseLDAPURL(ldapURL string) (addr string, useTLS bool, err error) {
+	u, err := url.Parse(ldapURL)
+	if err != nil {
+		return "", false, errors.Wrap(err, "invalid LDAP URL")
+	}
+	var defaultPort string
+	switch u.Scheme {
+	case "ldap":
+		defaultPort = "389"
+	case "ldaps":
+		defaultPort = "636"
+		useTLS = true
+	default:
+		return "", false, errors.Errorf("invalid LDAP URL %q: scheme must be ldap or ldaps", ldapURL)
+	}
+	if u.Host == "" {
+		return "", false, errors.Errorf("invalid LDAP URL %q: missing host", ldapURL)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+		return "", false, errors.Errorf(
+			"invalid LDAP URL %q: only the scheme, host and port may be specified", ldapURL)
+	}
+	addr = u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	return addr, useTLS, nil
+}
+
+// ValidateLDAPBindDNTemplate returns an error if the given template can't be
+// used by an LDAPAuthenticator.
+func ValidateLDAPBindDNTemplate(template string) error {
+	if !strings.Contains(template, LDAPUserPlaceholder) {
+		return errors.Errorf("LDAP bind DN template must contain %s", LDAPUserPlaceholder)
+	}
+	return nil
+}
+
+// Authenticate implements the ExternalAuthenticator interface.
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, user, password string) error {
+	// A simple bind with an empty password is an unauthenticated bind, which
+	// most servers accept for any DN (RFC 4513, section 5.1.2).
+	if len(password) == 0 {
+		return errors.New("invalid password")
+	}
+	if err := ValidateLDAPBindDNTemplate(a.BindDNTemplate); err != nil {
+		return err
+	}
+	addr, useTLS, err := parseLDAPURL(a.URL)
+	if err != nil {
+		return err
+	}
+
+	timeout := a.Timeout
+	if timeout == 0 {
+		timeout = DefaultLDAPTimeout
+	}
+	deadline := timeutil.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "unable to connect to LDAP server")
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	messageID := 1
+	if !useTLS && !a.InsecureCleartext {
+		if err := ldapStartTLS(conn, messageID); err != nil {
+			return err
+		}
+		messageID++
+		useTLS = true
+	}
+	if useTLS {
+		tlsConfig := &tls.Config{}
+		if a.TLSConfig != nil {
+			tlsConfig = a.TLSConfig.Clone()
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		// The deadline set on conn applies to the TLS connection.
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	dn := strings.Replace(a.BindDNTemplate, LDAPUserPlaceholder, escapeLDAPDNValue(user), -1)
+	return ldapSimpleBind(conn, messageID, dn, password)
+}
+
+// The LDAP requests sent to the server: a BindRequest for a simple bind (RFC
+// 4511, section 4.2) and an ExtendedRequest (section 4.12). Requests are
+// encoded in DER, which is valid BER. Responses are decoded by
+// parseLDAPResult, which accepts any BER encoding.
+type ldapBindRequest struct {
+	Version int
+	Name    []byte
+	Simple  []byte `asn1:"tag:0"`
+}
+
+type ldapBindRequestMessage struct {
+	MessageID   int
+	BindRequest ldapBindRequest `asn1:"application,tag:0"`
+}
+
+type ldapExtendedRequestMessage struct {
+	MessageID       int
+	ExtendedRequest struct {
+		RequestName []byte `asn1:"tag:0"`
+	} `asn1:"application,tag:23"`
+}
+
+const (
+	ldapVersion                        = 3
+	ldapResultSuccess                  = 0
+	ldapResultInvalidCredentials       = 49
+	ldapMaxMessageSize                 = 1 << 20
+	ldapApplicationTagBindResponse     = 1
+	ldapApplicationTagUnbind           = 2
+	ldapApplicationTagExtendedResponse = 24
+	// LDAPStartTLSOID is the name of the StartTLS extended operation.
+	LDAPStartTLSOID = "1.3.6.1.4.1.1466.20037"
+)
+
+// ldapStartTLS asks the server to start TLS on the connection. The caller
+// must perform the TLS handshake if it succeeds.
+func ldapStartTLS(conn net.Conn, messageID int) error {
+	var msg ldapExtendedRequestMessage
+	msg.MessageID = messageID
+	msg.ExtendedRequest.RequestName = []byte(LDAPStartTLSOID)
+	req, err := asn1.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return errors.Wrap(err, "unable to send LDAP StartTLS request")
+	}
+	// The server sends nothing after the response until the TLS handshake
+	// starts, so no data is lost to the buffering of the reader.
+	resp, err := ReadLDAPMessage(bufio.NewReader(conn))
+	if err != nil {
+		return errors.Wrap(err, "unable to read LDAP StartTLS response")
+	}
+	res, err := parseLDAPResult(resp, ldapApplicationTagExtendedResponse)
+	if err != nil {
+		return err
+	}
+	if res.MessageID != int64(messageID) {
+		return errors.Errorf("unexpected LDAP message ID %d", res.MessageID)
+	}
+	if res.ResultCode != ldapResultSuccess {
+		return errors.Errorf("LDAP server refused StartTLS with result code %d: %s",
+			res.ResultCode, res.DiagnosticMessage)
+	}
+	return nil
+}
+
+func ldapSimpleBind(conn net.Conn, messageID int, dn, password string) error {
+	req, err := asn1.Marshal(ldapBindRequestMessage{
+		MessageID: messageID,
+		BindRequest: ldapBindRequest{
+			Version: ldapVersion,
+			Name:    []byte(dn),
+			Simple:  []byte(password),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return errors.Wrap(err, "unable to send LDAP bind request")
+	}
+
+	msg, err := ReadLDAPMessage(bufio.NewReader(conn))
+	if err != nil {
+		return errors.Wrap(err, "unable to read LDAP bind response")
+	}
+	resp, err := parseLDAPResult(msg, ldapApplicationTagBindResponse)
+	if err != nil {
+		return err
+	}
+	if resp.MessageID != int64(messageID) {
+		return errors.Errorf("unexpected LDAP message ID %d", resp.MessageID)
+	}
+
+	// Politely end the session. The server doesn't respond to an
+	// UnbindRequest, and errors don't matter since we're done either way.
+	if unbind, err := asn1.Marshal(struct {
+		MessageID int
+		Unbind    asn1.RawValue
+	}{
+		MessageID: messageID + 1,
+		Unbind:    asn1.RawValue{Class: asn1.ClassApplication, Tag: ldapApplicationTagUnbind},
+	}); err == nil {
+		_, _ = conn.Write(unbind)
+	}
+
+	switch resp.ResultCode {
+	case ldapResultSuccess:
+		return nil
+	case ldapResultInvalidCredentials:
+		return errors.New("invalid password")
+	default:
+		return errors.Errorf("LDAP bind failed with result code %d: %s",
+			resp.ResultCode, resp.DiagnosticMessage)
+	}
+}
+
+// ReadLDAPMessage reads a single BER-encoded LDAP message, returning its
+// encoding including the tag and length.
+func ReadLDAPMessage(r *bufio.Reader) ([]byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	// LDAPMessage is a universal, constructed SEQUENCE.
+	if tag != 0x30 {
+		return nil, errors.Errorf("unexpected LDAP message tag 0x%x", tag)
+	}
+	header := []byte{tag}
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	header = append(header, b)
+	length := int(b)
+	if b&0x80 != 0 {
+		// Long form: the low bits are the number of length bytes.
+		n := int(b & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, errors.Errorf("unsupported LDAP message length encoding 0x%x", b)
+		}
+		length = 0
+		for i := 0; i < n; i++ {
+			if b, err = r.ReadByte(); err != nil {
+				return nil, err
+			}
+			header = append(header, b)
+			length = length<<8 | int(b)
+		}
+	}
+	if length > ldapMaxMessageSize {
+		return nil, errors.Errorf("LDAP message too large (%d bytes)", length)
+	}
+	msg := make([]byte, len(header)+length)
+	copy(msg, header)
+	if _, err := io.ReadFull(r, msg[len(header):]); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// escapeLDAPDNValue escapes a string for use as an attribute value in a DN,
+// as specified in RFC 4514, section 2.4.
+func escapeLDAPDNValue(s string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case strings.IndexByte(`"+,;<>\`, c) >= 0,
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(s)-1 && c == ' ':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == 0:
+			buf.WriteString(`\00`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/security/securitytest"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestLDAPAuthenticator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	caCert := filepath.Join(security.EmbeddedCertsDir, security.EmbeddedCACert)
+	nodeCert := filepath.Join(security.EmbeddedCertsDir, security.EmbeddedNodeCert)
+	nodeKey := filepath.Join(security.EmbeddedCertsDir, security.EmbeddedNodeKey)
+	serverTLSConfig, err := security.LoadServerTLSConfig(caCert, nodeCert, nodeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTLSConfig, err := security.LoadClientTLSConfig(caCert, nodeCert, nodeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ldapServer, err := securitytest.StartLDAPServer(serverTLSConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ldapServer.Close()
+	ldapServer.SetPassword("uid=carl,ou=people,dc=example,dc=com", "abc")
+	ldapServer.SetPassword(`uid=\,carl,ou=people,dc=example,dc=com`, "def")
+
+	// The password is sent after StartTLS.
+	authenticator := &security.LDAPAuthenticator{
+		URL:            ldapServer.URL(),
+		BindDNTemplate: "uid={user},ou=people,dc=example,dc=com",
+		TLSConfig:      clientTLSConfig,
+	}
+	ctx := context.Background()
+
+	testData := []struct {
+		user, password string
+		expected       string
+	}{
+		{"carl", "abc", ""},
+		{"carl", "abd", "invalid password"},
+		{"carl", "", "invalid password"},
+		{"bob", "abc", "invalid password"},
+		// Special characters in the user name are escaped.
+		{",carl", "def", ""},
+	}
+	for _, d := range testData {
+		err := authenticator.Authenticate(ctx, d.user, d.password)
+		if d.expected == "" {
+			if err != nil {
+				t.Errorf("%s/%s: unexpected error: %v", d.user, d.password, err)
+			}
+		} else if !testutils.IsError(err, d.expected) {
+			t.Errorf("%s/%s: expected error %q, got %v", d.user, d.password, d.expected, err)
+		}
+	}
+	// The empty password must never reach the server: it would be an
+	// unauthenticated bind.
+	if binds := ldapServer.Binds(); binds != len(testData)-1 {
+		t.Errorf("expected %d binds, got %d", len(testData)-1, binds)
+	}
+
+	// The server certificate is verified.
+	untrusted := &security.LDAPAuthenticator{
+		URL:            ldapServer.URL(),
+		BindDNTemplate: "uid={user},ou=people,dc=example,dc=com",
+	}
+	if err := untrusted.Authenticate(ctx, "carl", "abc"); !testutils.IsError(
+		err, "certificate signed by unknown authority",
+	) {
+		t.Errorf("expected certificate error, got %v", err)
+	}
+
+	// Passwords are not sent in cleartext unless explicitly allowed.
+	cleartextServer, err := securitytest.StartLDAPServer(nil /* tlsConfig */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleartextServer.Close()
+	cleartextServer.SetPassword("uid=carl,ou=people,dc=example,dc=com", "abc")
+	cleartext := &security.LDAPAuthenticator{
+		URL:            cleartextServer.URL(),
+		BindDNTemplate: "uid={user},ou=people,dc=example,dc=com",
+	}
+	if err := cleartext.Authenticate(ctx, "carl", "abc"); !testutils.IsError(
+		err, "LDAP server refused StartTLS",
+	) {
+		t.Errorf("expected StartTLS error, got %v", err)
+	}
+	if binds := cleartextServer.Binds(); binds != 0 {
+		t.Errorf("expected no binds, got %d", binds)
+	}
+	cleartext.InsecureCleartext = true
+	if err := cleartext.Authenticate(ctx, "carl", "abc"); err != nil {
+		t.Error(err)
+	}
+
+	for _, d := range []struct {
+		url, template string
+		expected      string
+	}{
+		{"http://localhost", "uid={user}", "scheme must be ldap or ldaps"},
+		{"ldap://", "uid={user}", "missing host"},
+		{"ldap://localhost/dc=example", "uid={user}", "only the scheme, host and port"},
+		{ldapServer.URL(), "uid=carl", "must contain {user}"},
+	} {
+		a := &security.LDAPAuthenticator{URL: d.url, BindDNTemplate: d.template}
+		if err := a.Authenticate(ctx, "carl", "abc"); !testutils.IsError(err, d.expected) {
+			t.Errorf("%s %s: expected error %q, got %v", d.url, d.template, d.expected, err)
+		}
+	}
+}
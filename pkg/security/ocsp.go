@@ -0,0 +1,277 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// This file implements the subset of the OCSP protocol (RFC 6960) needed
+// to check certificates: building requests for a single certificate, and
+// parsing and verifying the basic responses to them.
+
+type ocspStatus int
+
+const (
+	ocspGood ocspStatus = iota
+	ocspRevoked
+	ocspUnknown
+)
+
+// ocspResponse is the status of a certificate reported by an OCSP
+// responder.
+type ocspResponse struct {
+	Status     ocspStatus
+	RevokedAt  time.Time
+	ThisUpdate time.Time
+	// NextUpdate is the time until which the response can be cached. Zero
+	// if the responder does not say.
+	NextUpdate time.Time
+}
+
+const (
+	// ocspMaxClockSkew is the difference tolerated between our clock and
+	// the clock of the OCSP responders when checking the validity period
+	// of their responses.
+	ocspMaxClockSkew = 5 * time.Minute
+	// ocspDefaultValidity is how long a response that does not specify its
+	// next update time is considered valid.
+	ocspDefaultValidity = time.Hour
+)
+
+// expiry returns the time until which the response can be used.
+func (r *ocspResponse) expiry() time.Time {
+	if r.NextUpdate.IsZero() {
+		return r.ThisUpdate.Add(ocspDefaultValidity)
+	}
+	return r.NextUpdate
+}
+
+// checkValidity returns an error if the response is not valid at the
+// given time: if it is stale, which happens when an old response is
+// replayed, or if it is dated in the future.
+func (r *ocspResponse) checkValidity(now time.Time) error {
+	if r.ThisUpdate.After(now.Add(ocspMaxClockSkew)) {
+		return errors.Errorf("OCSP response is dated in the future (%s)", r.ThisUpdate)
+	}
+	if expiry := r.expiry(); now.After(expiry.Add(ocspMaxClockSkew)) {
+		return errors.Errorf("OCSP response is stale: it expired at %s", expiry)
+	}
+	return nil
+}
+
+var (
+	oidSHA1          = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidOCSPBasic     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	ocspSignatureAlg = map[string]x509.SignatureAlgorithm{
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}.String():  x509.SHA1WithRSA,
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}.String(): x509.SHA256WithRSA,
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}.String(): x509.SHA384WithRSA,
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}.String(): x509.SHA512WithRSA,
+		asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}.String():      x509.ECDSAWithSHA1,
+		asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}.String():   x509.ECDSAWithSHA256,
+		asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}.String():   x509.ECDSAWithSHA384,
+		asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}.String():   x509.ECDSAWithSHA512,
+	}
+)
+
+type ocspCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest struct {
+		Version     int `asn1:"explicit,tag:0,default:0,optional"`
+		RequestList []struct {
+			CertID ocspCertID
+		}
+	}
+}
+
+type ocspResponseASN1 struct {
+	Status        asn1.Enumerated
+	ResponseBytes struct {
+		ResponseType asn1.ObjectIdentifier
+		Response     []byte
+	} `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData struct {
+		Raw         asn1.RawContent
+		Version     int `asn1:"explicit,tag:0,default:0,optional"`
+		ResponderID asn1.RawValue
+		ProducedAt  time.Time `asn1:"generalized"`
+		Responses   []ocspSingleResponse
+	}
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID  ocspCertID
+	Good    asn1.Flag `asn1:"tag:0,optional"`
+	Revoked struct {
+		RevocationTime time.Time `asn1:"generalized"`
+	} `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag `asn1:"tag:2,optional"`
+	ThisUpdate time.Time `asn1:"generalized"`
+	NextUpdate time.Time `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+// makeOCSPCertID returns the identifier of the certificate in OCSP
+// requests and responses: the SHA-1 hashes of the name and public key of
+// its issuer, and its serial number.
+func makeOCSPCertID(cert, issuer *x509.Certificate) (ocspCertID, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return ocspCertID{}, errors.Wrap(err, "could not parse the public key of the issuer")
+	}
+	nameHash := sha1.Sum(issuer.RawSubject)
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+	return ocspCertID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidSHA1,
+			Parameters: asn1.RawValue{Tag: 5 /* ASN.1 NULL */},
+		},
+		IssuerNameHash: nameHash[:],
+		IssuerKeyHash:  keyHash[:],
+		SerialNumber:   cert.SerialNumber,
+	}, nil
+}
+
+// createOCSPRequest returns the DER encoding of an OCSP request for the
+// status of the certificate.
+func createOCSPRequest(cert, issuer *x509.Certificate) ([]byte, error) {
+	id, err := makeOCSPCertID(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	var req ocspRequest
+	req.TBSRequest.RequestList = []struct{ CertID ocspCertID }{{CertID: id}}
+	return asn1.Marshal(req)
+}
+
+// parseOCSPResponse parses the DER encoding of an OCSP response about the
+// certificate and checks that it was signed by its issuer, or by a
+// responder the issuer delegated to, and that it is valid at the given
+// time.
+func parseOCSPResponse(
+	raw []byte, cert, issuer *x509.Certificate, now time.Time,
+) (*ocspResponse, error) {
+	var resp ocspResponseASN1
+	if rest, err := asn1.Unmarshal(raw, &resp); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data in OCSP response")
+	}
+	if resp.Status != 0 {
+		return nil, errors.Errorf("OCSP request failed with status %d", resp.Status)
+	}
+	if !resp.ResponseBytes.ResponseType.Equal(oidOCSPBasic) {
+		return nil, errors.Errorf("unsupported OCSP response type %s", resp.ResponseBytes.ResponseType)
+	}
+	var basic ocspBasicResponse
+	if _, err := asn1.Unmarshal(resp.ResponseBytes.Response, &basic); err != nil {
+		return nil, err
+	}
+
+	signer := issuer
+	if len(basic.Certificates) > 0 {
+		responderCert, err := x509.ParseCertificate(basic.Certificates[0].FullBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse the certificate of the OCSP responder")
+		}
+		if !bytes.Equal(responderCert.Raw, issuer.Raw) {
+			if err := responderCert.CheckSignatureFrom(issuer); err != nil {
+				return nil, errors.Wrap(err, "certificate of the OCSP responder not signed by the issuer")
+			}
+			if !hasExtKeyUsage(responderCert, x509.ExtKeyUsageOCSPSigning) {
+				return nil, errors.New("certificate of the OCSP responder not valid for OCSP signing")
+			}
+			signer = responderCert
+		}
+	}
+	alg, ok := ocspSignatureAlg[basic.SignatureAlgorithm.Algorithm.String()]
+	if !ok {
+		return nil, errors.Errorf("unsupported OCSP signature algorithm %s",
+			basic.SignatureAlgorithm.Algorithm)
+	}
+	if err := signer.CheckSignature(
+		alg, basic.TBSResponseData.Raw, basic.Signature.RightAlign(),
+	); err != nil {
+		return nil, errors.Wrap(err, "bad OCSP signature")
+	}
+
+	id, err := makeOCSPCertID(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	for _, single := range basic.TBSResponseData.Responses {
+		if single.CertID.SerialNumber.Cmp(id.SerialNumber) != 0 {
+			continue
+		}
+		if single.CertID.HashAlgorithm.Algorithm.Equal(oidSHA1) &&
+			(!bytes.Equal(single.CertID.IssuerNameHash, id.IssuerNameHash) ||
+				!bytes.Equal(single.CertID.IssuerKeyHash, id.IssuerKeyHash)) {
+			continue
+		}
+		r := &ocspResponse{
+			ThisUpdate: single.ThisUpdate,
+			NextUpdate: single.NextUpdate,
+		}
+		switch {
+		case bool(single.Good):
+			r.Status = ocspGood
+		case bool(single.Unknown):
+			r.Status = ocspUnknown
+		case !single.Revoked.RevocationTime.IsZero():
+			r.Status = ocspRevoked
+			r.RevokedAt = single.Revoked.RevocationTime
+		default:
+			return nil, errors.New("OCSP response has no certificate status")
+		}
+		if err := r.checkValidity(now); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	return nil, errors.Errorf("OCSP response does not cover certificate %q (serial %s)",
+		cert.Subject.CommonName, cert.SerialNumber)
+}
+
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
@@ -37,10 +37,42 @@ var BcryptCost = bcrypt.DefaultCost
 // ErrEmptyPassword indicates that an empty password was attempted to be set.
 var ErrEmptyPassword = errors.New("empty passwords are not permitted")
 
+// PasswordHashMethod is the method used to compute the password hashes
+// stored in system.users.
+type PasswordHashMethod int
+
+const (
+	// HashBcrypt hashes passwords with bcrypt. Clients authenticating with
+	// such a password must send it in cleartext.
+	HashBcrypt PasswordHashMethod = iota
+	// HashSCRAMSHA256 stores SCRAM-SHA-256 verifiers, which allow clients to
+	// authenticate without sending their password.
+	HashSCRAMSHA256
+)
+
+// GetPasswordHashMethod returns the method used to compute the given stored
+// password hash.
+func GetPasswordHashMethod(hashedPassword []byte) PasswordHashMethod {
+	if IsSCRAMHash(hashedPassword) {
+		return HashSCRAMSHA256
+	}
+	return HashBcrypt
+}
+
 // CompareHashAndPassword tests that the provided bytes are equivalent to the
 // hash of the supplied password. If they are not equivalent, returns an
-// error.
+// error. Both bcrypt hashes and SCRAM verifiers are supported.
 func CompareHashAndPassword(hashedPassword []byte, password string) error {
+	if IsSCRAMHash(hashedPassword) {
+		verifier, err := ParseSCRAMVerifier(hashedPassword)
+		if err != nil {
+			return err
+		}
+		if !verifier.Matches(password) {
+			return errors.New("password does not match SCRAM verifier")
+		}
+		return nil
+	}
 	h := sha256.New()
 	return bcrypt.CompareHashAndPassword(hashedPassword, h.Sum([]byte(password)))
 }
@@ -51,6 +83,19 @@ func HashPassword(password string) ([]byte, error) {
 	return bcrypt.GenerateFromPassword(h.Sum([]byte(password)), BcryptCost)
 }
 
+// HashPasswordWithMethod takes a raw password and returns its hash computed
+// using the given method.
+func HashPasswordWithMethod(password string, method PasswordHashMethod) ([]byte, error) {
+	switch method {
+	case HashBcrypt:
+		return HashPassword(password)
+	case HashSCRAMSHA256:
+		return HashPasswordSCRAM(password)
+	default:
+		return nil, errors.Errorf("unknown password hash method %d", method)
+	}
+}
+
 // PromptForPassword prompts for a password.
 // This is meant to be used when using a password.
 func PromptForPassword() (string, error) {
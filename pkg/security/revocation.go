@@ -0,0 +1,438 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+
+	"github.com/pkg/errors"
+)
+
+// Certificates can be revoked in two ways: by listing their serial
+// number in a certificate revocation list (CRL) file placed in the
+// certs directory, or by having the OCSP responder named in the
+// certificate report them as revoked. CRLs are always checked; OCSP is
+// controlled by the cluster settings below.
+
+const crlExtension = `.crl`
+
+// OCSPModeType is the mode used to check certificates against their
+// OCSP responders.
+type OCSPModeType int
+
+const (
+	// OCSPOff disables OCSP checks.
+	OCSPOff OCSPModeType = iota
+	// OCSPLax rejects certificates reported as revoked, but accepts
+	// certificates whose status can't be determined.
+	OCSPLax
+	// OCSPStrict rejects certificates reported as revoked and
+	// certificates whose status can't be determined.
+	OCSPStrict
+)
+
+// OCSPMode controls whether certificates are checked against the OCSP
+// responders listed in them.
+var OCSPMode = settings.RegisterEnumSetting(
+	"security.ocsp.mode",
+	"use OCSP to check whether TLS certificates are revoked. If the OCSP "+
+		"server is unreachable, in strict mode all certificates will be rejected "+
+		"and in lax mode all certificates will be accepted.",
+	"off",
+	map[int64]string{
+		int64(OCSPOff):    "off",
+		int64(OCSPLax):    "lax",
+		int64(OCSPStrict): "strict",
+	},
+)
+
+// OCSPTimeout is the timeout of the requests to the OCSP responders.
+var OCSPTimeout = settings.RegisterNonNegativeDurationSetting(
+	"security.ocsp.timeout",
+	"timeout before considering the OCSP server unreachable",
+	3*time.Second,
+)
+
+// OCSPStaplingEnabled controls whether the OCSP response for the node
+// certificate is stapled to the TLS handshakes of incoming connections.
+var OCSPStaplingEnabled = settings.RegisterBoolSetting(
+	"security.ocsp.stapling.enabled",
+	"staple the OCSP response for the node certificate to the TLS handshakes "+
+		"of incoming connections, for clients that check OCSP staples",
+	false,
+)
+
+// ocspRetryInterval is how long failures to reach the OCSP responders
+// are remembered before asking them again.
+const ocspRetryInterval = time.Minute
+
+// TLSSettings allows the certificate manager to read the settings
+// controlling certificate revocation checks.
+type TLSSettings interface {
+	ocspMode() OCSPModeType
+	ocspTimeout() time.Duration
+	ocspStapling() bool
+}
+
+type clusterTLSSettings struct {
+	sv *settings.Values
+}
+
+// ClusterTLSSettings returns the TLSSettings backed by the given
+// cluster settings.
+func ClusterTLSSettings(sv *settings.Values) TLSSettings {
+	return clusterTLSSettings{sv: sv}
+}
+
+func (s clusterTLSSettings) ocspMode() OCSPModeType {
+	return OCSPModeType(OCSPMode.Get(s.sv))
+}
+
+func (s clusterTLSSettings) ocspTimeout() time.Duration {
+	return OCSPTimeout.Get(s.sv)
+}
+
+func (s clusterTLSSettings) ocspStapling() bool {
+	return OCSPStaplingEnabled.Get(s.sv)
+}
+
+func isCRLFile(filename string) bool {
+	return strings.HasSuffix(filename, crlExtension)
+}
+
+// crlsByIssuer are certificate revocation lists, indexed by the raw
+// certificate of the CA that signed them.
+type crlsByIssuer map[string][]*pkix.CertificateList
+
+// loadCRLs parses the certificate revocation lists in the certs
+// directory: all the files with the .crl extension, in PEM or DER form.
+// Their signatures are checked against the given CA certificates once
+// here rather than on every handshake; lists signed by none of them are
+// ignored.
+func loadCRLs(certsDir string, issuers []*x509.Certificate) (crlsByIssuer, error) {
+	fileInfos, err := assetLoaderImpl.ReadDir(certsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	crls := make(crlsByIssuer)
+	for _, info := range fileInfos {
+		filename := info.Name()
+		if info.IsDir() || !isCRLFile(filename) {
+			continue
+		}
+		fullPath := filepath.Join(certsDir, filename)
+		contents, err := assetLoaderImpl.ReadFile(fullPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read revocation list %s", fullPath)
+		}
+		crl, err := x509.ParseCRL(contents)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse revocation list %s", fullPath)
+		}
+		if crl.HasExpired(timeutil.Now()) {
+			log.Warningf(context.Background(), "revocation list %s is past its next update time", fullPath)
+		}
+		var signed bool
+		for _, issuer := range issuers {
+			if issuer.CheckCRLSignature(crl) == nil {
+				key := string(issuer.Raw)
+				crls[key] = append(crls[key], crl)
+				signed = true
+			}
+		}
+		if !signed {
+			log.Warningf(context.Background(),
+				"revocation list %s is not signed by any known CA, ignoring it", fullPath)
+			continue
+		}
+		if log.V(3) {
+			log.Infof(context.Background(), "found revocation list %s with %d entries",
+				fullPath, len(crl.TBSCertList.RevokedCertificates))
+		}
+	}
+	return crls, nil
+}
+
+// verifyPeerCertificate is the tls.Config.VerifyPeerCertificate callback
+// of the configs built by the certificate manager. It is called after
+// the chains of the peer certificate have been verified, and rejects
+// them if any certificate in them has been revoked.
+func (cm *CertificateManager) verifyPeerCertificate(
+	_ [][]byte, verifiedChains [][]*x509.Certificate,
+) error {
+	cm.mu.RLock()
+	crls := cm.crls
+	tlsSettings := cm.tlsSettings
+	cm.mu.RUnlock()
+
+	for _, chain := range verifiedChains {
+		// The last certificate of the chain is the CA certificate, which
+		// we trust.
+		for i := 0; i < len(chain)-1; i++ {
+			cert, issuer := chain[i], chain[i+1]
+			if err := checkCRLs(cert, issuer, crls); err != nil {
+				return err
+			}
+			if tlsSettings == nil || tlsSettings.ocspMode() == OCSPOff {
+				continue
+			}
+			if err := cm.checkOCSP(cert, issuer, tlsSettings); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkCRLs returns an error if the certificate appears in one of the
+// revocation lists signed by its issuer.
+func checkCRLs(cert, issuer *x509.Certificate, crls crlsByIssuer) error {
+	for _, crl := range crls[string(issuer.Raw)] {
+		for _, revoked := range crl.TBSCertList.RevokedCertificates {
+			if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return errors.Errorf("certificate %q (serial %s) was revoked at %s",
+					cert.Subject.CommonName, cert.SerialNumber, revoked.RevocationTime)
+			}
+		}
+	}
+	return nil
+}
+
+// checkOCSP asks the OCSP responders listed in the certificate whether
+// it has been revoked. Certificates that don't list any responder are
+// accepted. Failures to determine the status of the certificate are
+// only errors in strict mode.
+func (cm *CertificateManager) checkOCSP(
+	cert, issuer *x509.Certificate, tlsSettings TLSSettings,
+) error {
+	if len(cert.OCSPServer) == 0 {
+		return nil
+	}
+	resp, err := cm.getOCSPResponse(cert, issuer, tlsSettings.ocspTimeout())
+	if err != nil {
+		if tlsSettings.ocspMode() == OCSPStrict {
+			return errors.Wrapf(err, "could not check the revocation status of certificate %q",
+				cert.Subject.CommonName)
+		}
+		return nil
+	}
+	switch resp.Status {
+	case ocspGood:
+		return nil
+	case ocspRevoked:
+		return errors.Errorf("certificate %q (serial %s) was revoked at %s",
+			cert.Subject.CommonName, cert.SerialNumber, resp.RevokedAt)
+	default:
+		if tlsSettings.ocspMode() == OCSPStrict {
+			return errors.Errorf("OCSP responder does not know certificate %q (serial %s)",
+				cert.Subject.CommonName, cert.SerialNumber)
+		}
+		return nil
+	}
+}
+
+// ocspCacheEntry is the result of a query to the OCSP responders of a
+// certificate: either a response or the error preventing to get one.
+type ocspCacheEntry struct {
+	resp   *ocspResponse
+	err    error
+	expiry time.Time
+}
+
+// getOCSPResponse returns the OCSP response for the certificate, from the
+// cache if a response is still valid, or from the responders listed in
+// the certificate. Responses are cached until their next update time, or
+// for ocspDefaultValidity if they don't have one. Failures are cached for
+// ocspRetryInterval, so that an unreachable responder does not add its
+// timeout to every handshake.
+func (cm *CertificateManager) getOCSPResponse(
+	cert, issuer *x509.Certificate, timeout time.Duration,
+) (*ocspResponse, error) {
+	key := string(cert.RawIssuer) + cert.SerialNumber.String()
+	now := timeutil.Now()
+	cm.ocspMu.Lock()
+	entry, ok := cm.ocspMu.cache[key]
+	cm.ocspMu.Unlock()
+	if ok && now.Before(entry.expiry) {
+		return entry.resp, entry.err
+	}
+
+	resp, _, err := queryOCSP(cert, issuer, timeout)
+	entry = ocspCacheEntry{resp: resp, err: err, expiry: now.Add(ocspRetryInterval)}
+	if err != nil {
+		log.Warningf(context.Background(),
+			"could not check the revocation status of certificate %q, retrying in %s: %v",
+			cert.Subject.CommonName, ocspRetryInterval, err)
+	} else {
+		entry.expiry = resp.expiry()
+	}
+	cm.ocspMu.Lock()
+	cm.ocspMu.cache[key] = entry
+	cm.ocspMu.Unlock()
+	return resp, err
+}
+
+// queryOCSP asks the OCSP responders listed in the certificate for its
+// status, trying them in order until one answers. It returns the parsed
+// response and its raw bytes, which can be used as an OCSP staple.
+func queryOCSP(
+	cert, issuer *x509.Certificate, timeout time.Duration,
+) (*ocspResponse, []byte, error) {
+	req, err := createOCSPRequest(cert, issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := http.Client{Timeout: timeout}
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		raw, err := func() ([]byte, error) {
+			httpResp, err := client.Post(server, "application/ocsp-request", bytes.NewReader(req))
+			if err != nil {
+				return nil, err
+			}
+			defer httpResp.Body.Close()
+			if httpResp.StatusCode != http.StatusOK {
+				return nil, errors.Errorf("OCSP responder %s returned %s", server, httpResp.Status)
+			}
+			return ioutil.ReadAll(httpResp.Body)
+		}()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp, err := parseOCSPResponse(raw, cert, issuer, timeutil.Now())
+		if err != nil {
+			lastErr = errors.Wrapf(err, "invalid response from OCSP responder %s", server)
+			continue
+		}
+		return resp, raw, nil
+	}
+	return nil, nil, lastErr
+}
+
+// StartOCSPStapler starts a worker keeping the OCSP response for the
+// node certificate, which is stapled to the TLS handshakes of incoming
+// connections when OCSP stapling is enabled, up to date. Handshakes only
+// use the last response fetched by the worker, and never wait for the
+// OCSP responders.
+func (cm *CertificateManager) StartOCSPStapler(stopper *stop.Stopper) {
+	ctx := context.Background()
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		for {
+			timer.Reset(cm.refreshOCSPStaple(ctx).Sub(timeutil.Now()))
+			select {
+			case <-timer.C:
+				timer.Read = true
+			case <-cm.stapleRefresh:
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// refreshOCSPStaple asks the OCSP responders listed in the node
+// certificate for its status, and stores the response to be stapled.
+// It returns the time of the next refresh. On failure, the previous
+// staple is kept until it expires.
+func (cm *CertificateManager) refreshOCSPStaple(ctx context.Context) time.Time {
+	cm.mu.RLock()
+	tlsSettings := cm.tlsSettings
+	nodeCert := cm.nodeCert
+	caCert := cm.caCert
+	cm.mu.RUnlock()
+
+	now := timeutil.Now()
+	retry := now.Add(ocspRetryInterval)
+	if tlsSettings == nil || !tlsSettings.ocspStapling() || nodeCert == nil || caCert == nil ||
+		len(nodeCert.ParsedCertificates) == 0 || len(nodeCert.ParsedCertificates[0].OCSPServer) == 0 {
+		// Nothing to staple.
+		cm.setOCSPStaple(nil, nil, time.Time{})
+		return retry
+	}
+	cert := nodeCert.ParsedCertificates[0]
+	var issuer *x509.Certificate
+	for _, ca := range caCert.ParsedCertificates {
+		if cert.CheckSignatureFrom(ca) == nil {
+			issuer = ca
+			break
+		}
+	}
+	if issuer == nil {
+		log.Warningf(ctx, "could not staple OCSP response: issuer of the node certificate not found")
+		return retry
+	}
+	resp, raw, err := queryOCSP(cert, issuer, tlsSettings.ocspTimeout())
+	if err != nil {
+		log.Warningf(ctx, "could not staple OCSP response: %v", err)
+		return retry
+	}
+	if resp.Status != ocspGood {
+		log.Warningf(ctx, "OCSP responder does not report the node certificate as good; not stapling")
+		cm.setOCSPStaple(nil, nil, time.Time{})
+		return retry
+	}
+	expiry := resp.expiry()
+	cm.setOCSPStaple(raw, nodeCert, expiry)
+	// Refresh the staple well before it expires, to leave time for retries.
+	if expiry.Before(now) {
+		// The response was accepted within the allowed clock skew.
+		return retry
+	}
+	return now.Add(expiry.Sub(now) / 2)
+}
+
+func (cm *CertificateManager) setOCSPStaple(staple []byte, cert *CertInfo, expiry time.Time) {
+	cm.ocspMu.Lock()
+	defer cm.ocspMu.Unlock()
+	cm.ocspMu.staple = staple
+	cm.ocspMu.stapleCert = cert
+	cm.ocspMu.stapleExpiry = expiry
+}
+
+// ocspStapleLocked returns the OCSP staple for the node certificate, or
+// nil if there is no valid staple for it.
+// cm.mu must be held.
+func (cm *CertificateManager) ocspStapleLocked() []byte {
+	cm.ocspMu.Lock()
+	defer cm.ocspMu.Unlock()
+	if cm.ocspMu.stapleCert != cm.nodeCert {
+		return nil
+	}
+	if !cm.ocspMu.stapleExpiry.IsZero() && !timeutil.Now().Before(cm.ocspMu.stapleExpiry) {
+		return nil
+	}
+	return cm.ocspMu.staple
+}
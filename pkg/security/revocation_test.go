@@ -0,0 +1,404 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/security/securitytest"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// setUpRevocationTest generates certificates in a temporary directory
+// and returns the directory, the CA certificate and key, and a function
+// removing the directory.
+func setUpRevocationTest(t *testing.T) (string, *x509.Certificate, crypto.Signer, func()) {
+	// Do not mock cert access for this test.
+	security.ResetAssetLoader()
+	certsDir, err := ioutil.TempDir("", "revocation_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() {
+		ResetTest()
+		if err := os.RemoveAll(certsDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := generateAllCerts(certsDir); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	caCert := readCert(t, filepath.Join(certsDir, security.EmbeddedCACert))
+	keyPEM, err := ioutil.ReadFile(filepath.Join(certsDir, security.EmbeddedCAKey))
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	caKey, err := security.PEMToPrivateKey(keyPEM)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return certsDir, caCert, caKey.(crypto.Signer), cleanup
+}
+
+func readCert(t *testing.T, path string) *x509.Certificate {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs, err := security.PEMContentsToX509(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certs[0]
+}
+
+// reissueWithOCSPServer replaces the certificate at the given path by
+// an identical certificate listing the given OCSP responder.
+func reissueWithOCSPServer(
+	t *testing.T, path string, caCert *x509.Certificate, caKey crypto.Signer, ocspServer string,
+) *x509.Certificate {
+	template := readCert(t, path)
+	template.OCSPServer = []string{ocspServer}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, template.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := security.WritePEMToFile(
+		path, 0644, true /* overwrite */, &pem.Block{Type: "CERTIFICATE", Bytes: der},
+	); err != nil {
+		t.Fatal(err)
+	}
+	return template
+}
+
+// handshake performs a TLS handshake between a server using the server
+// config of the certificate manager and a client using its client
+// config for the given user. It returns the OCSP response stapled by the
+// server and the errors of the client and server sides of the handshake.
+// The side rejecting the certificate of its peer reports why; the other
+// side only sees a TLS alert.
+func handshake(
+	t *testing.T, cm *security.CertificateManager, user string,
+) (staple []byte, clientErr, serverErr error) {
+	serverCfg, err := cm.GetServerTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCfg, err := cm.GetClientTLSConfig(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		tlsConn := tls.Server(conn, serverCfg)
+		defer tlsConn.Close()
+		errCh <- tlsConn.Handshake()
+	}()
+
+	conn, clientErr := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+	if clientErr == nil {
+		staple = conn.OCSPResponse()
+		// Wait for the server to finish its side of the handshake.
+		serverErr = <-errCh
+		_ = conn.Close()
+		return staple, nil, serverErr
+	}
+	return nil, clientErr, <-errCh
+}
+
+func TestCertificateRevocationList(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	certsDir, caCert, caKey, cleanup := setUpRevocationTest(t)
+	defer cleanup()
+
+	cm, err := security.NewCertificateManager(certsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := handshake(t, cm, security.RootUser); err != nil {
+		t.Fatalf("unexpected handshake error: %v", err)
+	}
+
+	// Revocation lists signed by another CA are ignored.
+	clientCert := readCert(t, filepath.Join(certsDir, "client.root.crt"))
+	now := timeutil.Now()
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               caCert.Subject,
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	otherDER, err := x509.CreateCertificate(
+		rand.Reader, otherTemplate, otherTemplate, otherKey.Public(), otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCA, err := x509.ParseCertificate(otherDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCRL, err := otherCA.CreateCRL(rand.Reader, otherKey, []pkix.RevokedCertificate{
+		{SerialNumber: clientCert.SerialNumber, RevocationTime: now},
+	}, now, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := security.WritePEMToFile(
+		filepath.Join(certsDir, "other.crl"), 0644, true, /* overwrite */
+		&pem.Block{Type: "X509 CRL", Bytes: otherCRL},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.LoadCertificates(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := handshake(t, cm, security.RootUser); err != nil {
+		t.Fatalf("unexpected handshake error with a foreign revocation list: %v", err)
+	}
+
+	// Revoke the client certificate of the root user.
+	crl, err := caCert.CreateCRL(rand.Reader, caKey, []pkix.RevokedCertificate{
+		{SerialNumber: clientCert.SerialNumber, RevocationTime: now},
+	}, now, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := security.WritePEMToFile(
+		filepath.Join(certsDir, "ca.crl"), 0644, true, /* overwrite */
+		&pem.Block{Type: "X509 CRL", Bytes: crl},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// The revocation list is only loaded along with the certificates.
+	if _, _, err := handshake(t, cm, security.RootUser); err != nil {
+		t.Fatalf("unexpected handshake error before reload: %v", err)
+	}
+	if err := cm.LoadCertificates(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := handshake(t, cm, security.RootUser); !testutils.IsError(err, "was revoked") {
+		t.Fatalf("expected revocation error, got %v", err)
+	}
+	// The node certificate is still accepted.
+	if _, _, err := handshake(t, cm, security.NodeUser); err != nil {
+		t.Fatalf("unexpected handshake error for the node certificate: %v", err)
+	}
+
+	// Revocation lists that can't be parsed fail the reload.
+	if err := ioutil.WriteFile(filepath.Join(certsDir, "bad.crl"), []byte("foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.LoadCertificates(); !testutils.IsError(err, "could not parse revocation list") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestOCSP(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	certsDir, caCert, caKey, cleanup := setUpRevocationTest(t)
+	defer cleanup()
+
+	responder := securitytest.StartOCSPResponder(caCert, caKey)
+	defer responder.Close()
+	clientCert := reissueWithOCSPServer(
+		t, filepath.Join(certsDir, "client.root.crt"), caCert, caKey, responder.URL())
+	reissueWithOCSPServer(
+		t, filepath.Join(certsDir, security.EmbeddedNodeCert), caCert, caKey, responder.URL())
+
+	cm, err := security.NewCertificateManager(certsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := cluster.MakeTestingClusterSettings()
+	cm.SetTLSSettings(security.ClusterTLSSettings(&st.SV))
+
+	// OCSP is disabled by default.
+	if _, _, err := handshake(t, cm, security.RootUser); err != nil {
+		t.Fatalf("unexpected handshake error: %v", err)
+	}
+	if n := responder.Requests(); n != 0 {
+		t.Fatalf("expected no OCSP requests, got %d", n)
+	}
+
+	security.OCSPMode.Override(&st.SV, int64(security.OCSPStrict))
+	if _, _, err := handshake(t, cm, security.RootUser); err != nil {
+		t.Fatalf("unexpected handshake error: %v", err)
+	}
+	if n := responder.Requests(); n == 0 {
+		t.Fatal("expected OCSP requests")
+	}
+
+	// Reloading the certificates discards the cached OCSP responses.
+	responder.Revoke(clientCert.SerialNumber)
+	if err := cm.LoadCertificates(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := handshake(t, cm, security.RootUser); !testutils.IsError(err, "was revoked") {
+		t.Fatalf("expected revocation error, got %v", err)
+	}
+	if _, _, err := handshake(t, cm, security.NodeUser); err != nil {
+		t.Fatalf("unexpected handshake error for the node certificate: %v", err)
+	}
+
+	// The server staples the OCSP response for its certificate, once the
+	// OCSP stapler fetched it.
+	security.OCSPStaplingEnabled.Override(&st.SV, true)
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	cm.StartOCSPStapler(stopper)
+	testutils.SucceedsSoon(t, func() error {
+		staple, _, err := handshake(t, cm, security.NodeUser)
+		if err != nil {
+			t.Fatalf("unexpected handshake error: %v", err)
+		}
+		if len(staple) == 0 {
+			return errors.New("expected an OCSP staple")
+		}
+		return nil
+	})
+	security.OCSPStaplingEnabled.Override(&st.SV, false)
+	if staple, _, err := handshake(t, cm, security.NodeUser); err != nil {
+		t.Fatalf("unexpected handshake error: %v", err)
+	} else if len(staple) != 0 {
+		t.Fatal("expected no OCSP staple once stapling is disabled")
+	}
+
+	// When the responder is unreachable, strict mode rejects all the
+	// certificates and lax mode accepts them. The client is the first to
+	// check the certificate of its peer.
+	responder.Close()
+	if err := cm.LoadCertificates(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err, _ := handshake(t, cm, security.NodeUser); !testutils.IsError(
+		err, "could not check the revocation status",
+	) {
+		t.Fatalf("expected OCSP error, got %v", err)
+	}
+	security.OCSPMode.Override(&st.SV, int64(security.OCSPLax))
+	if _, _, err := handshake(t, cm, security.NodeUser); err != nil {
+		t.Fatalf("unexpected handshake error in lax mode: %v", err)
+	}
+	// The failure is cached, and strict mode keeps rejecting the
+	// certificates until the responder is queried again.
+	security.OCSPMode.Override(&st.SV, int64(security.OCSPStrict))
+	if _, err, _ := handshake(t, cm, security.NodeUser); !testutils.IsError(
+		err, "could not check the revocation status",
+	) {
+		t.Fatalf("expected OCSP error, got %v", err)
+	}
+}
+
+func TestOCSPResponseValidity(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	certsDir, caCert, caKey, cleanup := setUpRevocationTest(t)
+	defer cleanup()
+
+	responder := securitytest.StartOCSPResponder(caCert, caKey)
+	defer responder.Close()
+	reissueWithOCSPServer(
+		t, filepath.Join(certsDir, "client.root.crt"), caCert, caKey, responder.URL())
+
+	cm, err := security.NewCertificateManager(certsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := cluster.MakeTestingClusterSettings()
+	cm.SetTLSSettings(security.ClusterTLSSettings(&st.SV))
+	security.OCSPMode.Override(&st.SV, int64(security.OCSPStrict))
+
+	for _, d := range []struct {
+		offset, validity time.Duration
+		expected         string
+	}{
+		// Replayed responses are rejected once past their next update time.
+		{-2 * time.Hour, time.Hour, "OCSP response is stale"},
+		// Responses without a next update time only last for a while.
+		{-2 * time.Hour, 0, "OCSP response is stale"},
+		{time.Hour, time.Hour, "OCSP response is dated in the future"},
+		// Small differences between the clocks are tolerated.
+		{-time.Hour - time.Minute, time.Hour, ""},
+		{time.Minute, time.Hour, ""},
+		{0, 0, ""},
+	} {
+		// Reloading the certificates discards the cached OCSP responses.
+		if err := cm.LoadCertificates(); err != nil {
+			t.Fatal(err)
+		}
+		responder.SetResponseTimes(d.offset, d.validity)
+		_, _, err := handshake(t, cm, security.RootUser)
+		if d.expected == "" {
+			if err != nil {
+				t.Errorf("%s/%s: unexpected handshake error: %v", d.offset, d.validity, err)
+			}
+		} else if !testutils.IsError(err, d.expected) {
+			t.Errorf("%s/%s: expected error %q, got %v", d.offset, d.validity, d.expected, err)
+		}
+	}
+
+	// The last response, which has no next update time, is cached.
+	requests := responder.Requests()
+	if _, _, err := handshake(t, cm, security.RootUser); err != nil {
+		t.Fatalf("unexpected handshake error: %v", err)
+	}
+	if n := responder.Requests(); n != requests {
+		t.Fatalf("expected the OCSP response to be cached, got %d new requests", n-requests)
+	}
+}
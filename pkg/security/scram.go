@@ -0,0 +1,308 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SCRAMMechanism is the name of the SASL mechanism implemented by
+// SCRAMConversation, as advertised to pgwire clients.
+const SCRAMMechanism = "SCRAM-SHA-256"
+
+// SCRAMIterations is the iteration count used when computing new SCRAM
+// verifiers. It is exposed for testing.
+//
+// 4096 is the minimum recommended by RFC 7677, and the default used by
+// PostgreSQL.
+var SCRAMIterations = 4096
+
+const (
+	scramSaltLength  = 16
+	scramNonceLength = 18
+)
+
+// scramHashPrefix is the prefix of SCRAM verifiers stored in system.users.
+// Verifiers use the same format as PostgreSQL:
+//
+//   SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>
+//
+// where the salt and keys are base64-encoded.
+const scramHashPrefix = SCRAMMechanism + "$"
+
+// SCRAMVerifier holds what the server needs to know about a password to
+// authenticate a client using SCRAM-SHA-256, without being able to recover
+// the password or impersonate the client.
+type SCRAMVerifier struct {
+	Iterations int
+	Salt       []byte
+	StoredKey  []byte
+	ServerKey  []byte
+}
+
+// IsSCRAMHash returns whether the given stored password hash is a SCRAM
+// verifier, as opposed to a bcrypt hash.
+func IsSCRAMHash(hashedPassword []byte) bool {
+	return bytes.HasPrefix(hashedPassword, []byte(scramHashPrefix))
+}
+
+// HashPasswordSCRAM takes a raw password and returns an encoded SCRAM
+// verifier using a random salt.
+func HashPasswordSCRAM(password string) ([]byte, error) {
+	salt := make([]byte, scramSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return MakeSCRAMVerifier(password, salt, SCRAMIterations).Encode(), nil
+}
+
+// MakeSCRAMVerifier computes the SCRAM verifier for the given password, salt
+// and iteration count.
+//
+// The password is not normalized with SASLprep (RFC 4013). Like PostgreSQL
+// does for passwords that are not valid UTF-8, the raw bytes are used instead;
+// clients are expected to do the same when they fail to normalize the
+// password.
+func MakeSCRAMVerifier(password string, salt []byte, iterations int) *SCRAMVerifier {
+	saltedPassword := scramHi([]byte(password), salt, iterations)
+	clientKey := scramHMAC(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	return &SCRAMVerifier{
+		Iterations: iterations,
+		Salt:       salt,
+		StoredKey:  storedKey[:],
+		ServerKey:  scramHMAC(saltedPassword, []byte("Server Key")),
+	}
+}
+
+// ParseSCRAMVerifier decodes a SCRAM verifier stored in system.users.
+func ParseSCRAMVerifier(hashedPassword []byte) (*SCRAMVerifier, error) {
+	if !IsSCRAMHash(hashedPassword) {
+		return nil, errors.New("not a SCRAM verifier")
+	}
+	parts := strings.Split(string(hashedPassword[len(scramHashPrefix):]), "$")
+	if len(parts) != 2 {
+		return nil, errors.New("malformed SCRAM verifier")
+	}
+	iterSalt := strings.Split(parts[0], ":")
+	keys := strings.Split(parts[1], ":")
+	if len(iterSalt) != 2 || len(keys) != 2 {
+		return nil, errors.New("malformed SCRAM verifier")
+	}
+	v := &SCRAMVerifier{}
+	var err error
+	if v.Iterations, err = strconv.Atoi(iterSalt[0]); err != nil || v.Iterations <= 0 {
+		return nil, errors.New("malformed SCRAM verifier: invalid iteration count")
+	}
+	for _, f := range []struct {
+		dst *[]byte
+		src string
+	}{
+		{&v.Salt, iterSalt[1]},
+		{&v.StoredKey, keys[0]},
+		{&v.ServerKey, keys[1]},
+	} {
+		if *f.dst, err = base64.StdEncoding.DecodeString(f.src); err != nil {
+			return nil, errors.Wrap(err, "malformed SCRAM verifier")
+		}
+	}
+	if len(v.StoredKey) != sha256.Size || len(v.ServerKey) != sha256.Size {
+		return nil, errors.New("malformed SCRAM verifier: invalid key length")
+	}
+	return v, nil
+}
+
+// Encode returns the representation of the verifier stored in system.users.
+func (v *SCRAMVerifier) Encode() []byte {
+	return []byte(fmt.Sprintf("%s%d:%s$%s:%s", scramHashPrefix, v.Iterations,
+		base64.StdEncoding.EncodeToString(v.Salt),
+		base64.StdEncoding.EncodeToString(v.StoredKey),
+		base64.StdEncoding.EncodeToString(v.ServerKey)))
+}
+
+// Matches returns whether the verifier was computed from the given password.
+// It is used when a client supplies its password in cleartext.
+func (v *SCRAMVerifier) Matches(password string) bool {
+	other := MakeSCRAMVerifier(password, v.Salt, v.Iterations)
+	return hmac.Equal(v.StoredKey, other.StoredKey) && hmac.Equal(v.ServerKey, other.ServerKey)
+}
+
+// SCRAMConversation implements the server side of a SCRAM-SHA-256
+// authentication exchange (RFC 5802, RFC 7677). Channel binding is not
+// supported.
+//
+// The exchange consists of two round trips:
+//  - the client-first-message is passed to ServerFirst, which returns the
+//    server-first-message;
+//  - the client-final-message is passed to ServerFinal, which verifies the
+//    client's proof and returns the server-final-message.
+//
+// As in PostgreSQL, the user name sent by the client is ignored: the user is
+// the one named in the connection's startup message.
+type SCRAMConversation struct {
+	verifier *SCRAMVerifier
+
+	gs2Header       string
+	clientFirstBare string
+	serverFirst     string
+	nonce           string
+}
+
+// scramMakeNonce generates the server part of the exchange's nonce. It is
+// overridden in tests.
+var scramMakeNonce = func() (string, error) {
+	buf := make([]byte, scramNonceLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// NewSCRAMConversation starts a SCRAM exchange authenticating against the
+// given verifier.
+func NewSCRAMConversation(verifier *SCRAMVerifier) *SCRAMConversation {
+	return &SCRAMConversation{verifier: verifier}
+}
+
+// ServerFirst processes the client-first-message and returns the
+// server-first-message.
+func (c *SCRAMConversation) ServerFirst(clientFirst []byte) ([]byte, error) {
+	msg := string(clientFirst)
+	// gs2-header = gs2-cbind-flag "," [ authzid ] ","
+	i := strings.IndexByte(msg, ',')
+	if i < 0 {
+		return nil, errors.New("malformed SCRAM message")
+	}
+	switch flag := msg[:i]; {
+	case flag == "n", flag == "y":
+		// The client does not support channel binding, or thinks we don't.
+	case strings.HasPrefix(flag, "p="):
+		return nil, errors.New("SCRAM channel binding is not supported")
+	default:
+		return nil, errors.Errorf("malformed SCRAM message: invalid channel binding flag %q", flag)
+	}
+	j := strings.IndexByte(msg[i+1:], ',')
+	if j < 0 {
+		return nil, errors.New("malformed SCRAM message")
+	}
+	if authzid := msg[i+1 : i+1+j]; authzid != "" {
+		return nil, errors.New("SCRAM authorization identities are not supported")
+	}
+	c.gs2Header = msg[:i+1+j+1]
+	c.clientFirstBare = msg[len(c.gs2Header):]
+
+	attrs := strings.Split(c.clientFirstBare, ",")
+	if len(attrs) < 2 {
+		return nil, errors.New("malformed SCRAM message")
+	}
+	if strings.HasPrefix(attrs[0], "m=") {
+		return nil, errors.New("SCRAM extensions are not supported")
+	}
+	if !strings.HasPrefix(attrs[0], "n=") {
+		return nil, errors.New("malformed SCRAM message: missing user name")
+	}
+	if !strings.HasPrefix(attrs[1], "r=") || len(attrs[1]) == len("r=") {
+		return nil, errors.New("malformed SCRAM message: missing nonce")
+	}
+	serverNonce, err := scramMakeNonce()
+	if err != nil {
+		return nil, err
+	}
+	c.nonce = attrs[1][len("r="):] + serverNonce
+	c.serverFirst = fmt.Sprintf("r=%s,s=%s,i=%d",
+		c.nonce, base64.StdEncoding.EncodeToString(c.verifier.Salt), c.verifier.Iterations)
+	return []byte(c.serverFirst), nil
+}
+
+// ServerFinal processes the client-final-message, verifying the client's
+// proof of knowledge of the password, and returns the server-final-message
+// that proves to the client that the server knows the verifier.
+func (c *SCRAMConversation) ServerFinal(clientFinal []byte) ([]byte, error) {
+	if c.serverFirst == "" {
+		return nil, errors.New("SCRAM exchange out of order")
+	}
+	msg := string(clientFinal)
+	i := strings.LastIndex(msg, ",p=")
+	if i < 0 {
+		return nil, errors.New("malformed SCRAM message: missing proof")
+	}
+	withoutProof := msg[:i]
+	proof, err := base64.StdEncoding.DecodeString(msg[i+len(",p="):])
+	if err != nil || len(proof) != sha256.Size {
+		return nil, errors.New("malformed SCRAM message: invalid proof")
+	}
+	attrs := strings.Split(withoutProof, ",")
+	if len(attrs) < 2 {
+		return nil, errors.New("malformed SCRAM message")
+	}
+	if attrs[0] != "c="+base64.StdEncoding.EncodeToString([]byte(c.gs2Header)) {
+		return nil, errors.New("SCRAM channel binding mismatch")
+	}
+	if attrs[1] != "r="+c.nonce {
+		return nil, errors.New("SCRAM nonce mismatch")
+	}
+
+	authMessage := []byte(c.clientFirstBare + "," + c.serverFirst + "," + withoutProof)
+	clientSignature := scramHMAC(c.verifier.StoredKey, authMessage)
+	clientKey := make([]byte, sha256.Size)
+	for i := range clientKey {
+		clientKey[i] = proof[i] ^ clientSignature[i]
+	}
+	storedKey := sha256.Sum256(clientKey)
+	if subtle.ConstantTimeCompare(storedKey[:], c.verifier.StoredKey) != 1 {
+		return nil, errors.New("invalid password")
+	}
+
+	serverSignature := scramHMAC(c.verifier.ServerKey, authMessage)
+	return []byte("v=" + base64.StdEncoding.EncodeToString(serverSignature)), nil
+}
+
+func scramHMAC(key, msg []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+// scramHi is the Hi function of RFC 5802, which is PBKDF2 with HMAC-SHA-256
+// producing a single block.
+func scramHi(password, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha256.New, password)
+	_, _ = mac.Write(salt)
+	var blockIndex [4]byte
+	binary.BigEndian.PutUint32(blockIndex[:], 1)
+	_, _ = mac.Write(blockIndex[:])
+	u := mac.Sum(nil)
+	result := append([]byte(nil), u...)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		_, _ = mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range result {
+			result[j] ^= u[j]
+		}
+	}
+	return result
+}
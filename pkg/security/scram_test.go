@@ -0,0 +1,160 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// isError is like testutils.IsError, which can't be imported from this
+// package.
+func isError(err error, substr string) bool {
+	return err != nil && strings.Contains(err.Error(), substr)
+}
+
+// TestSCRAMConversation runs the example exchange of RFC 7677, section 3.
+func TestSCRAMConversation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	defer func(f func() (string, error)) { scramMakeNonce = f }(scramMakeNonce)
+	scramMakeNonce = func() (string, error) { return "%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0", nil }
+
+	salt, err := base64.StdEncoding.DecodeString("W22ZaJ0SNY7soEsUEjb6gQ==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := MakeSCRAMVerifier("pencil", salt, 4096)
+
+	const (
+		clientFirst = "n,,n=user,r=rOprNGfwEbeRWgbNEkqO"
+		serverFirst = "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0," +
+			"s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+		clientFinalWithoutProof = "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+		proof                   = "dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+		serverFinal             = "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+	)
+
+	conv := NewSCRAMConversation(verifier)
+	msg, err := conv.ServerFirst([]byte(clientFirst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != serverFirst {
+		t.Fatalf("expected server-first-message %q, got %q", serverFirst, msg)
+	}
+	msg, err = conv.ServerFinal([]byte(clientFinalWithoutProof + ",p=" + proof))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != serverFinal {
+		t.Fatalf("expected server-final-message %q, got %q", serverFinal, msg)
+	}
+
+	// A proof computed from another password is rejected.
+	badProof := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	conv = NewSCRAMConversation(verifier)
+	if _, err := conv.ServerFirst([]byte(clientFirst)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conv.ServerFinal(
+		[]byte(clientFinalWithoutProof + ",p=" + badProof),
+	); !isError(err, "invalid password") {
+		t.Fatalf("expected invalid password error, got %v", err)
+	}
+
+	// Messages that don't match the first round trip are rejected.
+	for _, tc := range []struct {
+		clientFinal string
+		expected    string
+	}{
+		{"c=eSws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=" + proof, "channel binding mismatch"},
+		{"c=biws,r=rOprNGfwEbeRWgbNEkqO,p=" + proof, "nonce mismatch"},
+		{clientFinalWithoutProof, "missing proof"},
+	} {
+		conv = NewSCRAMConversation(verifier)
+		if _, err := conv.ServerFirst([]byte(clientFirst)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conv.ServerFinal([]byte(tc.clientFinal)); !isError(err, tc.expected) {
+			t.Errorf("%s: expected %q, got %v", tc.clientFinal, tc.expected, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		clientFirst string
+		expected    string
+	}{
+		{"p=tls-unique,,n=user,r=abc", "channel binding is not supported"},
+		{"n,a=admin,n=user,r=abc", "authorization identities are not supported"},
+		{"n,,m=ext,n=user,r=abc", "extensions are not supported"},
+		{"n,,n=user", "malformed SCRAM message"},
+		{"n,,n=user,r=", "missing nonce"},
+	} {
+		conv = NewSCRAMConversation(verifier)
+		if _, err := conv.ServerFirst([]byte(tc.clientFirst)); !isError(err, tc.expected) {
+			t.Errorf("%s: expected %q, got %v", tc.clientFirst, tc.expected, err)
+		}
+	}
+}
+
+func TestSCRAMVerifierEncoding(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	hashed, err := HashPasswordSCRAM("pencil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSCRAMHash(hashed) || GetPasswordHashMethod(hashed) != HashSCRAMSHA256 {
+		t.Fatalf("expected a SCRAM verifier, got %q", hashed)
+	}
+	verifier, err := ParseSCRAMVerifier(hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(verifier.Encode(), hashed) {
+		t.Fatalf("expected %q, got %q", hashed, verifier.Encode())
+	}
+	if err := CompareHashAndPassword(hashed, "pencil"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CompareHashAndPassword(hashed, "pen"); err == nil {
+		t.Fatal("expected mismatched password to fail")
+	}
+
+	bcryptHashed, err := HashPassword("pencil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsSCRAMHash(bcryptHashed) || GetPasswordHashMethod(bcryptHashed) != HashBcrypt {
+		t.Fatalf("expected a bcrypt hash, got %q", bcryptHashed)
+	}
+
+	for _, malformed := range []string{
+		"SCRAM-SHA-256$",
+		"SCRAM-SHA-256$4096:c2FsdA==",
+		"SCRAM-SHA-256$0:c2FsdA==$AAAA:AAAA",
+		"SCRAM-SHA-256$4096:c2FsdA==$AAAA:AAAA",
+		"SCRAM-SHA-256$4096:!!!$AAAA:AAAA",
+	} {
+		if _, err := ParseSCRAMVerifier([]byte(malformed)); err == nil {
+			t.Errorf("%s: expected error", malformed)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package securitytest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/asn1"
+	"net"
+	"sync"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// LDAPServer is an in-process stand-in for an LDAP directory server, for
+// testing LDAP authentication. It only understands StartTLS and simple binds:
+// a bind succeeds if the DN has been given a password with SetPassword and the
+// password matches. Every other request ends the connection.
+//
+// Like Active Directory, it encodes the lengths of its responses in the long
+// form, which DER forbids.
+type LDAPServer struct {
+	ln        net.Listener
+	tlsConfig *tls.Config
+	wg        sync.WaitGroup
+
+	mu struct {
+		syncutil.Mutex
+		passwords map[string]string
+		conns     map[net.Conn]struct{}
+		closed    bool
+		binds     int
+	}
+}
+
+// StartLDAPServer starts an LDAPServer listening on a local port. StartTLS
+// requests are refused if tlsConfig is nil.
+func StartLDAPServer(tlsConfig *tls.Config) (*LDAPServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &LDAPServer{ln: ln, tlsConfig: tlsConfig}
+	s.mu.passwords = make(map[string]string)
+	s.mu.conns = make(map[net.Conn]struct{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			if s.mu.closed {
+				s.mu.Unlock()
+				_ = conn.Close()
+				return
+			}
+			s.mu.conns[conn] = struct{}{}
+			s.mu.Unlock()
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serveConn(conn)
+				s.mu.Lock()
+				delete(s.mu.conns, conn)
+				s.mu.Unlock()
+			}()
+		}
+	}()
+	return s, nil
+}
+
+// URL returns the ldap URL of the server.
+func (s *LDAPServer) URL() string {
+	return "ldap://" + s.ln.Addr().String()
+}
+
+// SetPassword sets the password of the given DN.
+func (s *LDAPServer) SetPassword(dn, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mu.passwords[dn] = password
+}
+
+// Binds returns the number of bind requests received by the server.
+func (s *LDAPServer) Binds() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mu.binds
+}
+
+// Close stops the server and waits for its goroutines to exit.
+func (s *LDAPServer) Close() {
+	_ = s.ln.Close()
+	s.mu.Lock()
+	s.mu.closed = true
+	for conn := range s.mu.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// The LDAPMessages carrying the requests and responses understood by the
+// server: BindRequest and BindResponse (RFC 4511, section 4.2), and
+// ExtendedRequest and ExtendedResponse (section 4.12).
+type ldapRequestMessage struct {
+	MessageID int
+	Request   asn1.RawValue
+}
+
+type ldapBindRequest struct {
+	Version int
+	Name    []byte
+	Simple  []byte `asn1:"tag:0"`
+}
+
+type ldapExtendedRequest struct {
+	RequestName []byte `asn1:"tag:0"`
+}
+
+type ldapResult struct {
+	ResultCode        asn1.Enumerated
+	MatchedDN         []byte
+	DiagnosticMessage []byte
+}
+
+type ldapBindResponseMessage struct {
+	MessageID    int
+	BindResponse ldapResult `asn1:"application,tag:1"`
+}
+
+type ldapExtendedResponseMessage struct {
+	MessageID        int
+	ExtendedResponse ldapResult `asn1:"application,tag:24"`
+}
+
+const (
+	ldapApplicationTagBindRequest     = 0
+	ldapApplicationTagExtendedRequest = 23
+
+	ldapResultSuccess            = 0
+	ldapResultProtocolError      = 2
+	ldapResultInvalidCredentials = 49
+)
+
+func (s *LDAPServer) serveConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := security.ReadLDAPMessage(r)
+		if err != nil {
+			return
+		}
+		var req ldapRequestMessage
+		if _, err := asn1.Unmarshal(msg, &req); err != nil ||
+			req.Request.Class != asn1.ClassApplication {
+			return
+		}
+
+		var resp interface{}
+		startTLS := false
+		switch req.Request.Tag {
+		case ldapApplicationTagBindRequest:
+			var bind ldapBindRequest
+			if _, err := asn1.UnmarshalWithParams(
+				req.Request.FullBytes, &bind, "application,tag:0",
+			); err != nil {
+				return
+			}
+			var result ldapResult
+			s.mu.Lock()
+			s.mu.binds++
+			password, ok := s.mu.passwords[string(bind.Name)]
+			s.mu.Unlock()
+			switch {
+			case bind.Version != 3:
+				result.ResultCode = ldapResultProtocolError
+				result.DiagnosticMessage = []byte("unsupported protocol version")
+			case ok && password == string(bind.Simple):
+				result.ResultCode = ldapResultSuccess
+			default:
+				result.ResultCode = ldapResultInvalidCredentials
+			}
+			resp = ldapBindResponseMessage{MessageID: req.MessageID, BindResponse: result}
+
+		case ldapApplicationTagExtendedRequest:
+			var ext ldapExtendedRequest
+			if _, err := asn1.UnmarshalWithParams(
+				req.Request.FullBytes, &ext, "application,tag:23",
+			); err != nil {
+				return
+			}
+			var result ldapResult
+			switch {
+			case string(ext.RequestName) != security.LDAPStartTLSOID:
+				result.ResultCode = ldapResultProtocolError
+				result.DiagnosticMessage = []byte("unsupported extended operation")
+			case s.tlsConfig == nil:
+				result.ResultCode = ldapResultProtocolError
+				result.DiagnosticMessage = []byte("StartTLS not supported")
+			default:
+				result.ResultCode = ldapResultSuccess
+				startTLS = true
+			}
+			resp = ldapExtendedResponseMessage{MessageID: req.MessageID, ExtendedResponse: result}
+
+		default:
+			// Most likely an UnbindRequest.
+			return
+		}
+
+		out, err := asn1.Marshal(resp)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write(longFormLength(out)); err != nil {
+			return
+		}
+		if startTLS {
+			// The client sends nothing until it starts the handshake, so r
+			// holds no buffered data.
+			conn = tls.Server(conn, s.tlsConfig)
+			r = bufio.NewReader(conn)
+		}
+	}
+}
+
+// longFormLength re-encodes the length of the DER-encoded LDAPMessage in the
+// four byte long form, as sent by Active Directory.
+func longFormLength(der []byte) []byte {
+	headerLen := 2
+	if der[1]&0x80 != 0 {
+		headerLen += int(der[1] & 0x7f)
+	}
+	content := der[headerLen:]
+	n := len(content)
+	out := []byte{der[0], 0x84, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	return append(out, content...)
+}
@@ -0,0 +1,261 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package securitytest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// OCSPResponder is an in-process stand-in for an OCSP responder, for
+// testing certificate revocation checks. It answers POST requests for
+// the certificates of a single CA, signing the responses with the CA
+// key: certificates marked with Revoke are reported as revoked, all the
+// others as good.
+type OCSPResponder struct {
+	server *httptest.Server
+	issuer *x509.Certificate
+	key    crypto.Signer
+
+	mu struct {
+		syncutil.Mutex
+		revoked  map[string]time.Time
+		requests int
+		// offset and validity determine the times of the responses.
+		offset   time.Duration
+		validity time.Duration
+	}
+}
+
+// StartOCSPResponder starts an OCSPResponder for the certificates
+// issued by the given CA, listening on a local port.
+func StartOCSPResponder(issuer *x509.Certificate, key crypto.Signer) *OCSPResponder {
+	r := &OCSPResponder{issuer: issuer, key: key}
+	r.mu.revoked = make(map[string]time.Time)
+	// Keep the responses short-lived by default, so that clients caching
+	// them see revocations quickly.
+	r.mu.validity = time.Second
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+// URL returns the URL of the responder, to be listed in certificates.
+func (r *OCSPResponder) URL() string {
+	return r.server.URL
+}
+
+// Revoke marks the certificate with the given serial number as revoked.
+func (r *OCSPResponder) Revoke(serial *big.Int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.revoked[serial.String()] = timeutil.Now()
+}
+
+// SetResponseTimes dates the responses offset from the current time, and
+// makes them valid for the given duration. A zero validity omits the
+// next update time from the responses.
+func (r *OCSPResponder) SetResponseTimes(offset, validity time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.offset = offset
+	r.mu.validity = validity
+}
+
+// Requests returns the number of requests received by the responder.
+func (r *OCSPResponder) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mu.requests
+}
+
+// Close stops the responder. Requests sent afterwards fail.
+func (r *OCSPResponder) Close() {
+	r.server.Close()
+}
+
+// The ASN.1 structures of OCSP requests and responses (RFC 6960), limited
+// to what the responder needs.
+
+type certID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest struct {
+		Version       int           `asn1:"explicit,tag:0,default:0,optional"`
+		RequestorName asn1.RawValue `asn1:"explicit,tag:1,optional"`
+		RequestList   []struct {
+			CertID certID
+		}
+	}
+}
+
+type ocspResponse struct {
+	Status        asn1.Enumerated
+	ResponseBytes responseBytes `asn1:"explicit,tag:0"`
+}
+
+type responseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type basicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+}
+
+type responseData struct {
+	ResponderKeyHash []byte    `asn1:"explicit,tag:2"`
+	ProducedAt       time.Time `asn1:"generalized"`
+	Responses        []singleResponse
+}
+
+type singleResponse struct {
+	CertID     certID
+	Good       asn1.Flag   `asn1:"tag:0,optional"`
+	Revoked    revokedInfo `asn1:"tag:1,optional"`
+	ThisUpdate time.Time   `asn1:"generalized"`
+	NextUpdate time.Time   `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type revokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+var (
+	oidOCSPBasic       = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// keyCompromise is the revocation reason of the revoked certificates.
+const keyCompromise = 1
+
+func (r *OCSPResponder) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var ocspReq ocspRequest
+	if _, err := asn1.Unmarshal(body, &ocspReq); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(ocspReq.TBSRequest.RequestList) != 1 {
+		http.Error(w, "only requests for a single certificate are supported", http.StatusBadRequest)
+		return
+	}
+	id := ocspReq.TBSRequest.RequestList[0].CertID
+
+	r.mu.Lock()
+	r.mu.requests++
+	revokedAt, revoked := r.mu.revoked[id.SerialNumber.String()]
+	offset, validity := r.mu.offset, r.mu.validity
+	r.mu.Unlock()
+
+	resp, err := r.createResponse(id, revoked, revokedAt, offset, validity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	_, _ = w.Write(resp)
+}
+
+// createResponse returns a successful OCSP response about the certificate
+// with the given ID, signed with the CA key.
+func (r *OCSPResponder) createResponse(
+	id certID, revoked bool, revokedAt time.Time, offset, validity time.Duration,
+) ([]byte, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(r.issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, err
+	}
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+
+	now := timeutil.Now().Add(offset).UTC()
+	single := singleResponse{
+		CertID:     id,
+		ThisUpdate: now,
+	}
+	if validity != 0 {
+		single.NextUpdate = now.Add(validity)
+	}
+	if revoked {
+		single.Revoked = revokedInfo{
+			RevocationTime: revokedAt.UTC(),
+			Reason:         keyCompromise,
+		}
+	} else {
+		single.Good = true
+	}
+	tbs, err := asn1.Marshal(responseData{
+		ResponderKeyHash: keyHash[:],
+		ProducedAt:       now,
+		Responses:        []singleResponse{single},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(tbs)
+	signature, err := r.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	sigAlg := oidSHA256WithRSA
+	if _, ok := r.key.Public().(*ecdsa.PublicKey); ok {
+		sigAlg = oidECDSAWithSHA256
+	}
+	basic, err := asn1.Marshal(basicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: sigAlg},
+		Signature:          asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspResponse{
+		ResponseBytes: responseBytes{ResponseType: oidOCSPBasic, Response: basic},
+	})
+}
@@ -720,7 +720,8 @@ func (s *adminServer) Events(
 		if err := scanner.ScanIndex(row, 4, &event.Info); err != nil {
 			return nil, err
 		}
-		if event.EventType == string(sql.EventLogSetClusterSetting) {
+		if event.EventType == string(sql.EventLogSetClusterSetting) ||
+			event.EventType == string(sql.EventLogClusterSettingDrift) {
 			if s.getUser(req) != security.RootUser {
 				// TODO(dt): unpack and selectively redact the setting value.
 				event.Info = ""
@@ -1215,6 +1216,14 @@ func (s *adminServer) Drain(req *serverpb.DrainRequest, stream serverpb.Admin_Dr
 	}
 }
 
+// DrainStatus reports the drain progress of the node.
+func (s *adminServer) DrainStatus(
+	ctx context.Context, req *serverpb.DrainStatusRequest,
+) (*serverpb.DrainStatusResponse, error) {
+	res := s.server.DrainStatus()
+	return &res, nil
+}
+
 // DecommissionStatus returns the DecommissionStatus for all or the given nodes.
 func (s *adminServer) DecommissionStatus(
 	ctx context.Context, req *serverpb.DecommissionStatusRequest,
@@ -1134,6 +1134,57 @@ func TestHealthAPI(t *testing.T) {
 	}
 }
 
+func TestDrainStatusAPI(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	ts := s.(*TestServer)
+
+	if _, err := db.Exec(`SELECT 1`); err != nil {
+		t.Fatal(err)
+	}
+	var resp serverpb.DrainStatusResponse
+	if err := getAdminJSONProto(s, "drain", &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.On) != 0 || resp.Drained {
+		t.Fatalf("expected the node not to be draining, got %+v", resp)
+	}
+	if resp.SQLConns == 0 {
+		t.Fatalf("expected an open SQL connection, got %+v", resp)
+	}
+
+	// Drain without shutting down.
+	if _, err := ts.Drain(GracefulDrainModes); err != nil {
+		t.Fatal(err)
+	}
+	testutils.SucceedsSoon(t, func() error {
+		if err := getAdminJSONProto(s, "drain", &resp); err != nil {
+			return err
+		}
+		if len(resp.On) != len(GracefulDrainModes) {
+			return errors.Errorf("expected all the drain modes to be active, got %+v", resp)
+		}
+		// The idle SQL connection is closed.
+		if resp.SQLConns != 0 {
+			return errors.Errorf("expected no SQL connections, got %+v", resp)
+		}
+		return nil
+	})
+	// The leases of a single node cluster cannot move away.
+	if resp.LeaseCount == 0 || resp.Drained {
+		t.Fatalf("expected the node to hold leases, got %+v", resp)
+	}
+	if len(resp.LeaseRangeIDs) == 0 {
+		t.Fatalf("expected the ranges of the leases to be listed, got %+v", resp)
+	}
+	select {
+	case <-s.Stopper().ShouldQuiesce():
+		t.Fatal("the node shut down while draining")
+	default:
+	}
+}
+
 // getSystemJobIDs queries the jobs table for all jobs IDs. Sorted by decreasing creation time.
 func getSystemJobIDs(t testing.TB, db *sqlutils.SQLRunner) []int64 {
 	rows := db.Query(t, `SELECT id FROM crdb_internal.jobs ORDER BY created DESC;`)
@@ -245,7 +245,13 @@ func (s *authenticationServer) verifyPassword(
 	if !exists {
 		return false, nil
 	}
-	return (security.CompareHashAndPassword(hashedPassword, password) == nil), nil
+	if security.CompareHashAndPassword(hashedPassword, password) != nil {
+		return false, nil
+	}
+	sql.MaybeUpgradeUserHashedPassword(
+		ctx, s.server.sqlExecutor, s.memMetrics, username, password, hashedPassword,
+	)
+	return true, nil
 }
 
 // newAuthSession attempts to create a new authentication session for the given
@@ -0,0 +1,175 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/grpcutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+var certRenewalEnabled = settings.RegisterBoolSetting(
+	"server.certificate_renewal.enabled",
+	"if set, nodes renew their node and client certificates before they expire "+
+		"by requesting new certificates from the node started with --ca-key",
+	false,
+)
+
+var certRenewalWindow = settings.RegisterNonNegativeDurationSetting(
+	"server.certificate_renewal.window",
+	"certificates are renewed when they expire within this duration",
+	14*24*time.Hour,
+)
+
+var certRenewalLifetime = settings.RegisterNonNegativeDurationSetting(
+	"server.certificate_renewal.lifetime",
+	"lifetime of the certificates issued by the node started with --ca-key; "+
+		"should be longer than server.certificate_renewal.window",
+	90*24*time.Hour,
+)
+
+// certRenewalInterval is the interval at which nodes check whether their
+// certificates need to be renewed, and at which the certificate signer
+// node gossips its descriptor.
+var certRenewalInterval = envutil.EnvOrDefaultDuration(
+	"COCKROACH_CERTIFICATE_RENEWAL_INTERVAL", 10*time.Minute)
+
+// startCertificateRenewal starts a worker that periodically renews the
+// certificates about to expire. On the certificate signer node, the
+// worker also gossips the node descriptor under
+// gossip.KeyCertificateSigner so that the other nodes can find it.
+func (s *Server) startCertificateRenewal() {
+	if s.cfg.Insecure {
+		return
+	}
+	ctx := s.AnnotateCtx(context.Background())
+	s.stopper.RunWorker(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(certRenewalInterval)
+		defer ticker.Stop()
+		for {
+			if s.certSigner != nil {
+				if err := s.gossip.AddInfoProto(
+					gossip.KeyCertificateSigner, &s.node.Descriptor, 2*certRenewalInterval,
+				); err != nil {
+					log.Warningf(ctx, "could not gossip certificate signer: %v", err)
+				}
+			}
+			if certRenewalEnabled.Get(&s.st.SV) {
+				if err := s.renewCertificates(ctx); err != nil {
+					log.Warningf(ctx, "could not renew certificates: %v", err)
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-s.stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// renewCertificates replaces the node and client certificates of the
+// certs directory that expire within the renewal window by certificates
+// signed by the certificate signer node, with fresh keys.
+func (s *Server) renewCertificates(ctx context.Context) error {
+	cm, err := s.cfg.GetCertificateManager()
+	if err != nil {
+		return err
+	}
+	expiring := cm.CertificatesExpiringBefore(timeutil.Now().Add(certRenewalWindow.Get(&s.st.SV)))
+	if len(expiring) == 0 {
+		return nil
+	}
+
+	var signer roachpb.NodeDescriptor
+	if err := s.gossip.GetInfoProto(gossip.KeyCertificateSigner, &signer); err != nil {
+		return errors.Wrap(err, "no certificate signer node found")
+	}
+	conn, err := s.rpcContext.GRPCDial(signer.Address.String()).Connect(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "could not connect to certificate signer node %d", signer.NodeID)
+	}
+	client := serverpb.NewAdminClient(conn)
+
+	for _, ci := range expiring {
+		csr, key, err := security.CreateRenewalRequest(ci)
+		if err != nil {
+			return errors.Wrapf(err, "could not renew %s", ci.Filename)
+		}
+		resp, err := client.SignCertificate(ctx, &serverpb.SignCertificateRequest{CSR: csr})
+		if err != nil {
+			return errors.Wrapf(err, "certificate signer node %d could not renew %s",
+				signer.NodeID, ci.Filename)
+		}
+		if err := cm.InstallRenewedCertificate(ci, resp.Certificate, key); err != nil {
+			return errors.Wrapf(err, "could not install renewed %s", ci.Filename)
+		}
+		log.Infof(ctx, "renewed certificate %s", ci.Filename)
+	}
+	return nil
+}
+
+// SignCertificate implements the serverpb.AdminServer interface.
+func (s *adminServer) SignCertificate(
+	ctx context.Context, req *serverpb.SignCertificateRequest,
+) (*serverpb.SignCertificateResponse, error) {
+	ctx = s.server.AnnotateCtx(ctx)
+	if s.server.certSigner == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"node %d was not started with the CA key", s.server.NodeID())
+	}
+
+	// The requester is authenticated by its TLS client certificate. Only
+	// the nodes renew certificates, including the client certificates in
+	// their certs directories: letting users renew their own client
+	// certificate would keep it valid forever once it leaked.
+	if !grpcutil.IsLocalRequestContext(ctx) {
+		var tlsInfo credentials.TLSInfo
+		if p, ok := peer.FromContext(ctx); ok {
+			tlsInfo, _ = p.AuthInfo.(credentials.TLSInfo)
+		}
+		user, err := security.GetCertificateUser(&tlsInfo.State)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+		}
+		if user != security.NodeUser {
+			return nil, status.Errorf(codes.PermissionDenied,
+				"user %s is not allowed to request certificates, only the %s user is",
+				user, security.NodeUser)
+		}
+	}
+
+	cert, err := s.server.certSigner.Sign(req.CSR, certRenewalLifetime.Get(&s.server.st.SV))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	log.Info(ctx, "signed certificate renewal request")
+	return &serverpb.SignCertificateResponse{Certificate: cert}, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package server
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/security/securitytest"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+func TestCertificateRenewal(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	// Do not mock cert access for this test.
+	security.ResetAssetLoader()
+	defer security.SetAssetLoader(securitytest.EmbeddedAssets)
+
+	certsDir, err := ioutil.TempDir("", "cert_renewal_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(certsDir); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	caKey := filepath.Join(certsDir, security.EmbeddedCAKey)
+	if err := security.CreateCAPair(certsDir, caKey, 512, 96*time.Hour, true, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := security.CreateNodePair(
+		certsDir, caKey, 512, 24*time.Hour, true, []string{"127.0.0.1", "localhost"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := security.CreateClientPair(
+		certsDir, caKey, 512, 24*time.Hour, true, security.RootUser,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(interval time.Duration) { certRenewalInterval = interval }(certRenewalInterval)
+	certRenewalInterval = 10 * time.Millisecond
+
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{
+		SSLCertsDir: certsDir,
+		SSLCAKey:    caKey,
+	})
+	defer s.Stopper().Stop(context.TODO())
+	ts := s.(*TestServer)
+	if ts.certSigner == nil {
+		t.Fatal("expected the server to be a certificate signer")
+	}
+	cm, err := ts.Cfg.GetCertificateManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The certificates expire within the renewal window, and are renewed
+	// for a lifetime longer than the window.
+	sv := &s.ClusterSettings().SV
+	certRenewalWindow.Override(sv, 48*time.Hour)
+	certRenewalLifetime.Override(sv, 72*time.Hour)
+	certRenewalEnabled.Override(sv, true)
+
+	deadline := timeutil.Now().Add(48 * time.Hour)
+	testutils.SucceedsSoon(t, func() error {
+		if expiring := cm.CertificatesExpiringBefore(deadline); len(expiring) > 0 {
+			return errors.Errorf("%s not yet renewed", expiring[0].Filename)
+		}
+		return nil
+	})
+
+	nodeCert := cm.NodeCert().ParsedCertificates[0]
+	if len(nodeCert.IPAddresses) != 1 || nodeCert.IPAddresses[0].String() != "127.0.0.1" ||
+		len(nodeCert.DNSNames) != 1 || nodeCert.DNSNames[0] != "localhost" {
+		t.Fatalf("renewed node certificate has unexpected hosts %v %v",
+			nodeCert.IPAddresses, nodeCert.DNSNames)
+	}
+
+	// New connections use the renewed certificates on both ends.
+	pgURL := url.URL{
+		Scheme: "postgres",
+		User:   url.User(security.RootUser),
+		Host:   s.ServingAddr(),
+		RawQuery: url.Values{
+			"sslmode":     {"verify-full"},
+			"sslrootcert": {filepath.Join(certsDir, security.EmbeddedCACert)},
+			"sslcert":     {filepath.Join(certsDir, fmt.Sprintf("client.%s.crt", security.RootUser))},
+			"sslkey":      {filepath.Join(certsDir, fmt.Sprintf("client.%s.key", security.RootUser))},
+		}.Encode(),
+	}
+	db, err := gosql.Open("postgres", pgURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`SELECT 1`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ts.certSigner.Sign(nil, time.Hour); !testutils.IsError(
+		err, "could not parse certificate signing request",
+	) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clientCI := cm.ClientCerts()[security.RootUser]
+	csr, _, err := security.CreateRenewalRequest(clientCI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the node user can request certificates, so that a client
+	// certificate cannot be renewed with itself.
+	tlsConfig, err := cm.GetClientTLSConfig(security.RootUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.Dial(s.ServingAddr(), grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	req := &serverpb.SignCertificateRequest{CSR: csr}
+	if _, err := serverpb.NewAdminClient(conn).SignCertificate(context.TODO(), req); !testutils.IsError(
+		err, "user root is not allowed to request certificates",
+	) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nodeConn, err := ts.rpcContext.GRPCDial(s.ServingAddr()).Connect(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := serverpb.NewAdminClient(nodeConn).SignCertificate(context.TODO(), req); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -227,6 +227,10 @@ type Config struct {
 	// it is ready.
 	PIDFile string
 
+	// SettingsFile is the settings file to apply to the cluster settings
+	// once the server has started. See package settingsfile.
+	SettingsFile string
+
 	// EnableWebSessionAuthentication enables session-based authentication for
 	// the Admin API's HTTP endpoints.
 	EnableWebSessionAuthentication bool
@@ -397,6 +401,9 @@ func (cfg *Config) String() string {
 	if cfg.PIDFile != "" {
 		fmt.Fprintln(w, "PID file\t", cfg.PIDFile)
 	}
+	if cfg.SettingsFile != "" {
+		fmt.Fprintln(w, "settings file\t", cfg.SettingsFile)
+	}
 	_ = w.Flush()
 
 	return buf.String()
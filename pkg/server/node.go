@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"sort"
 	"time"
 
 	"google.golang.org/grpc/credentials"
@@ -436,6 +437,7 @@ func (n *Node) start(
 	n.startedAt = n.storeCfg.Clock.Now().WallTime
 
 	n.startComputePeriodicMetrics(n.stopper, DefaultMetricsSampleInterval)
+	n.startReencryptStores(n.stopper, reencryptInterval)
 	// Be careful about moving this line above `startStores`; store migrations rely
 	// on the fact that the cluster version has not been updated via Gossip (we
 	// have migrations that want to run only if the server starts with a given
@@ -468,6 +470,36 @@ func (n *Node) SetDraining(drain bool) error {
 	})
 }
 
+// LeaseCount returns the number of valid range leases held by the node's
+// underlying stores.
+func (n *Node) LeaseCount() int {
+	var count int
+	if err := n.stores.VisitStores(func(s *storage.Store) error {
+		count += s.LeaseCount()
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+	return count
+}
+
+// LeaseRangeIDs returns the sorted IDs of the ranges whose valid range
+// leases are held by the node's underlying stores, up to limit of them.
+func (n *Node) LeaseRangeIDs(limit int) []roachpb.RangeID {
+	var rangeIDs []roachpb.RangeID
+	if err := n.stores.VisitStores(func(s *storage.Store) error {
+		rangeIDs = append(rangeIDs, s.LeaseRangeIDs()...)
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+	sort.Slice(rangeIDs, func(i, j int) bool { return rangeIDs[i] < rangeIDs[j] })
+	if len(rangeIDs) > limit {
+		rangeIDs = rangeIDs[:limit]
+	}
+	return rangeIDs
+}
+
 // initStores initializes the Stores map from ID to Store. Stores are
 // added to the local sender if already bootstrapped. A bootstrapped
 // Store has a valid ident with cluster, node and Store IDs set. If
@@ -745,6 +777,39 @@ func (n *Node) startComputePeriodicMetrics(stopper *stop.Stopper, interval time.
 	})
 }
 
+// reencryptInterval is the interval at which the stores rewrite the files
+// written before the rotation of their data key. Data keys are rotated on the
+// order of days, so a rotation is picked up soon enough.
+const reencryptInterval = 10 * time.Minute
+
+// startReencryptStores starts a loop which periodically instructs each store
+// to rewrite the files encrypted with an older data key than the active one,
+// so that a key rotation eventually covers all the data of the store.
+func (n *Node) startReencryptStores(stopper *stop.Stopper, interval time.Duration) {
+	ctx := log.WithLogTag(n.AnnotateCtx(context.Background()), "reencrypt", nil)
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		ctx = stopper.WithCancel(ctx)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = n.stores.VisitStores(func(store *storage.Store) error {
+					rewritten, err := store.Engine().ReencryptStaleFiles(ctx)
+					if err != nil {
+						log.Warningf(ctx, "%s: unable to rewrite files with the active data key: %s", store, err)
+					} else if rewritten > 0 {
+						log.Infof(ctx, "%s: rewrote %d files with the active data key", store, rewritten)
+					}
+					return nil
+				})
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
 // computePeriodicMetrics instructs each store to compute the value of
 // complicated metrics.
 func (n *Node) computePeriodicMetrics(ctx context.Context, tick int) error {
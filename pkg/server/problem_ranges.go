@@ -125,12 +125,17 @@ func (s *statusServer) ProblemRanges(
 					problems.NoLeaseRangeIDs =
 						append(problems.NoLeaseRangeIDs, info.State.Desc.RangeID)
 				}
+				if info.Problems.ConstraintViolation {
+					problems.ConstraintViolatingRangeIDs =
+						append(problems.ConstraintViolatingRangeIDs, info.State.Desc.RangeID)
+				}
 			}
 			sort.Sort(roachpb.RangeIDSlice(problems.UnavailableRangeIDs))
 			sort.Sort(roachpb.RangeIDSlice(problems.RaftLeaderNotLeaseHolderRangeIDs))
 			sort.Sort(roachpb.RangeIDSlice(problems.NoRaftLeaderRangeIDs))
 			sort.Sort(roachpb.RangeIDSlice(problems.NoLeaseRangeIDs))
 			sort.Sort(roachpb.RangeIDSlice(problems.UnderreplicatedRangeIDs))
+			sort.Sort(roachpb.RangeIDSlice(problems.ConstraintViolatingRangeIDs))
 			response.ProblemsByNodeID[resp.nodeID] = problems
 		case <-ctx.Done():
 			return nil, status.Errorf(codes.DeadlineExceeded, ctx.Err().Error())
@@ -0,0 +1,277 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package server
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// replicationReportScanBatchSize is the number of range descriptors read from
+// the meta ranges at a time while computing a replication report.
+const replicationReportScanBatchSize = 10000
+
+// replicationReportMaxAge is how long a computed replication report is served
+// to subsequent requests, which among other things lets a query over
+// crdb_internal.replication_report and crdb_internal.critical_localities scan
+// the meta ranges only once.
+const replicationReportMaxAge = 10 * time.Second
+
+// replicationReportCache holds the last replication report computed on this
+// node.
+type replicationReportCache struct {
+	// Held while computing a report, so that concurrent requests wait for it
+	// instead of computing their own.
+	syncutil.Mutex
+	computedAt time.Time
+	response   *serverpb.ReplicationReportResponse
+}
+
+// ReplicationReport computes the replication health of every range in the
+// cluster, grouped by the zone config that applies to it. The report is
+// computed on this node from the range descriptors in the meta ranges and
+// the liveness, node and store information gossiped to it.
+func (s *statusServer) ReplicationReport(
+	ctx context.Context, req *serverpb.ReplicationReportRequest,
+) (*serverpb.ReplicationReportResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+
+	s.replicationReport.Lock()
+	defer s.replicationReport.Unlock()
+	if r := s.replicationReport.response; r != nil &&
+		timeutil.Since(s.replicationReport.computedAt) < replicationReportMaxAge {
+		return r, nil
+	}
+
+	cfg, ok := s.gossip.GetSystemConfig()
+	if !ok {
+		return nil, status.Errorf(codes.Unavailable, "system config not yet available")
+	}
+
+	// Page through the meta ranges rather than loading every range descriptor
+	// of the cluster at once.
+	iterateRanges := func(visit func(roachpb.RangeDescriptor) error) error {
+		startKey := keys.Meta2Prefix
+		for {
+			kvs, err := s.db.Scan(ctx, startKey, keys.MetaMax, replicationReportScanBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, kv := range kvs {
+				var desc roachpb.RangeDescriptor
+				if err := kv.ValueProto(&desc); err != nil {
+					return err
+				}
+				if err := visit(desc); err != nil {
+					return err
+				}
+			}
+			if len(kvs) < replicationReportScanBatchSize {
+				return nil
+			}
+			startKey = kvs[len(kvs)-1].Key.Next()
+		}
+	}
+
+	getStoreDesc := func(storeID roachpb.StoreID) (roachpb.StoreDescriptor, bool) {
+		var desc roachpb.StoreDescriptor
+		if err := s.gossip.GetInfoProto(gossip.MakeStoreKey(storeID), &desc); err != nil {
+			return roachpb.StoreDescriptor{}, false
+		}
+		return desc, true
+	}
+
+	computedAt := timeutil.Now()
+	response, err := computeReplicationReport(
+		iterateRanges,
+		func(key roachpb.RKey) (uint32, config.ZoneConfig, *config.Subzone, error) {
+			return sql.ZoneConfigForKey(cfg, key)
+		},
+		s.nodeLiveness.GetIsLiveMap(),
+		s.gossip.GetNodeDescriptor,
+		getStoreDesc,
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	response.NodeID = s.gossip.NodeID.Get()
+	s.replicationReport.computedAt = computedAt
+	s.replicationReport.response = response
+	return response, nil
+}
+
+// replicationReportZone identifies the zone config, or the subzone config of
+// an index or partition, that applies to a range.
+type replicationReportZone struct {
+	zoneID        uint32
+	indexID       uint32
+	partitionName string
+}
+
+// computeReplicationReport tallies the unavailable, under-replicated,
+// over-replicated and constraint-violating ranges of each zone, as well as the
+// available ranges that would lose quorum if all of the nodes in a locality
+// became unavailable. Ranges to which a subzone config applies are attributed
+// to the subzone rather than to the zone of its table.
+func computeReplicationReport(
+	iterateRanges func(func(roachpb.RangeDescriptor) error) error,
+	getZoneConfig func(roachpb.RKey) (uint32, config.ZoneConfig, *config.Subzone, error),
+	isLiveMap map[roachpb.NodeID]bool,
+	getNodeDesc func(roachpb.NodeID) (*roachpb.NodeDescriptor, error),
+	getStoreDesc func(roachpb.StoreID) (roachpb.StoreDescriptor, bool),
+) (*serverpb.ReplicationReportResponse, error) {
+	type localityKey struct {
+		locality string
+		zone     replicationReportZone
+	}
+	zones := make(map[replicationReportZone]*serverpb.ReplicationReportResponse_ZoneReport)
+	critical := make(map[localityKey]int64)
+
+	// Cache the locality prefixes of each node, e.g. "region=us-east" and
+	// "region=us-east,zone=a" for a node in zone a of us-east.
+	nodeLocalities := make(map[roachpb.NodeID][]string)
+	getNodeLocalities := func(nodeID roachpb.NodeID) []string {
+		if localities, ok := nodeLocalities[nodeID]; ok {
+			return localities
+		}
+		var localities []string
+		if desc, err := getNodeDesc(nodeID); err == nil {
+			tiers := make([]string, len(desc.Locality.Tiers))
+			for i, tier := range desc.Locality.Tiers {
+				tiers[i] = tier.String()
+				localities = append(localities, strings.Join(tiers[:i+1], ","))
+			}
+		}
+		nodeLocalities[nodeID] = localities
+		return localities
+	}
+
+	if err := iterateRanges(func(desc roachpb.RangeDescriptor) error {
+		zoneID, zone, subzone, err := getZoneConfig(desc.StartKey)
+		if err != nil {
+			return err
+		}
+		key := replicationReportZone{zoneID: zoneID}
+		if subzone != nil {
+			key.indexID = subzone.IndexID
+			key.partitionName = subzone.PartitionName
+		}
+		report, ok := zones[key]
+		if !ok {
+			report = &serverpb.ReplicationReportResponse_ZoneReport{
+				ZoneID:        key.zoneID,
+				IndexID:       key.indexID,
+				PartitionName: key.partitionName,
+			}
+			zones[key] = report
+		}
+		report.TotalRanges++
+
+		var liveReplicas int
+		liveReplicasByLocality := make(map[string]int)
+		for _, repl := range desc.Replicas {
+			if !isLiveMap[repl.NodeID] {
+				continue
+			}
+			liveReplicas++
+			for _, locality := range getNodeLocalities(repl.NodeID) {
+				liveReplicasByLocality[locality]++
+			}
+		}
+
+		// A majority of the range's replicas must be live for the range to be
+		// available. This mirrors the quorum computation in the storage package.
+		quorum := len(desc.Replicas)/2 + 1
+		if liveReplicas < quorum {
+			report.UnavailableRanges++
+		} else {
+			for locality, n := range liveReplicasByLocality {
+				if liveReplicas-n < quorum {
+					critical[localityKey{locality: locality, zone: key}]++
+				}
+			}
+		}
+		if int32(liveReplicas) < zone.NumReplicas {
+			report.UnderReplicatedRanges++
+		}
+		if int32(len(desc.Replicas)) > zone.NumReplicas {
+			report.OverReplicatedRanges++
+		}
+		if storage.ConstraintsViolated(getStoreDesc, desc.Replicas, zone.Constraints) {
+			report.ConstraintViolatingRanges++
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	response := &serverpb.ReplicationReportResponse{}
+	for _, report := range zones {
+		response.Zones = append(response.Zones, *report)
+	}
+	sort.Slice(response.Zones, func(i, j int) bool {
+		a, b := response.Zones[i], response.Zones[j]
+		return replicationReportZoneLess(
+			replicationReportZone{a.ZoneID, a.IndexID, a.PartitionName},
+			replicationReportZone{b.ZoneID, b.IndexID, b.PartitionName},
+		)
+	})
+	for key, count := range critical {
+		response.CriticalLocalities = append(response.CriticalLocalities,
+			serverpb.ReplicationReportResponse_CriticalLocality{
+				Locality:       key.locality,
+				ZoneID:         key.zone.zoneID,
+				IndexID:        key.zone.indexID,
+				PartitionName:  key.zone.partitionName,
+				CriticalRanges: count,
+			})
+	}
+	sort.Slice(response.CriticalLocalities, func(i, j int) bool {
+		a, b := response.CriticalLocalities[i], response.CriticalLocalities[j]
+		if a.Locality != b.Locality {
+			return a.Locality < b.Locality
+		}
+		return replicationReportZoneLess(
+			replicationReportZone{a.ZoneID, a.IndexID, a.PartitionName},
+			replicationReportZone{b.ZoneID, b.IndexID, b.PartitionName},
+		)
+	})
+	return response, nil
+}
+
+func replicationReportZoneLess(a, b replicationReportZone) bool {
+	if a.zoneID != b.zoneID {
+		return a.zoneID < b.zoneID
+	}
+	if a.indexID != b.indexID {
+		return a.indexID < b.indexID
+	}
+	return a.partitionName < b.partitionName
+}
@@ -0,0 +1,193 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestComputeReplicationReport(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	localities := map[roachpb.NodeID]roachpb.Locality{
+		1: {Tiers: []roachpb.Tier{{Key: "region", Value: "east"}, {Key: "zone", Value: "a"}}},
+		2: {Tiers: []roachpb.Tier{{Key: "region", Value: "east"}, {Key: "zone", Value: "b"}}},
+		3: {Tiers: []roachpb.Tier{{Key: "region", Value: "west"}, {Key: "zone", Value: "a"}}},
+		4: {Tiers: []roachpb.Tier{{Key: "region", Value: "west"}, {Key: "zone", Value: "b"}}},
+		5: {Tiers: []roachpb.Tier{{Key: "region", Value: "central"}}},
+	}
+	getNodeDesc := func(nodeID roachpb.NodeID) (*roachpb.NodeDescriptor, error) {
+		locality, ok := localities[nodeID]
+		if !ok {
+			return nil, errors.Errorf("unknown node %d", nodeID)
+		}
+		return &roachpb.NodeDescriptor{NodeID: nodeID, Locality: locality}, nil
+	}
+	// Only the stores on nodes 1 and 2 have SSDs.
+	getStoreDesc := func(storeID roachpb.StoreID) (roachpb.StoreDescriptor, bool) {
+		desc := roachpb.StoreDescriptor{StoreID: storeID}
+		if storeID <= 2 {
+			desc.Attrs.Attrs = []string{"ssd"}
+		}
+		return desc, true
+	}
+	// Node 5 is dead.
+	isLiveMap := map[roachpb.NodeID]bool{1: true, 2: true, 3: true, 4: true, 5: false}
+
+	// Ranges starting before "d" use the default zone, while those starting
+	// at or after it use a zone that requires SSDs, except for those starting
+	// at or after "f", which use a subzone of it requiring five replicas.
+	ssdZone := config.ZoneConfig{
+		NumReplicas: 3,
+		Constraints: config.Constraints{
+			Constraints: []config.Constraint{{Value: "ssd", Type: config.Constraint_REQUIRED}},
+		},
+	}
+	subzone := config.Subzone{
+		IndexID:       2,
+		PartitionName: "p1",
+		Config:        config.ZoneConfig{NumReplicas: 5},
+	}
+	getZoneConfig := func(key roachpb.RKey) (uint32, config.ZoneConfig, *config.Subzone, error) {
+		if key.Less(roachpb.RKey("d")) {
+			return keys.RootNamespaceID, config.ZoneConfig{NumReplicas: 3}, nil, nil
+		}
+		if key.Less(roachpb.RKey("f")) {
+			return 50, ssdZone, nil, nil
+		}
+		return 50, subzone.Config, &subzone, nil
+	}
+
+	replicas := func(ids ...int) []roachpb.ReplicaDescriptor {
+		var r []roachpb.ReplicaDescriptor
+		for _, id := range ids {
+			r = append(r, roachpb.ReplicaDescriptor{
+				NodeID:    roachpb.NodeID(id),
+				StoreID:   roachpb.StoreID(id),
+				ReplicaID: roachpb.ReplicaID(id),
+			})
+		}
+		return r
+	}
+	descs := []roachpb.RangeDescriptor{
+		{RangeID: 1, StartKey: roachpb.RKey("a"), Replicas: replicas(1, 2, 3)},
+		// Under-replicated.
+		{RangeID: 2, StartKey: roachpb.RKey("b"), Replicas: replicas(1, 3, 5)},
+		// Unavailable and under-replicated.
+		{RangeID: 3, StartKey: roachpb.RKey("c"), Replicas: replicas(1, 5)},
+		// Over-replicated and violating constraints.
+		{RangeID: 4, StartKey: roachpb.RKey("d"), Replicas: replicas(1, 2, 3, 4)},
+		// Under-replicated according to the subzone, and not violating the
+		// constraints of its table's zone.
+		{RangeID: 5, StartKey: roachpb.RKey("f"), Replicas: replicas(1, 2, 3)},
+	}
+	iterateRanges := func(visit func(roachpb.RangeDescriptor) error) error {
+		for _, desc := range descs {
+			if err := visit(desc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	response, err := computeReplicationReport(
+		iterateRanges, getZoneConfig, isLiveMap, getNodeDesc, getStoreDesc,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedZones := []serverpb.ReplicationReportResponse_ZoneReport{
+		{
+			ZoneID:                keys.RootNamespaceID,
+			TotalRanges:           3,
+			UnavailableRanges:     1,
+			UnderReplicatedRanges: 2,
+		},
+		{
+			ZoneID:                    50,
+			TotalRanges:               1,
+			OverReplicatedRanges:      1,
+			ConstraintViolatingRanges: 1,
+		},
+		{
+			ZoneID:                50,
+			IndexID:               2,
+			PartitionName:         "p1",
+			TotalRanges:           1,
+			UnderReplicatedRanges: 1,
+		},
+	}
+	if !reflect.DeepEqual(expectedZones, response.Zones) {
+		t.Errorf("expected zones %+v, but found %+v", expectedZones, response.Zones)
+	}
+
+	expectedCritical := []serverpb.ReplicationReportResponse_CriticalLocality{
+		{Locality: "region=east", ZoneID: keys.RootNamespaceID, CriticalRanges: 2},
+		{Locality: "region=east", ZoneID: 50, CriticalRanges: 1},
+		{Locality: "region=east", ZoneID: 50, IndexID: 2, PartitionName: "p1", CriticalRanges: 1},
+		{Locality: "region=east,zone=a", ZoneID: keys.RootNamespaceID, CriticalRanges: 1},
+		{Locality: "region=west", ZoneID: keys.RootNamespaceID, CriticalRanges: 1},
+		{Locality: "region=west", ZoneID: 50, CriticalRanges: 1},
+		{Locality: "region=west,zone=a", ZoneID: keys.RootNamespaceID, CriticalRanges: 1},
+	}
+	if !reflect.DeepEqual(expectedCritical, response.CriticalLocalities) {
+		t.Errorf("expected critical localities %+v, but found %+v",
+			expectedCritical, response.CriticalLocalities)
+	}
+}
+
+// TestReplicationReportResponse verifies that the replication report of a
+// single node cluster accounts for every range.
+func TestReplicationReportResponse(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ts := startServer(t)
+	defer ts.Stopper().Stop(context.TODO())
+
+	rows, err := ts.db.Scan(context.TODO(), keys.Meta2Prefix, keys.MetaMax, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var response serverpb.ReplicationReportResponse
+	if err := getStatusJSONProto(ts, "replicationreport", &response); err != nil {
+		t.Fatal(err)
+	}
+	var totalRanges int64
+	for _, z := range response.Zones {
+		totalRanges += z.TotalRanges
+		if z.UnavailableRanges != 0 {
+			t.Errorf("zone %d: expected no unavailable ranges, but found %d",
+				z.ZoneID, z.UnavailableRanges)
+		}
+		if z.OverReplicatedRanges != 0 {
+			t.Errorf("zone %d: expected no over-replicated ranges, but found %d",
+				z.ZoneID, z.OverReplicatedRanges)
+		}
+	}
+	if e, a := int64(len(rows)), totalRanges; e != a {
+		t.Errorf("expected %d ranges in the report, but found %d", e, a)
+	}
+}
@@ -54,6 +54,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/server/status"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/settings/settingsfile"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
 	"github.com/cockroachdb/cockroach/pkg/sql/jobs"
@@ -143,6 +144,9 @@ type Server struct {
 	engines            Engines
 	internalMemMetrics sql.MemoryMetrics
 	adminMemMetrics    sql.MemoryMetrics
+	// certSigner is set on the node started with the CA key, which signs
+	// the renewed certificates of the other nodes.
+	certSigner *security.CertificateSigner
 	serveMode
 }
 
@@ -179,6 +183,20 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	} else if certMgr != nil {
 		// The certificate manager is non-nil in secure mode.
 		s.registry.AddMetricStruct(certMgr.Metrics())
+		certMgr.SetTLSSettings(security.ClusterTLSSettings(&st.SV))
+		certMgr.StartOCSPStapler(stopper)
+	}
+	if cfg.SSLCAKey != "" {
+		if cfg.Insecure {
+			return nil, errors.New("the CA key cannot be used in insecure mode")
+		}
+		certMgr, err := cfg.GetCertificateManager()
+		if err != nil {
+			return nil, err
+		}
+		if s.certSigner, err = security.NewCertificateSigner(certMgr.CACertPath(), cfg.SSLCAKey); err != nil {
+			return nil, err
+		}
 	}
 
 	// Add a dynamic log tag value for the node ID.
@@ -521,6 +539,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	s.pgServer = pgwire.MakeServer(
 		s.cfg.AmbientCtx,
 		s.cfg.Config,
+		s.st,
 		s.sqlExecutor,
 		&s.internalMemMetrics,
 		&rootSQLMemoryMonitor,
@@ -700,6 +719,15 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 
+	// Read the settings file before starting anything, so that an invalid
+	// file prevents the node from starting at all.
+	var settingsFile settingsfile.File
+	if s.cfg.SettingsFile != "" {
+		if settingsFile, err = settingsfile.Read(s.cfg.SettingsFile); err != nil {
+			return err
+		}
+	}
+
 	httpServer := netutil.MakeServer(s.stopper, tlsConfig, s)
 
 	// The following code is a specialization of util/net.go's ListenAndServe
@@ -1140,6 +1168,9 @@ If problems persist, please see ` + base.DocsURL("cluster-setup-troubleshooting.
 	// Begin recording status summaries.
 	s.node.startWriteSummaries(DefaultMetricsSampleInterval)
 
+	// Begin renewing the certificates about to expire.
+	s.startCertificateRenewal()
+
 	// Create and start the schema change manager only after a NodeID
 	// has been assigned.
 	var testingKnobs *sql.SchemaChangerTestingKnobs
@@ -1243,6 +1274,13 @@ If problems persist, please see ` + base.DocsURL("cluster-setup-troubleshooting.
 		}
 	}
 	log.Infof(ctx, "done ensuring all necessary migrations have run")
+
+	if s.cfg.SettingsFile != "" {
+		if err := s.applySettingsFile(ctx, settingsFile); err != nil {
+			return errors.Wrapf(err, "applying settings file %s", s.cfg.SettingsFile)
+		}
+	}
+
 	close(serveSQL)
 
 	log.Info(ctx, "serving sql connections")
@@ -1351,6 +1389,11 @@ func (s *Server) doDrain(modes []serverpb.DrainMode, setTo bool) ([]serverpb.Dra
 			return nil, errors.Errorf("unknown drain mode: %s", mode)
 		}
 	}
+	return s.activeDrainModes(), nil
+}
+
+// activeDrainModes returns the DrainModes which are active on the Server.
+func (s *Server) activeDrainModes() []serverpb.DrainMode {
 	var nowOn []serverpb.DrainMode
 	if s.pgServer.IsDraining() {
 		nowOn = append(nowOn, serverpb.DrainMode_CLIENT)
@@ -1358,7 +1401,7 @@ func (s *Server) doDrain(modes []serverpb.DrainMode, setTo bool) ([]serverpb.Dra
 	if s.node.IsDraining() {
 		nowOn = append(nowOn, serverpb.DrainMode_LEASES)
 	}
-	return nowOn, nil
+	return nowOn
 }
 
 // Drain idempotently activates the given DrainModes on the Server in the order
@@ -1383,6 +1426,32 @@ func (s *Server) Undrain(off []serverpb.DrainMode) []serverpb.DrainMode {
 	return nowActive
 }
 
+// drainStatusMaxRangeIDs is the maximum number of ranges whose leases are
+// listed by DrainStatus.
+const drainStatusMaxRangeIDs = 100
+
+// DrainStatus reports the progress of the draining of the Server. It is
+// drained once all the GracefulDrainModes are active and it has neither SQL
+// client connections nor range leases left. Leases can fail to move away,
+// for example if no other node has a replica of the range, in which case
+// draining again (which is idempotent) retries their transfer.
+func (s *Server) DrainStatus() serverpb.DrainStatusResponse {
+	nowOn := s.activeDrainModes()
+	res := serverpb.DrainStatusResponse{
+		On:         make([]int32, len(nowOn)),
+		SQLConns:   s.pgServer.Metrics().Conns.Count(),
+		LeaseCount: int64(s.node.LeaseCount()),
+		// Listing the ranges of all the leases would make the response
+		// grow with the size of the node.
+		LeaseRangeIDs: s.node.LeaseRangeIDs(drainStatusMaxRangeIDs),
+	}
+	for i := range nowOn {
+		res.On[i] = int32(nowOn[i])
+	}
+	res.Drained = len(nowOn) == len(GracefulDrainModes) && res.SQLConns == 0 && res.LeaseCount == 0
+	return res
+}
+
 // Decommission idempotently sets the decommissioning flag for specified nodes.
 func (s *Server) Decommission(ctx context.Context, setTo bool, nodeIDs []roachpb.NodeID) error {
 	eventLogger := sql.MakeEventLogger(s.execCfg)
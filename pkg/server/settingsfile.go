@@ -0,0 +1,75 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package server
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/settings/settingsfile"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// applySettingsFile brings the cluster settings in system.settings in line
+// with the settings file the server was started with. Every setting which
+// drifted from the file is recorded in the event log, along with the value
+// it had, before being overwritten. The settings worker then picks up the
+// new values like any other change to system.settings.
+func (s *Server) applySettingsFile(ctx context.Context, file settingsfile.File) error {
+	ie := sql.InternalExecutor{ExecCfg: s.execCfg}
+	eventLogger := sql.MakeEventLogger(s.execCfg)
+	return s.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		rows, _, err := ie.QueryRowsInTransaction(
+			ctx, "read-settings", txn, "SELECT name, value FROM system.settings",
+		)
+		if err != nil {
+			return err
+		}
+		current := make(map[string]string, len(rows))
+		for _, row := range rows {
+			current[string(tree.MustBeDString(row[0]))] = string(tree.MustBeDString(row[1]))
+		}
+		drift, err := file.Diff(ctx, current)
+		if err != nil {
+			return err
+		}
+		for _, d := range drift {
+			if _, err := ie.ExecuteStatementInTransaction(
+				ctx, "apply-settings-file", txn,
+				`UPSERT INTO system.settings (name, value, "lastUpdated", "valueType") VALUES ($1, $2, NOW(), $3)`,
+				d.Name, d.Encoded, d.Typ,
+			); err != nil {
+				return err
+			}
+			if err := eventLogger.InsertEventRecord(
+				ctx,
+				txn,
+				sql.EventLogClusterSettingDrift,
+				0, /* no target */
+				int32(s.NodeID()),
+				struct {
+					SettingName   string
+					Value         string
+					PreviousValue string
+					SettingsFile  string
+				}{d.Name, d.Value, d.Current, s.cfg.SettingsFile},
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
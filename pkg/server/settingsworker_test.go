@@ -17,8 +17,13 @@ package server_test
 import (
 	"context"
 	gosql "database/sql"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -27,6 +32,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
@@ -355,3 +361,54 @@ func TestSettingsShowAll(t *testing.T) {
 		t.Fatalf("show all did not find the test keys: %q", rows)
 	}
 }
+
+func TestSettingsFile(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "settings.yaml")
+	// The duration is at its default, so it does not drift.
+	contents := fmt.Sprintf("%s: bar\n%s: 5\n%s: 1m\n", strKey, intKey, durationKey)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := cluster.MakeTestingClusterSettings()
+	s, rawDB, _ := serverutils.StartServer(t, base.TestServerArgs{Settings: st, SettingsFile: path})
+	defer s.Stopper().Stop(context.TODO())
+
+	db := sqlutils.MakeSQLRunner(rawDB)
+
+	testutils.SucceedsSoon(t, func() error {
+		if expected, actual := "bar", strA.Get(&st.SV); expected != actual {
+			return errors.Errorf("expected %v, got %v", expected, actual)
+		}
+		if expected, actual := int64(5), intA.Get(&st.SV); expected != actual {
+			return errors.Errorf("expected %v, got %v", expected, actual)
+		}
+		return nil
+	})
+
+	var drift []string
+	for _, row := range db.QueryStr(t,
+		`SELECT info FROM system.eventlog WHERE "eventType" = $1`,
+		string(sql.EventLogClusterSettingDrift),
+	) {
+		var info struct {
+			SettingName, Value, PreviousValue string
+		}
+		if err := json.Unmarshal([]byte(row[0]), &info); err != nil {
+			t.Fatal(err)
+		}
+		drift = append(drift, fmt.Sprintf("%s: %s -> %s", info.SettingName, info.PreviousValue, info.Value))
+	}
+	sort.Strings(drift)
+	expected := []string{
+		intKey + ": 1 -> 5",
+		strKey + ": <default> -> bar",
+	}
+	if !reflect.DeepEqual(expected, drift) {
+		t.Fatalf("expected drift events %v, got %v", expected, drift)
+	}
+}
@@ -27,6 +27,8 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -102,6 +104,8 @@ type statusServer struct {
 	stores          *storage.Stores
 	stopper         *stop.Stopper
 	sessionRegistry *sql.SessionRegistry
+
+	replicationReport replicationReportCache
 }
 
 // newStatusServer allocates and returns a statusServer.
@@ -705,6 +709,37 @@ func (s *statusServer) Stacks(
 	}
 }
 
+// Profile returns a profile of the node, in the format of the pprof
+// tool. Only heap profiles are supported.
+func (s *statusServer) Profile(
+	ctx context.Context, req *serverpb.ProfileRequest,
+) (*serverpb.JSONResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.Profile(ctx, req)
+	}
+
+	switch req.Type {
+	case serverpb.ProfileRequest_HEAP:
+		var buf bytes.Buffer
+		if err := pprof.WriteHeapProfile(&buf); err != nil {
+			return nil, grpcstatus.Errorf(codes.Internal, err.Error())
+		}
+		return &serverpb.JSONResponse{Data: buf.Bytes()}, nil
+	default:
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "unknown profile: %s", req.Type)
+	}
+}
+
 // Nodes returns all node statuses.
 func (s *statusServer) Nodes(
 	ctx context.Context, req *serverpb.NodesRequest,
@@ -963,6 +998,7 @@ func (s *statusServer) Ranges(
 				NoRaftLeader:         !storage.HasRaftLeader(raftStatus) && !metrics.Quiescent,
 				Underreplicated:      metrics.Leader && metrics.Underreplicated,
 				NoLease:              metrics.Leader && !metrics.LeaseValid && !metrics.Quiescent,
+				ConstraintViolation:  metrics.Leader && metrics.ConstraintViolation,
 			},
 			CmdQLocal:   serverpb.CommandQueueMetrics(metrics.CmdQMetricsLocal),
 			CmdQGlobal:  serverpb.CommandQueueMetrics(metrics.CmdQMetricsGlobal),
@@ -1248,7 +1284,14 @@ func (s *statusServer) CancelQuery(
 	}
 
 	output := &serverpb.CancelQueryResponse{}
-	canceled, err := s.sessionRegistry.CancelQuery(req.QueryID, req.Username)
+	var canceled bool
+	if req.QueryID == "" && req.SecretKey != 0 {
+		// A pgwire CancelRequest: the secret key identifies the session and
+		// authorizes the cancellation.
+		canceled, err = s.sessionRegistry.CancelQueryByKey(req.SecretKey)
+	} else {
+		canceled, err = s.sessionRegistry.CancelQuery(req.QueryID, req.Username)
+	}
 
 	if err != nil {
 		output.Error = err.Error()
@@ -1295,6 +1338,45 @@ func (s *statusServer) SpanStats(
 	return output, nil
 }
 
+// Stores returns details for each store on the given node.
+func (s *statusServer) Stores(
+	ctx context.Context, req *serverpb.StoresRequest,
+) (*serverpb.StoresResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.Stores(ctx, req)
+	}
+
+	resp := &serverpb.StoresResponse{}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		encryptionStatus, err := store.Engine().GetEncryptionStatus()
+		if err != nil {
+			return err
+		}
+		resp.Stores = append(resp.Stores, serverpb.StoreDetails{
+			StoreID:          store.Ident.StoreID,
+			EncryptionStatus: encryptionStatus,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.Internal, err.Error())
+	}
+	sort.Slice(resp.Stores, func(i, j int) bool {
+		return resp.Stores[i].StoreID < resp.Stores[j].StoreID
+	})
+	return resp, nil
+}
+
 // jsonWrapper provides a wrapper on any slice data type being
 // marshaled to JSON. This prevents a security vulnerability
 // where a phishing attack can trick a user's browser into
@@ -76,6 +76,25 @@ func TestStatusLocalStacks(t *testing.T) {
 	}
 }
 
+// TestStatusLocalProfile verifies that heap profiles are available via
+// the /_status/profile/local endpoint.
+func TestStatusLocalProfile(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	var profile serverpb.JSONResponse
+	for _, nodeID := range []string{"local", "1"} {
+		if err := getStatusJSONProto(s, "profile/"+nodeID, &profile); err != nil {
+			t.Fatal(err)
+		}
+		// Profiles are gzipped protobufs.
+		if !bytes.HasPrefix(profile.Data, []byte{0x1f, 0x8b}) {
+			t.Errorf("expected a gzipped profile, got %q", profile.Data)
+		}
+	}
+}
+
 // TestStatusJson verifies that status endpoints return expected Json results.
 // The content type of the responses is always httputil.JSONContentType.
 func TestStatusJson(t *testing.T) {
@@ -547,6 +566,30 @@ func TestSpanStatsResponse(t *testing.T) {
 	}
 }
 
+// TestStoresResponse verifies that the stores endpoint lists the stores of
+// the node. The test server's store is in memory, so its encryption status is
+// not available.
+func TestStoresResponse(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ts := startServer(t)
+	defer ts.Stopper().Stop(context.TODO())
+
+	var response serverpb.StoresResponse
+	if err := getStatusJSONProto(ts, "stores/local", &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Stores) != 1 {
+		t.Fatalf("expected 1 store, found %+v", response.Stores)
+	}
+	store := response.Stores[0]
+	if store.StoreID != 1 {
+		t.Errorf("expected store 1, found %d", store.StoreID)
+	}
+	if len(store.EncryptionStatus) != 0 {
+		t.Errorf("expected no encryption status, found %q", store.EncryptionStatus)
+	}
+}
+
 func TestSpanStatsGRPCResponse(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ts := startServer(t)
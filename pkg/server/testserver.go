@@ -134,6 +134,7 @@ func makeTestConfigFromParams(params base.TestServerArgs) Config {
 	if params.SSLCertsDir != "" {
 		cfg.SSLCertsDir = params.SSLCertsDir
 	}
+	cfg.SSLCAKey = params.SSLCAKey
 	if params.TimeSeriesQueryWorkerMax != 0 {
 		cfg.TimeSeriesServerConfig.QueryWorkerMax = params.TimeSeriesQueryWorkerMax
 	}
@@ -167,6 +168,9 @@ func makeTestConfigFromParams(params base.TestServerArgs) Config {
 	if params.ListeningURLFile != "" {
 		cfg.ListeningURLFile = params.ListeningURLFile
 	}
+	if params.SettingsFile != "" {
+		cfg.SettingsFile = params.SettingsFile
+	}
 	if params.DisableWebSessionAuthentication {
 		cfg.EnableWebSessionAuthentication = false
 	}
@@ -42,6 +42,11 @@ const (
 	VersionLeaseSequence
 	VersionUnreplicatedTombstoneKey
 	VersionRecomputeStats
+	VersionSCRAMAuthentication
+	VersionRoleOptions
+	VersionRowLevelSecurity
+	VersionSQLAuditing
+	VersionColumnPrivileges
 
 	// Add new versions here (step one of two).
 
@@ -152,6 +157,39 @@ var versionsSingleton = keyedVersions([]keyedVersion{
 		Key:     VersionRecomputeStats,
 		Version: roachpb.Version{Major: 1, Minor: 1, Unstable: 10},
 	},
+	{
+		// VersionSCRAMAuthentication allows passwords to be stored as
+		// SCRAM-SHA-256 verifiers, which older nodes can't check.
+		Key:     VersionSCRAMAuthentication,
+		Version: roachpb.Version{Major: 1, Minor: 1, Unstable: 11},
+	},
+	{
+		// VersionRoleOptions is the version from which role options other than
+		// PASSWORD can be set. They are stored in system.role_options, which
+		// is created by a migration.
+		Key:     VersionRoleOptions,
+		Version: roachpb.Version{Major: 1, Minor: 1, Unstable: 12},
+	},
+	{
+		// VersionRowLevelSecurity is the version from which row-level security
+		// can be enabled on tables. Older nodes ignore the policies stored in
+		// table descriptors.
+		Key:     VersionRowLevelSecurity,
+		Version: roachpb.Version{Major: 1, Minor: 1, Unstable: 13},
+	},
+	{
+		// VersionSQLAuditing is the version from which auditing can be
+		// enabled on tables. Older nodes don't write the audit log.
+		Key:     VersionSQLAuditing,
+		Version: roachpb.Version{Major: 1, Minor: 1, Unstable: 14},
+	},
+	{
+		// VersionColumnPrivileges is the version from which privileges can be
+		// granted on columns. Older nodes ignore the privileges stored in
+		// column descriptors.
+		Key:     VersionColumnPrivileges,
+		Version: roachpb.Version{Major: 1, Minor: 1, Unstable: 15},
+	},
 
 	// Add new versions here (step two of two).
 
@@ -0,0 +1,264 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package settingsfile reads declarative cluster settings files. A settings
+// file is a YAML map from cluster setting names to their desired values, in
+// the format accepted by SET CLUSTER SETTING:
+//
+//   kv.raft_log.synchronize: true
+//   sql.metrics.statement_details.enabled: false
+//   server.time_until_store_dead: 10m
+//
+// Every entry is validated against the settings registry, so a file that
+// names an unknown setting or holds an invalid value is rejected as a whole.
+package settingsfile
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// Setting is the desired value of a cluster setting, as read from a
+// settings file.
+type Setting struct {
+	// Name is the name of the cluster setting.
+	Name string
+	// Value is the value in the format displayed by SHOW CLUSTER SETTING.
+	Value string
+	// Encoded and Typ are the value and type as stored in system.settings.
+	Encoded string
+	Typ     string
+}
+
+// SetStatement returns the SET CLUSTER SETTING statement which sets the
+// setting to the value in the settings file.
+func (s Setting) SetStatement() string {
+	var buf bytes.Buffer
+	buf.WriteString("SET CLUSTER SETTING ")
+	for i, part := range strings.Split(s.Name, ".") {
+		if i > 0 {
+			buf.WriteByte('.')
+		}
+		lex.EncodeRestrictedSQLIdent(&buf, part, lex.EncNoFlags)
+	}
+	buf.WriteString(" = ")
+	switch s.Typ {
+	case "b", "i", "f":
+		buf.WriteString(s.Value)
+	case "e":
+		// The encoded value is the integer for the enum value.
+		buf.WriteString(s.Encoded)
+	case "z":
+		// The displayed byte size is rounded, unlike the encoded one.
+		lex.EncodeSQLString(&buf, s.Encoded)
+	default:
+		lex.EncodeSQLString(&buf, s.Value)
+	}
+	return buf.String()
+}
+
+// File is the content of a settings file, sorted by setting name.
+type File []Setting
+
+// Read reads and validates the settings file at the given path.
+func Read(path string) (File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid settings file %s", path)
+	}
+	return f, nil
+}
+
+// Parse parses and validates the YAML content of a settings file.
+func Parse(data []byte) (File, error) {
+	var m map[string]string
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, err
+	}
+	sv := newValues()
+	u := settings.NewUpdater(sv)
+	f := make(File, 0, len(m))
+	for name, value := range m {
+		s, ok := settings.Lookup(name)
+		if !ok {
+			return nil, errors.Errorf("unknown cluster setting '%s'", name)
+		}
+		encoded, err := encode(s, value)
+		if err == nil {
+			err = u.Set(name, encoded, s.Typ())
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for cluster setting '%s'", name)
+		}
+		f = append(f, Setting{
+			Name:    name,
+			Value:   s.String(sv),
+			Encoded: encoded,
+			Typ:     s.Typ(),
+		})
+	}
+	sort.Slice(f, func(i, j int) bool { return f[i].Name < f[j].Name })
+	return f, nil
+}
+
+// newValues returns a container holding the default values of all the
+// settings, independent from the one of the running node.
+func newValues() *settings.Values {
+	st := cluster.MakeClusterSettings(cluster.BinaryMinimumSupportedVersion, cluster.BinaryServerVersion)
+	return &st.SV
+}
+
+// encode validates the value of a setting and encodes it the way
+// SET CLUSTER SETTING would.
+func encode(s settings.Setting, value string) (string, error) {
+	switch s := s.(type) {
+	case *settings.StringSetting:
+		if err := s.Validate(value); err != nil {
+			return "", err
+		}
+		return value, nil
+	case *settings.BoolSetting:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+		return settings.EncodeBool(b), nil
+	case *settings.IntSetting:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if err := s.Validate(i); err != nil {
+			return "", err
+		}
+		return settings.EncodeInt(i), nil
+	case *settings.FloatSetting:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", err
+		}
+		if err := s.Validate(f); err != nil {
+			return "", err
+		}
+		return settings.EncodeFloat(f), nil
+	case *settings.EnumSetting:
+		i, ok := s.ParseEnum(value)
+		if !ok {
+			return "", errors.Errorf("invalid enum value '%s'", value)
+		}
+		return settings.EncodeInt(i), nil
+	case *settings.ByteSizeSetting:
+		bytes, err := humanizeutil.ParseBytes(value)
+		if err != nil {
+			return "", err
+		}
+		if err := s.Validate(bytes); err != nil {
+			return "", err
+		}
+		return settings.EncodeInt(bytes), nil
+	case *settings.DurationSetting:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "", err
+		}
+		if err := s.Validate(d); err != nil {
+			return "", err
+		}
+		return settings.EncodeDuration(d), nil
+	case *settings.StateMachineSetting:
+		// Upgrading the cluster version is a deliberate operation that must
+		// not be repeated by every node that restarts.
+		return "", errors.New("cannot be set in a settings file, use SET CLUSTER SETTING")
+	default:
+		return "", errors.Errorf("unsupported setting type %T", s)
+	}
+}
+
+// Drift is a setting whose value in the cluster differs from the value in
+// the settings file.
+type Drift struct {
+	Setting
+	// Current is the value in effect in the cluster, in the format displayed
+	// by SHOW CLUSTER SETTING.
+	Current string
+}
+
+// Diff returns the settings of the file whose current value differs from
+// the value in the file. The current values are given as a map from setting
+// name to the encoded value in system.settings; settings which are not in the
+// map are at their default value. A current value which cannot be decoded is
+// logged and counts as drift, so that the value in the file replaces it.
+func (f File) Diff(ctx context.Context, current map[string]string) ([]Drift, error) {
+	sv := newValues()
+	u := settings.NewUpdater(sv)
+	var drift []Drift
+	for _, s := range f {
+		setting, ok := settings.Lookup(s.Name)
+		if !ok {
+			return nil, errors.Errorf("unknown cluster setting '%s'", s.Name)
+		}
+		if encoded, ok := current[s.Name]; ok {
+			if err := u.Set(s.Name, encoded, s.Typ); err != nil {
+				log.Warningf(ctx, "setting %q to %q failed: %+v", s.Name, encoded, err)
+				drift = append(drift, Drift{Setting: s, Current: encoded})
+				continue
+			}
+		}
+		if encodedValue(setting, sv) != s.Encoded {
+			drift = append(drift, Drift{Setting: s, Current: setting.String(sv)})
+		}
+	}
+	return drift, nil
+}
+
+// encodedValue returns the value of a setting in the canonical encoding used
+// by SET CLUSTER SETTING, which is the one of the values in a File.
+func encodedValue(s settings.Setting, sv *settings.Values) string {
+	switch s := s.(type) {
+	case *settings.StringSetting:
+		return s.Get(sv)
+	case *settings.BoolSetting:
+		return settings.EncodeBool(s.Get(sv))
+	case *settings.IntSetting:
+		return settings.EncodeInt(s.Get(sv))
+	case *settings.EnumSetting:
+		return settings.EncodeInt(s.Get(sv))
+	case *settings.ByteSizeSetting:
+		return settings.EncodeInt(s.Get(sv))
+	case *settings.FloatSetting:
+		return settings.EncodeFloat(s.Get(sv))
+	case *settings.DurationSetting:
+		return settings.EncodeDuration(s.Get(sv))
+	default:
+		return s.String(sv)
+	}
+}
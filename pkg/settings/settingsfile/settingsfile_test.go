@@ -0,0 +1,149 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package settingsfile
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func init() {
+	settings.RegisterBoolSetting("settingsfile.bool", "desc", false)
+	settings.RegisterValidatedIntSetting("settingsfile.int", "desc", 1, func(v int64) error {
+		if v < 0 {
+			return errors.New("cannot be negative")
+		}
+		return nil
+	})
+	settings.RegisterStringSetting("settingsfile.str", "desc", "<default>")
+	settings.RegisterNonNegativeDurationSetting("settingsfile.duration", "desc", time.Second)
+	settings.RegisterByteSizeSetting("settingsfile.bytes", "desc", 1<<20)
+	settings.RegisterEnumSetting("settingsfile.enum", "desc", "foo", map[int64]string{1: "foo", 2: "bar"})
+}
+
+func TestParse(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	f, err := Parse([]byte(`
+settingsfile.str: "it's"
+settingsfile.bool: true
+settingsfile.int: 5
+settingsfile.duration: 90s
+settingsfile.bytes: 64mb
+settingsfile.enum: BAR
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := File{
+		{Name: "settingsfile.bool", Value: "true", Encoded: "true", Typ: "b"},
+		{Name: "settingsfile.bytes", Value: "61 MiB", Encoded: "64000000", Typ: "z"},
+		{Name: "settingsfile.duration", Value: "1m30s", Encoded: "1m30s", Typ: "d"},
+		{Name: "settingsfile.enum", Value: "2", Encoded: "2", Typ: "e"},
+		{Name: "settingsfile.int", Value: "5", Encoded: "5", Typ: "i"},
+		{Name: "settingsfile.str", Value: "it's", Encoded: "it's", Typ: "s"},
+	}
+	if !reflect.DeepEqual(expected, f) {
+		t.Fatalf("expected\n%+v\ngot\n%+v", expected, f)
+	}
+
+	expectedStmts := []string{
+		`SET CLUSTER SETTING settingsfile.bool = true`,
+		`SET CLUSTER SETTING settingsfile.bytes = '64000000'`,
+		`SET CLUSTER SETTING settingsfile.duration = '1m30s'`,
+		`SET CLUSTER SETTING settingsfile.enum = 2`,
+		`SET CLUSTER SETTING settingsfile.int = 5`,
+		`SET CLUSTER SETTING settingsfile.str = e'it\'s'`,
+	}
+	for i, s := range f {
+		if stmt := s.SetStatement(); stmt != expectedStmts[i] {
+			t.Errorf("expected %s, got %s", expectedStmts[i], stmt)
+		}
+	}
+
+	for _, tc := range []struct {
+		yaml   string
+		errStr string
+	}{
+		{`settingsfile.unknown: 1`, `unknown cluster setting 'settingsfile.unknown'`},
+		{`settingsfile.bool: maybe`, `invalid value for cluster setting 'settingsfile.bool'`},
+		{`settingsfile.int: -1`, `cannot be negative`},
+		{`settingsfile.duration: -1s`, `cannot set settingsfile.duration to a negative duration`},
+		{`settingsfile.enum: baz`, `invalid enum value 'baz'`},
+		{`settingsfile.duration: 1d`, `unknown unit`},
+		{`version: "2.0"`, `cannot be set in a settings file`},
+		{`[settingsfile.bool]`, `cannot unmarshal`},
+	} {
+		if _, err := Parse([]byte(tc.yaml)); !testutils.IsError(err, tc.errStr) {
+			t.Errorf("%s: expected error %q, got %v", tc.yaml, tc.errStr, err)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	f, err := Parse([]byte(`
+settingsfile.bool: true
+settingsfile.int: 1
+settingsfile.duration: 1m
+settingsfile.str: foo
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drift, err := f.Diff(context.TODO(), map[string]string{
+		"settingsfile.duration": "60s",
+		"settingsfile.str":      "bar",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The int setting is at its default, which matches the file, and the
+	// current duration is equal to the one in the file.
+	expected := []Drift{
+		{Setting: f[0], Current: "false"},
+		{Setting: f[3], Current: "bar"},
+	}
+	if !reflect.DeepEqual(expected, drift) {
+		t.Fatalf("expected\n%+v\ngot\n%+v", expected, drift)
+	}
+
+	// A current value which cannot be decoded is replaced by the one in the
+	// file.
+	drift, err = f.Diff(context.TODO(), map[string]string{
+		"settingsfile.int": "foo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Drift{
+		{Setting: f[0], Current: "false"},
+		{Setting: f[1], Current: "1s"},
+		{Setting: f[2], Current: "foo"},
+		{Setting: f[3], Current: "<default>"},
+	}
+	if !reflect.DeepEqual(expected, drift) {
+		t.Fatalf("expected\n%+v\ngot\n%+v", expected, drift)
+	}
+}
@@ -362,6 +362,37 @@ func (n *alterTableNode) startExec(params runParams) error {
 				descriptorChanged = true
 			}
 
+			// You can't drop a column referenced by a row-level security
+			// policy unless CASCADE was specified, in which case the policy
+			// is dropped too.
+			validPolicies := n.tableDesc.Policies[:0]
+			for _, policy := range n.tableDesc.Policies {
+				referencesColumn := false
+				for _, exprStr := range []string{policy.UsingExpr, policy.WithCheckExpr} {
+					if exprStr == "" {
+						continue
+					}
+					expr, err := parser.ParseExpr(exprStr)
+					if err != nil {
+						return err
+					}
+					found, err := exprContainsColumnName(expr, col)
+					if err != nil {
+						return err
+					}
+					referencesColumn = referencesColumn || found
+				}
+				if !referencesColumn {
+					validPolicies = append(validPolicies, policy)
+				} else if t.DropBehavior != tree.DropCascade {
+					return fmt.Errorf("column %q is referenced by policy %q", col.Name, policy.Name)
+				}
+			}
+			if len(validPolicies) != len(n.tableDesc.Policies) {
+				n.tableDesc.Policies = validPolicies
+				descriptorChanged = true
+			}
+
 			found := false
 			for i := range n.tableDesc.Columns {
 				if n.tableDesc.Columns[i].ID == col.ID {
@@ -513,6 +544,26 @@ func (n *alterTableNode) startExec(params runParams) error {
 				return err
 			}
 			n.tableDesc.PrimaryIndex.Partitioning = partitioning
+
+		case *tree.AlterTableSetRowLevelSecurity:
+			if err := params.p.RequireSuperUser("change the row-level security of a table"); err != nil {
+				return err
+			}
+			if t.Enable {
+				if err := params.p.checkRowLevelSecurityVersion(); err != nil {
+					return err
+				}
+			}
+			descriptorChanged = n.tableDesc.RowLevelSecurity != t.Enable
+			n.tableDesc.RowLevelSecurity = t.Enable
+
+		case *tree.AlterTableSetAudit:
+			changed, err := params.p.setAuditMode(n.tableDesc, t.Mode)
+			if err != nil {
+				return err
+			}
+			descriptorChanged = descriptorChanged || changed
+
 		default:
 			return fmt.Errorf("unsupported alter command: %T", cmd)
 		}
@@ -23,78 +23,144 @@ import (
 	"github.com/pkg/errors"
 )
 
-// alterUserSetPasswordNode represents an ALTER USER ... WITH PASSWORD statement.
-type alterUserSetPasswordNode struct {
+// AlterRoleNode represents an ALTER USER ... WITH or ALTER ROLE ... WITH
+// statement.
+type AlterRoleNode struct {
 	userAuthInfo
-	ifExists bool
+	roleOptions []roleOptionUpdate
+	ifExists    bool
+	isRole      bool
+	// viaRoleOption is set if the session user is only allowed to alter users
+	// and roles because it has the CREATEROLE role option.
+	viaRoleOption bool
 
-	run alterUserSetPasswordRun
+	run alterRoleRun
 }
 
-// AlterUserSetPassword changes a user's password.
-// Privileges: UPDATE on the users table.
-func (p *planner) AlterUserSetPassword(
-	ctx context.Context, n *tree.AlterUserSetPassword,
-) (planNode, error) {
-	tDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), &tree.TableName{DatabaseName: "system", TableName: "users"})
+// AlterRole changes a user's password or role options.
+// Privileges: UPDATE on the users table, or the CREATEROLE role option.
+func (p *planner) AlterRole(ctx context.Context, n *tree.AlterRole) (planNode, error) {
+	if n.IsRole {
+		// ALTER ROLE is implemented by a CCL plan hook, like the other role
+		// statements.
+		return nil, errors.Errorf("unknown statement type: %T", n)
+	}
+	return p.AlterRoleNode(ctx, n.Name, n.Options, n.IfExists, n.IsRole, "ALTER USER")
+}
+
+// AlterRoleNode creates an "alter role" plan node. This can be called from
+// ALTER USER or ALTER ROLE.
+func (p *planner) AlterRoleNode(
+	ctx context.Context,
+	nameE tree.Expr,
+	options tree.RoleOptions,
+	ifExists bool,
+	isRole bool,
+	opName string,
+) (*AlterRoleNode, error) {
+	viaRoleOption, err := p.checkCreateRolePrivilege(ctx, privilege.UPDATE)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(tDesc, privilege.UPDATE); err != nil {
+	passwordE, roleOptions, err := p.getRoleOptions(options, isRole, opName)
+	if err != nil {
 		return nil, err
 	}
 
-	ua, err := p.getUserAuthInfo(n.Name, n.Password, "ALTER USER")
+	ua, err := p.getUserAuthInfo(nameE, passwordE, opName)
 	if err != nil {
 		return nil, err
 	}
 
-	return &alterUserSetPasswordNode{
-		userAuthInfo: ua,
-		ifExists:     n.IfExists,
+	return &AlterRoleNode{
+		userAuthInfo:  ua,
+		roleOptions:   roleOptions,
+		ifExists:      ifExists,
+		isRole:        isRole,
+		viaRoleOption: viaRoleOption,
 	}, nil
 }
 
-// alterUserSetPasswordRun is the run-time state of
-// alterUserSetPasswordNode for local execution.
-type alterUserSetPasswordRun struct {
+// alterRoleRun is the run-time state of AlterRoleNode for local execution.
+type alterRoleRun struct {
 	rowsAffected int
 }
 
-func (n *alterUserSetPasswordNode) startExec(params runParams) error {
-	normalizedUsername, hashedPassword, err := n.userAuthInfo.resolve()
+func (n *AlterRoleNode) startExec(params runParams) error {
+	normalizedUsername, hashedPassword, err := n.userAuthInfo.resolve(params.extendedEvalCtx.Settings)
 	if err != nil {
 		return err
 	}
 
-	// The root user is not allowed a password.
+	var entryType, opName string
+	if n.isRole {
+		entryType, opName = "role", "alter-role"
+	} else {
+		entryType, opName = "user", "alter-user"
+	}
+
+	// The root user is not allowed a password, and as a superuser it can't
+	// be restricted by role options.
 	if normalizedUsername == security.RootUser {
-		return errors.Errorf("user %s cannot use password authentication", security.RootUser)
+		if n.userAuthInfo.password != nil {
+			return errors.Errorf("user %s cannot use password authentication", security.RootUser)
+		}
+		return errors.Errorf("user %s cannot be altered", security.RootUser)
+	}
+	if n.viaRoleOption {
+		if err := checkNotAdminMember(params, normalizedUsername); err != nil {
+			return err
+		}
 	}
 
 	internalExecutor := InternalExecutor{ExecCfg: params.extendedEvalCtx.ExecCfg}
-	n.run.rowsAffected, err = internalExecutor.ExecuteStatementInTransaction(
-		params.ctx,
-		"create-user",
-		params.p.txn,
-		`UPDATE system.users SET "hashedPassword" = $2 WHERE username = $1 AND "isRole" = false`,
-		normalizedUsername,
-		hashedPassword,
-	)
+	if n.userAuthInfo.password != nil {
+		n.run.rowsAffected, err = internalExecutor.ExecuteStatementInTransaction(
+			params.ctx,
+			opName,
+			params.p.txn,
+			`UPDATE system.users SET "hashedPassword" = $2 WHERE username = $1 AND "isRole" = false`,
+			normalizedUsername,
+			hashedPassword,
+		)
+	} else {
+		// Only role options are changed: check that the user or role exists.
+		var values tree.Datums
+		values, err = internalExecutor.QueryRowInTransaction(
+			params.ctx,
+			opName,
+			params.p.txn,
+			`SELECT 1 FROM system.users WHERE username = $1 AND "isRole" = $2`,
+			normalizedUsername,
+			n.isRole,
+		)
+		if len(values) > 0 {
+			n.run.rowsAffected = 1
+		}
+	}
 	if err != nil {
 		return err
 	}
-	if n.run.rowsAffected == 0 && !n.ifExists {
-		return errors.Errorf("user %s does not exist", normalizedUsername)
+	if n.run.rowsAffected == 0 {
+		if !n.ifExists {
+			return errors.Errorf("%s %s does not exist", entryType, normalizedUsername)
+		}
+		return nil
 	}
-	return err
+	return applyRoleOptionUpdates(params, opName, normalizedUsername, n.roleOptions)
 }
 
-func (*alterUserSetPasswordNode) Next(runParams) (bool, error) { return false, nil }
-func (*alterUserSetPasswordNode) Values() tree.Datums          { return tree.Datums{} }
-func (*alterUserSetPasswordNode) Close(context.Context)        {}
+// Next implements the planNode interface.
+func (*AlterRoleNode) Next(runParams) (bool, error) { return false, nil }
+
+// Values implements the planNode interface.
+func (*AlterRoleNode) Values() tree.Datums { return tree.Datums{} }
+
+// Close implements the planNode interface.
+func (*AlterRoleNode) Close(context.Context) {}
 
-func (n *alterUserSetPasswordNode) FastPathResults() (int, bool) {
+// FastPathResults implements the planNodeFastPath interface.
+func (n *AlterRoleNode) FastPathResults() (int, bool) {
 	return n.run.rowsAffected, true
 }
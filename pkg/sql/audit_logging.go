@@ -0,0 +1,147 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// AuditLogFileMaxSize is the maximum size of a SQL audit log file in
+// bytes.
+var AuditLogFileMaxSize int64 = 10 << 20 // 10MiB
+
+// AuditLogFilesCombinedMaxSize is the maximum total size in bytes of
+// the SQL audit log files. The oldest files are removed when this size
+// is exceeded.
+var AuditLogFilesCombinedMaxSize = AuditLogFileMaxSize * 10 // 100MiB
+
+// auditLogger writes the SQL audit log. Every entry is synced to disk
+// before the statement completes, so that no access to an audited
+// table goes unrecorded.
+var auditLogger = log.NewSecondaryLogger(
+	"sql-audit", &AuditLogFileMaxSize, &AuditLogFilesCombinedMaxSize, true, /* forceSyncWrites */
+)
+
+// auditEvent records that a statement accessed a table with auditing
+// enabled.
+type auditEvent struct {
+	desc    *sqlbase.TableDescriptor
+	writing bool
+}
+
+// auditModes maps the audit modes of the ALTER TABLE syntax to the
+// audit modes stored in table descriptors.
+var auditModes = [...]sqlbase.TableDescriptor_AuditMode{
+	tree.AuditModeDisable:   sqlbase.TableDescriptor_DISABLED,
+	tree.AuditModeReadWrite: sqlbase.TableDescriptor_READWRITE,
+}
+
+// setAuditMode configures the audit mode of a table, and returns
+// whether the descriptor was changed. Only the root user can configure
+// auditing.
+func (p *planner) setAuditMode(desc *sqlbase.TableDescriptor, mode tree.AuditMode) (bool, error) {
+	if err := p.RequireSuperUser("change the audit mode of a table"); err != nil {
+		return false, err
+	}
+	if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionSQLAuditing) {
+		return false, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+			"table auditing is not supported until the cluster is upgraded to version %s",
+			cluster.VersionByKey(cluster.VersionSQLAuditing))
+	}
+	newMode := auditModes[mode]
+	if desc.AuditMode == newMode {
+		return false, nil
+	}
+	desc.AuditMode = newMode
+	return true, nil
+}
+
+// maybeAudit registers the access to the table by the current
+// statement, if the table is audited. The access is registered before
+// privileges are checked, so that denied accesses are recorded too.
+func (p *planner) maybeAudit(desc *sqlbase.TableDescriptor, priv privilege.Kind) {
+	if desc.AuditMode == sqlbase.TableDescriptor_DISABLED {
+		return
+	}
+	writing := priv != privilege.SELECT
+	for _, ev := range p.curPlan.auditEvents {
+		if ev.desc.ID == desc.ID && ev.writing == writing {
+			return
+		}
+	}
+	p.curPlan.auditEvents = append(p.curPlan.auditEvents, auditEvent{desc: desc, writing: writing})
+}
+
+// maybeLogStatementToAuditLog writes an entry to the SQL audit log if
+// the statement accessed audited tables. The entry lists the user and
+// application that ran the statement, the audited tables it accessed,
+// the statement with its placeholders and their values, the number of
+// rows affected and the error, if any.
+func (p *planner) maybeLogStatementToAuditLog(
+	ctx context.Context, stmt Statement, rows int, err error,
+) {
+	if len(p.curPlan.auditEvents) == 0 {
+		return
+	}
+
+	var tables bytes.Buffer
+	tables.WriteByte('{')
+	for i, ev := range p.curPlan.auditEvents {
+		if i > 0 {
+			tables.WriteString(", ")
+		}
+		mode := "READ"
+		if ev.writing {
+			mode = "WRITE"
+		}
+		fmt.Fprintf(&tables, "%q[%d]:%s", ev.desc.Name, ev.desc.ID, mode)
+	}
+	tables.WriteByte('}')
+
+	placeholders := p.semaCtx.Placeholders.Values
+	names := make([]string, 0, len(placeholders))
+	for name := range placeholders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var args bytes.Buffer
+	args.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			args.WriteString(", ")
+		}
+		fmt.Fprintf(&args, "$%s:%q", name, placeholders[name].String())
+	}
+	args.WriteByte('}')
+
+	errStr := ""
+	if err != nil {
+		errStr = err.Error()
+	}
+
+	auditLogger.Logf(ctx, "user=%q app=%q tables=%s stmt=%q args=%s rows=%d error=%q",
+		p.User(), p.EvalContext().ApplicationName, tables.String(),
+		stmt.String(), args.String(), rows, errStr)
+}
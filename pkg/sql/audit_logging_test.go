@@ -0,0 +1,118 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// readAuditLog returns the contents of the SQL audit log files.
+func readAuditLog(t *testing.T) string {
+	log.Flush()
+	files, err := log.ListLogFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents bytes.Buffer
+	for _, f := range files {
+		if !strings.HasSuffix(f.Details.Program, "-sql-audit") {
+			continue
+		}
+		r, err := log.GetLogReader(f.Name, true /* restricted */)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents.Write(b)
+	}
+	return contents.String()
+}
+
+func TestAuditLogging(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s := log.ScopeWithoutShowLogs(t)
+	defer s.Close(t)
+
+	params := base.TestServerArgs{}
+	srv, db, _ := serverutils.StartServer(t, params)
+	defer srv.Stopper().Stop(context.TODO())
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	sqlDB.Exec(t, `CREATE DATABASE d`)
+	sqlDB.Exec(t, `CREATE TABLE d.audited (k INT PRIMARY KEY, v INT)`)
+	sqlDB.Exec(t, `CREATE TABLE d.unaudited (k INT PRIMARY KEY, v INT)`)
+
+	var id int
+	sqlDB.QueryRow(t, `SELECT table_id FROM crdb_internal.tables WHERE name = 'audited'`).Scan(&id)
+	table := fmt.Sprintf(`"audited"[%d]`, id)
+
+	sqlDB.Exec(t, `INSERT INTO d.audited VALUES (1, 1)`)
+	sqlDB.Exec(t, `ALTER TABLE d.audited EXPERIMENTAL_AUDIT SET READ WRITE`)
+
+	sqlDB.Exec(t, `INSERT INTO d.audited VALUES ($1, $2)`, 2, 20)
+	sqlDB.Exec(t, `INSERT INTO d.unaudited VALUES (1, 1)`)
+	sqlDB.Exec(t, `UPDATE d.audited SET v = v + 1`)
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM d.audited`, [][]string{{"2"}})
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM d.unaudited`, [][]string{{"1"}})
+	if _, err := db.Exec(`SELECT nonexistent FROM d.audited`); !testutils.IsError(
+		err, `column name "nonexistent" not found`,
+	) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	auditLog := readAuditLog(t)
+	for _, expected := range []string{
+		`user="root" app="" tables={` + table + `:WRITE} ` +
+			`stmt="INSERT INTO d.audited VALUES ($1, $2)" args={$1:"2", $2:"20"} rows=1 error=""`,
+		`tables={` + table + `:WRITE, ` + table + `:READ} ` +
+			`stmt="UPDATE d.audited SET v = v + 1" args={} rows=2 error=""`,
+		`tables={` + table + `:READ} stmt="SELECT count(*) FROM d.audited" args={} rows=1 error=""`,
+		`tables={` + table + `:READ} stmt="SELECT nonexistent FROM d.audited" args={} rows=0 ` +
+			`error="column name \"nonexistent\" not found"`,
+	} {
+		if !strings.Contains(auditLog, expected) {
+			t.Errorf("expected audit log to contain:\n%s\ngot:\n%s", expected, auditLog)
+		}
+	}
+	if strings.Contains(auditLog, "unaudited") {
+		t.Errorf("unexpected entry for an unaudited table in the audit log:\n%s", auditLog)
+	}
+
+	// Once auditing is disabled, the accesses to the table are not
+	// recorded any more.
+	sqlDB.Exec(t, `ALTER TABLE d.audited EXPERIMENTAL_AUDIT SET OFF`)
+	sqlDB.Exec(t, `DELETE FROM d.audited`)
+	if auditLog := readAuditLog(t); strings.Contains(auditLog, "DELETE") {
+		t.Errorf("unexpected entry for DELETE in the audit log:\n%s", auditLog)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util"
 )
 
 // AuthorizationAccessor for checking authorization (e.g. desc privileges).
@@ -42,6 +43,10 @@ type AuthorizationAccessor interface {
 	// MemberOfWithAdminOption looks up all the roles (direct and indirect) that 'member' is a member
 	// of and returns a map of role -> isAdmin.
 	MemberOfWithAdminOption(ctx context.Context, member string) (map[string]bool, error)
+
+	// HasRoleOption returns true if the session user has the given role
+	// option, either CREATEDB or CREATEROLE. Super-users have all options.
+	HasRoleOption(ctx context.Context, option string) (bool, error)
 }
 
 var _ AuthorizationAccessor = &planner{}
@@ -64,6 +69,81 @@ func (p *planner) CheckPrivilege(
 	return CheckPrivilegeForUser(p.SessionData().User, descriptor, privilege)
 }
 
+// CheckColumnPrivilege verifies that the user has `priv` on column `col`
+// of `desc`, either because it was granted on the table or on the column
+// itself.
+func (p *planner) CheckColumnPrivilege(
+	desc *sqlbase.TableDescriptor, col *sqlbase.ColumnDescriptor, priv privilege.Kind,
+) error {
+	user := p.SessionData().User
+	if desc.Privileges.CheckPrivilege(user, priv) {
+		return nil
+	}
+	if col.Privileges != nil && col.Privileges.CheckPrivilege(user, priv) {
+		return nil
+	}
+	return fmt.Errorf("user %s does not have %s privilege on column %s of %s %s",
+		user, priv, col.Name, desc.TypeName(), desc.GetName())
+}
+
+// checkColumnPrivileges verifies that the user has `priv` on all the
+// given columns of `desc`.
+func (p *planner) checkColumnPrivileges(
+	desc *sqlbase.TableDescriptor, cols []sqlbase.ColumnDescriptor, priv privilege.Kind,
+) error {
+	for i := range cols {
+		if err := p.CheckColumnPrivilege(desc, &cols[i], priv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasColumnPrivilegesOnly returns true if the user does not have `priv`
+// on `desc` but has it on at least one of its columns. Statements run by
+// such a user can only access the columns it has been granted.
+func (p *planner) hasColumnPrivilegesOnly(desc *sqlbase.TableDescriptor, priv privilege.Kind) bool {
+	user := p.SessionData().User
+	if desc.Privileges.CheckPrivilege(user, priv) {
+		return false
+	}
+	for i := range desc.Columns {
+		if privs := desc.Columns[i].Privileges; privs != nil && privs.CheckPrivilege(user, priv) {
+			return true
+		}
+	}
+	return false
+}
+
+// unreadableColumns returns the set of columns among cols on which the
+// user does not have the SELECT privilege. It is used to populate
+// dataSourceInfo.unreadableColumns for tables on which the user only
+// has column-level SELECT privileges.
+func (p *planner) unreadableColumns(
+	desc *sqlbase.TableDescriptor, cols []sqlbase.ColumnDescriptor,
+) util.FastIntSet {
+	var res util.FastIntSet
+	for i := range cols {
+		if p.CheckColumnPrivilege(desc, &cols[i], privilege.SELECT) != nil {
+			res.Add(i)
+		}
+	}
+	return res
+}
+
+// checkColumnReadable verifies that `user` can read the column at index
+// colIdx of the data source. This is performed during name resolution
+// for every column referenced by a query, whether by name, by ordinal
+// reference or through a star expansion.
+func (src *dataSourceInfo) checkColumnReadable(user string, colIdx int) error {
+	if !src.unreadableColumns.Contains(colIdx) {
+		return nil
+	}
+	tn, _ := src.findTableAlias(colIdx)
+	return fmt.Errorf("user %s does not have %s privilege on column %s of relation %s",
+		user, privilege.SELECT, src.sourceColumns[colIdx].Name, tn.Table())
+}
+
 // CheckAnyPrivilege implements the AuthorizationAccessor interface.
 func (p *planner) CheckAnyPrivilege(descriptor sqlbase.DescriptorProto) error {
 	if isVirtualDescriptor(descriptor) {
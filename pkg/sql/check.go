@@ -31,8 +31,15 @@ import (
 )
 
 // checkHelper validates check constraints on rows, on INSERT and UPDATE.
+// It also validates that the rows satisfy the row-level security policies
+// of the table, if any.
 type checkHelper struct {
-	exprs        []tree.TypedExpr
+	exprs []tree.TypedExpr
+	// policyExpr is the expression rows must satisfy according to the
+	// row-level security policies of the table, or nil if they don't
+	// apply to the statement.
+	policyExpr   tree.TypedExpr
+	tableName    string
 	cols         []sqlbase.ColumnDescriptor
 	sourceInfo   *dataSourceInfo
 	ivarHelper   *tree.IndexedVarHelper
@@ -40,9 +47,20 @@ type checkHelper struct {
 }
 
 func (c *checkHelper) init(
-	ctx context.Context, p *planner, tn *tree.TableName, tableDesc *sqlbase.TableDescriptor,
+	ctx context.Context,
+	p *planner,
+	tn *tree.TableName,
+	tableDesc *sqlbase.TableDescriptor,
+	cmd sqlbase.TableDescriptor_Policy_Command,
 ) error {
-	if len(tableDesc.Checks) == 0 {
+	var policyExpr tree.Expr
+	if !p.bypassRowLevelSecurity(tableDesc) {
+		var err error
+		if policyExpr, err = p.policyCheckExpr(ctx, tableDesc, cmd); err != nil {
+			return err
+		}
+	}
+	if len(tableDesc.Checks) == 0 && policyExpr == nil {
 		return nil
 	}
 
@@ -70,6 +88,14 @@ func (c *checkHelper) init(
 		}
 		c.exprs[i] = typedExpr
 	}
+	if policyExpr != nil {
+		c.policyExpr, err = p.analyzeExpr(ctx, policyExpr, multiSourceInfo{c.sourceInfo}, ivarHelper,
+			types.Bool, false, "")
+		if err != nil {
+			return err
+		}
+		c.tableName = tableDesc.Name
+	}
 	c.ivarHelper = &ivarHelper
 	c.curSourceRow = make(tree.Datums, len(c.cols))
 	return nil
@@ -79,7 +105,7 @@ func (c *checkHelper) init(
 // Any value not passed is set to NULL, unless `merge` is true, in which
 // case it is left unchanged (allowing updating a subset of a row's values).
 func (c *checkHelper) loadRow(colIdx map[sqlbase.ColumnID]int, row tree.Datums, merge bool) error {
-	if len(c.exprs) == 0 {
+	if len(c.exprs) == 0 && c.policyExpr == nil {
 		return nil
 	}
 	// Populate IndexedVars.
@@ -132,6 +158,15 @@ func (c *checkHelper) check(ctx *tree.EvalContext) error {
 				"failed to satisfy CHECK constraint (%s)", expr)
 		}
 	}
+	if c.policyExpr != nil {
+		// Unlike for CHECK constraints, NULL does not satisfy the policies.
+		if ok, err := sqlbase.RunFilter(c.policyExpr, ctx); err != nil {
+			return err
+		} else if !ok {
+			return pgerror.NewErrorf(pgerror.CodeInsufficientPrivilegeError,
+				"new row violates row-level security policy for table %q", c.tableName)
+		}
+	}
 	return nil
 }
 
@@ -148,6 +183,10 @@ func (p *planner) validateCheckExpr(
 		Where: &tree.Where{Expr: &tree.NotExpr{Expr: expr}},
 	}
 	lim := &tree.Limit{Count: tree.NewDInt(1)}
+	// The validation must see all the rows of the table, regardless of its
+	// row-level security policies.
+	p.rowLevelSecurity.skip = true
+	defer func() { p.rowLevelSecurity.skip = false }()
 	// This could potentially use a variant of planner.SelectClause that could
 	// use the tableDesc we have, but this is a rare operation and be benefit
 	// would be marginal compared to the work of the actual query, so the added
@@ -231,7 +270,11 @@ func (p *planner) validateForeignKey(
 		query,
 	)
 
+	// The validation must see all the rows of both tables, regardless of
+	// their row-level security policies.
+	p.rowLevelSecurity.skip = true
 	values, _ /* cols */, err := p.queryRows(ctx, query)
+	p.rowLevelSecurity.skip = false
 	if err != nil {
 		return err
 	}
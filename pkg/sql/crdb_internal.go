@@ -60,6 +60,7 @@ var crdbInternal = virtualSchema{
 		crdbInternalClusterSessionsTable,
 		crdbInternalClusterSettingsTable,
 		crdbInternalCreateStmtsTable,
+		crdbInternalCriticalLocalitiesTable,
 		crdbInternalForwardDependenciesTable,
 		crdbInternalGossipNodesTable,
 		crdbInternalGossipLivenessTable,
@@ -72,6 +73,7 @@ var crdbInternal = virtualSchema{
 		crdbInternalLocalSessionsTable,
 		crdbInternalPartitionsTable,
 		crdbInternalRangesTable,
+		crdbInternalReplicationReportTable,
 		crdbInternalRuntimeInfoTable,
 		crdbInternalSchemaChangesTable,
 		crdbInternalSessionTraceTable,
@@ -1488,6 +1490,127 @@ CREATE TABLE crdb_internal.ranges (
 	},
 }
 
+// zoneCLISpecifierResolver returns a function that returns the CLI specifier
+// of the zone with the given ID, or of its subzone for the given index and
+// partition if the index ID is not zero. The CLI specifier is NULL if the
+// object that the zone belongs to no longer exists.
+func (p *planner) zoneCLISpecifierResolver(
+	ctx context.Context,
+) (func(id uint32, indexID uint32, partition string) tree.Datum, error) {
+	namespace, err := p.getAllNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolveID := func(id uint32) (parentID uint32, name string, err error) {
+		if entry, ok := namespace[sqlbase.ID(id)]; ok {
+			return uint32(entry.parentID), entry.name, nil
+		}
+		return 0, "", fmt.Errorf("object with ID %d does not exist", id)
+	}
+	return func(id uint32, indexID uint32, partition string) tree.Datum {
+		zs, err := config.ZoneSpecifierFromID(id, resolveID)
+		if err != nil {
+			return tree.DNull
+		}
+		if indexID != 0 {
+			table, err := sqlbase.GetTableDescFromID(ctx, p.txn, sqlbase.ID(id))
+			if err != nil {
+				return tree.DNull
+			}
+			index, err := table.FindIndexByID(sqlbase.IndexID(indexID))
+			if err != nil {
+				return tree.DNull
+			}
+			zs.TableOrIndex.Index = tree.UnrestrictedName(index.Name)
+			zs.Partition = tree.Name(partition)
+		}
+		return tree.NewDString(config.CLIZoneSpecifier(&zs))
+	}, nil
+}
+
+// crdbInternalReplicationReportTable exposes the replication health of the
+// ranges to which each zone config applies.
+var crdbInternalReplicationReportTable = virtualSchemaTable{
+	schema: `
+CREATE TABLE crdb_internal.replication_report (
+  zone_id                     INT NOT NULL,
+  cli_specifier               STRING,
+  total_ranges                INT NOT NULL,
+  unavailable_ranges          INT NOT NULL,
+  under_replicated_ranges     INT NOT NULL,
+  over_replicated_ranges      INT NOT NULL,
+  constraint_violating_ranges INT NOT NULL
+)
+`,
+	populate: func(ctx context.Context, p *planner, prefix string, addRow func(...tree.Datum) error) error {
+		if err := p.RequireSuperUser("read crdb_internal.replication_report"); err != nil {
+			return err
+		}
+		response, err := p.ExecCfg().StatusServer.ReplicationReport(
+			ctx, &serverpb.ReplicationReportRequest{})
+		if err != nil {
+			return err
+		}
+		cliSpecifier, err := p.zoneCLISpecifierResolver(ctx)
+		if err != nil {
+			return err
+		}
+		for _, z := range response.Zones {
+			if err := addRow(
+				tree.NewDInt(tree.DInt(z.ZoneID)),
+				cliSpecifier(z.ZoneID, z.IndexID, z.PartitionName),
+				tree.NewDInt(tree.DInt(z.TotalRanges)),
+				tree.NewDInt(tree.DInt(z.UnavailableRanges)),
+				tree.NewDInt(tree.DInt(z.UnderReplicatedRanges)),
+				tree.NewDInt(tree.DInt(z.OverReplicatedRanges)),
+				tree.NewDInt(tree.DInt(z.ConstraintViolatingRanges)),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// crdbInternalCriticalLocalitiesTable exposes, for each locality and zone, the
+// number of ranges that would lose quorum if all of the nodes in the locality
+// became unavailable.
+var crdbInternalCriticalLocalitiesTable = virtualSchemaTable{
+	schema: `
+CREATE TABLE crdb_internal.critical_localities (
+  locality        STRING NOT NULL,
+  zone_id         INT NOT NULL,
+  cli_specifier   STRING,
+  critical_ranges INT NOT NULL
+)
+`,
+	populate: func(ctx context.Context, p *planner, prefix string, addRow func(...tree.Datum) error) error {
+		if err := p.RequireSuperUser("read crdb_internal.critical_localities"); err != nil {
+			return err
+		}
+		response, err := p.ExecCfg().StatusServer.ReplicationReport(
+			ctx, &serverpb.ReplicationReportRequest{})
+		if err != nil {
+			return err
+		}
+		cliSpecifier, err := p.zoneCLISpecifierResolver(ctx)
+		if err != nil {
+			return err
+		}
+		for _, l := range response.CriticalLocalities {
+			if err := addRow(
+				tree.NewDString(l.Locality),
+				tree.NewDInt(tree.DInt(l.ZoneID)),
+				cliSpecifier(l.ZoneID, l.IndexID, l.PartitionName),
+				tree.NewDInt(tree.DInt(l.CriticalRanges)),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 // crdbInternalZonesTable decodes and exposes the zone configs in the
 // system.zones table.
 var crdbInternalZonesTable = virtualSchemaTable{
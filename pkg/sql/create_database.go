@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 )
 
@@ -27,10 +28,10 @@ type createDatabaseNode struct {
 }
 
 // CreateDatabase creates a database.
-// Privileges: superuser.
+// Privileges: superuser or the CREATEDB role option.
 //   Notes: postgres requires superuser or "CREATEDB".
 //          mysql uses the mysqladmin command.
-func (p *planner) CreateDatabase(n *tree.CreateDatabase) (planNode, error) {
+func (p *planner) CreateDatabase(ctx context.Context, n *tree.CreateDatabase) (planNode, error) {
 	if n.Name == "" {
 		return nil, errEmptyDatabaseName
 	}
@@ -66,7 +67,13 @@ func (p *planner) CreateDatabase(n *tree.CreateDatabase) (planNode, error) {
 	}
 
 	if err := p.RequireSuperUser("CREATE DATABASE"); err != nil {
-		return nil, err
+		hasCreateDB, roleOptErr := p.HasRoleOption(ctx, tree.RoleOptCreateDB)
+		if roleOptErr != nil {
+			return nil, roleOptErr
+		}
+		if !hasCreateDB {
+			return nil, err
+		}
 	}
 
 	return &createDatabaseNode{n: n}, nil
@@ -74,6 +81,11 @@ func (p *planner) CreateDatabase(n *tree.CreateDatabase) (planNode, error) {
 
 func (n *createDatabaseNode) startExec(params runParams) error {
 	desc := makeDatabaseDesc(n.n)
+	if params.p.RequireSuperUser("CREATE DATABASE") != nil {
+		// The database is created using the CREATEDB role option: like the
+		// owner of a database in postgres, its creator can use it.
+		desc.Privileges.Grant(params.p.User(), privilege.List{privilege.ALL})
+	}
 
 	created, err := params.p.createDatabase(params.ctx, &desc, n.n.IfNotExists)
 	if err != nil {
@@ -19,6 +19,7 @@ import (
 	"regexp"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -31,28 +32,34 @@ type CreateUserNode struct {
 	ifNotExists bool
 	isRole      bool
 	userAuthInfo
+	roleOptions []roleOptionUpdate
 
 	run createUserRun
 }
 
 // CreateUser creates a user.
-// Privileges: INSERT on system.users.
+// Privileges: INSERT on system.users, or the CREATEROLE role option.
 //   notes: postgres allows the creation of users with an empty password. We do
 //          as well, but disallow password authentication for these users.
 func (p *planner) CreateUser(ctx context.Context, n *tree.CreateUser) (planNode, error) {
-	return p.CreateUserNode(ctx, n.Name, n.Password, n.IfNotExists, false /* isRole */, "CREATE USER")
+	return p.CreateUserNode(ctx, n.Name, n.Options, n.IfNotExists, false /* isRole */, "CREATE USER")
 }
 
 // CreateUserNode creates a "create user" plan node. This can be called from CREATE USER or CREATE ROLE.
 func (p *planner) CreateUserNode(
-	ctx context.Context, nameE, passwordE tree.Expr, ifNotExists bool, isRole bool, opName string,
+	ctx context.Context,
+	nameE tree.Expr,
+	options tree.RoleOptions,
+	ifNotExists bool,
+	isRole bool,
+	opName string,
 ) (*CreateUserNode, error) {
-	tDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), &tree.TableName{DatabaseName: "system", TableName: "users"})
-	if err != nil {
+	if _, err := p.checkCreateRolePrivilege(ctx, privilege.INSERT); err != nil {
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(tDesc, privilege.INSERT); err != nil {
+	passwordE, roleOptions, err := p.getRoleOptions(options, isRole, opName)
+	if err != nil {
 		return nil, err
 	}
 
@@ -63,13 +70,14 @@ func (p *planner) CreateUserNode(
 
 	return &CreateUserNode{
 		userAuthInfo: ua,
+		roleOptions:  roleOptions,
 		ifNotExists:  ifNotExists,
 		isRole:       isRole,
 	}, nil
 }
 
 func (n *CreateUserNode) startExec(params runParams) error {
-	normalizedUsername, hashedPassword, err := n.userAuthInfo.resolve()
+	normalizedUsername, hashedPassword, err := n.userAuthInfo.resolve(params.extendedEvalCtx.Settings)
 	if err != nil {
 		return err
 	}
@@ -126,7 +134,7 @@ func (n *CreateUserNode) startExec(params runParams) error {
 		)
 	}
 
-	return nil
+	return applyRoleOptionUpdates(params, opName, normalizedUsername, n.roleOptions)
 }
 
 type createUserRun struct {
@@ -189,8 +197,9 @@ func (p *planner) getUserAuthInfo(nameE, passwordE tree.Expr, ctx string) (userA
 	return userAuthInfo{name: name, password: password}, nil
 }
 
-// resolve returns the actual user name and (hashed) password.
-func (ua *userAuthInfo) resolve() (string, []byte, error) {
+// resolve returns the actual user name and (hashed) password. The password is
+// hashed using the method configured in the cluster settings.
+func (ua *userAuthInfo) resolve(st *cluster.Settings) (string, []byte, error) {
 	name, err := ua.name()
 	if err != nil {
 		return "", nil, err
@@ -213,7 +222,9 @@ func (ua *userAuthInfo) resolve() (string, []byte, error) {
 			return "", nil, security.ErrEmptyPassword
 		}
 
-		hashedPassword, err = security.HashPassword(resolvedPassword)
+		hashedPassword, err = security.HashPasswordWithMethod(
+			resolvedPassword, passwordHashMethod(st),
+		)
 		if err != nil {
 			return "", nil, err
 		}
@@ -136,6 +136,12 @@ type dataSourceInfo struct {
 	// The number of backfill source columns. The backfill columns are
 	// always the last columns from sourceColumns.
 	numBackfillColumns int
+
+	// unreadableColumns is the set of source columns that the current
+	// user may not read. It is only ever non-empty for sources scanning
+	// a table on which the user has SELECT privileges on some columns
+	// but not on the table itself. See checkColumnReadable.
+	unreadableColumns util.FastIntSet
 }
 
 // planDataSource contains the data source information for data
@@ -625,9 +631,24 @@ func (p *planner) getPlanForDesc(
 			"unexpected table descriptor of type %s for %q", desc.TypeName(), tree.ErrString(tn))
 	}
 
+	p.maybeAudit(desc, privilege.SELECT)
+
+	// A user with SELECT privileges on some of the columns of the table
+	// but not on the table itself can still scan it. The columns it can't
+	// read are rejected during name resolution instead.
+	columnPrivilegesOnly := !p.skipSelectPrivilegeChecks &&
+		p.hasColumnPrivilegesOnly(desc, privilege.SELECT)
+
 	// This name designates a real table.
 	scan := p.Scan()
-	if err := scan.initTable(p, desc, hints, scanVisibility, wantedColumns); err != nil {
+	if columnPrivilegesOnly {
+		p.skipSelectPrivilegeChecks = true
+	}
+	err := scan.initTable(p, desc, hints, scanVisibility, wantedColumns)
+	if columnPrivilegesOnly {
+		p.skipSelectPrivilegeChecks = false
+	}
+	if err != nil {
 		return planDataSource{}, err
 	}
 
@@ -636,6 +657,14 @@ func (p *planner) getPlanForDesc(
 		plan: scan,
 	}
 	ds.info.numBackfillColumns = scan.numBackfillColumns
+	if columnPrivilegesOnly {
+		ds.info.unreadableColumns = p.unreadableColumns(desc, scan.cols)
+	}
+	if !p.bypassRowLevelSecurity(desc) {
+		if err := p.addRowLevelSecurityFilter(ctx, &ds, desc, tn); err != nil {
+			return planDataSource{}, err
+		}
+	}
 	return ds, nil
 }
 
@@ -751,22 +780,27 @@ func (p *planner) getSequenceSource(
 }
 
 // expandStar returns the array of column metadata and name
-// expressions that correspond to the expansion of a star.
+// expressions that correspond to the expansion of a star. An error is
+// returned if the user can't read one of the expanded columns.
 func (src *dataSourceInfo) expandStar(
-	v tree.VarName, ivarHelper tree.IndexedVarHelper,
+	v tree.VarName, ivarHelper tree.IndexedVarHelper, user string,
 ) (columns sqlbase.ResultColumns, exprs []tree.TypedExpr, err error) {
 	if len(src.sourceColumns) == 0 {
 		return nil, nil, pgerror.NewErrorf(pgerror.CodeInvalidNameError,
 			"cannot use %q without a FROM clause", tree.ErrString(v))
 	}
 
-	colSel := func(idx int) {
+	colSel := func(idx int) error {
 		col := src.sourceColumns[idx]
 		if !col.Hidden {
+			if err := src.checkColumnReadable(user, idx); err != nil {
+				return err
+			}
 			ivar := ivarHelper.IndexedVar(idx)
 			columns = append(columns, sqlbase.ResultColumn{Name: col.Name, Typ: ivar.ResolvedType()})
 			exprs = append(exprs, ivar)
 		}
+		return nil
 	}
 
 	tableName := tree.TableName{OmitDBNameDuringFormatting: true}
@@ -775,7 +809,9 @@ func (src *dataSourceInfo) expandStar(
 	}
 	if tableName.Table() == "" {
 		for i := 0; i < len(src.sourceColumns); i++ {
-			colSel(i)
+			if err := colSel(i); err != nil {
+				return nil, nil, err
+			}
 		}
 	} else {
 		qualifiedTn, err := src.checkDatabaseName(tableName)
@@ -788,7 +824,9 @@ func (src *dataSourceInfo) expandStar(
 			return nil, nil, sqlbase.NewUndefinedRelationError(&tableName)
 		}
 		for i, ok := colSet.Next(0); ok; i, ok = colSet.Next(i + 1) {
-			colSel(i)
+			if err := colSel(i); err != nil {
+				return nil, nil, err
+			}
 		}
 	}
 
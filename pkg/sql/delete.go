@@ -100,11 +100,17 @@ func (p *planner) Delete(
 	// this node's initSelect() method both does type checking and also
 	// performs index selection. We cannot perform index selection
 	// properly until the placeholder values are known.
+	//
+	// The scan of the table also applies its row-level security policies
+	// for DELETE, if any.
+	p.rowLevelSecurity.targetID = en.tableDesc.ID
+	p.rowLevelSecurity.targetCmd = sqlbase.TableDescriptor_Policy_DELETE
 	rows, err := p.SelectClause(ctx, &tree.SelectClause{
 		Exprs: sqlbase.ColumnsSelectors(rd.FetchCols, true /* forUpdateOrDelete */),
 		From:  &tree.From{Tables: []tree.TableExpr{n.Table}},
 		Where: n.Where,
 	}, n.OrderBy, n.Limit, nil, nil, publicAndNonPublicColumns)
+	p.rowLevelSecurity.targetID = sqlbase.InvalidID
 	if err != nil {
 		return nil, err
 	}
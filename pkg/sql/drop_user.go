@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -33,12 +34,15 @@ type DropUserNode struct {
 	ifExists bool
 	isRole   bool
 	names    func() ([]string, error)
+	// viaRoleOption is set if the session user is only allowed to drop users
+	// and roles because it has the CREATEROLE role option.
+	viaRoleOption bool
 
 	run dropUserRun
 }
 
 // DropUser drops a list of users.
-// Privileges: DELETE on system.users.
+// Privileges: DELETE on system.users, or the CREATEROLE role option.
 func (p *planner) DropUser(ctx context.Context, n *tree.DropUser) (planNode, error) {
 	return p.DropUserNode(ctx, n.Names, n.IfExists, false /* isRole */, "DROP USER")
 }
@@ -47,24 +51,21 @@ func (p *planner) DropUser(ctx context.Context, n *tree.DropUser) (planNode, err
 func (p *planner) DropUserNode(
 	ctx context.Context, namesE tree.Exprs, ifExists bool, isRole bool, opName string,
 ) (*DropUserNode, error) {
-	tDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), &tree.TableName{DatabaseName: "system", TableName: "users"})
+	viaRoleOption, err := p.checkCreateRolePrivilege(ctx, privilege.DELETE)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(tDesc, privilege.DELETE); err != nil {
-		return nil, err
-	}
-
 	names, err := p.TypeAsStringArray(namesE, opName)
 	if err != nil {
 		return nil, err
 	}
 
 	return &DropUserNode{
-		ifExists: ifExists,
-		isRole:   isRole,
-		names:    names,
+		ifExists:      ifExists,
+		isRole:        isRole,
+		names:         names,
+		viaRoleOption: viaRoleOption,
 	}, nil
 }
 
@@ -115,18 +116,32 @@ func (n *DropUserNode) startExec(params runParams) error {
 	}
 	if err := forEachTableDescAll(params.ctx, params.p, "",
 		func(db *sqlbase.DatabaseDescriptor, table *sqlbase.TableDescriptor) error {
+			hasGrants := false
 			for _, u := range table.GetPrivileges().Users {
 				if _, ok := userNames[u.User]; ok {
-					tn := tree.TableName{
-						DatabaseName: tree.Name(db.Name),
-						TableName:    tree.Name(table.Name),
-					}
-					if f.Len() > 0 {
-						f.WriteString(", ")
+					hasGrants = true
+				}
+			}
+			for _, col := range table.Columns {
+				if col.Privileges == nil {
+					continue
+				}
+				for _, u := range col.Privileges.Users {
+					if _, ok := userNames[u.User]; ok {
+						hasGrants = true
 					}
-					f.FormatNode(&tn)
 				}
 			}
+			if hasGrants {
+				tn := tree.TableName{
+					DatabaseName: tree.Name(db.Name),
+					TableName:    tree.Name(table.Name),
+				}
+				if f.Len() > 0 {
+					f.WriteString(", ")
+				}
+				f.FormatNode(&tn)
+			}
 			return nil
 		}); err != nil {
 		return err
@@ -157,6 +172,11 @@ func (n *DropUserNode) startExec(params runParams) error {
 		if normalizedUsername == security.RootUser {
 			return errors.Errorf("user %s cannot be dropped", security.RootUser)
 		}
+		if n.viaRoleOption {
+			if err := checkNotAdminMember(params, normalizedUsername); err != nil {
+				return err
+			}
+		}
 
 		internalExecutor := InternalExecutor{ExecCfg: params.extendedEvalCtx.ExecCfg}
 		rowsAffected, err := internalExecutor.ExecuteStatementInTransaction(
@@ -187,6 +207,21 @@ func (n *DropUserNode) startExec(params runParams) error {
 		if err != nil {
 			return err
 		}
+
+		// Drop the role options of the user/role, if it was dropped.
+		if rowsAffected > 0 &&
+			params.extendedEvalCtx.Settings.Version.IsActive(cluster.VersionRoleOptions) {
+			_, err = internalExecutor.ExecuteStatementInTransaction(
+				params.ctx,
+				"drop-role-options",
+				params.p.txn,
+				`DELETE FROM system.role_options WHERE username = $1`,
+				normalizedUsername,
+			)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	n.run.numDeleted = numDeleted
@@ -86,6 +86,14 @@ const (
 
 	// EventLogSetClusterSetting is recorded when a cluster setting is changed.
 	EventLogSetClusterSetting EventLogType = "set_cluster_setting"
+	// EventLogClusterSettingDrift is recorded when a node started with a
+	// settings file changes a cluster setting whose value differs from the
+	// file.
+	EventLogClusterSettingDrift EventLogType = "cluster_setting_drift"
+
+	// EventLogRejectConnection is recorded when a SQL connection is rejected
+	// by the host-based authentication configuration.
+	EventLogRejectConnection EventLogType = "reject_connection"
 )
 
 // An EventLogger exposes methods used to record events to the event table.
@@ -210,6 +210,8 @@ type Executor struct {
 	stopper *stop.Stopper
 	reCache *tree.RegexpCache
 
+	rejectedConnLogger rejectedConnLogger
+
 	// Transient stats.
 	SelectCount   *metric.Counter
 	TxnBeginCount *metric.Counter
@@ -375,6 +377,8 @@ func NewExecutor(cfg ExecutorConfig, stopper *stop.Stopper) *Executor {
 		stopper: stopper,
 		reCache: tree.NewRegexpCache(512),
 
+		rejectedConnLogger: makeRejectedConnLogger(),
+
 		TxnBeginCount:    metric.NewCounter(MetaTxnBegin),
 		TxnCommitCount:   metric.NewCounter(MetaTxnCommit),
 		TxnAbortCount:    metric.NewCounter(MetaTxnAbort),
@@ -731,6 +735,28 @@ func (e *Executor) RecordError(err error) {
 	}
 }
 
+// CancelQueryByKey serves a pgwire CancelRequest: it cancels the active queries
+// of the session identified by the given BackendKeyData, which may be running
+// on another node.
+func (e *Executor) CancelQueryByKey(
+	ctx context.Context, processID int32, secretKey int32,
+) (bool, error) {
+	if e.cfg.StatusServer == nil {
+		return false, errors.New("query cancellation is not available")
+	}
+	response, err := e.cfg.StatusServer.CancelQuery(ctx, &serverpb.CancelQueryRequest{
+		NodeId:    strconv.Itoa(int(processID)),
+		SecretKey: secretKey,
+	})
+	if err != nil {
+		return false, err
+	}
+	if !response.Canceled && response.Error != "" {
+		return false, errors.New(response.Error)
+	}
+	return response.Canceled, nil
+}
+
 // execParsed executes a batch of statements received as a unit from the client
 // and returns query execution errors and communication errors.
 func (e *Executor) execParsed(
@@ -2171,6 +2197,7 @@ func (e *Executor) execStmt(
 	err := planner.makePlan(ctx, stmt)
 	planner.statsCollector.PhaseTimes()[plannerEndLogicalPlan] = timeutil.Now()
 	if err != nil {
+		planner.maybeLogStatementToAuditLog(ctx, stmt, 0 /* rows */, err)
 		return err
 	}
 	defer planner.curPlan.close(ctx)
@@ -2210,6 +2237,7 @@ func (e *Executor) execStmt(
 	recordStatementSummary(
 		planner, stmt, useDistSQL, automaticRetryCount, res, err, &e.EngineMetrics,
 	)
+	planner.maybeLogStatementToAuditLog(ctx, stmt, res.RowsAffected(), err)
 	if e.cfg.TestingKnobs.AfterExecute != nil {
 		e.cfg.TestingKnobs.AfterExecute(ctx, stmt.String(), res, err)
 	}
@@ -2241,6 +2269,7 @@ func (e *Executor) execStmtInParallel(
 	}
 
 	if err := planner.makePlan(ctx, stmt); err != nil {
+		planner.maybeLogStatementToAuditLog(ctx, stmt, 0 /* rows */, err)
 		return nil, err
 	}
 	var cols sqlbase.ResultColumns
@@ -2269,6 +2298,7 @@ func (e *Executor) execStmtInParallel(
 		err = e.execLocal(planner, planner.curPlan.plan, bufferedWriter)
 		planner.statsCollector.PhaseTimes()[plannerEndExecStmt] = timeutil.Now()
 		recordStatementSummary(planner, stmt, false, 0, bufferedWriter, err, &e.EngineMetrics)
+		planner.maybeLogStatementToAuditLog(ctx, stmt, bufferedWriter.RowsAffected(), err)
 		if e.cfg.TestingKnobs.AfterExecute != nil {
 			e.cfg.TestingKnobs.AfterExecute(ctx, stmt.String(), bufferedWriter, err)
 		}
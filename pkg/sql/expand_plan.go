@@ -232,7 +232,7 @@ func doExpandPlan(
 	case *alterIndexNode:
 	case *alterTableNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *AlterRoleNode:
 	case *cancelQueryNode:
 	case *scrubNode:
 	case *controlJobNode:
@@ -242,11 +242,13 @@ func doExpandPlan(
 	case *createViewNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createPolicyNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
 	case *dropSequenceNode:
+	case *dropPolicyNode:
 	case *DropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -620,7 +622,7 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *alterIndexNode:
 	case *alterTableNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *AlterRoleNode:
 	case *cancelQueryNode:
 	case *scrubNode:
 	case *controlJobNode:
@@ -630,11 +632,13 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *createViewNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createPolicyNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
 	case *dropSequenceNode:
+	case *dropPolicyNode:
 	case *DropUserNode:
 	case *zeroNode:
 	case *unaryNode:
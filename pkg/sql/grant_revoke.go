@@ -17,6 +17,8 @@ package sql
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -26,6 +28,7 @@ import (
 // Grant adds privileges to users.
 // Current status:
 // - Target: single database, table, or view.
+// - Columns: SELECT, INSERT and UPDATE on columns of a table.
 // TODO(marc): open questions:
 // - should we have root always allowed and not present in the permissions list?
 // - should we make users case-insensitive?
@@ -33,14 +36,27 @@ import (
 //   Notes: postgres requires the object owner.
 //          mysql requires the "grant option" and the same privileges, and sometimes superuser.
 func (p *planner) Grant(ctx context.Context, n *tree.Grant) (planNode, error) {
-	return p.changePrivileges(ctx, n.Targets, n.Grantees, func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
-		privDesc.Grant(grantee, n.Privileges)
-	})
+	if len(n.Columns) > 0 {
+		privs, err := columnPrivileges(n.Privileges)
+		if err != nil {
+			return nil, err
+		}
+		return p.changeColumnPrivileges(ctx, n.Targets, n.Columns, n.Grantees,
+			func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
+				privDesc.Grant(grantee, privs)
+			})
+	}
+	return p.changePrivileges(ctx, n.Targets, n.Grantees,
+		func(desc sqlbase.DescriptorProto, grantee string) error {
+			desc.GetPrivileges().Grant(grantee, n.Privileges)
+			return nil
+		})
 }
 
 // Revoke removes privileges from users.
 // Current status:
 // - Target: single database, table, or view.
+// - Columns: SELECT, INSERT and UPDATE on columns of a table.
 // TODO(marc): open questions:
 // - should we have root always allowed and not present in the permissions list?
 // - should we make users case-insensitive?
@@ -48,16 +64,125 @@ func (p *planner) Grant(ctx context.Context, n *tree.Grant) (planNode, error) {
 //   Notes: postgres requires the object owner.
 //          mysql requires the "grant option" and the same privileges, and sometimes superuser.
 func (p *planner) Revoke(ctx context.Context, n *tree.Revoke) (planNode, error) {
-	return p.changePrivileges(ctx, n.Targets, n.Grantees, func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
-		privDesc.Revoke(grantee, n.Privileges)
-	})
+	if len(n.Columns) > 0 {
+		privs, err := columnPrivileges(n.Privileges)
+		if err != nil {
+			return nil, err
+		}
+		return p.changeColumnPrivileges(ctx, n.Targets, n.Columns, n.Grantees,
+			func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
+				privDesc.Revoke(grantee, privs)
+			})
+	}
+	return p.changePrivileges(ctx, n.Targets, n.Grantees,
+		func(desc sqlbase.DescriptorProto, grantee string) error {
+			desc.GetPrivileges().Revoke(grantee, n.Privileges)
+			// Revoking a privilege on a table also revokes it on all its
+			// columns, like in PostgreSQL.
+			if tableDesc, ok := desc.(*sqlbase.TableDescriptor); ok {
+				for i := range tableDesc.Columns {
+					revokeColumnPrivileges(&tableDesc.Columns[i], grantee, n.Privileges)
+				}
+			}
+			return nil
+		})
 }
 
-func (p *planner) changePrivileges(
+// columnPrivileges validates the privileges of a GRANT or REVOKE
+// statement with a column list. ALL designates all the privileges that
+// can be granted on a column.
+func columnPrivileges(privs privilege.List) (privilege.List, error) {
+	for _, priv := range privs {
+		switch priv {
+		case privilege.ALL:
+			return privilege.List{privilege.SELECT, privilege.INSERT, privilege.UPDATE}, nil
+		case privilege.SELECT, privilege.INSERT, privilege.UPDATE:
+		default:
+			return nil, pgerror.NewErrorf(pgerror.CodeInvalidGrantOperationError,
+				"invalid privilege type %s for column", priv)
+		}
+	}
+	return privs, nil
+}
+
+// revokeColumnPrivileges revokes privileges from grantee on the
+// given column, dropping the column's privilege descriptor once empty.
+func revokeColumnPrivileges(
+	col *sqlbase.ColumnDescriptor, grantee string, privs privilege.List,
+) {
+	if col.Privileges == nil {
+		return
+	}
+	col.Privileges.Revoke(grantee, privs)
+	if len(col.Privileges.Users) == 0 {
+		col.Privileges = nil
+	}
+}
+
+// changeColumnPrivileges applies changePrivilege to the named columns
+// of the target tables, for all grantees.
+func (p *planner) changeColumnPrivileges(
 	ctx context.Context,
 	targets tree.TargetList,
+	columns tree.NameList,
 	grantees tree.NameList,
 	changePrivilege func(*sqlbase.PrivilegeDescriptor, string),
+) (planNode, error) {
+	if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionColumnPrivileges) {
+		return nil, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+			"column privileges are not supported until the cluster is upgraded to version %s",
+			cluster.VersionByKey(cluster.VersionColumnPrivileges))
+	}
+	if targets.Databases != nil {
+		return nil, pgerror.NewErrorf(pgerror.CodeInvalidGrantOperationError,
+			"column privileges can only be granted on tables")
+	}
+	return p.changePrivileges(ctx, targets, grantees,
+		func(desc sqlbase.DescriptorProto, grantee string) error {
+			tableDesc, ok := desc.(*sqlbase.TableDescriptor)
+			if !ok {
+				return pgerror.NewErrorf(pgerror.CodeWrongObjectTypeError,
+					"column privileges can only be granted on tables")
+			}
+			if !tableDesc.IsTable() {
+				return pgerror.NewErrorf(pgerror.CodeWrongObjectTypeError,
+					"column privileges can only be granted on tables, %q is a %s",
+					tableDesc.Name, tableDesc.Kind())
+			}
+			for _, name := range columns {
+				col, err := findPublicColumn(tableDesc, string(name))
+				if err != nil {
+					return err
+				}
+				if col.Privileges == nil {
+					col.Privileges = &sqlbase.PrivilegeDescriptor{}
+				}
+				changePrivilege(col.Privileges, grantee)
+				if len(col.Privileges.Users) == 0 {
+					col.Privileges = nil
+				}
+			}
+			return nil
+		})
+}
+
+// findPublicColumn returns a pointer to the public column of desc with
+// the given name.
+func findPublicColumn(desc *sqlbase.TableDescriptor, name string) (*sqlbase.ColumnDescriptor, error) {
+	for i := range desc.Columns {
+		if desc.Columns[i].Name == name {
+			return &desc.Columns[i], nil
+		}
+	}
+	return nil, pgerror.NewErrorf(pgerror.CodeUndefinedColumnError,
+		"column %q of table %q does not exist", name, desc.Name)
+}
+
+func (p *planner) changePrivileges(
+	ctx context.Context,
+	targets tree.TargetList,
+	grantees tree.NameList,
+	changePrivilege func(sqlbase.DescriptorProto, string) error,
 ) (planNode, error) {
 	// Check whether grantees exists
 	users, err := p.GetAllUsersAndRoles(ctx)
@@ -80,9 +205,10 @@ func (p *planner) changePrivileges(
 		if err := p.CheckPrivilege(descriptor, privilege.GRANT); err != nil {
 			return nil, err
 		}
-		privileges := descriptor.GetPrivileges()
 		for _, grantee := range grantees {
-			changePrivilege(privileges, string(grantee))
+			if err := changePrivilege(descriptor, string(grantee)); err != nil {
+				return nil, err
+			}
 		}
 
 		switch d := descriptor.(type) {
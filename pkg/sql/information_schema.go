@@ -152,6 +152,32 @@ CREATE TABLE information_schema.column_privileges (
 					}
 				}
 			}
+			// Privileges granted on individual columns.
+			for _, cd := range table.Columns {
+				if cd.Privileges == nil {
+					continue
+				}
+				for _, u := range cd.Privileges.Users {
+					for _, priv := range columndata {
+						if priv.Mask()&u.Privileges == 0 || table.Privileges.CheckPrivilege(u.User, priv) {
+							// Not granted, or already reported through the table.
+							continue
+						}
+						if err := addRow(
+							tree.DNull,                     // grantor
+							tree.NewDString(u.User),        // grantee
+							defString,                      // table_catalog
+							tree.NewDString(db.Name),       // table_schema
+							tree.NewDString(table.Name),    // table_name
+							tree.NewDString(cd.Name),       // column_name
+							tree.NewDString(priv.String()), // privilege_type
+							tree.DNull,                     // is_grantable
+						); err != nil {
+							return err
+						}
+					}
+				}
+			}
 			return nil
 		})
 	},
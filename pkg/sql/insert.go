@@ -65,7 +65,7 @@ type insertNode struct {
 var _ autoCommitNode = &insertNode{}
 
 // Insert inserts rows into the database.
-// Privileges: INSERT on table. Also requires UPDATE on "ON DUPLICATE KEY UPDATE".
+// Privileges: INSERT on table or on the target columns. Also requires UPDATE on "ON DUPLICATE KEY UPDATE".
 //   Notes: postgres requires INSERT. No "on duplicate key update" option.
 //          mysql requires INSERT. Also requires UPDATE on "ON DUPLICATE KEY UPDATE".
 func (p *planner) Insert(
@@ -93,6 +93,10 @@ func (p *planner) Insert(
 			if err := p.CheckPrivilege(en.tableDesc, privilege.UPDATE); err != nil {
 				return nil, err
 			}
+			if !p.bypassRowLevelSecurity(en.tableDesc) {
+				return nil, pgerror.Unimplemented("rls upsert",
+					"UPSERT and ON CONFLICT DO UPDATE are not supported on tables with row-level security")
+			}
 		}
 		if _, ok := n.Returning.(*tree.ReturningExprs); ok {
 			isUpsertReturning = true
@@ -108,6 +112,9 @@ func (p *planner) Insert(
 		if cols, err = p.processColumns(en.tableDesc, n.Columns); err != nil {
 			return nil, err
 		}
+		if err := p.checkColumnPrivileges(en.tableDesc, cols, privilege.INSERT); err != nil {
+			return nil, err
+		}
 	}
 	// Number of columns expecting an input. This doesn't include the
 	// columns receiving a default value.
@@ -264,7 +271,9 @@ func (p *planner) Insert(
 		},
 	}
 
-	if err := in.checkHelper.init(ctx, p, tn, en.tableDesc); err != nil {
+	if err := in.checkHelper.init(
+		ctx, p, tn, en.tableDesc, sqlbase.TableDescriptor_Policy_INSERT,
+	); err != nil {
 		return nil, err
 	}
 
@@ -134,6 +134,18 @@ func (p *planner) makeJoin(
 		return planDataSource{}, err
 	}
 
+	// The equality columns are compared by the join even when they
+	// don't appear in an expression, as with USING and NATURAL.
+	user := p.SessionData().User
+	for i := range pred.leftEqualityIndices {
+		if err := leftInfo.checkColumnReadable(user, pred.leftEqualityIndices[i]); err != nil {
+			return planDataSource{}, err
+		}
+		if err := rightInfo.checkColumnReadable(user, pred.rightEqualityIndices[i]); err != nil {
+			return planDataSource{}, err
+		}
+	}
+
 	n := &joinNode{
 		left:     left,
 		right:    right,
@@ -300,6 +312,9 @@ func (p *planner) makeJoin(
 		r.addRenderColumn(expr, symbolicExprStr(expr), c)
 	}
 	rInfo.sourceColumns = r.columns
+	info.unreadableColumns.ForEach(func(col int) {
+		rInfo.unreadableColumns.Add(remapped[col])
+	})
 
 	// Copy the aliases, remapping the columns as necessary. We extract any
 	// anonymous aliases for special handling.
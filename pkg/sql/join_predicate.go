@@ -272,6 +272,8 @@ func makeEqualityPredicate(
 		sourceColumns: columns,
 		sourceAliases: aliases,
 	}
+	info.unreadableColumns.UnionWith(left.unreadableColumns)
+	info.unreadableColumns.UnionWith(right.unreadableColumns.Shift(len(left.sourceColumns)))
 
 	pred := &joinPredicate{
 		joinType:             typ,
@@ -317,7 +317,7 @@ func (p *planner) propagateFilters(
 	case *alterIndexNode:
 	case *alterTableNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *AlterRoleNode:
 	case *cancelQueryNode:
 	case *scrubNode:
 	case *controlJobNode:
@@ -327,11 +327,13 @@ func (p *planner) propagateFilters(
 	case *createViewNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createPolicyNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
 	case *dropSequenceNode:
+	case *dropPolicyNode:
 	case *DropUserNode:
 	case *hookFnNode:
 	case *valueGenerator:
@@ -167,7 +167,7 @@ func (p *planner) applyLimit(plan planNode, numRows int64, soft bool) {
 	case *alterIndexNode:
 	case *alterTableNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *AlterRoleNode:
 	case *cancelQueryNode:
 	case *scrubNode:
 	case *controlJobNode:
@@ -177,11 +177,13 @@ func (p *planner) applyLimit(plan planNode, numRows int64, soft bool) {
 	case *createViewNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createPolicyNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
 	case *dropSequenceNode:
+	case *dropPolicyNode:
 	case *DropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -193,7 +193,7 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *alterIndexNode:
 	case *alterTableNode:
 	case *alterSequenceNode:
-	case *alterUserSetPasswordNode:
+	case *AlterRoleNode:
 	case *cancelQueryNode:
 	case *controlJobNode:
 	case *scrubNode:
@@ -203,11 +203,13 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *createViewNode:
 	case *createSequenceNode:
 	case *createStatsNode:
+	case *createPolicyNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
 	case *dropSequenceNode:
+	case *dropPolicyNode:
 	case *DropUserNode:
 	case *zeroNode:
 	case *unaryNode:
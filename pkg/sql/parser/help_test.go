@@ -69,6 +69,10 @@ func TestContextualHelp(t *testing.T) {
 
 		{`ALTER USER IF ??`, `ALTER USER`},
 		{`ALTER USER foo WITH PASSWORD ??`, `ALTER USER`},
+		{`ALTER USER foo CREATEDB ??`, `ALTER USER`},
+
+		{`ALTER ROLE IF ??`, `ALTER ROLE`},
+		{`ALTER ROLE bleh WITH ??`, `ALTER ROLE`},
 
 		{`CANCEL ??`, `CANCEL`},
 		{`CANCEL JOB ??`, `CANCEL JOB`},
@@ -90,12 +94,17 @@ func TestContextualHelp(t *testing.T) {
 		{`CREATE USER blih WITH ??`, `CREATE USER`},
 
 		{`CREATE ROLE bleh ??`, `CREATE ROLE`},
+		{`CREATE ROLE bleh WITH CREATEDB ??`, `CREATE ROLE`},
 
 		{`CREATE VIEW blah (??`, `CREATE VIEW`},
 		{`CREATE VIEW blah AS (SELECT c FROM x) ??`, `CREATE VIEW`},
 		{`CREATE VIEW blah AS SELECT c FROM x ??`, `SELECT`},
 		{`CREATE VIEW blah AS (??`, `<SELECTCLAUSE>`},
 
+		{`CREATE POLICY ??`, `CREATE POLICY`},
+		{`CREATE POLICY blah ON blih FOR ??`, `CREATE POLICY`},
+		{`CREATE POLICY blah ON blih USING (true) ??`, `CREATE POLICY`},
+
 		{`CREATE SEQUENCE ??`, `CREATE SEQUENCE`},
 
 		{`CREATE STATISTICS ??`, `CREATE STATISTICS`},
@@ -124,6 +133,9 @@ func TestContextualHelp(t *testing.T) {
 		{`DROP INDEX blah, ??`, `DROP INDEX`},
 		{`DROP INDEX blah@blih ??`, `DROP INDEX`},
 
+		{`DROP POLICY ??`, `DROP POLICY`},
+		{`DROP POLICY IF EXISTS blah ON ??`, `DROP POLICY`},
+
 		{`DROP ROLE ??`, `DROP ROLE`},
 		{`DROP ROLE IF ??`, `DROP ROLE`},
 		{`DROP ROLE IF EXISTS bluh ??`, `DROP ROLE`},
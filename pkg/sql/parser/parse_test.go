@@ -248,6 +248,14 @@ func TestParse(t *testing.T) {
 		{`CREATE STATISTICS a ON col1, col2 FROM t`},
 		{`CREATE STATISTICS a ON col1 FROM d.t`},
 
+		{`CREATE POLICY a ON t USING (b = 'foo')`},
+		{`CREATE POLICY a ON d.t FOR SELECT TO foo, bar USING (b > 1)`},
+		{`CREATE POLICY a ON t FOR INSERT WITH CHECK (b = 'foo')`},
+		{`CREATE POLICY a ON t FOR UPDATE USING (b = 1) WITH CHECK (b = 2)`},
+		{`CREATE POLICY a ON t FOR DELETE TO foo USING (true)`},
+		{`DROP POLICY a ON t`},
+		{`DROP POLICY IF EXISTS a ON d.t`},
+
 		{`DELETE FROM a`},
 		{`DELETE FROM a.b`},
 		{`DELETE FROM a WHERE a = b`},
@@ -424,6 +432,9 @@ func TestParse(t *testing.T) {
 		{`GRANT SELECT, INSERT ON DATABASE bar TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO "test-user"`},
+		{`GRANT SELECT (a, b) ON foo TO root`},
+		{`GRANT SELECT, UPDATE (a) ON db.foo TO root, bar`},
+		{`GRANT ALL (a) ON foo TO root`},
 		{`GRANT rolea, roleb TO usera, userb`},
 		{`GRANT rolea, roleb TO usera, userb WITH ADMIN OPTION`},
 
@@ -435,6 +446,8 @@ func TestParse(t *testing.T) {
 		{`REVOKE ALL ON DATABASE foo FROM root, test`},
 		{`REVOKE SELECT, INSERT ON DATABASE bar FROM foo, bar, baz`},
 		{`REVOKE SELECT, INSERT ON DATABASE db1, db2 FROM foo, bar, baz`},
+		{`REVOKE SELECT (a, b) ON foo FROM root`},
+		{`REVOKE INSERT, UPDATE (a) ON db.foo FROM root, bar`},
 		{`REVOKE rolea, roleb FROM usera, userb`},
 		{`REVOKE ADMIN OPTION FOR rolea, roleb FROM usera, userb`},
 
@@ -832,6 +845,10 @@ func TestParse(t *testing.T) {
 		{`ALTER TABLE a DROP CONSTRAINT b CASCADE`},
 		{`ALTER TABLE a DROP CONSTRAINT IF EXISTS b RESTRICT`},
 		{`ALTER TABLE a VALIDATE CONSTRAINT a`},
+		{`ALTER TABLE a ENABLE ROW LEVEL SECURITY`},
+		{`ALTER TABLE a DISABLE ROW LEVEL SECURITY`},
+		{`ALTER TABLE a EXPERIMENTAL_AUDIT SET READ WRITE`},
+		{`ALTER TABLE a EXPERIMENTAL_AUDIT SET OFF`},
 
 		{`ALTER TABLE a ALTER COLUMN b SET DEFAULT 42`},
 		{`ALTER TABLE a ALTER COLUMN b SET DEFAULT NULL`},
@@ -949,6 +966,7 @@ func TestParse2(t *testing.T) {
 		{`CREATE TABLE a (UNIQUE INDEX (b) PARTITION BY LIST (c) (PARTITION d VALUES IN (1)))`,
 			`CREATE TABLE a (UNIQUE (b) PARTITION BY LIST (c) (PARTITION d VALUES IN (1)))`},
 		{`CREATE INDEX ON a (b) COVERING (c)`, `CREATE INDEX ON a (b) STORING (c)`},
+		{`CREATE POLICY a ON t FOR ALL USING (true)`, `CREATE POLICY a ON t USING (true)`},
 
 		{`SELECT TIMESTAMP WITHOUT TIME ZONE 'foo'`, `SELECT TIMESTAMP 'foo'`},
 		{`SELECT CAST('foo' AS TIMESTAMP WITHOUT TIME ZONE)`, `SELECT CAST('foo' AS TIMESTAMP)`},
@@ -1260,11 +1278,23 @@ func TestParse2(t *testing.T) {
 			`DROP USER IF EXISTS 'foo', 'bar'`},
 		{`ALTER USER foo WITH PASSWORD bar`,
 			`ALTER USER 'foo' WITH PASSWORD 'bar'`},
+		{`CREATE USER foo WITH PASSWORD bar CREATEDB VALID UNTIL '2020-01-01' CONNECTION LIMIT 3`,
+			`CREATE USER 'foo' WITH PASSWORD 'bar' CREATEDB VALID UNTIL '2020-01-01' CONNECTION LIMIT 3`},
+		{`ALTER USER foo NOLOGIN NOCREATEROLE`,
+			`ALTER USER 'foo' WITH NOLOGIN NOCREATEROLE`},
+		{`ALTER USER IF EXISTS foo WITH LOGIN CONNECTION LIMIT -1`,
+			`ALTER USER IF EXISTS 'foo' WITH LOGIN CONNECTION LIMIT -1`},
 
 		{`CREATE ROLE foo`,
 			`CREATE ROLE 'foo'`},
 		{`CREATE ROLE IF NOT EXISTS foo`,
 			`CREATE ROLE IF NOT EXISTS 'foo'`},
+		{`CREATE ROLE foo CREATEROLE`,
+			`CREATE ROLE 'foo' WITH CREATEROLE`},
+		{`ALTER ROLE foo WITH NOCREATEDB`,
+			`ALTER ROLE 'foo' WITH NOCREATEDB`},
+		{`ALTER ROLE IF EXISTS foo CREATEDB`,
+			`ALTER ROLE IF EXISTS 'foo' WITH CREATEDB`},
 		{`DROP ROLE foo, bar`,
 			`DROP ROLE 'foo', 'bar'`},
 		{`DROP ROLE IF EXISTS foo, bar`,
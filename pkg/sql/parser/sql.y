@@ -236,6 +236,12 @@ func (u *sqlSymUnion) seqOpt() tree.SequenceOption {
 func (u *sqlSymUnion) seqOpts() []tree.SequenceOption {
     return u.val.([]tree.SequenceOption)
 }
+func (u *sqlSymUnion) roleOpt() tree.RoleOption {
+    return u.val.(tree.RoleOption)
+}
+func (u *sqlSymUnion) roleOpts() tree.RoleOptions {
+    return u.val.(tree.RoleOptions)
+}
 func (u *sqlSymUnion) expr() tree.Expr {
     if expr, ok := u.val.(tree.Expr); ok {
         return expr
@@ -356,6 +362,12 @@ func (u *sqlSymUnion) dropBehavior() tree.DropBehavior {
 func (u *sqlSymUnion) validationBehavior() tree.ValidationBehavior {
     return u.val.(tree.ValidationBehavior)
 }
+func (u *sqlSymUnion) auditMode() tree.AuditMode {
+    return u.val.(tree.AuditMode)
+}
+func (u *sqlSymUnion) policyCommand() tree.PolicyCommand {
+    return u.val.(tree.PolicyCommand)
+}
 func (u *sqlSymUnion) interleave() *tree.InterleaveDef {
     return u.val.(*tree.InterleaveDef)
 }
@@ -462,17 +474,17 @@ func newNameFromStr(s string) *tree.Name {
 %token <str>   CHARACTER CHARACTERISTICS CHECK
 %token <str>   CLUSTER COALESCE COLLATE COLLATION COLUMN COLUMNS COMMENT COMMIT
 %token <str>   COMMITTED COMPACT CONCAT CONFIGURATION CONFIGURATIONS CONFIGURE
-%token <str>   CONFLICT CONSTRAINT CONSTRAINTS CONTAINS COPY COVERING CREATE
-%token <str>   CROSS CSV CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
+%token <str>   CONFLICT CONNECTION CONSTRAINT CONSTRAINTS CONTAINS COPY COVERING CREATE
+%token <str>   CREATEDB CREATEROLE CROSS CSV CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
 %token <str>   CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP
 %token <str>   CURRENT_USER CYCLE
 
 %token <str>   DATA DATABASE DATABASES DATE DAY DEC DECIMAL DEFAULT
 %token <str>   DEALLOCATE DEFERRABLE DELETE DESC
-%token <str>   DISCARD DISTINCT DO DOUBLE DROP
+%token <str>   DISABLE DISCARD DISTINCT DO DOUBLE DROP
 
-%token <str>   ELSE ENCODING END ESCAPE EXCEPT
-%token <str>   EXISTS EXECUTE EXPERIMENTAL EXPERIMENTAL_FINGERPRINTS EXPERIMENTAL_REPLICA
+%token <str>   ELSE ENABLE ENCODING END ESCAPE EXCEPT
+%token <str>   EXISTS EXECUTE EXPERIMENTAL EXPERIMENTAL_AUDIT EXPERIMENTAL_FINGERPRINTS EXPERIMENTAL_REPLICA
 %token <str>   EXPLAIN EXTRACT EXTRACT_DURATION
 
 %token <str>   FALSE FAMILY FETCH FETCHVAL FETCHTEXT FETCHVAL_PATH FETCHTEXT_PATH FILTER
@@ -493,19 +505,19 @@ func newNameFromStr(s string) *tree.Name {
 
 %token <str>   LATERAL LC_CTYPE LC_COLLATE
 %token <str>   LEADING LEAST LEFT LESS LEVEL LIKE LIMIT LIST LOCAL
-%token <str>   LOCALTIME LOCALTIMESTAMP LOW LSHIFT
+%token <str>   LOCALTIME LOCALTIMESTAMP LOGIN LOW LSHIFT
 
 %token <str>   MATCH MINVALUE MAXVALUE MINUTE MONTH
 
-%token <str>   NAN NAME NAMES NATURAL NEXT NO NO_INDEX_JOIN NORMAL
-%token <str>   NOT NOTHING NULL NULLIF
+%token <str>   NAN NAME NAMES NATURAL NEXT NO NOCREATEDB NOCREATEROLE NOLOGIN NO_INDEX_JOIN
+%token <str>   NORMAL NOT NOTHING NULL NULLIF
 %token <str>   NULLS NUMERIC
 
 %token <str>   OF OFF OFFSET OID ON ONLY OPTION OPTIONS OR
 %token <str>   ORDER ORDINALITY OUT OUTER OVER OVERLAPS OVERLAY OWNED
 
 %token <str>   PARENT PARTIAL PARTITION PASSWORD PAUSE PHYSICAL PLACING
-%token <str>   PLANS POSITION PRECEDING PRECISION PREPARE PRIMARY PRIORITY
+%token <str>   PLANS POLICY POSITION PRECEDING PRECISION PREPARE PRIMARY PRIORITY
 
 %token <str>   QUERIES QUERY
 
@@ -515,7 +527,7 @@ func newNameFromStr(s string) *tree.Name {
 %token <str>   RELEASE RESET RESTORE RESTRICT RESUME RETURNING REVOKE RIGHT
 %token <str>   ROLE ROLES ROLLBACK ROLLUP ROW ROWS RSHIFT
 
-%token <str>   SAVEPOINT SCATTER SCRUB SEARCH SECOND SELECT SEQUENCE SEQUENCES
+%token <str>   SAVEPOINT SCATTER SCRUB SEARCH SECOND SECURITY SELECT SEQUENCE SEQUENCES
 %token <str>   SERIAL SERIAL2 SERIAL4 SERIAL8
 %token <str>   SERIALIZABLE SESSION SESSIONS SESSION_USER SET SETTING SETTINGS
 %token <str>   SHOW SIMILAR SIMPLE SMALLINT SMALLSERIAL SNAPSHOT SOME SOME_EXISTENCE SPLIT SQL
@@ -527,7 +539,7 @@ func newNameFromStr(s string) *tree.Name {
 %token <str>   TIME TIMESTAMP TIMESTAMPTZ TO TRAILING TRACE TRANSACTION TREAT TRIM TRUE
 %token <str>   TRUNCATE TYPE
 
-%token <str>   UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN
+%token <str>   UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN UNTIL
 %token <str>   UPDATE UPSERT USE USER USERS USING UUID
 
 %token <str>   VALID VALIDATE VALUE VALUES VARCHAR VARIADIC VIEW VARYING
@@ -567,6 +579,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> alter_sequence_stmt
 %type <tree.Statement> alter_database_stmt
 %type <tree.Statement> alter_user_stmt
+%type <tree.Statement> alter_role_stmt
 %type <tree.Statement> alter_range_stmt
 
 // ALTER RANGE
@@ -584,9 +597,6 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> alter_rename_database_stmt
 %type <tree.Statement> alter_zone_database_stmt
 
-// ALTER USER
-%type <tree.Statement> alter_user_password_stmt
-
 // ALTER INDEX
 %type <tree.Statement> alter_oneindex_stmt
 %type <tree.Statement> alter_scatter_index_stmt
@@ -625,6 +635,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> create_ddl_stmt
 %type <tree.Statement> create_database_stmt
 %type <tree.Statement> create_index_stmt
+%type <tree.Statement> create_policy_stmt
 %type <tree.Statement> create_role_stmt
 %type <tree.Statement> create_table_stmt
 %type <tree.Statement> create_table_as_stmt
@@ -639,6 +650,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> drop_ddl_stmt
 %type <tree.Statement> drop_database_stmt
 %type <tree.Statement> drop_index_stmt
+%type <tree.Statement> drop_policy_stmt
 %type <tree.Statement> drop_role_stmt
 %type <tree.Statement> drop_table_stmt
 %type <tree.Statement> drop_user_stmt
@@ -733,9 +745,14 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.DropBehavior> opt_interleave_drop_behavior
 
 %type <tree.ValidationBehavior> opt_validate_behavior
+%type <tree.AuditMode> audit_mode
+%type <tree.PolicyCommand> opt_policy_command
+%type <tree.NameList> opt_policy_roles
+%type <tree.Expr> opt_policy_using opt_policy_with_check
 
 %type <str> opt_template_clause opt_encoding_clause opt_lc_collate_clause opt_lc_ctype_clause
-%type <tree.Expr> opt_password
+%type <tree.RoleOptions> opt_role_options role_option_list
+%type <tree.RoleOption> role_option
 
 %type <tree.IsolationLevel> transaction_iso_level
 %type <tree.UserPriority>  transaction_user_priority
@@ -1063,10 +1080,11 @@ stmt:
 
 // %Help: ALTER
 // %Category: Group
-// %Text: ALTER TABLE, ALTER INDEX, ALTER VIEW, ALTER SEQUENCE, ALTER DATABASE, ALTER USER
+// %Text: ALTER TABLE, ALTER INDEX, ALTER VIEW, ALTER SEQUENCE, ALTER DATABASE, ALTER USER, ALTER ROLE
 alter_stmt:
   alter_ddl_stmt      // help texts in sub-rule
 | alter_user_stmt     // EXTEND WITH HELP: ALTER USER
+| alter_role_stmt     // EXTEND WITH HELP: ALTER ROLE
 | ALTER error         // SHOW HELP: ALTER
 
 alter_ddl_stmt:
@@ -1094,6 +1112,8 @@ alter_ddl_stmt:
 //   ALTER TABLE ... VALIDATE CONSTRAINT <constraintname>
 //   ALTER TABLE ... SPLIT AT <selectclause>
 //   ALTER TABLE ... SCATTER [ FROM ( <exprs...> ) TO ( <exprs...> ) ]
+//   ALTER TABLE ... {ENABLE | DISABLE} ROW LEVEL SECURITY
+//   ALTER TABLE ... EXPERIMENTAL_AUDIT SET {READ WRITE | OFF}
 //
 // Column qualifiers:
 //   [CONSTRAINT <constraintname>] {NULL | NOT NULL | UNIQUE | PRIMARY KEY | CHECK (<expr>) | DEFAULT <expr>}
@@ -1152,12 +1172,47 @@ alter_sequence_options_stmt:
 // %Help: ALTER USER - change user properties
 // %Category: Priv
 // %Text:
-// ALTER USER [IF EXISTS] <name> WITH PASSWORD <password>
+// ALTER USER [IF EXISTS] <name> [WITH] <option> [...]
+//
+// Options:
+//    PASSWORD <password>
+//    LOGIN | NOLOGIN
+//    CREATEDB | NOCREATEDB
+//    CREATEROLE | NOCREATEROLE
+//    VALID UNTIL <timestamp>
+//    CONNECTION LIMIT <limit>
 // %SeeAlso: CREATE USER
 alter_user_stmt:
-  alter_user_password_stmt
+  ALTER USER string_or_placeholder opt_with role_option_list
+  {
+    $$.val = &tree.AlterRole{Name: $3.expr(), Options: $5.roleOpts()}
+  }
+| ALTER USER IF EXISTS string_or_placeholder opt_with role_option_list
+  {
+    $$.val = &tree.AlterRole{Name: $5.expr(), Options: $7.roleOpts(), IfExists: true}
+  }
 | ALTER USER error // SHOW HELP: ALTER USER
 
+// %Help: ALTER ROLE - change role properties
+// %Category: Priv
+// %Text:
+// ALTER ROLE [IF EXISTS] <name> [WITH] <option> [...]
+//
+// Options:
+//    CREATEDB | NOCREATEDB
+//    CREATEROLE | NOCREATEROLE
+// %SeeAlso: CREATE ROLE
+alter_role_stmt:
+  ALTER ROLE string_or_placeholder opt_with role_option_list
+  {
+    $$.val = &tree.AlterRole{Name: $3.expr(), Options: $5.roleOpts(), IsRole: true}
+  }
+| ALTER ROLE IF EXISTS string_or_placeholder opt_with role_option_list
+  {
+    $$.val = &tree.AlterRole{Name: $5.expr(), Options: $7.roleOpts(), IfExists: true, IsRole: true}
+  }
+| ALTER ROLE error // SHOW HELP: ALTER ROLE
+
 // %Help: ALTER DATABASE - change the definition of a database
 // %Category: DDL
 // %Text:
@@ -1423,6 +1478,25 @@ alter_table_cmd:
       PartitionBy: $1.partitionBy(),
     }
   }
+  // ALTER TABLE <name> ENABLE ROW LEVEL SECURITY
+| ENABLE ROW LEVEL SECURITY
+  {
+    $$.val = &tree.AlterTableSetRowLevelSecurity{Enable: true}
+  }
+  // ALTER TABLE <name> DISABLE ROW LEVEL SECURITY
+| DISABLE ROW LEVEL SECURITY
+  {
+    $$.val = &tree.AlterTableSetRowLevelSecurity{Enable: false}
+  }
+  // ALTER TABLE <name> EXPERIMENTAL_AUDIT SET <mode>
+| EXPERIMENTAL_AUDIT SET audit_mode
+  {
+    $$.val = &tree.AlterTableSetAudit{Mode: $3.auditMode()}
+  }
+
+audit_mode:
+  READ WRITE { $$.val = tree.AuditModeReadWrite }
+| OFF        { $$.val = tree.AuditModeDisable }
 
 alter_index_cmds:
   alter_index_cmd
@@ -1717,7 +1791,7 @@ comment_text:
 // %Text:
 // CREATE DATABASE, CREATE TABLE, CREATE INDEX, CREATE TABLE AS,
 // CREATE USER, CREATE VIEW, CREATE SEQUENCE, CREATE STATISTICS,
-// CREATE ROLE
+// CREATE ROLE, CREATE POLICY
 create_stmt:
   create_user_stmt     // EXTEND WITH HELP: CREATE USER
 | create_role_stmt     // EXTEND WITH HELP: CREATE ROLE
@@ -1734,6 +1808,91 @@ create_ddl_stmt:
 | CREATE TABLE error   // SHOW HELP: CREATE TABLE
 | create_view_stmt     // EXTEND WITH HELP: CREATE VIEW
 | create_sequence_stmt // EXTEND WITH HELP: CREATE SEQUENCE
+| create_policy_stmt   // EXTEND WITH HELP: CREATE POLICY
+
+// %Help: CREATE POLICY - define a row-level security policy
+// %Category: Priv
+// %Text:
+// CREATE POLICY <name> ON <tablename>
+//    [FOR {ALL | SELECT | INSERT | UPDATE | DELETE}]
+//    [TO <user_or_role> [, ...]]
+//    [USING ( <expr> )]
+//    [WITH CHECK ( <expr> )]
+//
+// Policies only restrict access to tables on which row-level security
+// is enabled. Rows are accessible if they satisfy any of the policies
+// that apply to the user and statement. USING filters the existing
+// rows; WITH CHECK, which defaults to USING, checks the new rows.
+// %SeeAlso: DROP POLICY, ALTER TABLE
+create_policy_stmt:
+  CREATE POLICY name ON qualified_name opt_policy_command opt_policy_roles opt_policy_using opt_policy_with_check
+  {
+    $$.val = &tree.CreatePolicy{
+      Name: tree.Name($3),
+      Table: $5.normalizableTableNameFromUnresolvedName(),
+      Command: $6.policyCommand(),
+      Roles: $7.nameList(),
+      Using: $8.expr(),
+      WithCheck: $9.expr(),
+    }
+  }
+| CREATE POLICY error // SHOW HELP: CREATE POLICY
+
+opt_policy_command:
+  FOR ALL
+  {
+    $$.val = tree.PolicyAll
+  }
+| FOR SELECT
+  {
+    $$.val = tree.PolicySelect
+  }
+| FOR INSERT
+  {
+    $$.val = tree.PolicyInsert
+  }
+| FOR UPDATE
+  {
+    $$.val = tree.PolicyUpdate
+  }
+| FOR DELETE
+  {
+    $$.val = tree.PolicyDelete
+  }
+| /* EMPTY */
+  {
+    $$.val = tree.PolicyAll
+  }
+
+opt_policy_roles:
+  TO name_list
+  {
+    $$.val = $2.nameList()
+  }
+| /* EMPTY */
+  {
+    $$.val = tree.NameList(nil)
+  }
+
+opt_policy_using:
+  USING '(' a_expr ')'
+  {
+    $$.val = $3.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = nil
+  }
+
+opt_policy_with_check:
+  WITH CHECK '(' a_expr ')'
+  {
+    $$.val = $4.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = nil
+  }
 
 // %Help: CREATE STATISTICS - create a new table statistic
 // %Category: Misc
@@ -1791,7 +1950,7 @@ discard_stmt:
 // %Category: Group
 // %Text:
 // DROP DATABASE, DROP INDEX, DROP TABLE, DROP VIEW, DROP SEQUENCE,
-// DROP USER, DROP ROLE
+// DROP USER, DROP ROLE, DROP POLICY
 drop_stmt:
   drop_ddl_stmt      // help texts in sub-rule
 | drop_role_stmt     // EXTEND WITH HELP: DROP ROLE
@@ -1804,6 +1963,22 @@ drop_ddl_stmt:
 | drop_table_stmt    // EXTEND WITH HELP: DROP TABLE
 | drop_view_stmt     // EXTEND WITH HELP: DROP VIEW
 | drop_sequence_stmt // EXTEND WITH HELP: DROP SEQUENCE
+| drop_policy_stmt   // EXTEND WITH HELP: DROP POLICY
+
+// %Help: DROP POLICY - remove a row-level security policy
+// %Category: Priv
+// %Text: DROP POLICY [IF EXISTS] <name> ON <tablename>
+// %SeeAlso: CREATE POLICY
+drop_policy_stmt:
+  DROP POLICY name ON qualified_name
+  {
+    $$.val = &tree.DropPolicy{Name: tree.Name($3), Table: $5.normalizableTableNameFromUnresolvedName()}
+  }
+| DROP POLICY IF EXISTS name ON qualified_name
+  {
+    $$.val = &tree.DropPolicy{Name: tree.Name($5), Table: $7.normalizableTableNameFromUnresolvedName(), IfExists: true}
+  }
+| DROP POLICY error // SHOW HELP: DROP POLICY
 
 // %Help: DROP VIEW - remove a view
 // %Category: DDL
@@ -1990,6 +2165,7 @@ explain_stmt:
 
 preparable_stmt:
   alter_user_stmt   // EXTEND WITH HELP: ALTER USER
+| alter_role_stmt   // EXTEND WITH HELP: ALTER ROLE
 | backup_stmt       // EXTEND WITH HELP: BACKUP
 | cancel_stmt       // help texts in sub-rule
 | create_user_stmt  // EXTEND WITH HELP: CREATE USER
@@ -2113,13 +2289,17 @@ deallocate_stmt:
 // %Category: Priv
 // %Text:
 // Grant privileges:
-//   GRANT {ALL | <privileges...> } ON <targets...> TO <grantees...>
+//   GRANT {ALL | <privileges...> } [( <columns...> )] ON <targets...> TO <grantees...>
 // Grant role membership (CCL only):
 //   GRANT <roles...> TO <grantees...> [WITH ADMIN OPTION]
 //
 // Privileges:
 //   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE
 //
+// Column privileges:
+//   SELECT, INSERT and UPDATE can also be granted on a list of
+//   columns of a single table.
+//
 // Targets:
 //   DATABASE <databasename> [, ...]
 //   [TABLE] [<databasename> .] { <tablename> | * } [, ...]
@@ -2130,6 +2310,10 @@ grant_stmt:
   {
     $$.val = &tree.Grant{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| GRANT privileges '(' name_list ')' ON targets TO name_list
+  {
+    $$.val = &tree.Grant{Privileges: $2.privilegeList(), Columns: $4.nameList(), Grantees: $9.nameList(), Targets: $7.targetList()}
+  }
 | GRANT privilege_list TO name_list
   {
     $$.val = &tree.GrantRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false}
@@ -2144,13 +2328,17 @@ grant_stmt:
 // %Category: Priv
 // %Text:
 // Revoke privileges:
-//   REVOKE {ALL | <privileges...> } ON <targets...> FROM <grantees...>
+//   REVOKE {ALL | <privileges...> } [( <columns...> )] ON <targets...> FROM <grantees...>
 // Revoke role membership (CCL only):
 //   REVOKE [ADMIN OPTION FOR] <roles...> FROM <grantees...>
 //
 // Privileges:
 //   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE
 //
+// Column privileges:
+//   SELECT, INSERT and UPDATE can also be revoked on a list of
+//   columns of a single table.
+//
 // Targets:
 //   DATABASE <databasename> [, <databasename>]...
 //   [TABLE] [<databasename> .] { <tablename> | * } [, ...]
@@ -2161,6 +2349,10 @@ revoke_stmt:
   {
     $$.val = &tree.Revoke{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| REVOKE privileges '(' name_list ')' ON targets FROM name_list
+  {
+    $$.val = &tree.Revoke{Privileges: $2.privilegeList(), Columns: $4.nameList(), Grantees: $9.nameList(), Targets: $7.targetList()}
+  }
 | REVOKE privilege_list FROM name_list
   {
     $$.val = &tree.RevokeRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false }
@@ -3636,41 +3828,84 @@ truncate_stmt:
 
 // %Help: CREATE USER - define a new user
 // %Category: Priv
-// %Text: CREATE USER [IF NOT EXISTS] <name> [ [WITH] PASSWORD <passwd> ]
-// %SeeAlso: DROP USER, SHOW USERS, WEBDOCS/create-user.html
+// %Text: CREATE USER [IF NOT EXISTS] <name> [ [WITH] <option> [...] ]
+//
+// Options:
+//    PASSWORD <password>
+//    LOGIN | NOLOGIN
+//    CREATEDB | NOCREATEDB
+//    CREATEROLE | NOCREATEROLE
+//    VALID UNTIL <timestamp>
+//    CONNECTION LIMIT <limit>
+// %SeeAlso: DROP USER, SHOW USERS, ALTER USER, WEBDOCS/create-user.html
 create_user_stmt:
-  CREATE USER string_or_placeholder opt_password
+  CREATE USER string_or_placeholder opt_role_options
   {
-    $$.val = &tree.CreateUser{Name: $3.expr(), Password: $4.expr()}
+    $$.val = &tree.CreateUser{Name: $3.expr(), Options: $4.roleOpts()}
   }
-| CREATE USER IF NOT EXISTS string_or_placeholder opt_password
+| CREATE USER IF NOT EXISTS string_or_placeholder opt_role_options
   {
-    $$.val = &tree.CreateUser{Name: $6.expr(), Password: $7.expr(), IfNotExists: true}
+    $$.val = &tree.CreateUser{Name: $6.expr(), Options: $7.roleOpts(), IfNotExists: true}
   }
 | CREATE USER error // SHOW HELP: CREATE USER
 
-opt_password:
-  opt_with PASSWORD string_or_placeholder
+opt_role_options:
+  opt_with role_option_list
   {
-    $$.val = $3.expr()
+    $$.val = $2.roleOpts()
   }
 | /* EMPTY */
   {
-    $$.val = nil
+    $$.val = tree.RoleOptions(nil)
+  }
+
+role_option_list:
+  role_option
+  {
+    $$.val = tree.RoleOptions{$1.roleOpt()}
+  }
+| role_option_list role_option
+  {
+    $$.val = append($1.roleOpts(), $2.roleOpt())
+  }
+
+role_option:
+  PASSWORD string_or_placeholder
+  {
+    $$.val = tree.RoleOption{Name: tree.RoleOptPassword, Value: $2.expr()}
+  }
+| LOGIN        { $$.val = tree.RoleOption{Name: tree.RoleOptLogin} }
+| NOLOGIN      { $$.val = tree.RoleOption{Name: tree.RoleOptNoLogin} }
+| CREATEDB     { $$.val = tree.RoleOption{Name: tree.RoleOptCreateDB} }
+| NOCREATEDB   { $$.val = tree.RoleOption{Name: tree.RoleOptNoCreateDB} }
+| CREATEROLE   { $$.val = tree.RoleOption{Name: tree.RoleOptCreateRole} }
+| NOCREATEROLE { $$.val = tree.RoleOption{Name: tree.RoleOptNoCreateRole} }
+| VALID UNTIL string_or_placeholder
+  {
+    $$.val = tree.RoleOption{Name: tree.RoleOptValidUntil, Value: $3.expr()}
+  }
+| CONNECTION LIMIT signed_iconst64
+  {
+    x := $3.int64()
+    $$.val = tree.RoleOption{Name: tree.RoleOptConnectionLimit, IntVal: &x}
   }
 
 // %Help: CREATE ROLE - define a new role
 // %Category: Priv
-// %Text: CREATE ROLE [IF NOT EXISTS] <name>
-// %SeeAlso: DROP ROLE, SHOW ROLES
+// %Text: CREATE ROLE [IF NOT EXISTS] <name> [ [WITH] <option> [...] ]
+//
+// Options:
+//    CREATEDB | NOCREATEDB
+//    CREATEROLE | NOCREATEROLE
+// %SeeAlso: DROP ROLE, SHOW ROLES, ALTER ROLE
 create_role_stmt:
-  CREATE ROLE string_or_placeholder
+  CREATE ROLE string_or_placeholder opt_role_options
   {
-    $$.val = &tree.CreateRole{Name: $3.expr()}
+    $$.val = &tree.CreateRole{Name: $3.expr(), Options: $4.roleOpts()}
   }
-| CREATE ROLE IF NOT EXISTS string_or_placeholder
+| CREATE ROLE IF NOT EXISTS string_or_placeholder opt_role_options
   {
-    $$.val = &tree.CreateRole{Name: $6.expr(), IfNotExists: true}
+    $$.val = &tree.CreateRole{Name: $6.expr(), Options: $7.roleOpts(), IfNotExists: true}
   }
 | CREATE ROLE error // SHOW HELP: CREATE ROLE
 
@@ -3819,16 +4054,6 @@ alter_rename_database_stmt:
   }
 
 // https://www.postgresql.org/docs/10/static/sql-alteruser.html
-alter_user_password_stmt:
-  ALTER USER string_or_placeholder WITH PASSWORD string_or_placeholder
-  {
-    $$.val = &tree.AlterUserSetPassword{Name: $3.expr(), Password: $6.expr()}
-  }
-| ALTER USER IF EXISTS string_or_placeholder WITH PASSWORD string_or_placeholder
-  {
-    $$.val = &tree.AlterUserSetPassword{Name: $5.expr(), Password: $8.expr(), IfExists: true}
-  }
-
 alter_rename_table_stmt:
   ALTER TABLE relation_expr RENAME TO qualified_name
   {
@@ -7239,9 +7464,12 @@ unreserved_keyword:
 | CONFIGURATION
 | CONFIGURATIONS
 | CONFIGURE
+| CONNECTION
 | CONSTRAINTS
 | COPY
 | COVERING
+| CREATEDB
+| CREATEROLE
 | CSV
 | CUBE
 | CURRENT
@@ -7252,12 +7480,15 @@ unreserved_keyword:
 | DAY
 | DEALLOCATE
 | DELETE
+| DISABLE
 | DISCARD
 | DOUBLE
 | DROP
+| ENABLE
 | ENCODING
 | EXECUTE
 | EXPERIMENTAL
+| EXPERIMENTAL_AUDIT
 | EXPERIMENTAL_FINGERPRINTS
 | EXPERIMENTAL_REPLICA
 | EXPLAIN
@@ -7290,6 +7521,7 @@ unreserved_keyword:
 | LEVEL
 | LIST
 | LOCAL
+| LOGIN
 | LOW
 | MATCH
 | MINUTE
@@ -7298,6 +7530,9 @@ unreserved_keyword:
 | NAN
 | NEXT
 | NO
+| NOCREATEDB
+| NOCREATEROLE
+| NOLOGIN
 | NORMAL
 | NO_INDEX_JOIN
 | NULLS
@@ -7316,6 +7551,7 @@ unreserved_keyword:
 | PAUSE
 | PHYSICAL
 | PLANS
+| POLICY
 | PRECEDING
 | PREPARE
 | PRIORITY
@@ -7350,6 +7586,7 @@ unreserved_keyword:
 | SCRUB
 | SEARCH
 | SECOND
+| SECURITY
 | SERIALIZABLE
 | SEQUENCE
 | SEQUENCES
@@ -7384,6 +7621,7 @@ unreserved_keyword:
 | UNBOUNDED
 | UNCOMMITTED
 | UNKNOWN
+| UNTIL
 | UPDATE
 | UPSERT
 | USE
@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/lib/pq/oid"
@@ -76,6 +77,7 @@ var pgCatalog = virtualSchema{
 		pgCatalogInheritsTable,
 		pgCatalogNamespaceTable,
 		pgCatalogOperatorTable,
+		pgCatalogPoliciesTable,
 		pgCatalogProcTable,
 		pgCatalogRangeTable,
 		pgCatalogRewriteTable,
@@ -1096,6 +1098,58 @@ CREATE TABLE pg_catalog.pg_operator (
 	},
 }
 
+var publicRoleArray = newSingletonStringArray(publicRole)
+
+// See: https://www.postgresql.org/docs/10/static/view-pg-policies.html.
+var pgCatalogPoliciesTable = virtualSchemaTable{
+	schema: `
+CREATE TABLE pg_catalog.pg_policies (
+	schemaname NAME,
+	tablename NAME,
+	policyname NAME,
+	roles STRING[],
+	cmd STRING,
+	qual STRING,
+	with_check STRING
+);
+`,
+	populate: func(ctx context.Context, p *planner, prefix string, addRow func(...tree.Datum) error) error {
+		return forEachTableDesc(ctx, p, prefix, func(db *sqlbase.DatabaseDescriptor, table *sqlbase.TableDescriptor) error {
+			for _, policy := range table.Policies {
+				roles := publicRoleArray
+				if len(policy.Roles) > 0 {
+					arr := tree.NewDArray(types.String)
+					for _, role := range policy.Roles {
+						if err := arr.Append(tree.NewDString(role)); err != nil {
+							return err
+						}
+					}
+					roles = arr
+				}
+				qual, withCheck := tree.DNull, tree.DNull
+				if policy.UsingExpr != "" {
+					qual = tree.NewDString(policy.UsingExpr)
+				}
+				if policy.WithCheckExpr != "" {
+					withCheck = tree.NewDString(policy.WithCheckExpr)
+				}
+				if err := addRow(
+					tree.NewDName(db.Name),                   // schemaname
+					tree.NewDName(table.Name),                // tablename
+					tree.NewDName(policy.Name),               // policyname
+					roles,                                    // roles
+					tree.NewDString(policy.Command.String()), // cmd
+					qual,                                     // qual
+					withCheck,                                // with_check
+				); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	},
+}
+
 func newSingletonStringArray(s string) tree.Datum {
 	return &tree.DArray{ParamTyp: types.String, Array: tree.Datums{tree.NewDString(s)}}
 }
@@ -1339,24 +1393,33 @@ CREATE TABLE pg_catalog.pg_roles (
 		// need to do the same. This shouldn't be an issue, because pg_roles doesn't
 		// include sensitive information such as password hashes.
 		h := makeOidHasher()
+		roleOptions, err := getAllRoleOptions(ctx, p)
+		if err != nil {
+			return err
+		}
 		return forEachRole(ctx, p,
 			func(username string, isRole tree.DBool) error {
 				isRoot := tree.DBool(username == security.RootUser || username == sqlbase.AdminRole)
+				opts := roleOptionsOrDefault(roleOptions, username)
+				validUntil := tree.DNull
+				if !opts.ValidUntil.IsZero() {
+					validUntil = tree.MakeDTimestampTZ(opts.ValidUntil, time.Microsecond)
+				}
 				return addRow(
-					h.UserOid(username),     // oid
-					tree.NewDName(username), // rolname
-					tree.MakeDBool(isRoot),  // rolsuper
-					tree.MakeDBool(isRole),  // rolinherit. Roles inherit by default.
-					tree.MakeDBool(isRoot),  // rolcreaterole
-					tree.MakeDBool(isRoot),  // rolcreatedb
-					tree.DBoolFalse,         // rolcatupdate
-					tree.MakeDBool(!isRole), // rolcanlogin. Only users can login.
-					tree.DBoolFalse,         // rolreplication
-					negOneVal,               // rolconnlimit
-					passwdStarString,        // rolpassword
-					tree.DNull,              // rolvaliduntil
-					tree.DBoolFalse,         // rolbypassrls
-					tree.DNull,              // rolconfig
+					h.UserOid(username),                                   // oid
+					tree.NewDName(username),                               // rolname
+					tree.MakeDBool(isRoot),                                // rolsuper
+					tree.MakeDBool(isRole),                                // rolinherit. Roles inherit by default.
+					tree.MakeDBool(isRoot || tree.DBool(opts.CreateRole)), // rolcreaterole
+					tree.MakeDBool(isRoot || tree.DBool(opts.CreateDB)),   // rolcreatedb
+					tree.DBoolFalse,                                       // rolcatupdate
+					tree.MakeDBool(!isRole && !tree.DBool(opts.NoLogin)),  // rolcanlogin. Only users can login.
+					tree.DBoolFalse,                                       // rolreplication
+					tree.NewDInt(tree.DInt(opts.ConnectionLimit)),         // rolconnlimit
+					passwdStarString,                                      // rolpassword
+					validUntil,                                            // rolvaliduntil
+					tree.DBoolFalse,                                       // rolbypassrls
+					tree.DNull,                                            // rolconfig
 				)
 			})
 	},
@@ -1483,7 +1546,7 @@ CREATE TABLE pg_catalog.pg_tables (
 				tree.MakeDBool(tree.DBool(table.IsPhysicalTable())), // hasindexes
 				tree.DBoolFalse,                                     // hasrules
 				tree.DBoolFalse,                                     // hastriggers
-				tree.DBoolFalse,                                     // rowsecurity
+				tree.MakeDBool(tree.DBool(table.RowLevelSecurity)),  // rowsecurity
 			)
 		})
 	},
@@ -1711,22 +1774,31 @@ CREATE TABLE pg_catalog.pg_user (
 `,
 	populate: func(ctx context.Context, p *planner, _ string, addRow func(...tree.Datum) error) error {
 		h := makeOidHasher()
+		roleOptions, err := getAllRoleOptions(ctx, p)
+		if err != nil {
+			return err
+		}
 		return forEachRole(ctx, p,
 			func(username string, isRole tree.DBool) error {
 				if isRole {
 					return nil
 				}
 				isRoot := tree.DBool(username == security.RootUser)
+				opts := roleOptionsOrDefault(roleOptions, username)
+				validUntil := tree.DNull
+				if !opts.ValidUntil.IsZero() {
+					validUntil = tree.MakeDTimestamp(opts.ValidUntil, time.Microsecond)
+				}
 				return addRow(
-					tree.NewDName(username), // usename
-					h.UserOid(username),     // usesysid
-					tree.MakeDBool(isRoot),  // usecreatedb
-					tree.MakeDBool(isRoot),  // usesuper
-					tree.DBoolFalse,         // userepl
-					tree.DBoolFalse,         // usebypassrls
-					passwdStarString,        // passwd
-					tree.DNull,              // valuntil
-					tree.DNull,              // useconfig
+					tree.NewDName(username),                             // usename
+					h.UserOid(username),                                 // usesysid
+					tree.MakeDBool(isRoot || tree.DBool(opts.CreateDB)), // usecreatedb
+					tree.MakeDBool(isRoot),                              // usesuper
+					tree.DBoolFalse,                                     // userepl
+					tree.DBoolFalse,                                     // usebypassrls
+					passwdStarString,                                    // passwd
+					validUntil,                                          // valuntil
+					tree.DNull,                                          // useconfig
 				)
 			})
 	},
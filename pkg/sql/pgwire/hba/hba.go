@@ -0,0 +1,301 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package hba implements host-based authentication configurations, in a
+// format modeled after PostgreSQL's pg_hba.conf.
+//
+// A configuration is a list of entries, one per line. Blank lines and text
+// following a # are ignored. Each entry takes one of the forms:
+//
+//   local  DATABASE  USER           METHOD
+//   host   DATABASE  USER  ADDRESS  METHOD
+//
+// local entries match connections over a Unix socket, host entries match
+// TCP connections. DATABASE and USER are either "all" or a comma-separated
+// list of names. ADDRESS is either "all", an IP address or a CIDR block.
+// METHOD is one of:
+//
+//   cert           require a valid client certificate
+//   password       require a password, even if a certificate is presented
+//   cert-password  use the client certificate if presented, otherwise
+//                  require a password
+//   ldap           require a password, verified by the configured LDAP
+//                  server rather than against system.users
+//   trust          allow the connection unconditionally
+//   reject         reject the connection
+//
+// The first entry matching a connection determines its authentication
+// method.
+package hba
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+)
+
+// ConnType is the type of connection an entry applies to.
+type ConnType int
+
+const (
+	// ConnLocal matches connections over a Unix socket.
+	ConnLocal ConnType = iota
+	// ConnHost matches TCP connections.
+	ConnHost
+)
+
+func (t ConnType) String() string {
+	switch t {
+	case ConnLocal:
+		return "local"
+	case ConnHost:
+		return "host"
+	default:
+		return fmt.Sprintf("ConnType(%d)", t)
+	}
+}
+
+// Method is an authentication method.
+type Method int
+
+const (
+	// MethodCertPassword authenticates with the client certificate if one is
+	// presented, and with a password otherwise. This is the behavior when no
+	// configuration is set.
+	MethodCertPassword Method = iota
+	// MethodCert requires a valid client certificate.
+	MethodCert
+	// MethodPassword requires a password.
+	MethodPassword
+	// MethodTrust allows the connection without authentication.
+	MethodTrust
+	// MethodReject rejects the connection.
+	MethodReject
+	// MethodLDAP requires a password, which is verified by an LDAP server.
+	MethodLDAP
+)
+
+var methodNames = map[Method]string{
+	MethodCertPassword: "cert-password",
+	MethodCert:         "cert",
+	MethodPassword:     "password",
+	MethodTrust:        "trust",
+	MethodReject:       "reject",
+	MethodLDAP:         "ldap",
+}
+
+func (m Method) String() string {
+	if name, ok := methodNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("Method(%d)", m)
+}
+
+// Entry is a single line of a configuration.
+type Entry struct {
+	ConnType ConnType
+	// Databases and Users are nil if the entry matches all of them.
+	Databases []string
+	Users     []string
+	// Address is nil if the entry matches all addresses, as well as for
+	// local entries.
+	Address *net.IPNet
+	Method  Method
+	// Line is the line of the configuration the entry was parsed from.
+	Line int
+}
+
+// RootEntry is implicitly the first entry of every configuration, so that an
+// erroneous configuration can never lock root out of the cluster.
+var RootEntry = Entry{
+	ConnType: ConnHost,
+	Users:    []string{security.RootUser},
+	Method:   MethodCert,
+}
+
+func (e Entry) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s %s", e.ConnType, listString(e.Databases), listString(e.Users))
+	if e.ConnType == ConnHost {
+		if e.Address == nil {
+			buf.WriteString(" all")
+		} else {
+			fmt.Fprintf(&buf, " %s", e.Address)
+		}
+	}
+	fmt.Fprintf(&buf, " %s", e.Method)
+	return buf.String()
+}
+
+func listString(l []string) string {
+	if l == nil {
+		return "all"
+	}
+	return strings.Join(l, ",")
+}
+
+// Conf is a parsed configuration.
+type Conf struct {
+	Entries []Entry
+}
+
+// Parse parses a configuration. Database and user names are used as-is:
+// callers are responsible for normalizing the names they look up.
+func Parse(input string) (*Conf, error) {
+	conf := &Conf{}
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		entry, err := parseEntry(fields)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		entry.Line = line
+		conf.Entries = append(conf.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func parseEntry(fields []string) (Entry, error) {
+	var entry Entry
+	var numFields int
+	switch fields[0] {
+	case "local":
+		entry.ConnType = ConnLocal
+		numFields = 4
+	case "host":
+		entry.ConnType = ConnHost
+		numFields = 5
+	default:
+		return Entry{}, errors.Errorf("unknown connection type %q", fields[0])
+	}
+	if len(fields) < numFields {
+		return Entry{}, errors.Errorf("expected %d fields, found %d", numFields, len(fields))
+	}
+	if len(fields) > numFields {
+		return Entry{}, errors.Errorf("unexpected authentication options %q",
+			strings.Join(fields[numFields:], " "))
+	}
+
+	entry.Databases = parseList(fields[1])
+	entry.Users = parseList(fields[2])
+	if entry.ConnType == ConnHost {
+		address, err := parseAddress(fields[3])
+		if err != nil {
+			return Entry{}, err
+		}
+		entry.Address = address
+	}
+
+	methodName := fields[numFields-1]
+	found := false
+	for m, name := range methodNames {
+		if name == methodName {
+			entry.Method = m
+			found = true
+			break
+		}
+	}
+	if !found {
+		return Entry{}, errors.Errorf("unknown authentication method %q", methodName)
+	}
+	return entry, nil
+}
+
+// parseList parses a comma-separated list of names, returning nil for "all".
+func parseList(field string) []string {
+	if field == "all" {
+		return nil
+	}
+	return strings.Split(field, ",")
+}
+
+// parseAddress parses an IP address or CIDR block, returning nil for "all".
+func parseAddress(field string) (*net.IPNet, error) {
+	if field == "all" {
+		return nil, nil
+	}
+	if strings.IndexByte(field, '/') >= 0 {
+		_, ipNet, err := net.ParseCIDR(field)
+		if err != nil {
+			return nil, errors.Errorf("invalid CIDR block %q", field)
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(field)
+	if ip == nil {
+		return nil, errors.Errorf("invalid address %q: host names are not supported", field)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 8 * net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Find returns the first entry matching a connection of the given type, for
+// the given database and user, from the given address. The address is
+// ignored for local connections. RootEntry is considered before the
+// configured entries. nil is returned if no entry matches, in which case the
+// connection must be rejected.
+func (c *Conf) Find(connType ConnType, database, user string, addr net.IP) *Entry {
+	if RootEntry.matches(connType, database, user, addr) {
+		return &RootEntry
+	}
+	for i := range c.Entries {
+		if c.Entries[i].matches(connType, database, user, addr) {
+			return &c.Entries[i]
+		}
+	}
+	return nil
+}
+
+func (e *Entry) matches(connType ConnType, database, user string, addr net.IP) bool {
+	if e.ConnType != connType || !listContains(e.Databases, database) ||
+		!listContains(e.Users, user) {
+		return false
+	}
+	return e.Address == nil || (addr != nil && e.Address.Contains(addr))
+}
+
+func listContains(l []string, s string) bool {
+	if l == nil {
+		return true
+	}
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
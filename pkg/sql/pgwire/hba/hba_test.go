@@ -0,0 +1,139 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hba
+
+import (
+	"net"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestParse(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	conf, err := Parse(`
+# Administrators may use passwords from the admin subnet only.
+host  all      admin,ops  10.1.0.0/16  password
+host  all      admin,ops  all          reject
+
+host  billing  all        192.168.0.1  trust   # the billing job
+host  all      all        10.2.0.0/16  ldap
+local all      all                     cert-password
+host  all      all        all          cert
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"host all admin,ops 10.1.0.0/16 password",
+		"host all admin,ops all reject",
+		"host billing all 192.168.0.1/32 trust",
+		"host all all 10.2.0.0/16 ldap",
+		"local all all cert-password",
+		"host all all all cert",
+	}
+	expectedLines := []int{3, 4, 6, 7, 8, 9}
+	if len(conf.Entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %v", len(expected), len(conf.Entries), conf.Entries)
+	}
+	for i, e := range conf.Entries {
+		if e.String() != expected[i] {
+			t.Errorf("%d: expected %q, got %q", i, expected[i], e.String())
+		}
+		if e.Line != expectedLines[i] {
+			t.Errorf("%d: expected line %d, got %d", i, expectedLines[i], e.Line)
+		}
+	}
+
+	if conf, err := Parse(""); err != nil || len(conf.Entries) != 0 {
+		t.Fatalf("expected empty configuration, got %v, %v", conf, err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testData := []struct {
+		input    string
+		expected string
+	}{
+		{"hostssl all all all cert", `line 1: unknown connection type "hostssl"`},
+		{"\nhost all all all", "line 2: expected 5 fields, found 4"},
+		{"host all all all cert clientcert=1", `unexpected authentication options "clientcert=1"`},
+		{"local all all all cert", `unexpected authentication options "cert"`},
+		{"host all all all md5", `unknown authentication method "md5"`},
+		{"host all all 10.0.0.0/33 cert", `invalid CIDR block "10.0.0.0/33"`},
+		{"host all all example.com cert", "host names are not supported"},
+	}
+	for _, d := range testData {
+		if _, err := Parse(d.input); !testutils.IsError(err, d.expected) {
+			t.Errorf("%q: expected error %q, got %v", d.input, d.expected, err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	conf, err := Parse(`
+host  all      admin  10.1.0.0/16  password
+host  all      admin  all          reject
+host  billing  all    192.168.0.1  trust
+host  all      all    ::1          trust
+local all      all                 password
+host  all      all    all          cert
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testData := []struct {
+		connType ConnType
+		database string
+		user     string
+		addr     string
+		expected string
+	}{
+		{ConnHost, "", "admin", "10.1.2.3", "host all admin 10.1.0.0/16 password"},
+		{ConnHost, "", "admin", "10.2.2.3", "host all admin all reject"},
+		{ConnHost, "billing", "bob", "192.168.0.1", "host billing all 192.168.0.1/32 trust"},
+		{ConnHost, "billing", "bob", "192.168.0.2", "host all all all cert"},
+		{ConnHost, "", "bob", "::1", "host all all ::1/128 trust"},
+		{ConnLocal, "billing", "bob", "", "local all all password"},
+		// root may always use its client certificate.
+		{ConnHost, "", "root", "10.2.2.3", RootEntry.String()},
+	}
+	for _, d := range testData {
+		e := conf.Find(d.connType, d.database, d.user, net.ParseIP(d.addr))
+		if e == nil {
+			t.Errorf("%+v: no entry found", d)
+		} else if e.String() != d.expected {
+			t.Errorf("%+v: expected %q, got %q", d, d.expected, e.String())
+		}
+	}
+
+	conf, err = Parse("host all admin all password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := conf.Find(ConnHost, "", "bob", net.ParseIP("10.0.0.1")); e != nil {
+		t.Errorf("expected no entry, got %q", e)
+	}
+	if e := conf.Find(ConnLocal, "", "admin", nil); e != nil {
+		t.Errorf("expected no entry, got %q", e)
+	}
+}
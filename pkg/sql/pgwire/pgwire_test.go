@@ -17,10 +17,16 @@ package pgwire_test
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
 	gosql "database/sql"
 	"database/sql/driver"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/url"
@@ -28,6 +34,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,6 +47,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/security/securitytest"
 	"github.com/cockroachdb/cockroach/pkg/server"
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
@@ -46,6 +55,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 func trivialQuery(pgURL url.URL) error {
@@ -1827,3 +1837,704 @@ func TestPGWireTooManyArguments(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestPGWireCancelRequest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	pgURL, cleanupFn := sqlutils.PGUrl(t, s.ServingAddr(), t.Name(), url.User(security.RootUser))
+	defer cleanupFn()
+
+	db, err := gosql.Open("postgres", pgURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A CancelRequest with an unknown secret key is ignored, and the server
+	// closes the connection without responding.
+	t.Run("unknown key", func(t *testing.T) {
+		conn, err := net.Dial("tcp", s.ServingAddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		var msg [16]byte
+		binary.BigEndian.PutUint32(msg[0:], uint32(len(msg)))
+		binary.BigEndian.PutUint32(msg[4:], 80877102)
+		binary.BigEndian.PutUint32(msg[8:], uint32(s.NodeID()))
+		binary.BigEndian.PutUint32(msg[12:], 12345)
+		if _, err := conn.Write(msg[:]); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := conn.Read(msg[:]); err != io.EOF {
+			t.Fatalf("expected EOF, got %d bytes and error %v", n, err)
+		}
+	})
+
+	// lib/pq sends a CancelRequest using the BackendKeyData of the connection
+	// when the context of a query is canceled.
+	t.Run("context cancellation", func(t *testing.T) {
+		const queryToCancel = "SELECT * FROM generate_series(1, 1000000000000)"
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errChan := make(chan error, 1)
+		go func() {
+			rows, err := db.QueryContext(ctx, queryToCancel)
+			if err == nil {
+				for rows.Next() {
+				}
+				err = rows.Err()
+			}
+			errChan <- err
+		}()
+
+		testutils.SucceedsSoon(t, func() error {
+			var count int
+			if err := db.QueryRow(
+				`SELECT count(*) FROM [SHOW QUERIES] WHERE query = $1`, queryToCancel,
+			).Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != 1 {
+				return errors.Errorf("expected query to be running, found %d", count)
+			}
+			return nil
+		})
+		cancel()
+		if err := <-errChan; err == nil {
+			t.Fatal("expected query to fail")
+		}
+
+		// The query must actually stop running on the server.
+		testutils.SucceedsSoon(t, func() error {
+			var count int
+			if err := db.QueryRow(
+				`SELECT count(*) FROM [SHOW QUERIES] WHERE query = $1`, queryToCancel,
+			).Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != 0 {
+				return errors.Errorf("expected query to be canceled, found %d running", count)
+			}
+			return nil
+		})
+	})
+}
+
+// scramAuth connects to addr over TLS without a client certificate and
+// authenticates as user using SCRAM-SHA-256. lib/pq does not support SCRAM,
+// so this implements the client side of the protocol.
+func scramAuth(addr, user, password string) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var sslRequest [8]byte
+	binary.BigEndian.PutUint32(sslRequest[0:], 8)
+	binary.BigEndian.PutUint32(sslRequest[4:], 80877103)
+	if _, err := conn.Write(sslRequest[:]); err != nil {
+		return err
+	}
+	var resp [1]byte
+	if _, err := io.ReadFull(conn, resp[:]); err != nil {
+		return err
+	}
+	if resp[0] != 'S' {
+		return errors.Errorf("server refused SSL: %q", resp[0])
+	}
+	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+
+	writeMsg := func(typ byte, body []byte) error {
+		var msg []byte
+		if typ != 0 {
+			msg = append(msg, typ)
+		}
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(body)+4))
+		msg = append(append(msg, length[:]...), body...)
+		_, err := tlsConn.Write(msg)
+		return err
+	}
+	// readAuth reads an Authentication message, returning its type and data.
+	readAuth := func() (int32, []byte, error) {
+		var header [5]byte
+		if _, err := io.ReadFull(tlsConn, header[:]); err != nil {
+			return 0, nil, err
+		}
+		body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+		if _, err := io.ReadFull(tlsConn, body); err != nil {
+			return 0, nil, err
+		}
+		switch header[0] {
+		case 'R':
+			return int32(binary.BigEndian.Uint32(body)), body[4:], nil
+		case 'E':
+			for _, field := range bytes.Split(body, []byte{0}) {
+				if len(field) > 0 && field[0] == 'M' {
+					return 0, nil, errors.New(string(field[1:]))
+				}
+			}
+			return 0, nil, errors.New("unknown error")
+		default:
+			return 0, nil, errors.Errorf("unexpected message %q", header[0])
+		}
+	}
+
+	var startup bytes.Buffer
+	_ = binary.Write(&startup, binary.BigEndian, uint32(196608))
+	startup.WriteString("user\x00" + user + "\x00\x00")
+	if err := writeMsg(0, startup.Bytes()); err != nil {
+		return err
+	}
+	if typ, data, err := readAuth(); err != nil {
+		return err
+	} else if typ != 10 || !bytes.Equal(data, []byte("SCRAM-SHA-256\x00\x00")) {
+		return errors.Errorf("unexpected SASL request %d: %q", typ, data)
+	}
+
+	const clientNonce = "fyko+d2lbbFgONRv9qkxdawL"
+	clientFirstBare := "n=,r=" + clientNonce
+	var initial bytes.Buffer
+	initial.WriteString("SCRAM-SHA-256\x00")
+	_ = binary.Write(&initial, binary.BigEndian, uint32(len(clientFirstBare)+3))
+	initial.WriteString("n,," + clientFirstBare)
+	if err := writeMsg('p', initial.Bytes()); err != nil {
+		return err
+	}
+	typ, serverFirst, err := readAuth()
+	if err != nil {
+		return err
+	}
+	if typ != 11 {
+		return errors.Errorf("unexpected SASL continue %d", typ)
+	}
+	var nonce, salt string
+	var iterations int
+	for _, attr := range strings.Split(string(serverFirst), ",") {
+		switch {
+		case strings.HasPrefix(attr, "r="):
+			nonce = attr[2:]
+		case strings.HasPrefix(attr, "s="):
+			salt = attr[2:]
+		case strings.HasPrefix(attr, "i="):
+			iterations, _ = strconv.Atoi(attr[2:])
+		}
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return err
+	}
+
+	// Compute the client proof as per RFC 5802.
+	hmacSum := func(key []byte, msg string) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(msg))
+		return mac.Sum(nil)
+	}
+	u := hmacSum([]byte(password), string(saltBytes)+"\x00\x00\x00\x01")
+	saltedPassword := append([]byte(nil), u...)
+	for i := 1; i < iterations; i++ {
+		u = hmacSum([]byte(password), string(u))
+		for j := range saltedPassword {
+			saltedPassword[j] ^= u[j]
+		}
+	}
+	clientKey := hmacSum(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	clientFinalWithoutProof := "c=biws,r=" + nonce
+	authMessage := clientFirstBare + "," + string(serverFirst) + "," + clientFinalWithoutProof
+	clientSignature := hmacSum(storedKey[:], authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range proof {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+	clientFinal := clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)
+	if err := writeMsg('p', []byte(clientFinal)); err != nil {
+		return err
+	}
+
+	typ, serverFinal, err := readAuth()
+	if err != nil {
+		return err
+	}
+	serverSignature := hmacSum(hmacSum(saltedPassword, "Server Key"), authMessage)
+	if typ != 12 || string(serverFinal) != "v="+base64.StdEncoding.EncodeToString(serverSignature) {
+		return errors.Errorf("unexpected SASL final %d: %q", typ, serverFinal)
+	}
+	if typ, _, err := readAuth(); err != nil {
+		return err
+	} else if typ != 0 {
+		return errors.Errorf("unexpected authentication message %d", typ)
+	}
+	return nil
+}
+
+func TestPGWireSCRAMAuth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	getHashedPassword := func(user string) []byte {
+		var hashedPassword []byte
+		if err := db.QueryRow(
+			`SELECT "hashedPassword" FROM system.users WHERE username = $1`, user,
+		).Scan(&hashedPassword); err != nil {
+			t.Fatal(err)
+		}
+		return hashedPassword
+	}
+
+	// Passwords set before SCRAM is enabled are stored as bcrypt hashes.
+	if _, err := db.Exec(`CREATE USER bcrypt_user WITH PASSWORD 'abc'`); err != nil {
+		t.Fatal(err)
+	}
+	if hashed := getHashedPassword("bcrypt_user"); security.IsSCRAMHash(hashed) {
+		t.Fatalf("expected a bcrypt hash, got %q", hashed)
+	}
+
+	sql.PasswordEncryption.Override(&s.ClusterSettings().SV, int64(security.HashSCRAMSHA256))
+
+	t.Run("scram", func(t *testing.T) {
+		if _, err := db.Exec(`CREATE USER scram_user WITH PASSWORD 'def'`); err != nil {
+			t.Fatal(err)
+		}
+		if hashed := getHashedPassword("scram_user"); !security.IsSCRAMHash(hashed) {
+			t.Fatalf("expected a SCRAM verifier, got %q", hashed)
+		}
+		if err := scramAuth(s.ServingAddr(), "scram_user", "def"); err != nil {
+			t.Fatal(err)
+		}
+		if err := scramAuth(s.ServingAddr(), "scram_user", "abc"); !testutils.IsError(err, "invalid password") {
+			t.Fatalf("expected invalid password error, got %v", err)
+		}
+	})
+
+	t.Run("upgrade", func(t *testing.T) {
+		// Logging in with the cleartext password converts the stored bcrypt
+		// hash to a SCRAM verifier.
+		host, port, err := net.SplitHostPort(s.ServingAddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pgURL := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword("bcrypt_user", "abc"),
+			Host:     net.JoinHostPort(host, port),
+			RawQuery: "sslmode=require",
+		}
+		if err := trivialQuery(pgURL); err != nil {
+			t.Fatal(err)
+		}
+		if hashed := getHashedPassword("bcrypt_user"); !security.IsSCRAMHash(hashed) {
+			t.Fatalf("expected a SCRAM verifier, got %q", hashed)
+		}
+		if err := scramAuth(s.ServingAddr(), "bcrypt_user", "abc"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// queryAfterRejection connects to addr over TLS without a client certificate
+// as user, sending password if the server asks for one, and expects the server
+// to reject the connection. It then sends a query regardless, which a client
+// ignoring the rejection could do. It returns the rejection error, or an error
+// if the connection was not rejected or if the server did anything but close
+// the connection after rejecting it.
+func queryAfterRejection(addr, user, password string) (rejection string, _ error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(timeutil.Now().Add(time.Minute)); err != nil {
+		return "", err
+	}
+
+	var sslRequest [8]byte
+	binary.BigEndian.PutUint32(sslRequest[0:], 8)
+	binary.BigEndian.PutUint32(sslRequest[4:], 80877103)
+	if _, err := conn.Write(sslRequest[:]); err != nil {
+		return "", err
+	}
+	var resp [1]byte
+	if _, err := io.ReadFull(conn, resp[:]); err != nil {
+		return "", err
+	}
+	if resp[0] != 'S' {
+		return "", errors.Errorf("server refused SSL: %q", resp[0])
+	}
+	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+
+	writeMsg := func(typ byte, body []byte) error {
+		var msg []byte
+		if typ != 0 {
+			msg = append(msg, typ)
+		}
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(body)+4))
+		msg = append(append(msg, length[:]...), body...)
+		_, err := tlsConn.Write(msg)
+		return err
+	}
+	readMsg := func() (byte, []byte, error) {
+		var header [5]byte
+		if _, err := io.ReadFull(tlsConn, header[:]); err != nil {
+			return 0, nil, err
+		}
+		body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+		if _, err := io.ReadFull(tlsConn, body); err != nil {
+			return 0, nil, err
+		}
+		return header[0], body, nil
+	}
+
+	var startup bytes.Buffer
+	_ = binary.Write(&startup, binary.BigEndian, uint32(196608))
+	startup.WriteString("user\x00" + user + "\x00\x00")
+	if err := writeMsg(0, startup.Bytes()); err != nil {
+		return "", err
+	}
+	for rejection == "" {
+		typ, body, err := readMsg()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case typ == 'R' && len(body) >= 4 && binary.BigEndian.Uint32(body) == 3:
+			// The server asks for the password in cleartext.
+			if err := writeMsg('p', []byte(password+"\x00")); err != nil {
+				return "", err
+			}
+		case typ == 'E':
+			rejection = "unknown error"
+			for _, field := range bytes.Split(body, []byte{0}) {
+				if len(field) > 0 && field[0] == 'M' {
+					rejection = string(field[1:])
+				}
+			}
+		default:
+			return "", errors.Errorf("connection was not rejected: received message %q", typ)
+		}
+	}
+
+	if err := writeMsg('Q', []byte("SELECT 1\x00")); err != nil {
+		// The server closed the connection already.
+		return rejection, nil
+	}
+	for {
+		typ, _, err := readMsg()
+		if err != nil {
+			// The server closed the connection.
+			return rejection, nil
+		}
+		if typ != 'E' {
+			return "", errors.Errorf("rejected connection %q was served: received message %q",
+				rejection, typ)
+		}
+	}
+}
+
+func TestPGWireHostBasedAuth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	if _, err := db.Exec(`CREATE USER hbauser WITH PASSWORD 'abc'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.host_based_authentication.configuration = 'host all all all md5'`,
+	); !testutils.IsError(err, `unknown authentication method "md5"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	host, port, err := net.SplitHostPort(s.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgURL := func(user, password string) url.URL {
+		u := url.User(user)
+		if password != "" {
+			u = url.UserPassword(user, password)
+		}
+		return url.URL{
+			Scheme:   "postgres",
+			User:     u,
+			Host:     net.JoinHostPort(host, port),
+			RawQuery: "sslmode=require",
+		}
+	}
+	rootPgURL, cleanupFn := sqlutils.PGUrl(t, s.ServingAddr(), t.Name(), url.User(security.RootUser))
+	defer cleanupFn()
+
+	testData := []struct {
+		conf     string
+		user     string
+		password string
+		expected string
+	}{
+		{"", "hbauser", "abc", ""},
+		{"host all hbauser all reject\nhost all all all cert-password", "hbauser", "abc",
+			"host-based authentication entry on line 1 rejects host"},
+		{"host all hbauser 10.0.0.0/8 password", "hbauser", "abc",
+			"no host-based authentication entry for host"},
+		{"host all hbauser 127.0.0.1 password\nhost all hbauser all reject", "hbauser", "abc", ""},
+		{"host all hbauser all cert", "hbauser", "abc",
+			"user hbauser must authenticate using a client certificate"},
+		{"host all hbauser all trust", "hbauser", "", ""},
+		// A rejected client can't find out whether the user exists.
+		{"host all nosuchuser all reject\nhost all all all cert-password", "nosuchuser", "abc",
+			"host-based authentication entry on line 1 rejects host"},
+	}
+	for _, d := range testData {
+		t.Run(d.conf, func(t *testing.T) {
+			if _, err := db.Exec(
+				`SET CLUSTER SETTING server.host_based_authentication.configuration = $1`, d.conf,
+			); err != nil {
+				t.Fatal(err)
+			}
+			// The configuration is applied asynchronously.
+			testutils.SucceedsSoon(t, func() error {
+				err := trivialQuery(pgURL(d.user, d.password))
+				if d.expected == "" {
+					return err
+				}
+				if !testutils.IsError(err, d.expected) {
+					return errors.Errorf("expected error %q, got %v", d.expected, err)
+				}
+				return nil
+			})
+			// root can always authenticate using its certificate.
+			if err := trivialQuery(rootPgURL); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// Rejections are recorded in the event log, asynchronously.
+	testutils.SucceedsSoon(t, func() error {
+		var count int
+		if err := db.QueryRow(
+			`SELECT count(*) FROM system.eventlog WHERE "eventType" = $1`,
+			string(sql.EventLogRejectConnection),
+		).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count == 0 {
+			return errors.New("expected rejected connection events")
+		}
+		return nil
+	})
+
+	// A client that ignores the rejection can't run queries.
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.host_based_authentication.configuration = $1`,
+		"host all hbauser all reject\nhost all all all cert-password",
+	); err != nil {
+		t.Fatal(err)
+	}
+	testutils.SucceedsSoon(t, func() error {
+		rejection, err := queryAfterRejection(s.ServingAddr(), "hbauser", "abc")
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(rejection, "rejects host") {
+			return errors.Errorf("unexpected rejection %q", rejection)
+		}
+		return nil
+	})
+}
+
+func TestPGWireLDAPAuth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The server doesn't support StartTLS.
+	ldapServer, err := securitytest.StartLDAPServer(nil /* tlsConfig */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ldapServer.Close()
+	ldapServer.SetPassword("uid=ldapuser,dc=example,dc=com", "abc")
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	// The user doesn't need a password in system.users.
+	if _, err := db.Exec(`CREATE USER ldapuser`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.ldap.url = 'http://example.com'`,
+	); !testutils.IsError(err, "scheme must be ldap or ldaps") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.ldap.bind_dn_template = 'uid=ldapuser'`,
+	); !testutils.IsError(err, "must contain {user}") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.host_based_authentication.configuration = $1`,
+		"host all ldapuser all ldap\nhost all all all cert-password",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, err := net.SplitHostPort(s.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgURL := func(password string) url.URL {
+		return url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword("ldapuser", password),
+			Host:     net.JoinHostPort(host, port),
+			RawQuery: "sslmode=require",
+		}
+	}
+	expectErr := func(password, expected string) {
+		t.Helper()
+		// Settings are applied asynchronously.
+		testutils.SucceedsSoon(t, func() error {
+			err := trivialQuery(pgURL(password))
+			if expected == "" {
+				return err
+			}
+			if !testutils.IsError(err, expected) {
+				return errors.Errorf("expected error %q, got %v", expected, err)
+			}
+			return nil
+		})
+	}
+
+	expectErr("abc", "server.ldap.url is not set")
+	// A client that ignores the error can't run queries.
+	if rejection, err := queryAfterRejection(s.ServingAddr(), "ldapuser", "abc"); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(rejection, "server.ldap.url is not set") {
+		t.Fatalf("unexpected rejection %q", rejection)
+	}
+
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.ldap.url = $1`, ldapServer.URL(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.ldap.bind_dn_template = 'uid={user},dc=example,dc=com'`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	expectErr("abc", "LDAP server refused StartTLS")
+	if binds := ldapServer.Binds(); binds != 0 {
+		t.Fatalf("expected no binds without TLS, got %d", binds)
+	}
+
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.ldap.insecure_cleartext.enabled = true`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	expectErr("abc", "")
+	expectErr("abd", "invalid password")
+
+	// Changing the password in the directory takes effect immediately.
+	ldapServer.SetPassword("uid=ldapuser,dc=example,dc=com", "def")
+	expectErr("abc", "invalid password")
+	expectErr("def", "")
+}
+
+func TestPGWireRoleOptions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	if _, err := db.Exec(`CREATE USER optuser WITH PASSWORD 'abc'`); err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, err := net.SplitHostPort(s.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword("optuser", "abc"),
+		Host:     net.JoinHostPort(host, port),
+		RawQuery: "sslmode=require",
+	}
+	expectErr := func(expected string) {
+		t.Helper()
+		err := trivialQuery(pgURL)
+		if expected == "" {
+			if err != nil {
+				t.Fatal(err)
+			}
+		} else if !testutils.IsError(err, expected) {
+			t.Fatalf("expected error %q, got %v", expected, err)
+		}
+	}
+	alterUser := func(options string) {
+		t.Helper()
+		if _, err := db.Exec(`ALTER USER optuser WITH ` + options); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectErr("")
+
+	// expectRejected verifies that a client ignoring the rejection of its
+	// connection can't run queries.
+	expectRejected := func(expected string) {
+		t.Helper()
+		rejection, err := queryAfterRejection(s.ServingAddr(), "optuser", "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(rejection, expected) {
+			t.Fatalf("expected rejection %q, got %q", expected, rejection)
+		}
+	}
+
+	alterUser(`NOLOGIN`)
+	expectErr("user optuser is not allowed to log in")
+	expectRejected("user optuser is not allowed to log in")
+	alterUser(`LOGIN`)
+	expectErr("")
+
+	alterUser(`VALID UNTIL '2000-01-01'`)
+	expectErr("password of user optuser has expired")
+	expectRejected("password of user optuser has expired")
+	alterUser(`VALID UNTIL '3000-01-01'`)
+	expectErr("")
+	alterUser(`VALID UNTIL 'infinity'`)
+	expectErr("")
+
+	alterUser(`CONNECTION LIMIT 1`)
+	conn, err := gosql.Open("postgres", pgURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	expectErr("too many connections for user optuser")
+	// The limit doesn't apply to connections of other users.
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The connection is counted until the server is done with it.
+	testutils.SucceedsSoon(t, func() error {
+		return trivialQuery(pgURL)
+	})
+}
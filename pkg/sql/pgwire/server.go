@@ -22,7 +22,11 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -59,8 +63,9 @@ var (
 )
 
 const (
-	version30  = 196608
-	versionSSL = 80877103
+	version30     = 196608
+	versionCancel = 80877102
+	versionSSL    = 80877103
 )
 
 const (
@@ -85,6 +90,61 @@ var (
 	sslUnsupported = []byte{'N'}
 )
 
+// connAuthConf is the host-based authentication configuration applied to
+// incoming SQL connections. See package hba for its format.
+var connAuthConf = settings.RegisterValidatedStringSetting(
+	"server.host_based_authentication.configuration",
+	"host-based authentication configuration to use during connection authentication; "+
+		"if empty, clients authenticate with a certificate if they present one and "+
+		"with a password otherwise",
+	"",
+	func(s string) error {
+		_, err := hba.Parse(s)
+		return err
+	},
+)
+
+// The LDAP server used to verify the passwords of connections authenticated
+// with the ldap host-based authentication method.
+var (
+	ldapURL = settings.RegisterValidatedStringSetting(
+		"server.ldap.url",
+		"URL of the LDAP server used by the ldap host-based authentication method, "+
+			"e.g. ldaps://ldap.example.com:636; ldap:// URLs use StartTLS",
+		"",
+		func(s string) error {
+			if s == "" {
+				return nil
+			}
+			return security.ValidateLDAPURL(s)
+		},
+	)
+	ldapBindDNTemplate = settings.RegisterValidatedStringSetting(
+		"server.ldap.bind_dn_template",
+		"DN to bind as to verify a user's password, in which "+security.LDAPUserPlaceholder+
+			" is replaced by the user name, e.g. uid="+security.LDAPUserPlaceholder+
+			",ou=people,dc=example,dc=com",
+		"",
+		func(s string) error {
+			if s == "" {
+				return nil
+			}
+			return security.ValidateLDAPBindDNTemplate(s)
+		},
+	)
+	ldapTimeout = settings.RegisterNonNegativeDurationSetting(
+		"server.ldap.timeout",
+		"maximum duration of a password check against the LDAP server",
+		security.DefaultLDAPTimeout,
+	)
+	ldapInsecureCleartext = settings.RegisterBoolSetting(
+		"server.ldap.insecure_cleartext.enabled",
+		"if set, passwords are sent to an ldap:// server without StartTLS, "+
+			"in cleartext (insecure)",
+		false,
+	)
+)
+
 // cancelChanMap keeps track of channels that are closed after the associated
 // cancellation function has been called and the cancellation has taken place.
 type cancelChanMap map[chan struct{}]context.CancelFunc
@@ -93,6 +153,7 @@ type cancelChanMap map[chan struct{}]context.CancelFunc
 type Server struct {
 	AmbientCtx log.AmbientContext
 	cfg        *base.Config
+	st         *cluster.Settings
 	executor   *sql.Executor
 
 	metrics ServerMetrics
@@ -105,10 +166,19 @@ type Server struct {
 		// that is closed when the connection is done.
 		connCancelMap cancelChanMap
 		draining      bool
+		// userConns is the number of authenticated connections of each user,
+		// used to enforce the CONNECTION LIMIT role option.
+		userConns map[string]int64
 	}
 
 	sqlMemoryPool mon.BytesMonitor
 	connMonitor   mon.BytesMonitor
+
+	auth struct {
+		syncutil.RWMutex
+		// conf is the parsed value of connAuthConf, or nil if it is empty.
+		conf *hba.Conf
+	}
 }
 
 // ServerMetrics is the set of metrics for the pgwire server.
@@ -149,6 +219,7 @@ var noteworthyConnMemoryUsageBytes = envutil.EnvOrDefaultInt64("COCKROACH_NOTEWO
 func MakeServer(
 	ambientCtx log.AmbientContext,
 	cfg *base.Config,
+	st *cluster.Settings,
 	executor *sql.Executor,
 	internalMemMetrics *sql.MemoryMetrics,
 	parentMemoryMonitor *mon.BytesMonitor,
@@ -157,6 +228,7 @@ func MakeServer(
 	server := &Server{
 		AmbientCtx: ambientCtx,
 		cfg:        cfg,
+		st:         st,
 		executor:   executor,
 		metrics:    makeServerMetrics(internalMemMetrics, histogramWindow),
 	}
@@ -176,11 +248,55 @@ func MakeServer(
 
 	server.mu.Lock()
 	server.mu.connCancelMap = make(cancelChanMap)
+	server.mu.userConns = make(map[string]int64)
 	server.mu.Unlock()
 
+	// Parse the authentication configuration once, when it changes, rather
+	// than for every connection.
+	updateAuthConf := func() {
+		var conf *hba.Conf
+		if val := connAuthConf.Get(&st.SV); val != "" {
+			var err error
+			if conf, err = hba.Parse(val); err != nil {
+				// The setting is validated when set, so this should only happen
+				// if the configuration format changed across versions.
+				log.Errorf(context.Background(), "invalid host-based authentication configuration: %v", err)
+				return
+			}
+		}
+		server.auth.Lock()
+		server.auth.conf = conf
+		server.auth.Unlock()
+	}
+	connAuthConf.SetOnChange(&st.SV, updateAuthConf)
+	updateAuthConf()
+
 	return server
 }
 
+// getAuthConf returns the host-based authentication configuration currently
+// in effect, or nil if none is set.
+func (s *Server) getAuthConf() *hba.Conf {
+	s.auth.RLock()
+	defer s.auth.RUnlock()
+	return s.auth.conf
+}
+
+// getLDAPAuthenticator returns the authenticator used by the ldap host-based
+// authentication method, or nil if no LDAP server is configured.
+func (s *Server) getLDAPAuthenticator() security.ExternalAuthenticator {
+	serverURL := ldapURL.Get(&s.st.SV)
+	if serverURL == "" {
+		return nil
+	}
+	return &security.LDAPAuthenticator{
+		URL:               serverURL,
+		BindDNTemplate:    ldapBindDNTemplate.Get(&s.st.SV),
+		Timeout:           ldapTimeout.Get(&s.st.SV),
+		InsecureCleartext: ldapInsecureCleartext.Get(&s.st.SV),
+	}
+}
+
 // Match returns true if rd appears to be a Postgres connection.
 func Match(rd io.Reader) bool {
 	var buf pgwirebase.ReadBuffer
@@ -192,7 +308,7 @@ func Match(rd io.Reader) bool {
 	if err != nil {
 		return false
 	}
-	return version == version30 || version == versionSSL
+	return version == version30 || version == versionSSL || version == versionCancel
 }
 
 // IsDraining returns true if the server is not currently accepting
@@ -381,6 +497,14 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 		errSSLRequired = true
 	}
 
+	if version == versionCancel {
+		// CancelRequests are served regardless of SSL and draining: the
+		// secret key is what authorizes them, and letting clients cancel
+		// their queries only helps a draining server.
+		defer conn.Close()
+		return s.handleCancel(ctx, &buf)
+	}
+
 	if version == version30 {
 		// We make a connection before anything. If there is an error
 		// parsing the connection arguments, the connection will only be
@@ -400,9 +524,17 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 		}
 
 		v3conn.sessionArgs.User = tree.Name(v3conn.sessionArgs.User).Normalize()
-		if err := v3conn.handleAuthentication(ctx, s.cfg.Insecure); err != nil {
-			return v3conn.sendError(pgerror.NewError(pgerror.CodeInvalidPasswordError, err.Error()))
+		if err := v3conn.handleAuthentication(
+			ctx, s.cfg.Insecure, s.getAuthConf(), s.getLDAPAuthenticator(),
+		); err != nil {
+			// The error was sent to the client already. Like the errors above,
+			// it is not an error of the server.
+			return nil
 		}
+		if err := s.registerUserConn(v3conn.sessionArgs.User, v3conn.connectionLimit); err != nil {
+			return v3conn.sendError(err)
+		}
+		defer s.unregisterUserConn(v3conn.sessionArgs.User)
 
 		// Reserve some memory for this connection using the server's
 		// monitor. This reduces pressure on the shared pool because the
@@ -430,3 +562,47 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 
 	return errors.Errorf("unknown protocol version %d", version)
 }
+
+// registerUserConn counts a new connection of the given user, unless the user
+// already has limit connections to this node, in which case an error is
+// returned. A negative limit means there is no limit.
+func (s *Server) registerUserConn(user string, limit int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if limit >= 0 && s.mu.userConns[user] >= limit {
+		return pgerror.NewErrorf(pgerror.CodeTooManyConnectionsError,
+			"too many connections for user %s", user)
+	}
+	s.mu.userConns[user]++
+	return nil
+}
+
+// unregisterUserConn undoes registerUserConn.
+func (s *Server) unregisterUserConn(user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mu.userConns[user]--; s.mu.userConns[user] == 0 {
+		delete(s.mu.userConns, user)
+	}
+}
+
+// handleCancel serves a CancelRequest, which carries the process ID and secret
+// key sent to a client in its BackendKeyData message. As per the protocol, the
+// client is never told whether the cancellation succeeded.
+func (s *Server) handleCancel(ctx context.Context, buf *pgwirebase.ReadBuffer) error {
+	processID, err := buf.GetUint32()
+	if err != nil {
+		return err
+	}
+	secretKey, err := buf.GetUint32()
+	if err != nil {
+		return err
+	}
+	if len(buf.Msg) > 0 {
+		return errors.Errorf("unexpected data after CancelRequest: %q", buf.Msg)
+	}
+	if _, err := s.executor.CancelQueryByKey(ctx, int32(processID), int32(secretKey)); err != nil {
+		log.VEventf(ctx, 2, "unable to serve CancelRequest for process %d: %v", processID, err)
+	}
+	return nil
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/build"
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -47,6 +48,9 @@ import (
 const (
 	authOK                int32 = 0
 	authCleartextPassword int32 = 3
+	authSASL              int32 = 10
+	authSASLContinue      int32 = 11
+	authSASLFinal         int32 = 12
 )
 
 // connResultsBufferSizeBytes refers to the size of the result set which we
@@ -124,6 +128,11 @@ type v3Conn struct {
 	sessionArgs sql.SessionArgs
 	session     *sql.Session
 
+	// connectionLimit is the maximum number of connections the authenticated
+	// user may open to this node, or -1 if there is no limit. It is set by
+	// handleAuthentication.
+	connectionLimit int64
+
 	// The logic governing these guys is hairy, and is not sufficiently
 	// specified in documentation. Consult the sources before you modify:
 	// https://github.com/postgres/postgres/blob/master/src/backend/tcop/postgres.c
@@ -206,6 +215,8 @@ func makeV3Conn(
 		metrics:       metrics,
 		executor:      executor,
 		sqlMemoryPool: sqlMemoryPool,
+
+		connectionLimit: -1,
 	}
 }
 
@@ -277,34 +288,120 @@ var statusReportParams = map[string]string{
 // name, if different from the one given initially. Note: at this
 // point the sql.Session does not exist yet! If need exists to access the
 // database to look up authentication data, use the internal executor.
-func (c *v3Conn) handleAuthentication(ctx context.Context, insecure bool) error {
+//
+// If the client cannot be authenticated, the error is sent to the client and
+// returned, and the connection must be closed.
+func (c *v3Conn) handleAuthentication(
+	ctx context.Context,
+	insecure bool,
+	authConf *hba.Conf,
+	ldapAuthenticator security.ExternalAuthenticator,
+) error {
+	// The host-based authentication configuration applies to every
+	// connection, including insecure ones, which can still be rejected. It is
+	// checked first, so that a rejected client learns nothing about the user.
+	method := hba.MethodCertPassword
+	if authConf != nil {
+		var err error
+		if method, err = c.findAuthMethod(authConf); err != nil {
+			_ = c.sendError(err)
+			return err
+		}
+	}
+
 	// Check that the requested user exists and retrieve the hashed
 	// password in case password authentication is needed.
 	exists, hashedPassword, err := sql.GetUserHashedPassword(
 		ctx, c.executor, c.metrics.internalMemMetrics, c.sessionArgs.User,
 	)
 	if err != nil {
-		return c.sendError(err)
+		_ = c.sendError(err)
+		return err
 	}
 	if !exists {
-		return c.sendError(errors.Errorf("user %s does not exist", c.sessionArgs.User))
+		err := errors.Errorf("user %s does not exist", c.sessionArgs.User)
+		_ = c.sendError(err)
+		return err
 	}
 
+	roleOptions, err := sql.GetUserRoleOptions(
+		ctx, c.executor, c.metrics.internalMemMetrics, c.sessionArgs.User,
+	)
+	if err != nil {
+		_ = c.sendError(err)
+		return err
+	}
+	if roleOptions.NoLogin {
+		err := pgerror.NewErrorf(pgerror.CodeInvalidAuthorizationSpecificationError,
+			"user %s is not allowed to log in", c.sessionArgs.User)
+		_ = c.sendError(err)
+		return err
+	}
+	c.connectionLimit = roleOptions.ConnectionLimit
+
 	if tlsConn, ok := c.conn.(*tls.Conn); ok {
 		var authenticationHook security.UserAuthHook
 
+		// cleartextPassword is set if the client sent its password.
+		var cleartextPassword string
+		// checkValidUntil is set if the password stored in system.users is
+		// used, and so must not have expired.
+		var checkValidUntil bool
+
 		tlsState := tlsConn.ConnectionState()
-		// If no certificates are provided, default to password
-		// authentication.
-		if len(tlsState.PeerCertificates) == 0 {
+		if method == hba.MethodCert && len(tlsState.PeerCertificates) == 0 {
+			err := pgerror.NewErrorf(pgerror.CodeInvalidAuthorizationSpecificationError,
+				"user %s must authenticate using a client certificate", c.sessionArgs.User)
+			_ = c.sendError(err)
+			return err
+		}
+
+		switch {
+		case method == hba.MethodTrust:
+			authenticationHook = func(string, bool) error { return nil }
+		case method == hba.MethodLDAP:
+			// The password is verified by the LDAP server, so the client
+			// must send it.
+			if ldapAuthenticator == nil {
+				err := pgerror.NewErrorf(pgerror.CodeConfigFileError,
+					"user %s must authenticate using LDAP, but server.ldap.url is not set",
+					c.sessionArgs.User)
+				_ = c.sendError(err)
+				return err
+			}
 			password, err := c.sendAuthPasswordRequest()
 			if err != nil {
-				return c.sendError(err)
+				_ = c.sendError(err)
+				return err
 			}
-			authenticationHook = security.UserAuthPasswordHook(
-				insecure, password, hashedPassword,
+			authenticationHook = security.UserAuthExternalHook(
+				ctx, insecure, ldapAuthenticator, password,
 			)
-		} else {
+		case method == hba.MethodPassword || len(tlsState.PeerCertificates) == 0:
+			// If no certificates are provided, default to password
+			// authentication.
+			checkValidUntil = true
+			if security.IsSCRAMHash(hashedPassword) {
+				// The password is stored as a SCRAM verifier: authenticate
+				// without having the client send it.
+				exchangeErr, err := c.handleSCRAMAuthentication(hashedPassword)
+				if err != nil {
+					_ = c.sendError(err)
+					return err
+				}
+				authenticationHook = security.UserAuthSCRAMHook(insecure, exchangeErr)
+			} else {
+				password, err := c.sendAuthPasswordRequest()
+				if err != nil {
+					_ = c.sendError(err)
+					return err
+				}
+				authenticationHook = security.UserAuthPasswordHook(
+					insecure, password, hashedPassword,
+				)
+				cleartextPassword = password
+			}
+		default:
 			// Normalize the username contained in the certificate.
 			tlsState.PeerCertificates[0].Subject.CommonName = tree.Name(
 				tlsState.PeerCertificates[0].Subject.CommonName,
@@ -312,12 +409,27 @@ func (c *v3Conn) handleAuthentication(ctx context.Context, insecure bool) error
 			var err error
 			authenticationHook, err = security.UserAuthCertHook(insecure, &tlsState)
 			if err != nil {
-				return c.sendError(err)
+				_ = c.sendError(err)
+				return err
 			}
 		}
 
 		if err := authenticationHook(c.sessionArgs.User, true /* public */); err != nil {
-			return c.sendError(err)
+			_ = c.sendError(err)
+			return err
+		}
+		if checkValidUntil && !roleOptions.ValidUntil.IsZero() &&
+			timeutil.Now().After(roleOptions.ValidUntil) {
+			err := pgerror.NewErrorf(pgerror.CodeInvalidPasswordError,
+				"password of user %s has expired", c.sessionArgs.User)
+			_ = c.sendError(err)
+			return err
+		}
+		if cleartextPassword != "" && !insecure {
+			sql.MaybeUpgradeUserHashedPassword(
+				ctx, c.executor, c.metrics.internalMemMetrics,
+				c.sessionArgs.User, cleartextPassword, hashedPassword,
+			)
 		}
 	}
 
@@ -326,6 +438,38 @@ func (c *v3Conn) handleAuthentication(ctx context.Context, insecure bool) error
 	return c.writeBuf.finishMsg(c.wr)
 }
 
+// findAuthMethod looks up the authentication method to use for the
+// connection in the given host-based authentication configuration. An error
+// is returned, and an event logged, if the configuration rejects the
+// connection.
+func (c *v3Conn) findAuthMethod(authConf *hba.Conf) (hba.Method, error) {
+	connType := hba.ConnHost
+	var ip net.IP
+	remoteAddr := c.conn.RemoteAddr()
+	if remoteAddr.Network() == "unix" {
+		connType = hba.ConnLocal
+	} else if host, _, err := net.SplitHostPort(remoteAddr.String()); err == nil {
+		ip = net.ParseIP(host)
+	}
+
+	user, database := c.sessionArgs.User, c.sessionArgs.Database
+	entry := authConf.Find(connType, database, user, ip)
+	var reason string
+	switch {
+	case entry == nil:
+		reason = fmt.Sprintf("no host-based authentication entry for host %s, user %s, database %s",
+			remoteAddr, user, database)
+	case entry.Method == hba.MethodReject:
+		reason = fmt.Sprintf("host-based authentication entry on line %d rejects host %s, "+
+			"user %s, database %s", entry.Line, remoteAddr, user, database)
+	default:
+		return entry.Method, nil
+	}
+
+	sql.LogRejectedConnection(c.executor, user, database, remoteAddr.String(), reason)
+	return 0, pgerror.NewError(pgerror.CodeInvalidAuthorizationSpecificationError, reason)
+}
+
 func (c *v3Conn) setupSession(ctx context.Context, reserved mon.BoundAccount) error {
 	c.session = sql.NewSession(
 		ctx, c.sessionArgs, c.executor, c.conn.RemoteAddr(), &c.metrics.SQLMemMetrics, c,
@@ -369,6 +513,16 @@ func (c *v3Conn) serve(ctx context.Context, draining func() bool, reserved mon.B
 		c.closeSession(ctx)
 	}()
 
+	// Send the client the data it needs to cancel its queries through a
+	// CancelRequest on a separate connection.
+	processID, secretKey := c.session.BackendKeyData()
+	c.writeBuf.initMsg(pgwirebase.ServerMsgBackendKeyData)
+	c.writeBuf.putInt32(processID)
+	c.writeBuf.putInt32(secretKey)
+	if err := c.writeBuf.finishMsg(c.wr); err != nil {
+		return err
+	}
+
 	// Once a session has been set up, the underlying net.Conn is switched to
 	// a conn that exits if the session's context is canceled or if the server
 	// is draining and the session does not have an ongoing transaction.
@@ -516,6 +670,101 @@ func (c *v3Conn) sendAuthPasswordRequest() (string, error) {
 	return c.readBuf.GetString()
 }
 
+// handleSCRAMAuthentication runs a SCRAM-SHA-256 exchange with the client
+// against the given stored verifier. The returned exchangeErr is set if the
+// client failed to prove knowledge of the password, in which case the
+// exchange is not completed; err is set if the exchange could not be run.
+func (c *v3Conn) handleSCRAMAuthentication(hashedPassword []byte) (exchangeErr, err error) {
+	verifier, err := security.ParseSCRAMVerifier(hashedPassword)
+	if err != nil {
+		return nil, err
+	}
+	conv := security.NewSCRAMConversation(verifier)
+
+	// AuthenticationSASL lists the supported mechanisms, terminated by an
+	// empty string.
+	c.writeBuf.initMsg(pgwirebase.ServerMsgAuth)
+	c.writeBuf.putInt32(authSASL)
+	c.writeBuf.writeTerminatedString(security.SCRAMMechanism)
+	c.writeBuf.writeTerminatedString("")
+	if err := c.writeBuf.finishMsg(c.wr); err != nil {
+		return nil, err
+	}
+	if err := c.wr.Flush(); err != nil {
+		return nil, err
+	}
+
+	// SASLInitialResponse.
+	if err := c.readSASLMessage(); err != nil {
+		return nil, err
+	}
+	mechanism, err := c.readBuf.GetString()
+	if err != nil {
+		return nil, err
+	}
+	if mechanism != security.SCRAMMechanism {
+		return nil, errors.Errorf("unsupported SASL mechanism %q", mechanism)
+	}
+	n, err := c.readBuf.GetUint32()
+	if err != nil {
+		return nil, err
+	}
+	if int32(n) < 0 {
+		return nil, errors.New("missing SASL initial response")
+	}
+	clientFirst, err := c.readBuf.GetBytes(int(n))
+	if err != nil {
+		return nil, err
+	}
+	serverFirst, err := conv.ServerFirst(clientFirst)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.sendSASLMessage(authSASLContinue, serverFirst); err != nil {
+		return nil, err
+	}
+
+	// SASLResponse.
+	if err := c.readSASLMessage(); err != nil {
+		return nil, err
+	}
+	clientFinal, err := c.readBuf.GetBytes(len(c.readBuf.Msg))
+	if err != nil {
+		return nil, err
+	}
+	serverFinal, exchangeErr := conv.ServerFinal(clientFinal)
+	if exchangeErr != nil {
+		return exchangeErr, nil
+	}
+	return nil, c.sendSASLMessage(authSASLFinal, serverFinal)
+}
+
+// readSASLMessage reads a SASLInitialResponse or SASLResponse message into
+// c.readBuf.
+func (c *v3Conn) readSASLMessage() error {
+	typ, n, err := c.readBuf.ReadTypedMsg(c.rd)
+	c.metrics.BytesInCount.Inc(int64(n))
+	if err != nil {
+		return err
+	}
+	if typ != pgwirebase.ClientMsgPassword {
+		return errors.Errorf("invalid response to authentication request: %s", typ)
+	}
+	return nil
+}
+
+// sendSASLMessage sends an AuthenticationSASLContinue or
+// AuthenticationSASLFinal message carrying the given data.
+func (c *v3Conn) sendSASLMessage(authType int32, data []byte) error {
+	c.writeBuf.initMsg(pgwirebase.ServerMsgAuth)
+	c.writeBuf.putInt32(authType)
+	c.writeBuf.write(data)
+	if err := c.writeBuf.finishMsg(c.wr); err != nil {
+		return err
+	}
+	return c.wr.Flush()
+}
+
 func (c *v3Conn) handleSimpleQuery(buf *pgwirebase.ReadBuffer) error {
 	defer c.session.FinishPlan()
 	query, err := buf.GetString()
@@ -162,6 +162,7 @@ var _ planNode = &createTableNode{}
 var _ planNode = &createViewNode{}
 var _ planNode = &createSequenceNode{}
 var _ planNode = &createStatsNode{}
+var _ planNode = &createPolicyNode{}
 var _ planNode = &delayedNode{}
 var _ planNode = &deleteNode{}
 var _ planNode = &distinctNode{}
@@ -170,6 +171,7 @@ var _ planNode = &dropIndexNode{}
 var _ planNode = &dropTableNode{}
 var _ planNode = &dropViewNode{}
 var _ planNode = &dropSequenceNode{}
+var _ planNode = &dropPolicyNode{}
 var _ planNode = &zeroNode{}
 var _ planNode = &unaryNode{}
 var _ planNode = &explainDistSQLNode{}
@@ -199,7 +201,7 @@ var _ planNode = &windowNode{}
 var _ planNode = &CreateUserNode{}
 var _ planNode = &DropUserNode{}
 
-var _ planNodeFastPath = &alterUserSetPasswordNode{}
+var _ planNodeFastPath = &AlterRoleNode{}
 var _ planNodeFastPath = &createTableNode{}
 var _ planNodeFastPath = &CreateUserNode{}
 var _ planNodeFastPath = &deleteNode{}
@@ -237,6 +239,11 @@ type planTop struct {
 
 	// plannedExecute is true if this planner has planned an EXECUTE statement.
 	plannedExecute bool
+
+	// auditEvents collects the accesses to tables with auditing enabled,
+	// which are recorded in the SQL audit log once the statement
+	// completes.
+	auditEvents []auditEvent
 }
 
 // makePlan implements the Planner interface. It populates the
@@ -543,8 +550,8 @@ func (p *planner) newPlan(
 		return p.AlterTable(ctx, n)
 	case *tree.AlterSequence:
 		return p.AlterSequence(ctx, n)
-	case *tree.AlterUserSetPassword:
-		return p.AlterUserSetPassword(ctx, n)
+	case *tree.AlterRole:
+		return p.AlterRole(ctx, n)
 	case *tree.CancelQuery:
 		return p.CancelQuery(ctx, n)
 	case *tree.CancelJob:
@@ -552,7 +559,7 @@ func (p *planner) newPlan(
 	case *tree.Scrub:
 		return p.Scrub(ctx, n)
 	case *tree.CreateDatabase:
-		return p.CreateDatabase(n)
+		return p.CreateDatabase(ctx, n)
 	case *tree.CreateIndex:
 		return p.CreateIndex(ctx, n)
 	case *tree.CreateTable:
@@ -565,6 +572,8 @@ func (p *planner) newPlan(
 		return p.CreateSequence(ctx, n)
 	case *tree.CreateStats:
 		return p.CreateStatistics(ctx, n)
+	case *tree.CreatePolicy:
+		return p.CreatePolicy(ctx, n)
 	case *tree.Deallocate:
 		return p.Deallocate(ctx, n)
 	case *tree.Delete:
@@ -581,6 +590,8 @@ func (p *planner) newPlan(
 		return p.DropView(ctx, n)
 	case *tree.DropSequence:
 		return p.DropSequence(ctx, n)
+	case *tree.DropPolicy:
+		return p.DropPolicy(ctx, n)
 	case *tree.DropUser:
 		return p.DropUser(ctx, n)
 	case *tree.Execute:
@@ -720,8 +731,8 @@ func (p *planner) doPrepare(ctx context.Context, stmt tree.Statement) (planNode,
 	p.isPreparing = true
 
 	switch n := stmt.(type) {
-	case *tree.AlterUserSetPassword:
-		return p.AlterUserSetPassword(ctx, n)
+	case *tree.AlterRole:
+		return p.AlterRole(ctx, n)
 	case *tree.CancelQuery:
 		return p.CancelQuery(ctx, n)
 	case *tree.CancelJob:
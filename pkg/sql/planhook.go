@@ -77,11 +77,24 @@ type PlanHookState interface {
 	Txn() *client.Txn
 	User() string
 	AuthorizationAccessor
-	// The role create/drop call into OSS code to reuse plan nodes.
+	// The role create/alter/drop call into OSS code to reuse plan nodes.
 	// TODO(mberhault): it would be easier to just pass a planner to plan hooks.
 	CreateUserNode(
-		ctx context.Context, nameE, passwordE tree.Expr, ifNotExists bool, isRole bool, opName string,
+		ctx context.Context,
+		nameE tree.Expr,
+		options tree.RoleOptions,
+		ifNotExists bool,
+		isRole bool,
+		opName string,
 	) (*CreateUserNode, error)
+	AlterRoleNode(
+		ctx context.Context,
+		nameE tree.Expr,
+		options tree.RoleOptions,
+		ifExists bool,
+		isRole bool,
+		opName string,
+	) (*AlterRoleNode, error)
 	DropUserNode(
 		ctx context.Context, namesE tree.Exprs, ifExists bool, isRole bool, opName string,
 	) (*DropUserNode, error)
@@ -136,6 +136,10 @@ type planner struct {
 	// initializing plans to read from a table. This should be used with care.
 	skipSelectPrivilegeChecks bool
 
+	// rowLevelSecurity holds the state used to apply row-level security
+	// policies when planning scans.
+	rowLevelSecurity rowLevelSecurityState
+
 	// autoCommit indicates whether we're planning for an implicit transaction.
 	// If autoCommit is true, the plan is allowed (but not required) to commit the
 	// transaction along with other KV operations. Committing the txn might be
@@ -17,6 +17,7 @@ package sql
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/types"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -54,7 +55,7 @@ func (p *planner) newReturningHelper(
 	r tree.ReturningClause,
 	desiredTypes []types.T,
 	tn *tree.TableName,
-	tablecols []sqlbase.ColumnDescriptor,
+	desc *sqlbase.TableDescriptor,
 ) (*returningHelper, error) {
 	rh := &returningHelper{
 		p: p,
@@ -79,10 +80,16 @@ func (p *planner) newReturningHelper(
 
 	rh.columns = make(sqlbase.ResultColumns, 0, len(rExprs))
 	rh.source = newSourceInfoForSingleTable(
-		*tn, sqlbase.ResultColumnsFromColDescs(tablecols),
+		*tn, sqlbase.ResultColumnsFromColDescs(desc.Columns),
 	)
+	// RETURNING reads the rows written by the statement. A user with
+	// SELECT privileges on some of the columns only can only return
+	// those columns.
+	if p.hasColumnPrivilegesOnly(desc, privilege.SELECT) {
+		rh.source.unreadableColumns = p.unreadableColumns(desc, desc.Columns)
+	}
 	rh.exprs = make([]tree.TypedExpr, 0, len(rExprs))
-	ivarHelper := tree.MakeIndexedVarHelper(rh, len(tablecols))
+	ivarHelper := tree.MakeIndexedVarHelper(rh, len(desc.Columns))
 	for _, target := range rExprs {
 		cols, typedExprs, _, err := p.computeRenderAllowingStars(
 			ctx, target, types.Any, multiSourceInfo{rh.source}, ivarHelper,
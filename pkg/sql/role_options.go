@@ -0,0 +1,389 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// RoleOptionValues holds the role options of a user or role, as stored in
+// system.role_options. Role options are not inherited through role
+// membership.
+type RoleOptionValues struct {
+	// NoLogin is set if the user may not open SQL connections.
+	NoLogin bool
+	// CreateDB is set if the user may create databases.
+	CreateDB bool
+	// CreateRole is set if the user may create, alter and drop users and roles.
+	CreateRole bool
+	// ValidUntil is the time after which the user's password is no longer
+	// valid, or the zero time if it never expires.
+	ValidUntil time.Time
+	// ConnectionLimit is the maximum number of concurrent connections the
+	// user may open to each node, or -1 if there is no limit.
+	ConnectionLimit int64
+}
+
+// defaultRoleOptionValues are the role options of a user or role that has no
+// rows in system.role_options.
+var defaultRoleOptionValues = RoleOptionValues{ConnectionLimit: -1}
+
+// roleOptionRows maps each role option other than PASSWORD to the row of
+// system.role_options it affects, and whether it sets or deletes the row.
+// Only the non-default state of each option is stored.
+var roleOptionRows = map[string]struct {
+	row string
+	set bool
+}{
+	tree.RoleOptLogin:           {tree.RoleOptNoLogin, false},
+	tree.RoleOptNoLogin:         {tree.RoleOptNoLogin, true},
+	tree.RoleOptCreateDB:        {tree.RoleOptCreateDB, true},
+	tree.RoleOptNoCreateDB:      {tree.RoleOptCreateDB, false},
+	tree.RoleOptCreateRole:      {tree.RoleOptCreateRole, true},
+	tree.RoleOptNoCreateRole:    {tree.RoleOptCreateRole, false},
+	tree.RoleOptValidUntil:      {tree.RoleOptValidUntil, true},
+	tree.RoleOptConnectionLimit: {tree.RoleOptConnectionLimit, true},
+}
+
+// roleOptionUpdate is a change to a row of system.role_options.
+type roleOptionUpdate struct {
+	// row is the option column of the row.
+	row string
+	// set is true if the row is written, false if it is deleted.
+	set bool
+	// value returns the value of the row, or is nil if the value is NULL.
+	value func() (string, error)
+}
+
+// getRoleOptions type checks the options of a CREATE/ALTER USER/ROLE
+// statement. It returns the PASSWORD expression, if any, and the changes to
+// make to system.role_options.
+func (p *planner) getRoleOptions(
+	opts tree.RoleOptions, isRole bool, opName string,
+) (passwordE tree.Expr, updates []roleOptionUpdate, err error) {
+	seen := make(map[string]bool)
+	for i := range opts {
+		opt := &opts[i]
+		key := opt.Name
+		if r, ok := roleOptionRows[opt.Name]; ok {
+			key = r.row
+		}
+		if seen[key] {
+			return nil, nil, pgerror.NewError(pgerror.CodeSyntaxError, "conflicting or redundant options")
+		}
+		seen[key] = true
+
+		if opt.Name == tree.RoleOptPassword {
+			if isRole {
+				return nil, nil, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+					"option %s is not supported for roles: roles cannot log in", opt.Name)
+			}
+			passwordE = opt.Value
+			continue
+		}
+
+		if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionRoleOptions) {
+			return nil, nil, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+				"option %s is not supported until the cluster is upgraded to version %s",
+				opt.Name, cluster.VersionByKey(cluster.VersionRoleOptions))
+		}
+
+		r := roleOptionRows[opt.Name]
+		u := roleOptionUpdate{row: r.row, set: r.set}
+		switch opt.Name {
+		case tree.RoleOptNoLogin:
+			if isRole {
+				// Roles never log in.
+				continue
+			}
+		case tree.RoleOptLogin, tree.RoleOptValidUntil, tree.RoleOptConnectionLimit:
+			if isRole {
+				return nil, nil, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+					"option %s is not supported for roles: roles cannot log in", opt.Name)
+			}
+			if opt.Name == tree.RoleOptValidUntil {
+				if u.value, err = p.TypeAsString(opt.Value, opName); err != nil {
+					return nil, nil, err
+				}
+			} else if opt.Name == tree.RoleOptConnectionLimit {
+				limit := *opt.IntVal
+				if limit < -1 {
+					return nil, nil, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+						"invalid connection limit %d", limit)
+				}
+				// -1 means no limit, which is the default.
+				u.set = limit != -1
+				u.value = func() (string, error) { return strconv.FormatInt(limit, 10), nil }
+			}
+		}
+		updates = append(updates, u)
+	}
+	return passwordE, updates, nil
+}
+
+// applyRoleOptionUpdates writes the given changes to system.role_options for
+// the specified user or role.
+func applyRoleOptionUpdates(
+	params runParams, opName string, username string, updates []roleOptionUpdate,
+) error {
+	internalExecutor := InternalExecutor{ExecCfg: params.extendedEvalCtx.ExecCfg}
+	for _, u := range updates {
+		set := u.set
+		var value interface{}
+		if u.value != nil && set {
+			s, err := u.value()
+			if err != nil {
+				return err
+			}
+			value = s
+			if u.row == tree.RoleOptValidUntil {
+				if strings.EqualFold(s, "infinity") {
+					// A password valid until infinity never expires.
+					set = false
+				} else {
+					ts, err := tree.ParseDTimestampTZ(
+						s, params.EvalContext().GetLocation(), time.Microsecond,
+					)
+					if err != nil {
+						return err
+					}
+					value = ts.Time.UTC().Format(time.RFC3339Nano)
+				}
+			}
+		}
+
+		var err error
+		if set {
+			_, err = internalExecutor.ExecuteStatementInTransaction(
+				params.ctx,
+				opName,
+				params.p.txn,
+				`UPSERT INTO system.role_options (username, option, value) VALUES ($1, $2, $3)`,
+				username, u.row, value,
+			)
+		} else {
+			_, err = internalExecutor.ExecuteStatementInTransaction(
+				params.ctx,
+				opName,
+				params.p.txn,
+				`DELETE FROM system.role_options WHERE username = $1 AND option = $2`,
+				username, u.row,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryRoleOptions returns the role options of the given user or role, or of
+// all users and roles if username is empty. Users and roles without any
+// stored options are omitted from the result. The planner must have been
+// created for the root user.
+func queryRoleOptions(
+	ctx context.Context, p *planner, username string,
+) (map[string]*RoleOptionValues, error) {
+	result := make(map[string]*RoleOptionValues)
+	// Until the cluster is upgraded, system.role_options may not exist, and
+	// no options can have been set.
+	if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionRoleOptions) {
+		return result, nil
+	}
+
+	query := `SELECT username, option, value FROM system.role_options`
+	var qargs []interface{}
+	if username != "" {
+		query += ` WHERE username = $1`
+		qargs = append(qargs, username)
+	}
+	rows, _ /* cols */, err := p.queryRows(ctx, query, qargs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		user := string(tree.MustBeDString(row[0]))
+		option := string(tree.MustBeDString(row[1]))
+		var value string
+		if row[2] != tree.DNull {
+			value = string(tree.MustBeDString(row[2]))
+		}
+
+		opts, ok := result[user]
+		if !ok {
+			defaults := defaultRoleOptionValues
+			opts = &defaults
+			result[user] = opts
+		}
+		switch option {
+		case tree.RoleOptNoLogin:
+			opts.NoLogin = true
+		case tree.RoleOptCreateDB:
+			opts.CreateDB = true
+		case tree.RoleOptCreateRole:
+			opts.CreateRole = true
+		case tree.RoleOptValidUntil:
+			if opts.ValidUntil, err = time.Parse(time.RFC3339Nano, value); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s for %s", option, user)
+			}
+		case tree.RoleOptConnectionLimit:
+			if opts.ConnectionLimit, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s for %s", option, user)
+			}
+		}
+	}
+	return result, nil
+}
+
+// getAllRoleOptions returns the role options of all users and roles that have
+// any.
+func getAllRoleOptions(
+	ctx context.Context, origPlanner *planner,
+) (map[string]*RoleOptionValues, error) {
+	p, cleanup := newInternalPlanner(
+		"get-all-role-options", origPlanner.txn, security.RootUser,
+		origPlanner.extendedEvalCtx.MemMetrics, origPlanner.ExecCfg(),
+	)
+	defer cleanup()
+	return queryRoleOptions(ctx, p, "" /* username */)
+}
+
+// roleOptionsOrDefault returns the role options of the given user or role in
+// the result of getAllRoleOptions.
+func roleOptionsOrDefault(all map[string]*RoleOptionValues, username string) RoleOptionValues {
+	if opts, ok := all[username]; ok {
+		return *opts
+	}
+	return defaultRoleOptionValues
+}
+
+// getRoleOptionsInTxn returns the role options of the given user or role.
+func getRoleOptionsInTxn(
+	ctx context.Context,
+	txn *client.Txn,
+	metrics *MemoryMetrics,
+	execCfg *ExecutorConfig,
+	username string,
+) (RoleOptionValues, error) {
+	p, cleanup := newInternalPlanner("get-role-options", txn, security.RootUser, metrics, execCfg)
+	defer cleanup()
+	all, err := queryRoleOptions(ctx, p, username)
+	if err != nil {
+		return RoleOptionValues{}, err
+	}
+	return roleOptionsOrDefault(all, username), nil
+}
+
+// GetUserRoleOptions returns the role options of the given user. The root
+// user has no options: it is a superuser and can't be restricted.
+func GetUserRoleOptions(
+	ctx context.Context, executor *Executor, metrics *MemoryMetrics, username string,
+) (RoleOptionValues, error) {
+	normalizedUsername := tree.Name(username).Normalize()
+	if normalizedUsername == security.RootUser {
+		return defaultRoleOptionValues, nil
+	}
+
+	var opts RoleOptionValues
+	err := executor.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var err error
+		opts, err = getRoleOptionsInTxn(ctx, txn, metrics, &executor.cfg, normalizedUsername)
+		return err
+	})
+	if err != nil {
+		return RoleOptionValues{}, errors.Wrapf(err, "error looking up user %s", normalizedUsername)
+	}
+	return opts, nil
+}
+
+// HasRoleOption implements the AuthorizationAccessor interface.
+func (p *planner) HasRoleOption(ctx context.Context, option string) (bool, error) {
+	if p.RequireSuperUser(option) == nil {
+		return true, nil
+	}
+	opts, err := getRoleOptionsInTxn(
+		ctx, p.txn, p.extendedEvalCtx.MemMetrics, p.ExecCfg(), p.SessionData().User,
+	)
+	if err != nil {
+		return false, err
+	}
+	switch option {
+	case tree.RoleOptCreateDB:
+		return opts.CreateDB, nil
+	case tree.RoleOptCreateRole:
+		return opts.CreateRole, nil
+	default:
+		return false, errors.Errorf("unsupported role option %s", option)
+	}
+}
+
+// checkCreateRolePrivilege returns nil if the session user may create,
+// alter or drop users and roles: that is if it has the given privilege on
+// system.users, or the CREATEROLE role option. viaRoleOption is set if only
+// the latter holds, in which case the user must not be allowed to modify
+// members of the admin role.
+func (p *planner) checkCreateRolePrivilege(
+	ctx context.Context, priv privilege.Kind,
+) (viaRoleOption bool, err error) {
+	tDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), &tree.TableName{DatabaseName: "system", TableName: "users"})
+	if err != nil {
+		return false, err
+	}
+	privErr := p.CheckPrivilege(tDesc, priv)
+	if privErr == nil {
+		return false, nil
+	}
+	hasCreateRole, err := p.HasRoleOption(ctx, tree.RoleOptCreateRole)
+	if err != nil {
+		return false, err
+	}
+	if !hasCreateRole {
+		return false, privErr
+	}
+	return true, nil
+}
+
+// checkNotAdminMember returns an error if the given user or role is a member
+// of the admin role. It is used to prevent users that only have the
+// CREATEROLE role option from modifying users with more privileges.
+func checkNotAdminMember(params runParams, username string) error {
+	if username == sqlbase.AdminRole || username == security.RootUser {
+		return pgerror.NewErrorf(pgerror.CodeInsufficientPrivilegeError,
+			"%s cannot be modified by %s with the CREATEROLE option", username, params.p.User())
+	}
+	memberOf, err := params.p.MemberOfWithAdminOption(params.ctx, username)
+	if err != nil {
+		return err
+	}
+	if _, ok := memberOf[sqlbase.AdminRole]; ok {
+		return pgerror.NewErrorf(pgerror.CodeInsufficientPrivilegeError,
+			"%s is a member of role %s and cannot be modified by %s with the CREATEROLE option",
+			username, sqlbase.AdminRole, params.p.User())
+	}
+	return nil
+}
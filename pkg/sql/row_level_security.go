@@ -0,0 +1,424 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/types"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// publicRole is the pseudo-role designating all users in the roles
+// of a policy.
+const publicRole = "public"
+
+// rowLevelSecurityState holds the planner state used to apply the
+// row-level security policies of the tables read by a statement.
+type rowLevelSecurityState struct {
+	// skip is set while planning queries which must see all the rows of
+	// the tables they read, e.g. to validate constraints.
+	skip bool
+
+	// targetID and targetCmd identify the table updated or deleted from
+	// by the statement being planned. The first scan of that table must
+	// also satisfy the policies for targetCmd.
+	targetID  sqlbase.ID
+	targetCmd sqlbase.TableDescriptor_Policy_Command
+}
+
+var policyCommands = [...]sqlbase.TableDescriptor_Policy_Command{
+	tree.PolicyAll:    sqlbase.TableDescriptor_Policy_ALL,
+	tree.PolicySelect: sqlbase.TableDescriptor_Policy_SELECT,
+	tree.PolicyInsert: sqlbase.TableDescriptor_Policy_INSERT,
+	tree.PolicyUpdate: sqlbase.TableDescriptor_Policy_UPDATE,
+	tree.PolicyDelete: sqlbase.TableDescriptor_Policy_DELETE,
+}
+
+type createPolicyNode struct {
+	n         *tree.CreatePolicy
+	tableDesc *sqlbase.TableDescriptor
+}
+
+// CreatePolicy creates a row-level security policy on a table.
+// Privileges: superuser.
+//   notes: postgres requires the table to be owned by the user.
+func (p *planner) CreatePolicy(ctx context.Context, n *tree.CreatePolicy) (planNode, error) {
+	if err := p.checkRowLevelSecurityVersion(); err != nil {
+		return nil, err
+	}
+	tableDesc, err := p.getPolicyTableDesc(ctx, &n.Table)
+	if err != nil {
+		return nil, err
+	}
+	return &createPolicyNode{n: n, tableDesc: tableDesc}, nil
+}
+
+func (n *createPolicyNode) startExec(params runParams) error {
+	desc := n.tableDesc
+	name := string(n.n.Name)
+	if findPolicy(desc, name) != -1 {
+		return pgerror.NewErrorf(pgerror.CodeDuplicateObjectError,
+			"policy %q for table %q already exists", name, desc.Name)
+	}
+
+	cmd := policyCommands[n.n.Command]
+	if n.n.Using == nil && n.n.WithCheck == nil {
+		return pgerror.NewErrorf(pgerror.CodeSyntaxError,
+			"a policy requires a USING or WITH CHECK expression")
+	}
+	if n.n.WithCheck != nil &&
+		(cmd == sqlbase.TableDescriptor_Policy_SELECT || cmd == sqlbase.TableDescriptor_Policy_DELETE) {
+		return pgerror.NewErrorf(pgerror.CodeSyntaxError,
+			"WITH CHECK cannot be applied to SELECT or DELETE")
+	}
+	if n.n.Using != nil && cmd == sqlbase.TableDescriptor_Policy_INSERT {
+		return pgerror.NewErrorf(pgerror.CodeSyntaxError,
+			"only WITH CHECK expression allowed for INSERT")
+	}
+
+	policy := sqlbase.TableDescriptor_Policy{Name: name, Command: cmd}
+	if len(n.n.Roles) > 0 {
+		users, err := params.p.GetAllUsersAndRoles(params.ctx)
+		if err != nil {
+			return err
+		}
+		for _, role := range n.n.Roles {
+			if _, ok := users[string(role)]; !ok && role != publicRole {
+				return errors.Errorf("user or role %s does not exist", &role)
+			}
+			policy.Roles = append(policy.Roles, string(role))
+		}
+	}
+
+	var err error
+	if n.n.Using != nil {
+		if policy.UsingExpr, err = params.p.validatePolicyExpr(desc, n.n.Using, "USING"); err != nil {
+			return err
+		}
+	}
+	if n.n.WithCheck != nil {
+		if policy.WithCheckExpr, err = params.p.validatePolicyExpr(
+			desc, n.n.WithCheck, "WITH CHECK",
+		); err != nil {
+			return err
+		}
+	}
+
+	desc.Policies = append(desc.Policies, policy)
+	return params.p.savePolicyChange(params, desc, n.n)
+}
+
+func (n *createPolicyNode) Next(runParams) (bool, error) { return false, nil }
+func (n *createPolicyNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *createPolicyNode) Close(context.Context)        {}
+
+type dropPolicyNode struct {
+	n         *tree.DropPolicy
+	tableDesc *sqlbase.TableDescriptor
+}
+
+// DropPolicy drops a row-level security policy from a table.
+// Privileges: superuser.
+//   notes: postgres requires the table to be owned by the user.
+func (p *planner) DropPolicy(ctx context.Context, n *tree.DropPolicy) (planNode, error) {
+	tableDesc, err := p.getPolicyTableDesc(ctx, &n.Table)
+	if err != nil {
+		return nil, err
+	}
+	return &dropPolicyNode{n: n, tableDesc: tableDesc}, nil
+}
+
+func (n *dropPolicyNode) startExec(params runParams) error {
+	desc := n.tableDesc
+	i := findPolicy(desc, string(n.n.Name))
+	if i == -1 {
+		if n.n.IfExists {
+			return nil
+		}
+		return pgerror.NewErrorf(pgerror.CodeUndefinedObjectError,
+			"policy %q for table %q does not exist", string(n.n.Name), desc.Name)
+	}
+	desc.Policies = append(desc.Policies[:i], desc.Policies[i+1:]...)
+	return params.p.savePolicyChange(params, desc, n.n)
+}
+
+func (n *dropPolicyNode) Next(runParams) (bool, error) { return false, nil }
+func (n *dropPolicyNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *dropPolicyNode) Close(context.Context)        {}
+
+// checkRowLevelSecurityVersion errors if the cluster version doesn't
+// support row-level security yet.
+func (p *planner) checkRowLevelSecurityVersion() error {
+	if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionRowLevelSecurity) {
+		return pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+			"row-level security is not supported until the cluster is upgraded to version %s",
+			cluster.VersionByKey(cluster.VersionRowLevelSecurity))
+	}
+	return nil
+}
+
+// getPolicyTableDesc returns the descriptor of the table the policy
+// designated by a CREATE or DROP POLICY statement is defined on. Only
+// superusers may change policies: a user who can change the schema of a
+// table must not be able to lift the restrictions on its rows.
+func (p *planner) getPolicyTableDesc(
+	ctx context.Context, name *tree.NormalizableTableName,
+) (*sqlbase.TableDescriptor, error) {
+	if err := p.RequireSuperUser("create or drop policies"); err != nil {
+		return nil, err
+	}
+	tn, err := name.NormalizeWithDatabaseName(p.SessionData().Database)
+	if err != nil {
+		return nil, err
+	}
+	tableDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), tn)
+	if err != nil {
+		return nil, err
+	}
+	if tableDesc == nil {
+		return nil, sqlbase.NewUndefinedRelationError(tn)
+	}
+	if !tableDesc.IsTable() {
+		return nil, pgerror.NewErrorf(pgerror.CodeWrongObjectTypeError,
+			"%q is not a table", tn.Table())
+	}
+	return tableDesc, nil
+}
+
+// savePolicyChange writes a table descriptor whose policies were
+// modified by the given statement.
+func (p *planner) savePolicyChange(
+	params runParams, desc *sqlbase.TableDescriptor, stmt tree.Statement,
+) error {
+	if err := desc.SetUpVersion(); err != nil {
+		return err
+	}
+	if err := p.writeTableDesc(params.ctx, desc); err != nil {
+		return err
+	}
+	if err := MakeEventLogger(params.extendedEvalCtx.ExecCfg).InsertEventRecord(
+		params.ctx,
+		p.txn,
+		EventLogAlterTable,
+		int32(desc.ID),
+		int32(params.extendedEvalCtx.NodeID),
+		struct {
+			TableName string
+			Statement string
+			User      string
+		}{desc.Name, stmt.String(), params.SessionData().User},
+	); err != nil {
+		return err
+	}
+	p.notifySchemaChange(desc, sqlbase.InvalidMutationID)
+	return nil
+}
+
+// findPolicy returns the index of the policy with the given name in
+// desc.Policies, or -1 if there is none.
+func findPolicy(desc *sqlbase.TableDescriptor, name string) int {
+	for i := range desc.Policies {
+		if desc.Policies[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// validatePolicyExpr checks that expr is a valid boolean expression
+// over the columns of desc and returns its serialized form.
+func (p *planner) validatePolicyExpr(
+	desc *sqlbase.TableDescriptor, expr tree.Expr, clause string,
+) (string, error) {
+	if _, err := tree.SimpleVisit(expr, func(expr tree.Expr) (err error, recurse bool, newExpr tree.Expr) {
+		if _, ok := expr.(*tree.Subquery); ok {
+			return pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+				"subqueries are not allowed in policy %s expressions", clause), false, expr
+		}
+		return nil, true, expr
+	}); err != nil {
+		return "", err
+	}
+
+	replaced, err := replaceVars(*desc, expr)
+	if err != nil {
+		return "", err
+	}
+	if err := p.txCtx.AssertNoAggregationOrWindowing(
+		replaced, "POLICY expressions", p.SessionData().SearchPath,
+	); err != nil {
+		return "", err
+	}
+	if _, err := sqlbase.SanitizeVarFreeExpr(
+		replaced, types.Bool, "POLICY", &p.semaCtx, p.EvalContext(),
+	); err != nil {
+		return "", err
+	}
+	return tree.Serialize(expr), nil
+}
+
+// bypassRowLevelSecurity returns true if the rows of desc accessed by
+// the current statement are not restricted by its policies, either
+// because row-level security is not enabled on the table or because
+// the session user is a super-user.
+func (p *planner) bypassRowLevelSecurity(desc *sqlbase.TableDescriptor) bool {
+	return !desc.RowLevelSecurity || p.rowLevelSecurity.skip ||
+		p.RequireSuperUser("bypass row-level security") == nil
+}
+
+// applicablePolicies returns the policies of desc which apply to the
+// session user for the given command.
+func (p *planner) applicablePolicies(
+	ctx context.Context, desc *sqlbase.TableDescriptor, cmd sqlbase.TableDescriptor_Policy_Command,
+) ([]*sqlbase.TableDescriptor_Policy, error) {
+	user := p.SessionData().User
+	// The roles of the user are only looked up if needed.
+	var memberOf map[string]bool
+	var res []*sqlbase.TableDescriptor_Policy
+	for i := range desc.Policies {
+		policy := &desc.Policies[i]
+		if policy.Command != sqlbase.TableDescriptor_Policy_ALL && policy.Command != cmd {
+			continue
+		}
+		applies := len(policy.Roles) == 0
+		for _, role := range policy.Roles {
+			if role == publicRole || role == user {
+				applies = true
+				break
+			}
+		}
+		if !applies {
+			if memberOf == nil {
+				var err error
+				if memberOf, err = p.MemberOfWithAdminOption(ctx, user); err != nil {
+					return nil, err
+				}
+			}
+			for _, role := range policy.Roles {
+				if _, ok := memberOf[role]; ok {
+					applies = true
+					break
+				}
+			}
+		}
+		if applies {
+			res = append(res, policy)
+		}
+	}
+	return res, nil
+}
+
+// policyUsingExpr returns the expression the existing rows of desc
+// must satisfy to be accessed by the given command. Rows are visible
+// if they satisfy the USING expression of any applicable policy, and
+// none are if no policy applies.
+func (p *planner) policyUsingExpr(
+	ctx context.Context, desc *sqlbase.TableDescriptor, cmd sqlbase.TableDescriptor_Policy_Command,
+) (tree.Expr, error) {
+	policies, err := p.applicablePolicies(ctx, desc, cmd)
+	if err != nil {
+		return nil, err
+	}
+	var exprs []string
+	for _, policy := range policies {
+		if policy.UsingExpr != "" {
+			exprs = append(exprs, policy.UsingExpr)
+		}
+	}
+	return orPolicyExprs(exprs)
+}
+
+// policyCheckExpr returns the expression the new rows of desc must
+// satisfy to be written by the given command. The WITH CHECK
+// expression of a policy defaults to its USING expression.
+func (p *planner) policyCheckExpr(
+	ctx context.Context, desc *sqlbase.TableDescriptor, cmd sqlbase.TableDescriptor_Policy_Command,
+) (tree.Expr, error) {
+	policies, err := p.applicablePolicies(ctx, desc, cmd)
+	if err != nil {
+		return nil, err
+	}
+	var exprs []string
+	for _, policy := range policies {
+		if policy.WithCheckExpr != "" {
+			exprs = append(exprs, policy.WithCheckExpr)
+		} else if policy.UsingExpr != "" {
+			exprs = append(exprs, policy.UsingExpr)
+		}
+	}
+	return orPolicyExprs(exprs)
+}
+
+// orPolicyExprs parses the given policy expressions and combines them
+// with OR. It returns false if there are none.
+func orPolicyExprs(exprStrs []string) (tree.Expr, error) {
+	if len(exprStrs) == 0 {
+		return tree.DBoolFalse, nil
+	}
+	exprs, err := parser.ParseExprs(exprStrs)
+	if err != nil {
+		return nil, err
+	}
+	var res tree.Expr
+	for _, expr := range exprs {
+		expr = &tree.ParenExpr{Expr: expr}
+		if res == nil {
+			res = expr
+		} else {
+			res = &tree.OrExpr{Left: res, Right: expr}
+		}
+	}
+	return res, nil
+}
+
+// addRowLevelSecurityFilter restricts the rows of desc produced by the
+// scan of a data source to those allowed by its policies.
+func (p *planner) addRowLevelSecurityFilter(
+	ctx context.Context, ds *planDataSource, desc *sqlbase.TableDescriptor, tn *tree.TableName,
+) error {
+	filter, err := p.policyUsingExpr(ctx, desc, sqlbase.TableDescriptor_Policy_SELECT)
+	if err != nil {
+		return err
+	}
+	if p.rowLevelSecurity.targetID == desc.ID {
+		cmd := p.rowLevelSecurity.targetCmd
+		p.rowLevelSecurity.targetID = sqlbase.InvalidID
+		cmdFilter, err := p.policyUsingExpr(ctx, desc, cmd)
+		if err != nil {
+			return err
+		}
+		filter = &tree.AndExpr{Left: filter, Right: cmdFilter}
+	}
+
+	f := &filterNode{source: *ds}
+	f.ivarHelper = tree.MakeIndexedVarHelper(f, len(ds.info.sourceColumns))
+	// The policies may refer to columns the user cannot read, so they
+	// are resolved against a source without column restrictions.
+	info := newSourceInfoForSingleTable(*tn, ds.info.sourceColumns)
+	f.filter, err = p.analyzeExpr(ctx, filter, multiSourceInfo{info}, f.ivarHelper,
+		types.Bool, true, "POLICY")
+	if err != nil {
+		return err
+	}
+	ds.plan = f
+	return nil
+}
@@ -38,6 +38,9 @@ type nameResolutionVisitor struct {
 	sources    multiSourceInfo
 	iVarHelper tree.IndexedVarHelper
 	searchPath sessiondata.SearchPath
+	// user is the user whose column privileges are checked for each
+	// resolved column.
+	user string
 
 	// foundDependentVars is set to true during the analysis if an
 	// expression was found which can change values between rows of the
@@ -81,7 +84,7 @@ func (v *nameResolutionVisitor) VisitPre(expr tree.Expr) (recurse bool, newNode
 		//    SELECT (kv.*) FROM kv               -> SELECT (k, v) FROM kv
 		//    SELECT COUNT(DISTINCT kv.*) FROM kv -> SELECT COUNT(DISTINCT (k, v)) FROM kv
 		//
-		_, exprs, err := v.sources[0].expandStar(t, v.iVarHelper)
+		_, exprs, err := v.sources[0].expandStar(t, v.iVarHelper, v.user)
 		if err != nil {
 			v.err = err
 			return false, expr
@@ -97,6 +100,13 @@ func (v *nameResolutionVisitor) VisitPre(expr tree.Expr) (recurse bool, newNode
 		return false, makeUntypedTuple(exprs)
 
 	case *tree.IndexedVar:
+		if !t.Used {
+			// An ordinal reference designates a column without naming
+			// it; check that the user can read it all the same.
+			if v.err = v.checkOrdinalReadable(t.Idx); v.err != nil {
+				return false, expr
+			}
+		}
 		// If the indexed var is a standalone ordinal reference, ensure it
 		// becomes a fully bound indexed var.
 		t, v.err = v.iVarHelper.BindIfUnbound(t)
@@ -121,6 +131,14 @@ func (v *nameResolutionVisitor) VisitPre(expr tree.Expr) (recurse bool, newNode
 			v.err = err
 			return false, expr
 		}
+		// The columns fetched on behalf of UPDATE and DELETE are not
+		// read by the user.
+		if !t.ForUpdateOrDelete {
+			if err := v.sources[srcIdx].checkColumnReadable(v.user, colIdx); err != nil {
+				v.err = err
+				return false, expr
+			}
+		}
 		ivar := v.iVarHelper.IndexedVar(v.sources[srcIdx].colOffset + colIdx)
 		v.foundDependentVars = true
 		return true, ivar
@@ -200,6 +218,17 @@ func (v *nameResolutionVisitor) VisitPre(expr tree.Expr) (recurse bool, newNode
 
 func (*nameResolutionVisitor) VisitPost(expr tree.Expr) tree.Expr { return expr }
 
+// checkOrdinalReadable checks that the user can read the column
+// designated by the given ordinal reference.
+func (v *nameResolutionVisitor) checkOrdinalReadable(idx int) error {
+	for _, src := range v.sources {
+		if idx >= src.colOffset && idx < src.colOffset+len(src.sourceColumns) {
+			return src.checkColumnReadable(v.user, idx-src.colOffset)
+		}
+	}
+	return nil
+}
+
 // resolveNamesForRender resolves the names in expr using the naming
 // context of the given renderNode (FROM clause).
 func (p *planner) resolveNamesForRender(
@@ -226,6 +255,7 @@ func (p *planner) resolveNames(
 		sources:            sources,
 		iVarHelper:         ivarHelper,
 		searchPath:         p.SessionData().SearchPath,
+		user:               p.SessionData().User,
 		foundDependentVars: false,
 	}
 	colOffset := 0
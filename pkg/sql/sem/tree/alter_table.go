@@ -52,14 +52,16 @@ type AlterTableCmd interface {
 	alterTableCmd()
 }
 
-func (*AlterTableAddColumn) alterTableCmd()          {}
-func (*AlterTableAddConstraint) alterTableCmd()      {}
-func (*AlterTableDropColumn) alterTableCmd()         {}
-func (*AlterTableDropConstraint) alterTableCmd()     {}
-func (*AlterTableDropNotNull) alterTableCmd()        {}
-func (*AlterTableSetDefault) alterTableCmd()         {}
-func (*AlterTableValidateConstraint) alterTableCmd() {}
-func (*AlterTablePartitionBy) alterTableCmd()        {}
+func (*AlterTableAddColumn) alterTableCmd()           {}
+func (*AlterTableAddConstraint) alterTableCmd()       {}
+func (*AlterTableDropColumn) alterTableCmd()          {}
+func (*AlterTableDropConstraint) alterTableCmd()      {}
+func (*AlterTableDropNotNull) alterTableCmd()         {}
+func (*AlterTableSetDefault) alterTableCmd()          {}
+func (*AlterTableValidateConstraint) alterTableCmd()  {}
+func (*AlterTablePartitionBy) alterTableCmd()         {}
+func (*AlterTableSetRowLevelSecurity) alterTableCmd() {}
+func (*AlterTableSetAudit) alterTableCmd()            {}
 
 var _ AlterTableCmd = &AlterTableAddColumn{}
 var _ AlterTableCmd = &AlterTableAddConstraint{}
@@ -69,6 +71,8 @@ var _ AlterTableCmd = &AlterTableDropNotNull{}
 var _ AlterTableCmd = &AlterTableSetDefault{}
 var _ AlterTableCmd = &AlterTableValidateConstraint{}
 var _ AlterTableCmd = &AlterTablePartitionBy{}
+var _ AlterTableCmd = &AlterTableSetRowLevelSecurity{}
+var _ AlterTableCmd = &AlterTableSetAudit{}
 
 // ColumnMutationCmd is the subset of AlterTableCmds that modify an
 // existing column.
@@ -234,3 +238,49 @@ type AlterTablePartitionBy struct {
 func (node *AlterTablePartitionBy) Format(ctx *FmtCtx) {
 	ctx.FormatNode(node.PartitionBy)
 }
+
+// AlterTableSetRowLevelSecurity represents an ALTER TABLE ENABLE ROW LEVEL
+// SECURITY or DISABLE ROW LEVEL SECURITY command.
+type AlterTableSetRowLevelSecurity struct {
+	Enable bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterTableSetRowLevelSecurity) Format(ctx *FmtCtx) {
+	if node.Enable {
+		ctx.WriteString(" ENABLE ROW LEVEL SECURITY")
+	} else {
+		ctx.WriteString(" DISABLE ROW LEVEL SECURITY")
+	}
+}
+
+// AuditMode represents a table audit mode.
+type AuditMode int
+
+const (
+	// AuditModeDisable is the default mode - no audit.
+	AuditModeDisable AuditMode = iota
+	// AuditModeReadWrite enables audit on read or write statements.
+	AuditModeReadWrite
+)
+
+var auditModeName = [...]string{
+	AuditModeDisable:   "OFF",
+	AuditModeReadWrite: "READ WRITE",
+}
+
+func (m AuditMode) String() string {
+	return auditModeName[m]
+}
+
+// AlterTableSetAudit represents an ALTER TABLE EXPERIMENTAL_AUDIT SET
+// command.
+type AlterTableSetAudit struct {
+	Mode AuditMode
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterTableSetAudit) Format(ctx *FmtCtx) {
+	ctx.WriteString(" EXPERIMENTAL_AUDIT SET ")
+	ctx.WriteString(node.Mode.String())
+}
@@ -943,18 +943,63 @@ const (
 	SeqOptStart     = "START"
 )
 
+// Names of options on CREATE USER, CREATE ROLE, ALTER USER and ALTER ROLE.
+const (
+	RoleOptPassword        = "PASSWORD"
+	RoleOptLogin           = "LOGIN"
+	RoleOptNoLogin         = "NOLOGIN"
+	RoleOptCreateDB        = "CREATEDB"
+	RoleOptNoCreateDB      = "NOCREATEDB"
+	RoleOptCreateRole      = "CREATEROLE"
+	RoleOptNoCreateRole    = "NOCREATEROLE"
+	RoleOptValidUntil      = "VALID UNTIL"
+	RoleOptConnectionLimit = "CONNECTION LIMIT"
+)
+
+// RoleOption represents an option on a CREATE USER, CREATE ROLE, ALTER USER
+// or ALTER ROLE statement.
+type RoleOption struct {
+	Name string
+
+	// Value is set for PASSWORD and VALID UNTIL.
+	Value Expr
+	// IntVal is set for CONNECTION LIMIT.
+	IntVal *int64
+}
+
+// RoleOptions represents a list of role options.
+type RoleOptions []RoleOption
+
+// Format implements the NodeFormatter interface.
+func (node *RoleOptions) Format(ctx *FmtCtx) {
+	for i := range *node {
+		option := &(*node)[i]
+		ctx.WriteByte(' ')
+		ctx.WriteString(option.Name)
+		switch option.Name {
+		case RoleOptPassword:
+			ctx.WriteByte(' ')
+			if ctx.flags.HasFlags(FmtShowPasswords) {
+				ctx.FormatNode(option.Value)
+			} else {
+				ctx.WriteString("*****")
+			}
+		case RoleOptValidUntil:
+			ctx.WriteByte(' ')
+			ctx.FormatNode(option.Value)
+		case RoleOptConnectionLimit:
+			ctx.Printf(" %d", *option.IntVal)
+		}
+	}
+}
+
 // CreateUser represents a CREATE USER statement.
 type CreateUser struct {
 	Name        Expr
-	Password    Expr // nil if no password specified
+	Options     RoleOptions
 	IfNotExists bool
 }
 
-// HasPassword returns if the CreateUser has a password.
-func (node *CreateUser) HasPassword() bool {
-	return node.Password != nil
-}
-
 // Format implements the NodeFormatter interface.
 func (node *CreateUser) Format(ctx *FmtCtx) {
 	ctx.WriteString("CREATE USER ")
@@ -962,41 +1007,40 @@ func (node *CreateUser) Format(ctx *FmtCtx) {
 		ctx.WriteString("IF NOT EXISTS ")
 	}
 	ctx.FormatNode(node.Name)
-	if node.HasPassword() {
-		ctx.WriteString(" WITH PASSWORD ")
-		if ctx.flags.HasFlags(FmtShowPasswords) {
-			ctx.FormatNode(node.Password)
-		} else {
-			ctx.WriteString("*****")
-		}
+	if len(node.Options) > 0 {
+		ctx.WriteString(" WITH")
+		ctx.FormatNode(&node.Options)
 	}
 }
 
-// AlterUserSetPassword represents an ALTER USER ... WITH PASSWORD statement.
-type AlterUserSetPassword struct {
+// AlterRole represents an ALTER USER ... WITH or ALTER ROLE ... WITH
+// statement.
+type AlterRole struct {
 	Name     Expr
-	Password Expr
+	Options  RoleOptions
 	IfExists bool
+	IsRole   bool
 }
 
 // Format implements the NodeFormatter interface.
-func (node *AlterUserSetPassword) Format(ctx *FmtCtx) {
-	ctx.WriteString("ALTER USER ")
+func (node *AlterRole) Format(ctx *FmtCtx) {
+	if node.IsRole {
+		ctx.WriteString("ALTER ROLE ")
+	} else {
+		ctx.WriteString("ALTER USER ")
+	}
 	if node.IfExists {
 		ctx.WriteString("IF EXISTS ")
 	}
 	ctx.FormatNode(node.Name)
-	ctx.WriteString(" WITH PASSWORD ")
-	if ctx.flags.HasFlags(FmtShowPasswords) {
-		ctx.FormatNode(node.Password)
-	} else {
-		ctx.WriteString("*****")
-	}
+	ctx.WriteString(" WITH")
+	ctx.FormatNode(&node.Options)
 }
 
 // CreateRole represents a CREATE ROLE statement.
 type CreateRole struct {
 	Name        Expr
+	Options     RoleOptions
 	IfNotExists bool
 }
 
@@ -1007,6 +1051,10 @@ func (node *CreateRole) Format(ctx *FmtCtx) {
 		ctx.WriteString("IF NOT EXISTS ")
 	}
 	ctx.FormatNode(node.Name)
+	if len(node.Options) > 0 {
+		ctx.WriteString(" WITH")
+		ctx.FormatNode(&node.Options)
+	}
 }
 
 // CreateView represents a CREATE VIEW statement.
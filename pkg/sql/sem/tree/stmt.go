@@ -182,12 +182,17 @@ func (*AlterSequence) StatementType() StatementType { return DDL }
 func (*AlterSequence) StatementTag() string { return "ALTER SEQUENCE" }
 
 // StatementType implements the Statement interface.
-func (*AlterUserSetPassword) StatementType() StatementType { return RowsAffected }
+func (*AlterRole) StatementType() StatementType { return RowsAffected }
 
 // StatementTag returns a short string identifying the type of statement.
-func (*AlterUserSetPassword) StatementTag() string { return "ALTER USER" }
+func (n *AlterRole) StatementTag() string {
+	if n.IsRole {
+		return "ALTER ROLE"
+	}
+	return "ALTER USER"
+}
 
-func (*AlterUserSetPassword) hiddenFromShowQueries() {}
+func (*AlterRole) hiddenFromShowQueries() {}
 
 // StatementType implements the Statement interface.
 func (*Backup) StatementType() StatementType { return Rows }
@@ -270,6 +275,12 @@ func (*CreateUser) StatementTag() string { return "CREATE USER" }
 
 func (*CreateUser) hiddenFromShowQueries() {}
 
+// StatementType implements the Statement interface.
+func (*CreatePolicy) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CreatePolicy) StatementTag() string { return "CREATE POLICY" }
+
 // StatementType implements the Statement interface.
 func (*CreateRole) StatementType() StatementType { return RowsAffected }
 
@@ -346,6 +357,12 @@ func (*DropView) StatementType() StatementType { return DDL }
 // StatementTag returns a short string identifying the type of statement.
 func (*DropView) StatementTag() string { return "DROP VIEW" }
 
+// StatementType implements the Statement interface.
+func (*DropPolicy) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*DropPolicy) StatementTag() string { return "DROP POLICY" }
+
 // StatementType implements the Statement interface.
 func (*DropSequence) StatementType() StatementType { return DDL }
 
@@ -843,7 +860,7 @@ func (n *AlterTableDropColumn) String() string      { return AsString(n) }
 func (n *AlterTableDropConstraint) String() string  { return AsString(n) }
 func (n *AlterTableDropNotNull) String() string     { return AsString(n) }
 func (n *AlterTableSetDefault) String() string      { return AsString(n) }
-func (n *AlterUserSetPassword) String() string      { return AsString(n) }
+func (n *AlterRole) String() string                 { return AsString(n) }
 func (n *AlterSequence) String() string             { return AsString(n) }
 func (n *Backup) String() string                    { return AsString(n) }
 func (n *BeginTransaction) String() string          { return AsString(n) }
@@ -853,6 +870,7 @@ func (n *CommitTransaction) String() string         { return AsString(n) }
 func (n *CopyFrom) String() string                  { return AsString(n) }
 func (n *CreateDatabase) String() string            { return AsString(n) }
 func (n *CreateIndex) String() string               { return AsString(n) }
+func (n *CreatePolicy) String() string              { return AsString(n) }
 func (n *CreateRole) String() string                { return AsString(n) }
 func (n *CreateTable) String() string               { return AsString(n) }
 func (n *CreateSequence) String() string            { return AsString(n) }
@@ -863,6 +881,7 @@ func (n *Deallocate) String() string                { return AsString(n) }
 func (n *Delete) String() string                    { return AsString(n) }
 func (n *DropDatabase) String() string              { return AsString(n) }
 func (n *DropIndex) String() string                 { return AsString(n) }
+func (n *DropPolicy) String() string                { return AsString(n) }
 func (n *DropRole) String() string                  { return AsString(n) }
 func (n *DropTable) String() string                 { return AsString(n) }
 func (n *DropView) String() string                  { return AsString(n) }
@@ -17,6 +17,8 @@ package sql
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"net"
 	"regexp"
@@ -153,6 +155,11 @@ type Session struct {
 	// ClientAddr is the client's IP address and port.
 	ClientAddr string
 
+	// secretKey is the key a client must present in a pgwire CancelRequest
+	// to cancel this session's queries. It is assigned by the
+	// SessionRegistry and is unique among the sessions on this node.
+	secretKey int32
+
 	//
 	// State structures for the logical SQL session.
 	//
@@ -310,26 +317,51 @@ type SessionArgs struct {
 type SessionRegistry struct {
 	syncutil.Mutex
 	store map[*Session]struct{}
+	// secretKeys maps the pgwire cancellation secret key of each registered
+	// session to that session.
+	secretKeys map[int32]*Session
 }
 
 // MakeSessionRegistry creates a new SessionRegistry with an empty set
 // of sessions.
 func MakeSessionRegistry() *SessionRegistry {
-	return &SessionRegistry{store: make(map[*Session]struct{})}
+	return &SessionRegistry{
+		store:      make(map[*Session]struct{}),
+		secretKeys: make(map[int32]*Session),
+	}
 }
 
 func (r *SessionRegistry) register(s *Session) {
 	r.Lock()
 	r.store[s] = struct{}{}
+	for {
+		key := makeSecretKey()
+		if _, ok := r.secretKeys[key]; key != 0 && !ok {
+			s.secretKey = key
+			r.secretKeys[key] = s
+			break
+		}
+	}
 	r.Unlock()
 }
 
 func (r *SessionRegistry) deregister(s *Session) {
 	r.Lock()
 	delete(r.store, s)
+	delete(r.secretKeys, s.secretKey)
 	r.Unlock()
 }
 
+// makeSecretKey returns a random pgwire cancellation secret key. The key is
+// the only thing authorizing a CancelRequest, so it must not be guessable.
+func makeSecretKey() int32 {
+	var buf [4]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(errors.Wrap(err, "failed to generate secret key"))
+	}
+	return int32(binary.BigEndian.Uint32(buf[:]))
+}
+
 // CancelQuery looks up the associated query in the session registry and cancels it.
 func (r *SessionRegistry) CancelQuery(queryIDStr string, username string) (bool, error) {
 	queryID, err := uint128.FromString(queryIDStr)
@@ -359,6 +391,28 @@ func (r *SessionRegistry) CancelQuery(queryIDStr string, username string) (bool,
 	return false, fmt.Errorf("query ID %s not found", queryID)
 }
 
+// CancelQueryByKey cancels all the active queries of the session identified by
+// the given pgwire cancellation secret key. Knowledge of the key is sufficient
+// authorization; no user check is performed.
+func (r *SessionRegistry) CancelQueryByKey(secretKey int32) (bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	session, ok := r.secretKeys[secretKey]
+	if !ok {
+		return false, fmt.Errorf("session for secret key %d not found", secretKey)
+	}
+
+	canceled := false
+	session.mu.Lock()
+	for _, queryMeta := range session.mu.ActiveQueries {
+		queryMeta.cancel()
+		canceled = true
+	}
+	session.mu.Unlock()
+	return canceled, nil
+}
+
 // SerializeAll returns a slice of all sessions in the registry, converted to serverpb.Sessions.
 func (r *SessionRegistry) SerializeAll() []serverpb.Session {
 	r.Lock()
@@ -565,6 +619,14 @@ func (s *Session) Ctx() context.Context {
 	return s.context
 }
 
+// BackendKeyData returns the process ID and secret key sent to the client in
+// pgwire's BackendKeyData message. The process ID is the ID of the node the
+// session is running on, so that a CancelRequest received by any node can be
+// routed to this one.
+func (s *Session) BackendKeyData() (processID int32, secretKey int32) {
+	return int32(s.execCfg.NodeID.Get()), s.secretKey
+}
+
 func (s *Session) resetPlanner(
 	p *planner,
 	txn *client.Txn,
@@ -183,6 +183,16 @@ CREATE TABLE system.role_members (
   INDEX ("role"),
   INDEX ("member")
 );`
+
+	// role_options stores the options of users and roles set with CREATE/ALTER
+	// USER/ROLE ... WITH. Options that take no value have a NULL value.
+	RoleOptionsTableSchema = `
+CREATE TABLE system.role_options (
+  username STRING NOT NULL,
+  option   STRING NOT NULL,
+  value    STRING,
+  PRIMARY KEY (username, option)
+);`
 )
 
 func pk(name string) IndexDescriptor {
@@ -239,6 +249,7 @@ var SystemAllowedPrivileges = map[ID]privilege.Lists{
 	keys.TableStatisticsTableID: {privilege.ReadWriteData},
 	keys.LocationsTableID:       {privilege.ReadWriteData},
 	keys.RoleMembersTableID:     {privilege.ReadWriteData},
+	keys.RoleOptionsTableID:     {privilege.ReadWriteData},
 }
 
 // SystemDesiredPrivileges returns the desired privilege list (i.e., the
@@ -814,6 +825,48 @@ var (
 		NextMutationID: 1,
 	}
 
+	// RoleOptionsTable is the descriptor for the role_options table.
+	RoleOptionsTable = TableDescriptor{
+		Name:     "role_options",
+		ID:       keys.RoleOptionsTableID,
+		ParentID: keys.SystemDatabaseID,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "username", ID: 1, Type: colTypeString},
+			{Name: "option", ID: 2, Type: colTypeString},
+			{Name: "value", ID: 3, Type: colTypeString, Nullable: true},
+		},
+		NextColumnID: 4,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "primary",
+				ID:          0,
+				ColumnNames: []string{"username", "option"},
+				ColumnIDs:   []ColumnID{1, 2},
+			},
+			{
+				Name:            "fam_3_value",
+				ID:              3,
+				ColumnNames:     []string{"value"},
+				ColumnIDs:       []ColumnID{3},
+				DefaultColumnID: 3,
+			},
+		},
+		NextFamilyID: 4,
+		PrimaryIndex: IndexDescriptor{
+			Name:             "primary",
+			ID:               1,
+			Unique:           true,
+			ColumnNames:      []string{"username", "option"},
+			ColumnDirections: []IndexDescriptor_Direction{IndexDescriptor_ASC, IndexDescriptor_ASC},
+			ColumnIDs:        []ColumnID{1, 2},
+		},
+		NextIndexID:    2,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemDesiredPrivileges(keys.RoleOptionsTableID)),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
+
 //***************************************************************************
 // WARNING: any tables added after LocationsTable must use:
 //   Privileges: NewCustomSuperuserPrivilegeDescriptor(...)
@@ -68,7 +68,7 @@ func (p *planner) computeRenderAllowingStars(
 		return nil, nil, false, err
 	}
 
-	if hasStar, cols, typedExprs, err := checkRenderStar(target, info, ivarHelper); err != nil {
+	if hasStar, cols, typedExprs, err := checkRenderStar(target, info, ivarHelper, p.SessionData().User); err != nil {
 		return nil, nil, false, err
 	} else if hasStar {
 		return cols, typedExprs, hasStar, nil
@@ -152,8 +152,9 @@ func symbolicExprStr(expr tree.Expr) string {
 // SQL star (UnqualifiedStar or AllColumnsSelector). We match the prefix of the
 // name to one of the tables in the query and then expand the "*" into a list
 // of columns. A sqlbase.ResultColumns and Expr pair is returned for each column.
+// The user is used to check that the expanded columns can be read.
 func checkRenderStar(
-	target tree.SelectExpr, info multiSourceInfo, ivarHelper tree.IndexedVarHelper,
+	target tree.SelectExpr, info multiSourceInfo, ivarHelper tree.IndexedVarHelper, user string,
 ) (isStar bool, columns sqlbase.ResultColumns, exprs []tree.TypedExpr, err error) {
 	v, ok := target.Expr.(tree.VarName)
 	if !ok {
@@ -166,7 +167,7 @@ func checkRenderStar(
 			return false, nil, nil, errors.Errorf("\"%s\" cannot be aliased", v)
 		}
 
-		columns, exprs, err = info[0].expandStar(v, ivarHelper)
+		columns, exprs, err = info[0].expandStar(v, ivarHelper, user)
 		return true, columns, exprs, err
 	default:
 		return false, nil, nil, nil
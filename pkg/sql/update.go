@@ -52,7 +52,8 @@ type updateNode struct {
 var _ autoCommitNode = &updateNode{}
 
 // Update updates columns for a selection of rows from a table.
-// Privileges: UPDATE and SELECT on table. We currently always use a select statement.
+// Privileges: UPDATE and SELECT on table, or on the columns being updated and read.
+// We currently always use a select statement.
 //   Notes: postgres requires UPDATE. Requires SELECT with WHERE clause with table.
 //          mysql requires UPDATE. Also requires SELECT with WHERE clause with table.
 func (p *planner) Update(
@@ -101,6 +102,9 @@ func (p *planner) Update(
 	if err != nil {
 		return nil, err
 	}
+	if err := p.checkColumnPrivileges(en.tableDesc, updateCols, privilege.UPDATE); err != nil {
+		return nil, err
+	}
 
 	defaultExprs, err := sqlbase.MakeDefaultExprs(
 		updateCols, &p.txCtx, p.EvalContext())
@@ -109,7 +113,8 @@ func (p *planner) Update(
 	}
 
 	var requestedCols []sqlbase.ColumnDescriptor
-	if _, retExprs := n.Returning.(*tree.ReturningExprs); retExprs || len(en.tableDesc.Checks) > 0 {
+	if _, retExprs := n.Returning.(*tree.ReturningExprs); retExprs || len(en.tableDesc.Checks) > 0 ||
+		!p.bypassRowLevelSecurity(en.tableDesc) {
 		// TODO(dan): This could be made tighter, just the rows needed for RETURNING
 		// exprs.
 		requestedCols = en.tableDesc.Columns
@@ -140,11 +145,15 @@ func (p *planner) Update(
 
 	// We construct a query containing the columns being updated, and then later merge the values
 	// they are being updated with into that renderNode to ideally reuse some of the queries.
+	// The scan of the table also applies its row-level security policies for UPDATE, if any.
+	p.rowLevelSecurity.targetID = en.tableDesc.ID
+	p.rowLevelSecurity.targetCmd = sqlbase.TableDescriptor_Policy_UPDATE
 	rows, err := p.SelectClause(ctx, &tree.SelectClause{
 		Exprs: sqlbase.ColumnsSelectors(ru.FetchCols, true /* forUpdateOrDelete */),
 		From:  &tree.From{Tables: []tree.TableExpr{n.Table}},
 		Where: n.Where,
 	}, n.OrderBy, n.Limit, nil /* with */, nil /*desiredTypes*/, publicAndNonPublicColumns)
+	p.rowLevelSecurity.targetID = sqlbase.InvalidID
 	if err != nil {
 		return nil, err
 	}
@@ -254,7 +263,9 @@ func (p *planner) Update(
 		tw:            tw,
 		sourceSlots:   sourceSlots,
 	}
-	if err := un.checkHelper.init(ctx, p, tn, en.tableDesc); err != nil {
+	if err := un.checkHelper.init(
+		ctx, p, tn, en.tableDesc, sqlbase.TableDescriptor_Policy_UPDATE,
+	); err != nil {
 		return nil, err
 	}
 	if err := un.run.initEditNode(
@@ -392,6 +403,8 @@ func (p *planner) makeEditNode(
 				priv, tableDesc.Kind(), tn, tableDesc.Kind())
 	}
 
+	p.maybeAudit(tableDesc, priv)
+
 	// TODO(justin): temporary to split up computed columns PR.
 	for _, col := range tableDesc.Columns {
 		if col.ComputeExpr != nil {
@@ -400,7 +413,12 @@ func (p *planner) makeEditNode(
 	}
 
 	if err := p.CheckPrivilege(tableDesc, priv); err != nil {
-		return editNodeBase{}, err
+		// INSERT and UPDATE can also be granted on individual columns. The
+		// columns written by the statement are checked once they are known.
+		if (priv != privilege.INSERT && priv != privilege.UPDATE) ||
+			!p.hasColumnPrivilegesOnly(tableDesc, priv) {
+			return editNodeBase{}, err
+		}
 	}
 
 	return editNodeBase{
@@ -429,7 +447,7 @@ func (r *editNodeRun) initEditNode(
 	r.rows = rows
 	r.tw = tw
 
-	rh, err := en.p.newReturningHelper(ctx, re, desiredTypes, tn, en.tableDesc.Columns)
+	rh, err := en.p.newReturningHelper(ctx, re, desiredTypes, tn, en.tableDesc)
 	if err != nil {
 		return err
 	}
@@ -16,14 +16,49 @@ package sql
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
 
+// PasswordEncryption controls how new passwords are stored in system.users.
+var PasswordEncryption = settings.RegisterEnumSetting(
+	"server.user_login.password_encryption",
+	"method used to store new user passwords; scram-sha-256 requires clients "+
+		"authenticating with a password to support SCRAM-SHA-256",
+	"crdb-bcrypt",
+	map[int64]string{
+		int64(security.HashBcrypt):      "crdb-bcrypt",
+		int64(security.HashSCRAMSHA256): "scram-sha-256",
+	},
+)
+
+// upgradeBcryptPasswords controls whether bcrypt password hashes are
+// converted to SCRAM verifiers when their users log in.
+var upgradeBcryptPasswords = settings.RegisterBoolSetting(
+	"server.user_login.upgrade_bcrypt_stored_passwords_to_scram.enabled",
+	"if server.user_login.password_encryption is scram-sha-256, convert the stored "+
+		"bcrypt password hash of a user to a SCRAM verifier when they log in with a password",
+	true,
+)
+
+// passwordHashMethod returns the method to use to hash new passwords.
+func passwordHashMethod(st *cluster.Settings) security.PasswordHashMethod {
+	// Nodes running older versions can't check SCRAM verifiers.
+	if !st.Version.IsActive(cluster.VersionSCRAMAuthentication) {
+		return security.HashBcrypt
+	}
+	return security.PasswordHashMethod(PasswordEncryption.Get(&st.SV))
+}
+
 // GetUserHashedPassword returns the hashedPassword for the given username if
 // found in system.users.
 func GetUserHashedPassword(
@@ -99,3 +134,133 @@ func existingUserIsRole(
 	isRole := bool(*(values[0]).(*tree.DBool))
 	return isRole, nil
 }
+
+// MaybeUpgradeUserHashedPassword replaces the bcrypt hash stored for the given
+// user with a SCRAM verifier, if the cluster is configured to store passwords
+// as such. It must only be called once password has been verified against
+// hashedPassword, the hash currently stored.
+//
+// Failures are logged but otherwise ignored: the user was authenticated
+// successfully, and the upgrade will be attempted again on the next login.
+func MaybeUpgradeUserHashedPassword(
+	ctx context.Context,
+	executor *Executor,
+	metrics *MemoryMetrics,
+	username string,
+	password string,
+	hashedPassword []byte,
+) {
+	st := executor.cfg.Settings
+	if security.GetPasswordHashMethod(hashedPassword) != security.HashBcrypt ||
+		passwordHashMethod(st) != security.HashSCRAMSHA256 ||
+		!upgradeBcryptPasswords.Get(&st.SV) {
+		return
+	}
+	newHashedPassword, err := security.HashPasswordSCRAM(password)
+	if err != nil {
+		log.Warningf(ctx, "unable to compute SCRAM verifier for user %s: %v", username, err)
+		return
+	}
+	normalizedUsername := tree.Name(username).Normalize()
+	if err := executor.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		p, cleanup := newInternalPlanner(
+			"upgrade-pwd", txn, security.RootUser, metrics, &executor.cfg)
+		defer cleanup()
+		// Only replace the hash that was checked, in case the password was
+		// changed concurrently.
+		_, err := p.exec(ctx,
+			`UPDATE system.users SET "hashedPassword" = $3 `+
+				`WHERE username = $1 AND "hashedPassword" = $2 AND "isRole" = false`,
+			normalizedUsername, hashedPassword, newHashedPassword)
+		return err
+	}); err != nil {
+		log.Warningf(ctx, "unable to upgrade stored password of user %s: %v", username, err)
+	}
+}
+
+// rejectedConnLogInterval is the minimum interval between two rejected
+// connections recorded in the event log.
+const rejectedConnLogInterval = 100 * time.Millisecond
+
+// maxConcurrentRejectedConnLogs bounds the number of rejected connections
+// being recorded in the event log at once.
+const maxConcurrentRejectedConnLogs = 4
+
+// rejectedConnLogger rate limits the recording of rejected connections in the
+// event log. Connections are rejected before the client authenticates, so a
+// client opening connections in a loop must neither flood the event log range
+// with writes nor get its rejections delayed by them.
+type rejectedConnLogger struct {
+	every log.EveryN
+	sem   chan struct{}
+	// skipped counts the rejections which were not recorded since the last
+	// recorded one. Accessed atomically.
+	skipped int64
+	// warnEvery rate limits the warnings about skipped rejections.
+	warnEvery log.EveryN
+}
+
+func makeRejectedConnLogger() rejectedConnLogger {
+	return rejectedConnLogger{
+		every:     log.Every(rejectedConnLogInterval),
+		sem:       make(chan struct{}, maxConcurrentRejectedConnLogs),
+		warnEvery: log.Every(10 * time.Second),
+	}
+}
+
+// LogRejectedConnection records in the event log that a SQL connection was
+// rejected by the host-based authentication configuration. The target of the
+// event is the node that rejected the connection.
+//
+// The event is recorded asynchronously, and rejections are dropped from the
+// event log when they come in faster than it can take them. The next recorded
+// event counts the rejections that were dropped, which are also reported in
+// the log file.
+func LogRejectedConnection(executor *Executor, user, database, clientAddr, reason string) {
+	l := &executor.rejectedConnLogger
+	ctx := executor.AnnotateCtx(context.Background())
+	skip := func() {
+		if skipped := atomic.AddInt64(&l.skipped, 1); l.warnEvery.ShouldLog() {
+			log.Warningf(ctx, "%d rejected connections not recorded in the event log, "+
+				"last: user %s, database %s, client %s: %s",
+				skipped, user, database, clientAddr, reason)
+		}
+	}
+	if !l.every.ShouldLog() {
+		skip()
+		return
+	}
+	select {
+	case l.sem <- struct{}{}:
+	default:
+		skip()
+		return
+	}
+	skipped := atomic.SwapInt64(&l.skipped, 0)
+	nodeID := int32(executor.cfg.NodeID.Get())
+	if err := executor.stopper.RunAsyncTask(ctx, "sql.LogRejectedConnection", func(ctx context.Context) {
+		defer func() { <-l.sem }()
+		if err := executor.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+			return MakeEventLogger(&executor.cfg).InsertEventRecord(
+				ctx,
+				txn,
+				EventLogRejectConnection,
+				nodeID,
+				nodeID,
+				struct {
+					User       string
+					Database   string
+					ClientAddr string
+					Reason     string
+					// SkippedRejections is the number of rejected connections
+					// not recorded since the previous event.
+					SkippedRejections int64 `json:",omitempty"`
+				}{user, database, clientAddr, reason, skipped},
+			)
+		}); err != nil {
+			log.Warningf(ctx, "unable to record rejected connection: %v", err)
+		}
+	}); err != nil {
+		<-l.sem
+	}
+}
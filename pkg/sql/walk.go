@@ -487,59 +487,61 @@ func nodeName(plan planNode) string {
 // strings are constant and not precomputed so that the type names can
 // be changed without changing the output of "EXPLAIN".
 var planNodeNames = map[reflect.Type]string{
-	reflect.TypeOf(&alterIndexNode{}):           "alter index",
-	reflect.TypeOf(&alterTableNode{}):           "alter table",
-	reflect.TypeOf(&alterSequenceNode{}):        "alter sequence",
-	reflect.TypeOf(&alterUserSetPasswordNode{}): "alter user",
-	reflect.TypeOf(&cancelQueryNode{}):          "cancel query",
-	reflect.TypeOf(&controlJobNode{}):           "control job",
-	reflect.TypeOf(&createDatabaseNode{}):       "create database",
-	reflect.TypeOf(&createIndexNode{}):          "create index",
-	reflect.TypeOf(&createTableNode{}):          "create table",
-	reflect.TypeOf(&CreateUserNode{}):           "create user | role",
-	reflect.TypeOf(&createViewNode{}):           "create view",
-	reflect.TypeOf(&createSequenceNode{}):       "create sequence",
-	reflect.TypeOf(&createStatsNode{}):          "create statistics",
-	reflect.TypeOf(&delayedNode{}):              "virtual table",
-	reflect.TypeOf(&deleteNode{}):               "delete",
-	reflect.TypeOf(&distinctNode{}):             "distinct",
-	reflect.TypeOf(&dropDatabaseNode{}):         "drop database",
-	reflect.TypeOf(&dropIndexNode{}):            "drop index",
-	reflect.TypeOf(&dropTableNode{}):            "drop table",
-	reflect.TypeOf(&dropViewNode{}):             "drop view",
-	reflect.TypeOf(&dropSequenceNode{}):         "drop sequence",
-	reflect.TypeOf(&DropUserNode{}):             "drop user | role",
-	reflect.TypeOf(&explainDistSQLNode{}):       "explain dist_sql",
-	reflect.TypeOf(&explainPlanNode{}):          "explain plan",
-	reflect.TypeOf(&showTraceNode{}):            "show trace for",
-	reflect.TypeOf(&showTraceReplicaNode{}):     "show trace for",
-	reflect.TypeOf(&filterNode{}):               "filter",
-	reflect.TypeOf(&groupNode{}):                "group",
-	reflect.TypeOf(&unaryNode{}):                "emptyrow",
-	reflect.TypeOf(&hookFnNode{}):               "plugin",
-	reflect.TypeOf(&indexJoinNode{}):            "index-join",
-	reflect.TypeOf(&insertNode{}):               "insert",
-	reflect.TypeOf(&joinNode{}):                 "join",
-	reflect.TypeOf(&limitNode{}):                "limit",
-	reflect.TypeOf(&ordinalityNode{}):           "ordinality",
-	reflect.TypeOf(&testingRelocateNode{}):      "testingRelocate",
-	reflect.TypeOf(&renderNode{}):               "render",
-	reflect.TypeOf(&scanNode{}):                 "scan",
-	reflect.TypeOf(&scatterNode{}):              "scatter",
-	reflect.TypeOf(&scrubNode{}):                "scrub",
-	reflect.TypeOf(&sequenceSelectNode{}):       "sequence select",
-	reflect.TypeOf(&setVarNode{}):               "set",
-	reflect.TypeOf(&setClusterSettingNode{}):    "set cluster setting",
-	reflect.TypeOf(&setZoneConfigNode{}):        "configure zone",
-	reflect.TypeOf(&showZoneConfigNode{}):       "show zone configuration",
-	reflect.TypeOf(&showRangesNode{}):           "showRanges",
-	reflect.TypeOf(&showFingerprintsNode{}):     "showFingerprints",
-	reflect.TypeOf(&sortNode{}):                 "sort",
-	reflect.TypeOf(&splitNode{}):                "split",
-	reflect.TypeOf(&unionNode{}):                "union",
-	reflect.TypeOf(&updateNode{}):               "update",
-	reflect.TypeOf(&valueGenerator{}):           "generator",
-	reflect.TypeOf(&valuesNode{}):               "values",
-	reflect.TypeOf(&windowNode{}):               "window",
-	reflect.TypeOf(&zeroNode{}):                 "norows",
+	reflect.TypeOf(&alterIndexNode{}):        "alter index",
+	reflect.TypeOf(&alterTableNode{}):        "alter table",
+	reflect.TypeOf(&alterSequenceNode{}):     "alter sequence",
+	reflect.TypeOf(&AlterRoleNode{}):         "alter user | role",
+	reflect.TypeOf(&cancelQueryNode{}):       "cancel query",
+	reflect.TypeOf(&controlJobNode{}):        "control job",
+	reflect.TypeOf(&createDatabaseNode{}):    "create database",
+	reflect.TypeOf(&createIndexNode{}):       "create index",
+	reflect.TypeOf(&createTableNode{}):       "create table",
+	reflect.TypeOf(&CreateUserNode{}):        "create user | role",
+	reflect.TypeOf(&createViewNode{}):        "create view",
+	reflect.TypeOf(&createSequenceNode{}):    "create sequence",
+	reflect.TypeOf(&createStatsNode{}):       "create statistics",
+	reflect.TypeOf(&createPolicyNode{}):      "create policy",
+	reflect.TypeOf(&delayedNode{}):           "virtual table",
+	reflect.TypeOf(&deleteNode{}):            "delete",
+	reflect.TypeOf(&distinctNode{}):          "distinct",
+	reflect.TypeOf(&dropDatabaseNode{}):      "drop database",
+	reflect.TypeOf(&dropIndexNode{}):         "drop index",
+	reflect.TypeOf(&dropTableNode{}):         "drop table",
+	reflect.TypeOf(&dropViewNode{}):          "drop view",
+	reflect.TypeOf(&dropSequenceNode{}):      "drop sequence",
+	reflect.TypeOf(&dropPolicyNode{}):        "drop policy",
+	reflect.TypeOf(&DropUserNode{}):          "drop user | role",
+	reflect.TypeOf(&explainDistSQLNode{}):    "explain dist_sql",
+	reflect.TypeOf(&explainPlanNode{}):       "explain plan",
+	reflect.TypeOf(&showTraceNode{}):         "show trace for",
+	reflect.TypeOf(&showTraceReplicaNode{}):  "show trace for",
+	reflect.TypeOf(&filterNode{}):            "filter",
+	reflect.TypeOf(&groupNode{}):             "group",
+	reflect.TypeOf(&unaryNode{}):             "emptyrow",
+	reflect.TypeOf(&hookFnNode{}):            "plugin",
+	reflect.TypeOf(&indexJoinNode{}):         "index-join",
+	reflect.TypeOf(&insertNode{}):            "insert",
+	reflect.TypeOf(&joinNode{}):              "join",
+	reflect.TypeOf(&limitNode{}):             "limit",
+	reflect.TypeOf(&ordinalityNode{}):        "ordinality",
+	reflect.TypeOf(&testingRelocateNode{}):   "testingRelocate",
+	reflect.TypeOf(&renderNode{}):            "render",
+	reflect.TypeOf(&scanNode{}):              "scan",
+	reflect.TypeOf(&scatterNode{}):           "scatter",
+	reflect.TypeOf(&scrubNode{}):             "scrub",
+	reflect.TypeOf(&sequenceSelectNode{}):    "sequence select",
+	reflect.TypeOf(&setVarNode{}):            "set",
+	reflect.TypeOf(&setClusterSettingNode{}): "set cluster setting",
+	reflect.TypeOf(&setZoneConfigNode{}):     "configure zone",
+	reflect.TypeOf(&showZoneConfigNode{}):    "show zone configuration",
+	reflect.TypeOf(&showRangesNode{}):        "showRanges",
+	reflect.TypeOf(&showFingerprintsNode{}):  "showFingerprints",
+	reflect.TypeOf(&sortNode{}):              "sort",
+	reflect.TypeOf(&splitNode{}):             "split",
+	reflect.TypeOf(&unionNode{}):             "union",
+	reflect.TypeOf(&updateNode{}):            "update",
+	reflect.TypeOf(&valueGenerator{}):        "generator",
+	reflect.TypeOf(&valuesNode{}):            "values",
+	reflect.TypeOf(&windowNode{}):            "window",
+	reflect.TypeOf(&zeroNode{}):              "norows",
 }
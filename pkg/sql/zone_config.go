@@ -114,6 +114,34 @@ func ZoneConfigHook(
 	return zone, true, nil
 }
 
+// ZoneConfigForKey returns the zone config that applies to the range
+// containing key using the cached system config, along with the ID of the
+// object whose zone config it is. If key is within a subzone, the subzone's
+// config is returned along with the ID of the table that contains it and the
+// subzone itself; otherwise the returned subzone is nil.
+func ZoneConfigForKey(
+	cfg config.SystemConfig, key roachpb.RKey,
+) (uint32, config.ZoneConfig, *config.Subzone, error) {
+	objectID, keySuffix := config.ObjectIDForKey(key)
+	zoneID, zone, subzone, err := getZoneConfig(
+		objectID,
+		func(key roachpb.Key) (*roachpb.Value, error) {
+			return cfg.GetValue(key), nil
+		},
+		func(zone config.ZoneConfig) *config.Subzone {
+			return zone.GetSubzoneForKeySuffix(keySuffix)
+		},
+	)
+	if err == errNoZoneConfigApplies {
+		return keys.RootNamespaceID, config.DefaultZoneConfig(), nil, nil
+	} else if err != nil {
+		return 0, config.ZoneConfig{}, nil, err
+	} else if subzone != nil {
+		return zoneID, subzone.Config, subzone, nil
+	}
+	return zoneID, zone, nil, nil
+}
+
 // GetZoneConfigInTxn looks up the zone and subzone for the specified object ID,
 // index, and partition.
 func GetZoneConfigInTxn(
@@ -158,6 +158,11 @@ var backwardCompatibleMigrations = []migrationDescriptor{
 		name:   "add default system.jobs zone config",
 		workFn: addDefaultSystemJobsZoneConfig,
 	},
+	{
+		name:             "create system.role_options table",
+		workFn:           createRoleOptionsTable,
+		newDescriptorIDs: []sqlbase.ID{keys.RoleOptionsTableID},
+	},
 }
 
 // migrationDescriptor describes a single migration hook that's used to modify
@@ -465,6 +470,10 @@ func createRoleMembersTable(ctx context.Context, r runner) error {
 	return createSystemTable(ctx, r, sqlbase.RoleMembersTable)
 }
 
+func createRoleOptionsTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.RoleOptionsTable)
+}
+
 func createSystemTable(ctx context.Context, r runner, desc sqlbase.TableDescriptor) error {
 	// We install the table at the KV layer so that we can choose a known ID in
 	// the reserved ID space. (The SQL layer doesn't allow this.)
@@ -41,6 +41,27 @@ const (
 	// stores.
 	maxFractionUsedThreshold = 0.95
 
+	// rebalanceToMaxFractionUsedThreshold: if the fraction used of a store
+	// descriptor capacity is greater than this value, it will never be used as a
+	// rebalance target. It is lower than maxFractionUsedThreshold so that the
+	// replicas shed by a full store aren't rebalanced onto stores that are about
+	// to become full themselves.
+	rebalanceToMaxFractionUsedThreshold = 0.925
+
+	// diskGrowthProjectionHorizon is how far into the future a store's recent
+	// disk growth rate is extrapolated when checking the fraction used of its
+	// capacity. This lets stores that are filling up quickly shed replicas
+	// before they actually run out of space.
+	diskGrowthProjectionHorizon = 30 * time.Minute
+
+	// maxL0FileCountThreshold and maxPendingCompactionBytesThreshold: if a
+	// store's RocksDB compaction debt exceeds either of these, the store is
+	// treated as if it were full. They're set below the points at which RocksDB
+	// starts throttling foreground writes (20 L0 files and 64 GiB of pending
+	// compactions) so that the store sheds load before that happens.
+	maxL0FileCountThreshold            = 16
+	maxPendingCompactionBytesThreshold = 32 << 30
+
 	// baseLeaseRebalanceThreshold is the minimum ratio of a store's lease surplus
 	// to the mean range/lease count that permits lease-transfers away from that
 	// store.
@@ -353,8 +374,10 @@ func (a *Allocator) AllocateTarget(
 	sl, _, throttledStoreCount := a.storePool.getStoreList(rangeInfo.Desc.RangeID, storeFilterThrottled)
 
 	options := a.scorerOptions(disableStatsBasedRebalancing)
+	analyzedConstraints := analyzeConstraints(a.storePool.getStoreDescriptor, existing, constraints)
 	candidates := allocateCandidates(
-		sl, constraints, existing, rangeInfo, a.storePool.getLocalities(existing), options,
+		sl, constraints, analyzedConstraints, existing, rangeInfo,
+		a.storePool.getLocalities(existing), options,
 	)
 	log.VEventf(ctx, 3, "allocate candidates: %s", candidates)
 	if target := candidates.selectGood(a.randGen); target != nil {
@@ -424,10 +447,13 @@ func (a Allocator) RemoveTarget(
 	}
 	sl, _, _ := a.storePool.getStoreListFromIDs(existingStoreIDs, roachpb.RangeID(0), storeFilterNone)
 
+	analyzedConstraints := analyzeConstraints(
+		a.storePool.getStoreDescriptor, rangeInfo.Desc.Replicas, constraints)
 	options := a.scorerOptions(disableStatsBasedRebalancing)
 	rankedCandidates := removeCandidates(
 		sl,
 		constraints,
+		analyzedConstraints,
 		rangeInfo,
 		a.storePool.getLocalities(rangeInfo.Desc.Replicas),
 		options,
@@ -482,11 +508,14 @@ func (a Allocator) RebalanceTarget(
 ) (*roachpb.StoreDescriptor, string) {
 	sl, _, _ := a.storePool.getStoreList(rangeInfo.Desc.RangeID, filter)
 
+	analyzedConstraints := analyzeConstraints(
+		a.storePool.getStoreDescriptor, rangeInfo.Desc.Replicas, constraints)
 	options := a.scorerOptions(disableStatsBasedRebalancing)
 	existingCandidates, candidates := rebalanceCandidates(
 		ctx,
 		sl,
 		constraints,
+		analyzedConstraints,
 		rangeInfo.Desc.Replicas,
 		rangeInfo,
 		a.storePool.getLocalities(rangeInfo.Desc.Replicas),
@@ -640,7 +669,7 @@ func (a *Allocator) scorerOptions(disableStatsBasedRebalancing bool) scorerOptio
 // unless asked to do otherwise by the checkTransferLeaseSource parameter.
 func (a *Allocator) TransferLeaseTarget(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	leaseStoreID roachpb.StoreID,
 	rangeID roachpb.RangeID,
@@ -650,7 +679,34 @@ func (a *Allocator) TransferLeaseTarget(
 	alwaysAllowDecisionWithoutStats bool,
 ) roachpb.ReplicaDescriptor {
 	sl, _, _ := a.storePool.getStoreList(rangeID, storeFilterNone)
-	sl = sl.filter(constraints)
+	sl = sl.filter(zone.Constraints)
+
+	// Determine which store(s) is preferred based on user-specified preferences.
+	// If any stores match, only consider those stores as candidates. If only one
+	// store matches, it's where the lease should be. When the source isn't
+	// checked, the lease has to move (e.g. because the leaseholder's replica is
+	// being removed), so the leaseholder can't be the preferred store even if it
+	// matches the preferences.
+	var leaseholderNotPreferred bool
+	preferCandidates := existing
+	if !checkTransferLeaseSource {
+		preferCandidates = replicasNotOnStore(leaseStoreID, existing)
+	}
+	preferred := a.preferredLeaseholders(zone, preferCandidates)
+	if len(preferred) == 1 {
+		if preferred[0].StoreID == leaseStoreID {
+			return roachpb.ReplicaDescriptor{}
+		}
+		return preferred[0]
+	} else if len(preferred) > 1 {
+		existing = preferred
+		// If the current leaseholder is not preferred, don't bother checking
+		// whether it's worth moving the lease; it always is.
+		if !storeHasReplica(leaseStoreID, preferred) {
+			leaseholderNotPreferred = true
+			checkTransferLeaseSource = false
+		}
+	}
 
 	// Filter stores that are on nodes containing existing replicas, but leave
 	// the stores containing the existing replicas in place. This excludes stores
@@ -710,6 +766,8 @@ func (a *Allocator) TransferLeaseTarget(
 	// Fall back to logic that doesn't take request counts and latency into
 	// account if the counts/latency-based logic couldn't pick a best replica.
 	candidates := make([]roachpb.ReplicaDescriptor, 0, len(existing))
+	var bestOption roachpb.ReplicaDescriptor
+	bestOptionLeaseCount := int32(math.MaxInt32)
 	for _, repl := range existing {
 		if leaseStoreID == repl.StoreID {
 			continue
@@ -720,9 +778,18 @@ func (a *Allocator) TransferLeaseTarget(
 		}
 		if !checkCandidateFullness || float64(storeDesc.Capacity.LeaseCount) < sl.candidateLeases.mean-0.5 {
 			candidates = append(candidates, repl)
+		} else if storeDesc.Capacity.LeaseCount < bestOptionLeaseCount {
+			bestOption = repl
+			bestOptionLeaseCount = storeDesc.Capacity.LeaseCount
 		}
 	}
 	if len(candidates) == 0 {
+		// If the current leaseholder doesn't match the lease preferences, the
+		// lease needs to move regardless of how full the preferred stores are,
+		// so return our best option.
+		if leaseholderNotPreferred {
+			return bestOption
+		}
 		return roachpb.ReplicaDescriptor{}
 	}
 	a.randGen.Lock()
@@ -735,7 +802,7 @@ func (a *Allocator) TransferLeaseTarget(
 // attributes.
 func (a *Allocator) ShouldTransferLease(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	leaseStoreID roachpb.StoreID,
 	rangeID roachpb.RangeID,
@@ -745,8 +812,24 @@ func (a *Allocator) ShouldTransferLease(
 	if !ok {
 		return false
 	}
+
+	// Determine which store(s) is preferred based on user-specified preferences.
+	// If any stores match, only consider those stores as options. If only one
+	// store matches, it's where the lease should be.
+	preferred := a.preferredLeaseholders(zone, existing)
+	if len(preferred) == 1 {
+		return preferred[0].StoreID != leaseStoreID
+	} else if len(preferred) > 1 {
+		existing = preferred
+		// If the current leaseholder isn't one of the preferred stores, then we
+		// should try to transfer the lease.
+		if !storeHasReplica(leaseStoreID, existing) {
+			return true
+		}
+	}
+
 	sl, _, _ := a.storePool.getStoreList(rangeID, storeFilterNone)
-	sl = sl.filter(constraints)
+	sl = sl.filter(zone.Constraints)
 	log.VEventf(ctx, 3, "ShouldTransferLease (lease-holder=%d):\n%s", leaseStoreID, sl)
 
 	transferDec, _ := a.shouldTransferLeaseUsingStats(ctx, sl, source, existing, stats)
@@ -970,6 +1053,57 @@ func (a Allocator) shouldTransferLeaseWithoutStats(
 	return false
 }
 
+// preferredLeaseholders returns the replicas from existing that match the
+// first of the zone's lease preferences which any existing replica matches.
+// Preferences are ordered by priority, so later preferences are only consulted
+// when no replica satisfies the earlier ones. It returns nil if the zone has
+// no lease preferences or none of them can be satisfied.
+func (a *Allocator) preferredLeaseholders(
+	zone config.ZoneConfig, existing []roachpb.ReplicaDescriptor,
+) []roachpb.ReplicaDescriptor {
+	for _, preference := range zone.LeasePreferences {
+		var preferred []roachpb.ReplicaDescriptor
+		for _, repl := range existing {
+			storeDesc, ok := a.storePool.getStoreDescriptor(repl.StoreID)
+			if !ok {
+				continue
+			}
+			if subConstraintsCheck(storeDesc, preference.Constraints) {
+				preferred = append(preferred, repl)
+			}
+		}
+		if len(preferred) > 0 {
+			return preferred
+		}
+	}
+	return nil
+}
+
+// storeHasReplica returns whether one of the provided replicas lives on the
+// store with the given ID.
+func storeHasReplica(storeID roachpb.StoreID, existing []roachpb.ReplicaDescriptor) bool {
+	for _, r := range existing {
+		if r.StoreID == storeID {
+			return true
+		}
+	}
+	return false
+}
+
+// replicasNotOnStore returns the provided replicas, except the one which lives
+// on the store with the given ID.
+func replicasNotOnStore(
+	storeID roachpb.StoreID, existing []roachpb.ReplicaDescriptor,
+) []roachpb.ReplicaDescriptor {
+	var result []roachpb.ReplicaDescriptor
+	for _, r := range existing {
+		if r.StoreID != storeID {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
 // computeQuorum computes the quorum value for the given number of nodes.
 func computeQuorum(nodes int) int {
 	return (nodes / 2) + 1
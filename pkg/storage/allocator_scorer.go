@@ -108,6 +108,7 @@ func (bd balanceDimensions) compactString(options scorerOptions) string {
 type candidate struct {
 	store          roachpb.StoreDescriptor
 	valid          bool
+	necessary      bool
 	diversityScore float64
 	preferredScore int
 	convergesScore int
@@ -121,9 +122,9 @@ func (c candidate) constraintScore() float64 {
 }
 
 func (c candidate) String() string {
-	str := fmt.Sprintf("s%d, valid:%t, constraint:%.2f, converges:%d, balance:%s, rangeCount:%d, "+
-		"logicalBytes:%s, writesPerSecond:%.2f",
-		c.store.StoreID, c.valid, c.constraintScore(), c.convergesScore, c.balanceScore, c.rangeCount,
+	str := fmt.Sprintf("s%d, valid:%t, necessary:%t, constraint:%.2f, converges:%d, balance:%s, "+
+		"rangeCount:%d, logicalBytes:%s, writesPerSecond:%.2f",
+		c.store.StoreID, c.valid, c.necessary, c.constraintScore(), c.convergesScore, c.balanceScore, c.rangeCount,
 		humanizeutil.IBytes(c.store.Capacity.LogicalBytes), c.store.Capacity.WritesPerSecond)
 	if c.details != "" {
 		return fmt.Sprintf("%s, details:(%s)", str, c.details)
@@ -137,6 +138,9 @@ func (c candidate) compactString(options scorerOptions) string {
 	if !c.valid {
 		fmt.Fprintf(&buf, ", valid:%t", c.valid)
 	}
+	if c.necessary {
+		fmt.Fprintf(&buf, ", necessary:%t", c.necessary)
+	}
 	if c.diversityScore != 0 {
 		fmt.Fprintf(&buf, ", diversity:%.2f", c.diversityScore)
 	}
@@ -163,6 +167,9 @@ func (c candidate) less(o candidate) bool {
 	if !c.valid {
 		return true
 	}
+	if c.necessary != o.necessary {
+		return o.necessary
+	}
 	if c.constraintScore() != o.constraintScore() {
 		return c.constraintScore() < o.constraintScore()
 	}
@@ -184,6 +191,9 @@ func (c candidate) worthRebalancingTo(o candidate, options scorerOptions) bool {
 	if !c.valid {
 		return true
 	}
+	if c.necessary != o.necessary {
+		return o.necessary
+	}
 	if c.constraintScore() != o.constraintScore() {
 		return c.constraintScore() < o.constraintScore()
 	}
@@ -260,6 +270,7 @@ func (c byScoreAndID) Less(i, j int) bool {
 		c[i].convergesScore == c[j].convergesScore &&
 		c[i].balanceScore.totalScore() == c[j].balanceScore.totalScore() &&
 		c[i].rangeCount == c[j].rangeCount &&
+		c[i].necessary == c[j].necessary &&
 		c[i].valid == c[j].valid {
 		return c[i].store.StoreID < c[j].store.StoreID
 	}
@@ -286,7 +297,8 @@ func (cl candidateList) best() candidateList {
 		return cl
 	}
 	for i := 1; i < len(cl); i++ {
-		if cl[i].constraintScore() < cl[0].constraintScore() ||
+		if cl[i].necessary != cl[0].necessary ||
+			cl[i].constraintScore() < cl[0].constraintScore() ||
 			(cl[i].constraintScore() == cl[len(cl)-1].constraintScore() &&
 				cl[i].convergesScore < cl[len(cl)-1].convergesScore) {
 			return cl[:i]
@@ -311,7 +323,8 @@ func (cl candidateList) worst() candidateList {
 	}
 	// Find the worst constraint values.
 	for i := len(cl) - 2; i >= 0; i-- {
-		if cl[i].constraintScore() > cl[len(cl)-1].constraintScore() ||
+		if cl[i].necessary != cl[len(cl)-1].necessary ||
+			cl[i].constraintScore() > cl[len(cl)-1].constraintScore() ||
 			(cl[i].constraintScore() == cl[len(cl)-1].constraintScore() &&
 				cl[i].convergesScore > cl[len(cl)-1].convergesScore) {
 			return cl[i+1:]
@@ -392,6 +405,7 @@ func (cl candidateList) removeCandidate(c candidate) candidateList {
 func allocateCandidates(
 	sl StoreList,
 	constraints config.Constraints,
+	analyzed analyzedConstraints,
 	existing []roachpb.ReplicaDescriptor,
 	rangeInfo RangeInfo,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
@@ -414,6 +428,7 @@ func allocateCandidates(
 		candidates = append(candidates, candidate{
 			store:          s,
 			valid:          true,
+			necessary:      allocateConstraintsCheck(s, analyzed),
 			diversityScore: diversityScore,
 			preferredScore: preferredMatched,
 			balanceScore:   balanceScore,
@@ -434,6 +449,7 @@ func allocateCandidates(
 func removeCandidates(
 	sl StoreList,
 	constraints config.Constraints,
+	analyzed analyzedConstraints,
 	rangeInfo RangeInfo,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
 	options scorerOptions,
@@ -471,6 +487,7 @@ func removeCandidates(
 		candidates = append(candidates, candidate{
 			store:          s,
 			valid:          true,
+			necessary:      removeConstraintsCheck(s, analyzed),
 			diversityScore: diversityScore,
 			preferredScore: preferredMatched,
 			convergesScore: convergesScore,
@@ -494,6 +511,7 @@ func rebalanceCandidates(
 	ctx context.Context,
 	sl StoreList,
 	constraints config.Constraints,
+	analyzed analyzedConstraints,
 	existing []roachpb.ReplicaDescriptor,
 	rangeInfo RangeInfo,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
@@ -528,6 +546,23 @@ func rebalanceCandidates(
 		}
 	}
 
+	// If one of the per-replica constraints isn't satisfied by enough of the
+	// existing replicas and there's another store that could satisfy it, we
+	// must rebalance even if the existing replicas are otherwise fine.
+	if !rebalanceConstraintsCheck {
+		for _, s := range sl.stores {
+			if _, ok := existingStoreIDs[s.StoreID]; ok || !storeInfos[s.StoreID].ok ||
+				!rebalanceToMaxCapacityCheck(s) {
+				continue
+			}
+			if allocateConstraintsCheck(s, analyzed) {
+				rebalanceConstraintsCheck = true
+				log.VEventf(ctx, 2, "must rebalance to s%d due to per-replica constraint check", s.StoreID)
+				break
+			}
+		}
+	}
+
 	constraintsOkStoreList := makeStoreList(constraintsOkStoreDescriptors)
 	var shouldRebalanceCheck bool
 	if !rebalanceConstraintsCheck {
@@ -552,7 +587,6 @@ func rebalanceCandidates(
 	var candidates candidateList
 	for _, s := range sl.stores {
 		storeInfo := storeInfos[s.StoreID]
-		maxCapacityOK := maxCapacityCheck(s)
 		if _, ok := existingStoreIDs[s.StoreID]; ok {
 			if !storeInfo.ok {
 				existingCandidates = append(existingCandidates, candidate{
@@ -562,7 +596,7 @@ func rebalanceCandidates(
 				})
 				continue
 			}
-			if !maxCapacityOK {
+			if !maxCapacityCheck(s) {
 				existingCandidates = append(existingCandidates, candidate{
 					store:   s,
 					valid:   false,
@@ -583,6 +617,7 @@ func rebalanceCandidates(
 			existingCandidates = append(existingCandidates, candidate{
 				store:          s,
 				valid:          true,
+				necessary:      removeConstraintsCheck(s, analyzed),
 				diversityScore: diversityScore,
 				preferredScore: storeInfo.matched,
 				convergesScore: convergesScore,
@@ -590,7 +625,7 @@ func rebalanceCandidates(
 				rangeCount:     int(s.Capacity.RangeCount),
 			})
 		} else {
-			if !storeInfo.ok || !maxCapacityOK {
+			if !storeInfo.ok || !rebalanceToMaxCapacityCheck(s) {
 				continue
 			}
 			balanceScore := balanceScore(sl, s.Capacity, rangeInfo, options)
@@ -611,6 +646,7 @@ func rebalanceCandidates(
 			candidates = append(candidates, candidate{
 				store:          s,
 				valid:          true,
+				necessary:      rebalanceToConstraintsCheck(s, analyzed),
 				diversityScore: diversityScore,
 				preferredScore: storeInfo.matched,
 				convergesScore: convergesScore,
@@ -641,10 +677,12 @@ func shouldRebalance(
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
 	options scorerOptions,
 ) bool {
-	// Rebalance if this store is too full.
+	// Rebalance if this store is too full or too far behind on compactions.
 	if !maxCapacityCheck(store) {
-		log.VEventf(ctx, 2, "s%d: should-rebalance(disk-full): fraction-used=%.2f, capacity=(%v)",
-			store.StoreID, store.Capacity.FractionUsed(), store.Capacity)
+		log.VEventf(ctx, 2,
+			"s%d: should-rebalance(disk-full): fraction-used=%.2f, projected-fraction-used=%.2f, capacity=(%v)",
+			store.StoreID, store.Capacity.FractionUsed(),
+			store.Capacity.ProjectedFractionUsed(diskGrowthProjectionHorizon), store.Capacity)
 		return true
 	}
 
@@ -801,6 +839,134 @@ func constraintCheck(store roachpb.StoreDescriptor, constraints config.Constrain
 	return true, positive
 }
 
+// subConstraintsCheck returns true iff the store satisfies all of the given
+// required and prohibited constraints. Positive constraints are ignored.
+func subConstraintsCheck(store roachpb.StoreDescriptor, constraints []config.Constraint) bool {
+	for _, constraint := range constraints {
+		hasConstraint := storeHasConstraint(store, constraint)
+		switch {
+		case constraint.Type == config.Constraint_REQUIRED && !hasConstraint:
+			return false
+		case constraint.Type == config.Constraint_PROHIBITED && hasConstraint:
+			return false
+		}
+	}
+	return true
+}
+
+// analyzedConstraints is the result of checking a zone's per-replica
+// constraints against the stores of a range's existing replicas.
+type analyzedConstraints struct {
+	constraints []config.ReplicaConstraints
+	// satisfiedBy[i] holds the stores of the existing replicas that satisfy
+	// constraints[i].
+	satisfiedBy [][]roachpb.StoreID
+	// satisfies maps the store of each existing replica to the indices of the
+	// constraints that it satisfies.
+	satisfies map[roachpb.StoreID][]int
+}
+
+// analyzeConstraints determines which of the existing replicas satisfy each of
+// the given per-replica constraints. Replicas whose store descriptors can't be
+// found don't count towards any constraint.
+func analyzeConstraints(
+	getStoreDescFn func(roachpb.StoreID) (roachpb.StoreDescriptor, bool),
+	existing []roachpb.ReplicaDescriptor,
+	constraints config.Constraints,
+) analyzedConstraints {
+	analyzed := analyzedConstraints{
+		constraints: constraints.PerReplica,
+		satisfiedBy: make([][]roachpb.StoreID, len(constraints.PerReplica)),
+		satisfies:   make(map[roachpb.StoreID][]int),
+	}
+	if len(constraints.PerReplica) == 0 {
+		return analyzed
+	}
+	for _, repl := range existing {
+		store, ok := getStoreDescFn(repl.StoreID)
+		if !ok {
+			continue
+		}
+		for i, rc := range constraints.PerReplica {
+			if subConstraintsCheck(store, rc.Constraints) {
+				analyzed.satisfiedBy[i] = append(analyzed.satisfiedBy[i], store.StoreID)
+				analyzed.satisfies[store.StoreID] = append(analyzed.satisfies[store.StoreID], i)
+			}
+		}
+	}
+	return analyzed
+}
+
+// violated returns true if any of the per-replica constraints is satisfied by
+// fewer existing replicas than it requires.
+func (ac analyzedConstraints) violated() bool {
+	for i, rc := range ac.constraints {
+		if len(ac.satisfiedBy[i]) < int(rc.NumReplicas) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConstraintsViolated returns true if any of the given replicas is on a store
+// that doesn't satisfy the required and prohibited constraints that apply to
+// every replica, or if the replicas don't satisfy the counts of the per-replica
+// constraints. Replicas whose store descriptors can't be found are ignored.
+func ConstraintsViolated(
+	getStoreDescFn func(roachpb.StoreID) (roachpb.StoreDescriptor, bool),
+	replicas []roachpb.ReplicaDescriptor,
+	constraints config.Constraints,
+) bool {
+	for _, repl := range replicas {
+		store, ok := getStoreDescFn(repl.StoreID)
+		if !ok {
+			continue
+		}
+		if constraintsOk, _ := constraintCheck(store, constraints); !constraintsOk {
+			return true
+		}
+	}
+	return analyzeConstraints(getStoreDescFn, replicas, constraints).violated()
+}
+
+// allocateConstraintsCheck returns true if adding a replica to the store would
+// help satisfy a per-replica constraint that isn't yet satisfied by enough of
+// the existing replicas.
+func allocateConstraintsCheck(store roachpb.StoreDescriptor, analyzed analyzedConstraints) bool {
+	for i, rc := range analyzed.constraints {
+		if len(analyzed.satisfiedBy[i]) < int(rc.NumReplicas) &&
+			subConstraintsCheck(store, rc.Constraints) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeConstraintsCheck returns true if the existing replica on the store is
+// needed to satisfy one of the per-replica constraints, i.e. if removing it
+// would leave a constraint satisfied by fewer replicas than it requires.
+func removeConstraintsCheck(store roachpb.StoreDescriptor, analyzed analyzedConstraints) bool {
+	for _, i := range analyzed.satisfies[store.StoreID] {
+		if len(analyzed.satisfiedBy[i]) <= int(analyzed.constraints[i].NumReplicas) {
+			return true
+		}
+	}
+	return false
+}
+
+// rebalanceToConstraintsCheck returns true if a replica on the store would be
+// needed to satisfy one of the per-replica constraints if it replaced one of
+// the existing replicas that satisfy the constraint.
+func rebalanceToConstraintsCheck(store roachpb.StoreDescriptor, analyzed analyzedConstraints) bool {
+	for i, rc := range analyzed.constraints {
+		if len(analyzed.satisfiedBy[i]) <= int(rc.NumReplicas) &&
+			subConstraintsCheck(store, rc.Constraints) {
+			return true
+		}
+	}
+	return false
+}
+
 // rangeDiversityScore returns a value between 0 and 1 based on how diverse the
 // given range is. A higher score means the range is more diverse.
 // All below diversity-scoring methods should in theory be implemented by
@@ -1183,5 +1349,20 @@ func divergesFromMean(oldVal, newVal, mean float64) bool {
 
 // maxCapacityCheck returns true if the store has room for a new replica.
 func maxCapacityCheck(store roachpb.StoreDescriptor) bool {
-	return store.Capacity.FractionUsed() < maxFractionUsedThreshold
+	return store.Capacity.ProjectedFractionUsed(diskGrowthProjectionHorizon) < maxFractionUsedThreshold &&
+		!compactionDebtCheck(store)
+}
+
+// rebalanceToMaxCapacityCheck returns true if the store has enough room left
+// for it to be worth rebalancing a replica to it.
+func rebalanceToMaxCapacityCheck(store roachpb.StoreDescriptor) bool {
+	return store.Capacity.ProjectedFractionUsed(diskGrowthProjectionHorizon) < rebalanceToMaxFractionUsedThreshold &&
+		!compactionDebtCheck(store)
+}
+
+// compactionDebtCheck returns true if the store's RocksDB instance is far
+// enough behind on compactions that it's at risk of stalling writes.
+func compactionDebtCheck(store roachpb.StoreDescriptor) bool {
+	return store.Capacity.L0FileCount >= maxL0FileCountThreshold ||
+		store.Capacity.PendingCompactionBytes >= maxPendingCompactionBytesThreshold
 }
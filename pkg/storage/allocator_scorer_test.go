@@ -1157,3 +1157,51 @@ func TestMaxCapacity(t *testing.T) {
 		}
 	}
 }
+
+func TestMaxCapacityGrowthAndCompactionDebt(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// growthPerSecond is the rate at which a store with 100 bytes of capacity
+	// must grow to use one more percent of it over the projection horizon.
+	growthPerSecond := 1 / diskGrowthProjectionHorizon.Seconds()
+
+	testCases := []struct {
+		name        string
+		capacity    roachpb.StoreCapacity
+		maxOK       bool
+		rebalanceOK bool
+	}{
+		{"empty", roachpb.StoreCapacity{Capacity: 100, Available: 100}, true, true},
+		{"full", roachpb.StoreCapacity{Capacity: 100, Available: 4}, false, false},
+		{"nearly-full", roachpb.StoreCapacity{Capacity: 100, Available: 6}, true, false},
+		{"shrinking", roachpb.StoreCapacity{
+			Capacity: 100, Available: 6, UsedBytesPerSecond: -10 * growthPerSecond,
+		}, true, false},
+		{"growing", roachpb.StoreCapacity{
+			Capacity: 100, Available: 10, UsedBytesPerSecond: 3.5 * growthPerSecond,
+		}, true, false},
+		{"growing-quickly", roachpb.StoreCapacity{
+			Capacity: 100, Available: 10, UsedBytesPerSecond: 10 * growthPerSecond,
+		}, false, false},
+		{"l0-files", roachpb.StoreCapacity{
+			Capacity: 100, Available: 100, L0FileCount: maxL0FileCountThreshold,
+		}, false, false},
+		{"few-l0-files", roachpb.StoreCapacity{
+			Capacity: 100, Available: 100, L0FileCount: maxL0FileCountThreshold - 1,
+		}, true, true},
+		{"pending-compaction", roachpb.StoreCapacity{
+			Capacity: 100, Available: 100, PendingCompactionBytes: maxPendingCompactionBytesThreshold,
+		}, false, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := roachpb.StoreDescriptor{StoreID: 1, Capacity: tc.capacity}
+			if a := maxCapacityCheck(s); a != tc.maxOK {
+				t.Errorf("expected max capacity check: %t, actual %t", tc.maxOK, a)
+			}
+			if a := rebalanceToMaxCapacityCheck(s); a != tc.rebalanceOK {
+				t.Errorf("expected rebalance-to max capacity check: %t, actual %t", tc.rebalanceOK, a)
+			}
+		})
+	}
+}
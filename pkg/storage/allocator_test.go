@@ -1296,7 +1296,7 @@ func TestAllocatorTransferLeaseTarget(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				c.existing,
 				c.leaseholder,
 				0,
@@ -1350,7 +1350,7 @@ func TestAllocatorTransferLeaseTargetMultiStore(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				existing,
 				c.leaseholder,
 				0,
@@ -1413,7 +1413,7 @@ func TestAllocatorShouldTransferLease(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			result := a.ShouldTransferLease(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				c.existing,
 				c.leaseholder,
 				0,
@@ -1426,6 +1426,301 @@ func TestAllocatorShouldTransferLease(t *testing.T) {
 	}
 }
 
+func TestAllocatorLeasePreferences(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper, g, _, a, _ := createTestAllocator( /* deterministic */ true)
+	defer stopper.Stop(context.Background())
+
+	// 4 stores with distinct localities, store attributes, and node attributes
+	// where the lease count for each store is equal to 100x the store ID.
+	var stores []*roachpb.StoreDescriptor
+	for i := 1; i <= 4; i++ {
+		stores = append(stores, &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i),
+			Attrs:   roachpb.Attributes{Attrs: []string{fmt.Sprintf("s%d", i)}},
+			Node: roachpb.NodeDescriptor{
+				NodeID: roachpb.NodeID(i),
+				Attrs:  roachpb.Attributes{Attrs: []string{fmt.Sprintf("n%d", i)}},
+				Locality: roachpb.Locality{
+					Tiers: []roachpb.Tier{
+						{Key: "dc", Value: strconv.Itoa(i)},
+					},
+				},
+			},
+			Capacity: roachpb.StoreCapacity{LeaseCount: int32(100 * i)},
+		})
+	}
+	sg := gossiputil.NewStoreGossiper(g)
+	sg.GossipStores(stores, t)
+
+	preferDC1 := []config.LeasePreference{
+		{Constraints: []config.Constraint{{Key: "dc", Value: "1", Type: config.Constraint_REQUIRED}}},
+	}
+	preferDC4Then3Then2 := []config.LeasePreference{
+		{Constraints: []config.Constraint{{Key: "dc", Value: "4", Type: config.Constraint_REQUIRED}}},
+		{Constraints: []config.Constraint{{Key: "dc", Value: "3", Type: config.Constraint_REQUIRED}}},
+		{Constraints: []config.Constraint{{Key: "dc", Value: "2", Type: config.Constraint_REQUIRED}}},
+	}
+	preferN2ThenS3 := []config.LeasePreference{
+		{Constraints: []config.Constraint{{Value: "n2", Type: config.Constraint_REQUIRED}}},
+		{Constraints: []config.Constraint{{Value: "s3", Type: config.Constraint_REQUIRED}}},
+	}
+	preferNotS1ThenNotN2 := []config.LeasePreference{
+		{Constraints: []config.Constraint{{Value: "s1", Type: config.Constraint_PROHIBITED}}},
+		{Constraints: []config.Constraint{{Value: "n2", Type: config.Constraint_PROHIBITED}}},
+	}
+	preferNotS1AndNotN2 := []config.LeasePreference{
+		{
+			Constraints: []config.Constraint{
+				{Value: "s1", Type: config.Constraint_PROHIBITED},
+				{Value: "n2", Type: config.Constraint_PROHIBITED},
+			},
+		},
+	}
+	preferMatchesNothing := []config.LeasePreference{
+		{Constraints: []config.Constraint{{Key: "dc", Value: "5", Type: config.Constraint_REQUIRED}}},
+		{Constraints: []config.Constraint{{Value: "n6", Type: config.Constraint_REQUIRED}}},
+	}
+
+	replicas := func(storeIDs ...roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		var r []roachpb.ReplicaDescriptor
+		for _, storeID := range storeIDs {
+			r = append(r, roachpb.ReplicaDescriptor{
+				NodeID:    roachpb.NodeID(storeID),
+				StoreID:   storeID,
+				ReplicaID: roachpb.ReplicaID(storeID),
+			})
+		}
+		return r
+	}
+
+	// Without checking the source, the lease has to move even when the
+	// leaseholder is the preferred store, as happens when its replica is being
+	// removed. It then goes to the most preferred of the other replicas.
+	testCases := []struct {
+		leaseholder        roachpb.StoreID
+		existing           []roachpb.ReplicaDescriptor
+		preferences        []config.LeasePreference
+		expectTransfer     bool
+		expectedCheckTrue  roachpb.StoreID /* checkTransferLeaseSource = true */
+		expectedCheckFalse roachpb.StoreID /* checkTransferLeaseSource = false */
+	}{
+		{1, nil, preferDC1, false, 0, 0},
+		{1, replicas(1, 2, 3, 4), preferDC1, false, 0, 2},
+		{1, replicas(2, 3, 4), preferDC1, false, 0, 2},
+		{2, replicas(1, 2, 3, 4), preferDC1, true, 1, 1},
+		{2, replicas(2, 3, 4), preferDC1, false, 0, 0},
+		{4, replicas(2, 3, 4), preferDC1, true, 2, 2},
+		{1, nil, preferDC4Then3Then2, false, 0, 0},
+		{1, replicas(1, 2, 3, 4), preferDC4Then3Then2, true, 4, 4},
+		{1, replicas(1, 2, 3), preferDC4Then3Then2, true, 3, 3},
+		{1, replicas(1, 2), preferDC4Then3Then2, true, 2, 2},
+		{3, replicas(1, 2, 3, 4), preferDC4Then3Then2, true, 4, 4},
+		{3, replicas(1, 2, 3), preferDC4Then3Then2, false, 0, 2},
+		{3, replicas(1, 3), preferDC4Then3Then2, false, 0, 1},
+		{4, replicas(1, 2, 3, 4), preferDC4Then3Then2, false, 0, 3},
+		{4, replicas(1, 2, 3), preferDC4Then3Then2, true, 3, 3},
+		{1, nil, preferN2ThenS3, false, 0, 0},
+		{1, replicas(1, 2, 3, 4), preferN2ThenS3, true, 2, 2},
+		{1, replicas(1, 3, 4), preferN2ThenS3, true, 3, 3},
+		{2, replicas(1, 2, 3, 4), preferN2ThenS3, false, 0, 3},
+		{3, replicas(1, 2, 3, 4), preferN2ThenS3, true, 2, 2},
+		{4, replicas(1, 2, 3, 4), preferN2ThenS3, true, 2, 2},
+		{4, replicas(1, 3, 4), preferN2ThenS3, true, 3, 3},
+		{1, replicas(1, 2, 3, 4), preferNotS1ThenNotN2, true, 2, 2},
+		{2, replicas(1, 2, 3, 4), preferNotS1ThenNotN2, false, 0, 3},
+		{3, replicas(1, 2, 3, 4), preferNotS1ThenNotN2, true, 2, 2},
+		{4, replicas(1, 2, 3, 4), preferNotS1ThenNotN2, true, 2, 2},
+		{1, replicas(1, 2), preferNotS1ThenNotN2, true, 2, 2},
+		{2, replicas(1, 2), preferNotS1ThenNotN2, false, 0, 1},
+		{1, replicas(1, 3, 4), preferNotS1ThenNotN2, true, 3, 3},
+		{3, replicas(1, 3, 4), preferNotS1ThenNotN2, true, 0, 4},
+		{1, replicas(1, 2, 3, 4), preferNotS1AndNotN2, true, 3, 3},
+		{2, replicas(1, 2, 3, 4), preferNotS1AndNotN2, true, 3, 3},
+		{3, replicas(1, 2, 3, 4), preferNotS1AndNotN2, true, 0, 4},
+		{4, replicas(1, 2, 3, 4), preferNotS1AndNotN2, true, 0, 3},
+		{1, replicas(1, 2, 3), preferNotS1AndNotN2, true, 3, 3},
+		{1, replicas(1, 2, 3, 4), preferMatchesNothing, false, 0, 2},
+		{2, replicas(1, 2, 3, 4), preferMatchesNothing, false, 0, 1},
+		{3, replicas(1, 3, 4), preferMatchesNothing, true, 1, 1},
+		{4, replicas(2, 3, 4), preferMatchesNothing, true, 2, 2},
+		{2, replicas(2, 3), preferMatchesNothing, false, 0, 0},
+	}
+
+	for _, c := range testCases {
+		t.Run("", func(t *testing.T) {
+			zone := config.ZoneConfig{LeasePreferences: c.preferences}
+			result := a.ShouldTransferLease(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil, /* replicaStats */
+			)
+			if c.expectTransfer != result {
+				t.Errorf("expected %v, but found %v", c.expectTransfer, result)
+			}
+			target := a.TransferLeaseTarget(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil,   /* replicaStats */
+				true,  /* checkTransferLeaseSource */
+				true,  /* checkCandidateFullness */
+				false, /* alwaysAllowDecisionWithoutStats */
+			)
+			if c.expectedCheckTrue != target.StoreID {
+				t.Errorf("expected s%d for check=true, but found %v", c.expectedCheckTrue, target)
+			}
+			target = a.TransferLeaseTarget(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil,   /* replicaStats */
+				false, /* checkTransferLeaseSource */
+				true,  /* checkCandidateFullness */
+				false, /* alwaysAllowDecisionWithoutStats */
+			)
+			if c.expectedCheckFalse != target.StoreID {
+				t.Errorf("expected s%d for check=false, but found %v", c.expectedCheckFalse, target)
+			}
+		})
+	}
+}
+
+// TestAllocatorPerReplicaConstraints verifies that the allocator satisfies
+// per-replica constraint counts when adding, removing, and rebalancing
+// replicas, even when doing so runs counter to locality diversity.
+func TestAllocatorPerReplicaConstraints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper, g, _, a, _ := createTestAllocator( /* deterministic */ true)
+	defer stopper.Stop(context.Background())
+
+	// 6 stores, the first three in region "east" and the rest in "west".
+	var stores []*roachpb.StoreDescriptor
+	for i := 1; i <= 6; i++ {
+		region := "east"
+		if i > 3 {
+			region = "west"
+		}
+		stores = append(stores, &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i),
+			Node: roachpb.NodeDescriptor{
+				NodeID: roachpb.NodeID(i),
+				Locality: roachpb.Locality{
+					Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+				},
+			},
+		})
+	}
+	sg := gossiputil.NewStoreGossiper(g)
+	sg.GossipStores(stores, t)
+
+	replicas := func(storeIDs ...roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		var r []roachpb.ReplicaDescriptor
+		for _, storeID := range storeIDs {
+			r = append(r, roachpb.ReplicaDescriptor{
+				NodeID:    roachpb.NodeID(storeID),
+				StoreID:   storeID,
+				ReplicaID: roachpb.ReplicaID(storeID),
+			})
+		}
+		return r
+	}
+	perReplica := func(region string, numReplicas int32) config.Constraints {
+		return config.Constraints{
+			PerReplica: []config.ReplicaConstraints{
+				{
+					NumReplicas: numReplicas,
+					Constraints: []config.Constraint{
+						{Key: "region", Value: region, Type: config.Constraint_REQUIRED},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("allocate", func(t *testing.T) {
+		// Locality diversity alone would prefer a store in the west.
+		existing := replicas(1, 2)
+		result, _, err := a.AllocateTarget(
+			context.Background(),
+			perReplica("east", 3),
+			existing,
+			testRangeInfo(existing, firstRange),
+			false, /* relaxConstraints */
+			false, /* disableStatsBasedRebalancing */
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.StoreID != 3 {
+			t.Errorf("expected s3, but found %+v", result)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		// Locality diversity alone would prefer removing a store in the east.
+		existing := replicas(1, 2, 3, 4)
+		targetRepl, _, err := a.RemoveTarget(
+			context.Background(),
+			perReplica("east", 3),
+			existing,
+			testRangeInfo(existing, firstRange),
+			false, /* disableStatsBasedRebalancing */
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if targetRepl.StoreID != 4 {
+			t.Errorf("expected s4, but found %+v", targetRepl)
+		}
+	})
+
+	t.Run("rebalance", func(t *testing.T) {
+		existing := replicas(1, 4, 5)
+		result, _ := a.RebalanceTarget(
+			context.Background(),
+			perReplica("east", 2),
+			nil, /* raftStatus */
+			testRangeInfo(existing, firstRange),
+			storeFilterThrottled,
+			false, /* disableStatsBasedRebalancing */
+		)
+		if result == nil {
+			t.Fatal("expected a rebalance target, but found none")
+		}
+		if result.StoreID != 2 && result.StoreID != 3 {
+			t.Errorf("expected s2 or s3, but found %+v", result)
+		}
+	})
+
+	t.Run("violated", func(t *testing.T) {
+		testCases := []struct {
+			existing    []roachpb.ReplicaDescriptor
+			constraints config.Constraints
+			expected    bool
+		}{
+			{replicas(1, 2, 4), config.Constraints{}, false},
+			{replicas(1, 2, 4), perReplica("east", 2), false},
+			{replicas(1, 4, 5), perReplica("east", 2), true},
+			{replicas(1, 4, 5), perReplica("west", 2), false},
+			{replicas(1, 2, 3), perReplica("west", 1), true},
+		}
+		for i, c := range testCases {
+			analyzed := analyzeConstraints(a.storePool.getStoreDescriptor, c.existing, c.constraints)
+			if violated := analyzed.violated(); violated != c.expected {
+				t.Errorf("%d: expected violated=%t, but found %t", i, c.expected, violated)
+			}
+		}
+	})
+}
+
 func TestAllocatorRemoveTargetLocality(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1857,7 +2152,7 @@ func TestAllocatorTransferLeaseTargetLoadBased(t *testing.T) {
 			})
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				existing,
 				c.leaseholder,
 				0,
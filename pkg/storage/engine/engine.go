@@ -223,6 +223,14 @@ type Engine interface {
 	Flush() error
 	// GetStats retrieves stats from the engine.
 	GetStats() (*Stats, error)
+	// GetEncryptionStatus returns the encryption status of the engine, as a
+	// serialized enginepbccl.EncryptionStatus. It returns nil if the status is
+	// not available.
+	GetEncryptionStatus() ([]byte, error)
+	// ReencryptStaleFiles rewrites the files of the engine which were written
+	// before its active data key was created, so that they get encrypted with
+	// it. It returns the number of files rewritten.
+	ReencryptStaleFiles(ctx context.Context) (int, error)
 	// GetAuxiliaryDir returns a path under which files can be stored
 	// persistently, and from which data can be ingested by the engine.
 	//
@@ -313,6 +321,7 @@ type Stats struct {
 	Compactions                    int64
 	TableReadersMemEstimate        int64
 	PendingCompactionBytesEstimate int64
+	L0FileCount                    int64
 }
 
 // PutProto sets the given key to the protobuf-serialized byte string
@@ -110,6 +110,9 @@ type SSTableInfo struct {
 	Size  int64
 	Start MVCCKey
 	End   MVCCKey
+	// Name is the name of the sstable file, relative to the engine
+	// directory.
+	Name string
 }
 
 // SSTableInfos is a slice of SSTableInfo structures.
@@ -1058,6 +1061,7 @@ func (r *RocksDB) GetSSTables() SSTableInfos {
 		if ptr := tv.end_key.key.data; ptr != nil {
 			C.free(unsafe.Pointer(ptr))
 		}
+		r.Name = cStringToGoString(tv.name)
 	}
 	C.free(unsafe.Pointer(tables))
 
@@ -1098,9 +1102,38 @@ func (r *RocksDB) GetStats() (*Stats, error) {
 		Compactions:                    int64(s.compactions),
 		TableReadersMemEstimate:        int64(s.table_readers_mem_estimate),
 		PendingCompactionBytesEstimate: int64(s.pending_compaction_bytes_estimate),
+		L0FileCount:                    int64(s.l0_file_count),
 	}, nil
 }
 
+// EncryptionStatusHook is a hook point for a CCL function which returns the
+// encryption status of the store in the given directory, as a serialized
+// enginepbccl.EncryptionStatus. It is nil in non-CCL builds.
+var EncryptionStatusHook func(dir string) ([]byte, error)
+
+// GetEncryptionStatus returns the encryption status of this engine's store, or
+// nil for in-memory engines and in non-CCL builds.
+func (r *RocksDB) GetEncryptionStatus() ([]byte, error) {
+	if r.cfg.Dir == "" || EncryptionStatusHook == nil {
+		return nil, nil
+	}
+	return EncryptionStatusHook(r.cfg.Dir)
+}
+
+// ReencryptHook is a hook point for a CCL function which rewrites the
+// sstables of the engine, whose store is in the given directory, which were
+// written before its active data key was created. It is nil in non-CCL builds.
+var ReencryptHook func(ctx context.Context, dir string, r *RocksDB) (int, error)
+
+// ReencryptStaleFiles implements the Engine interface. It does nothing for
+// in-memory engines and in non-CCL builds.
+func (r *RocksDB) ReencryptStaleFiles(ctx context.Context) (int, error) {
+	if r.cfg.Dir == "" || ReencryptHook == nil {
+		return 0, nil
+	}
+	return ReencryptHook(ctx, r.cfg.Dir, r)
+}
+
 // GetCompactionStats returns the internal RocksDB compaction stats. See
 // https://github.com/facebook/rocksdb/wiki/RocksDB-Tuning-Guide#rocksdb-statistics.
 func (r *RocksDB) GetCompactionStats() string {
@@ -164,6 +164,9 @@ var (
 	metaAverageWritesPerSecond = metric.Metadata{
 		Name: "rebalancing.writespersecond",
 		Help: "Number of keys written (i.e. applied by raft) per second to the store, averaged over a large time period as used in rebalancing decisions"}
+	metaDecliningReplicas = metric.Metadata{
+		Name: "rebalancing.declining",
+		Help: "1 if the store is nearly full or behind on compactions and is declining new replicas"}
 
 	// RocksDB metrics.
 	metaRdbBlockCacheHits = metric.Metadata{
@@ -202,6 +205,12 @@ var (
 	metaRdbNumSSTables = metric.Metadata{
 		Name: "rocksdb.num-sstables",
 		Help: "Number of rocksdb SSTables"}
+	metaRdbL0FileCount = metric.Metadata{
+		Name: "rocksdb.num-l0-sstables",
+		Help: "Number of rocksdb SSTables in level 0"}
+	metaRdbPendingCompaction = metric.Metadata{
+		Name: "rocksdb.estimated-pending-compaction",
+		Help: "Estimated pending compaction bytes"}
 
 	// Range event metrics.
 	metaRangeSplits = metric.Metadata{
@@ -222,6 +231,9 @@ var (
 	metaRangeSnapshotsPreemptiveApplied = metric.Metadata{
 		Name: "range.snapshots.preemptive-applied",
 		Help: "Number of applied pre-emptive snapshots"}
+	metaRangeSnapshotsDeclinedFull = metric.Metadata{
+		Name: "range.snapshots.declined-full",
+		Help: "Number of pre-emptive snapshots declined because the store was nearly full or behind on compactions"}
 	metaRangeRaftLeaderTransfers = metric.Metadata{
 		Name: "range.raftleadertransfers",
 		Help: "Number of raft leader transfers"}
@@ -522,6 +534,7 @@ type StoreMetrics struct {
 
 	// Rebalancing metrics.
 	AverageWritesPerSecond *metric.GaugeFloat64
+	DecliningReplicas      *metric.Gauge
 
 	// RocksDB metrics.
 	RdbBlockCacheHits           *metric.Gauge
@@ -536,6 +549,8 @@ type StoreMetrics struct {
 	RdbTableReadersMemEstimate  *metric.Gauge
 	RdbReadAmplification        *metric.Gauge
 	RdbNumSSTables              *metric.Gauge
+	RdbL0FileCount              *metric.Gauge
+	RdbPendingCompaction        *metric.Gauge
 
 	// TODO(mrtracy): This should be removed as part of #4465. This is only
 	// maintained to keep the current structure of StatusSummaries; it would be
@@ -549,6 +564,7 @@ type StoreMetrics struct {
 	RangeSnapshotsGenerated         *metric.Counter
 	RangeSnapshotsNormalApplied     *metric.Counter
 	RangeSnapshotsPreemptiveApplied *metric.Counter
+	RangeSnapshotsDeclinedFull      *metric.Counter
 	RangeRaftLeaderTransfers        *metric.Counter
 
 	// Raft processing metrics.
@@ -712,6 +728,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 
 		// Rebalancing metrics.
 		AverageWritesPerSecond: metric.NewGaugeFloat64(metaAverageWritesPerSecond),
+		DecliningReplicas:      metric.NewGauge(metaDecliningReplicas),
 
 		// RocksDB metrics.
 		RdbBlockCacheHits:           metric.NewGauge(metaRdbBlockCacheHits),
@@ -726,6 +743,8 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RdbTableReadersMemEstimate:  metric.NewGauge(metaRdbTableReadersMemEstimate),
 		RdbReadAmplification:        metric.NewGauge(metaRdbReadAmplification),
 		RdbNumSSTables:              metric.NewGauge(metaRdbNumSSTables),
+		RdbL0FileCount:              metric.NewGauge(metaRdbL0FileCount),
+		RdbPendingCompaction:        metric.NewGauge(metaRdbPendingCompaction),
 
 		// Range event metrics.
 		RangeSplits:                     metric.NewCounter(metaRangeSplits),
@@ -734,6 +753,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RangeSnapshotsGenerated:         metric.NewCounter(metaRangeSnapshotsGenerated),
 		RangeSnapshotsNormalApplied:     metric.NewCounter(metaRangeSnapshotsNormalApplied),
 		RangeSnapshotsPreemptiveApplied: metric.NewCounter(metaRangeSnapshotsPreemptiveApplied),
+		RangeSnapshotsDeclinedFull:      metric.NewCounter(metaRangeSnapshotsDeclinedFull),
 		RangeRaftLeaderTransfers:        metric.NewCounter(metaRangeRaftLeaderTransfers),
 
 		// Raft processing metrics.
@@ -899,6 +919,8 @@ func (sm *StoreMetrics) updateRocksDBStats(stats engine.Stats) {
 	sm.RdbFlushes.Update(stats.Flushes)
 	sm.RdbCompactions.Update(stats.Compactions)
 	sm.RdbTableReadersMemEstimate.Update(stats.TableReadersMemEstimate)
+	sm.RdbL0FileCount.Update(stats.L0FileCount)
+	sm.RdbPendingCompaction.Update(stats.PendingCompactionBytesEstimate)
 }
 
 func (sm *StoreMetrics) leaseRequestComplete(success bool) {
@@ -5793,12 +5793,15 @@ type ReplicaMetrics struct {
 	Quiescent   bool
 	// Is this the replica which collects per-range metrics? This is done either
 	// on the leader or, if there is no leader, on the largest live replica ID.
-	RangeCounter      bool
-	Unavailable       bool
-	Underreplicated   bool
-	BehindCount       int64
-	CmdQMetricsLocal  CommandQueueMetrics
-	CmdQMetricsGlobal CommandQueueMetrics
+	RangeCounter    bool
+	Unavailable     bool
+	Underreplicated bool
+	// ConstraintViolation is set if the range's replicas don't satisfy the
+	// per-replica constraints of its zone config.
+	ConstraintViolation bool
+	BehindCount         int64
+	CmdQMetricsLocal    CommandQueueMetrics
+	CmdQMetricsGlobal   CommandQueueMetrics
 }
 
 // Metrics returns the current metrics for the replica.
@@ -5819,6 +5822,11 @@ func (r *Replica) Metrics(
 	r.cmdQMu.Unlock()
 	r.mu.RUnlock()
 
+	var getStoreDescFn func(roachpb.StoreID) (roachpb.StoreDescriptor, bool)
+	if r.store.cfg.StorePool != nil {
+		getStoreDescFn = r.store.cfg.StorePool.getStoreDescriptor
+	}
+
 	return calcReplicaMetrics(
 		ctx,
 		now,
@@ -5828,6 +5836,7 @@ func (r *Replica) Metrics(
 		raftStatus,
 		leaseStatus,
 		r.store.StoreID(),
+		getStoreDescFn,
 		quiescent,
 		cmdQMetricsLocal,
 		cmdQMetricsGlobal,
@@ -5852,6 +5861,7 @@ func calcReplicaMetrics(
 	raftStatus *raft.Status,
 	leaseStatus LeaseStatus,
 	storeID roachpb.StoreID,
+	getStoreDescFn func(roachpb.StoreID) (roachpb.StoreDescriptor, bool),
 	quiescent bool,
 	cmdQMetricsLocal CommandQueueMetrics,
 	cmdQMetricsGlobal CommandQueueMetrics,
@@ -5899,8 +5909,14 @@ func calcReplicaMetrics(
 		}
 		if zoneConfig, err := cfg.GetZoneConfigForKey(desc.StartKey); err != nil {
 			log.Error(ctx, err)
-		} else if int32(goodReplicas) < zoneConfig.NumReplicas {
-			m.Underreplicated = true
+		} else {
+			if int32(goodReplicas) < zoneConfig.NumReplicas {
+				m.Underreplicated = true
+			}
+			if len(zoneConfig.Constraints.PerReplica) > 0 && getStoreDescFn != nil {
+				m.ConstraintViolation = analyzeConstraints(
+					getStoreDescFn, desc.Replicas, zoneConfig.Constraints).violated()
+			}
 		}
 	}
 
@@ -100,6 +100,16 @@ const (
 	// Messages that provide detail about why a preemptive snapshot was rejected.
 	snapshotApplySemBusyMsg = "store busy applying snapshots and/or removing replicas"
 	storeDrainingMsg        = "store is draining"
+	storeFullMsg            = "store is nearly full or behind on compactions"
+
+	// minDiskGrowthSampleInterval is the minimum amount of time between two
+	// measurements of the store's disk usage that are used to update its disk
+	// growth rate. Shorter intervals are too noisy to be useful.
+	minDiskGrowthSampleInterval = time.Minute
+
+	// diskGrowthDecay is the weight given to the previous disk growth rate when
+	// folding in a new measurement.
+	diskGrowthDecay = 0.8
 
 	// IntersectingSnapshotMsg is part of the error message returned from
 	// canApplySnapshotLocked and is exposed here so testing can rely on it.
@@ -374,6 +384,20 @@ type Store struct {
 	// value differs by enough to justify re-gossiping the store.
 	gossipWritesPerSecondVal syncutil.AtomicFloat64
 
+	// diskGrowth tracks the rate at which the store's disk usage has recently
+	// been growing. It is gossiped as part of the store's capacity so that the
+	// allocator can move replicas away before the disk fills up.
+	diskGrowth struct {
+		syncutil.Mutex
+		lastUsed       int64
+		lastUpdate     time.Time
+		bytesPerSecond float64
+	}
+	// 1 if the store is nearly full or too far behind on compactions to take
+	// on new replicas, in which case it declines preemptive snapshots. Updated
+	// along with the capacity metrics. To be accessed using atomic ops.
+	declineNewReplicas int32
+
 	coalescedMu struct {
 		syncutil.Mutex
 		heartbeats         map[roachpb.StoreIdent][]RaftHeartbeat
@@ -2397,6 +2421,19 @@ func (s *Store) Capacity() (roachpb.StoreCapacity, error) {
 
 	capacity.RangeCount = int32(s.ReplicaCount())
 
+	stats, err := s.engine.GetStats()
+	if err != nil {
+		return capacity, err
+	}
+	capacity.L0FileCount = stats.L0FileCount
+	capacity.PendingCompactionBytes = stats.PendingCompactionBytesEstimate
+
+	used := capacity.Used
+	if used == 0 {
+		used = capacity.Capacity - capacity.Available
+	}
+	capacity.UsedBytesPerSecond = s.recordDiskUsage(used, s.cfg.Clock.PhysicalTime())
+
 	now := s.cfg.Clock.Now()
 	var leaseCount int32
 	var logicalBytes int64
@@ -2429,6 +2466,28 @@ func (s *Store) Capacity() (roachpb.StoreCapacity, error) {
 	return capacity, nil
 }
 
+// recordDiskUsage folds a new measurement of the store's disk usage into its
+// disk growth rate and returns the updated rate in bytes per second.
+func (s *Store) recordDiskUsage(used int64, now time.Time) float64 {
+	s.diskGrowth.Lock()
+	defer s.diskGrowth.Unlock()
+	if s.diskGrowth.lastUpdate.IsZero() {
+		s.diskGrowth.lastUsed = used
+		s.diskGrowth.lastUpdate = now
+		return 0
+	}
+	elapsed := now.Sub(s.diskGrowth.lastUpdate)
+	if elapsed < minDiskGrowthSampleInterval {
+		return s.diskGrowth.bytesPerSecond
+	}
+	rate := float64(used-s.diskGrowth.lastUsed) / elapsed.Seconds()
+	s.diskGrowth.bytesPerSecond = diskGrowthDecay*s.diskGrowth.bytesPerSecond +
+		(1-diskGrowthDecay)*rate
+	s.diskGrowth.lastUsed = used
+	s.diskGrowth.lastUpdate = now
+	return s.diskGrowth.bytesPerSecond
+}
+
 // ReplicaCount returns the number of replicas contained by this store. This
 // method is O(n) in the number of replicas and should not be called from
 // performance critical code.
@@ -2441,6 +2500,34 @@ func (s *Store) ReplicaCount() int {
 	return count
 }
 
+// LeaseCount returns the number of replicas of the store which hold a valid
+// range lease.
+func (s *Store) LeaseCount() int {
+	now := s.cfg.Clock.Now()
+	var count int
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		if r.OwnsValidLease(now) {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// LeaseRangeIDs returns the IDs of the ranges whose replica on the store
+// holds a valid range lease.
+func (s *Store) LeaseRangeIDs() []roachpb.RangeID {
+	now := s.cfg.Clock.Now()
+	var rangeIDs []roachpb.RangeID
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		if r.OwnsValidLease(now) {
+			rangeIDs = append(rangeIDs, r.RangeID)
+		}
+		return true
+	})
+	return rangeIDs
+}
+
 // Registry returns the store registry.
 func (s *Store) Registry() *metric.Registry {
 	return s.metrics.registry
@@ -2806,6 +2893,14 @@ func (s *Store) maybeWaitForPushee(
 func (s *Store) reserveSnapshot(
 	ctx context.Context, header *SnapshotRequest_Header,
 ) (_cleanup func(), _rejectionMsg string, _err error) {
+	if header.CanDecline && atomic.LoadInt32(&s.declineNewReplicas) == 1 {
+		// Preemptive snapshots add new replicas to the store, which a store that
+		// is about to run out of disk space or stall its writes can't afford. The
+		// allocator should already be avoiding this store, but its view of the
+		// store's capacity may be stale.
+		s.metrics.RangeSnapshotsDeclinedFull.Inc(1)
+		return nil, storeFullMsg, nil
+	}
 	if header.RangeSize == 0 {
 		// Empty snapshots are exempt from rate limits because they're so cheap to
 		// apply. This vastly speeds up rebalancing any empty ranges created by a
@@ -4173,7 +4268,7 @@ func (s *Store) canApplySnapshotLocked(
 	return placeholder, nil
 }
 
-func (s *Store) updateCapacityGauges() error {
+func (s *Store) updateCapacityGauges(ctx context.Context) error {
 	desc, err := s.Descriptor()
 	if err != nil {
 		return err
@@ -4181,10 +4276,35 @@ func (s *Store) updateCapacityGauges() error {
 	s.metrics.Capacity.Update(desc.Capacity.Capacity)
 	s.metrics.Available.Update(desc.Capacity.Available)
 	s.metrics.Used.Update(desc.Capacity.Used)
+	s.updateDeclineNewReplicas(ctx, *desc)
 
 	return nil
 }
 
+// updateDeclineNewReplicas decides whether the store should decline new
+// replicas based on its most recent capacity. The allocator stops using stores
+// in this state as targets and sheds replicas from them; the store additionally
+// declines preemptive snapshots and warns loudly so that operators can add
+// capacity before foreground traffic is affected.
+func (s *Store) updateDeclineNewReplicas(ctx context.Context, desc roachpb.StoreDescriptor) {
+	var decline int32
+	if !maxCapacityCheck(desc) {
+		decline = 1
+	}
+	s.metrics.DecliningReplicas.Update(int64(decline))
+	if old := atomic.SwapInt32(&s.declineNewReplicas, decline); old == decline {
+		return
+	}
+	if decline == 1 {
+		log.Warningf(ctx, "store is nearly full or behind on compactions and will decline new "+
+			"replicas; consider adding capacity: fraction-used=%.2f, projected-fraction-used=%.2f, %s",
+			desc.Capacity.FractionUsed(),
+			desc.Capacity.ProjectedFractionUsed(diskGrowthProjectionHorizon), desc.Capacity)
+	} else {
+		log.Infof(ctx, "store has recovered and will accept new replicas: %s", desc.Capacity)
+	}
+}
+
 // updateReplicationGauges counts a number of simple replication statistics for
 // the ranges in this store.
 // TODO(bram): #4564 It may be appropriate to compute these statistics while
@@ -4345,7 +4465,7 @@ func (s *Store) updateCommandQueueGauges() error {
 // by a higher-level system which records store metrics.
 func (s *Store) ComputeMetrics(ctx context.Context, tick int) error {
 	ctx = s.AnnotateCtx(ctx)
-	if err := s.updateCapacityGauges(); err != nil {
+	if err := s.updateCapacityGauges(ctx); err != nil {
 		return err
 	}
 	if err := s.updateReplicationGauges(ctx); err != nil {
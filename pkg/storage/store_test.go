@@ -3085,6 +3085,75 @@ func TestReserveSnapshotThrottling(t *testing.T) {
 	}
 }
 
+// TestReserveSnapshotFullStore verifies that a store which is nearly full
+// declines preemptive snapshots but still accepts Raft snapshots.
+func TestReserveSnapshotFullStore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc := testContext{}
+	tc.Start(t, stopper)
+	s := tc.store
+
+	ctx := context.Background()
+
+	desc, err := s.Descriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc.Capacity = roachpb.StoreCapacity{Capacity: 100, Available: 1}
+	s.updateDeclineNewReplicas(ctx, *desc)
+	if n := s.metrics.DecliningReplicas.Value(); n != 1 {
+		t.Fatalf("expected declining gauge to be 1, but found %d", n)
+	}
+
+	cleanup, rejectionMsg, err := s.reserveSnapshot(ctx, &SnapshotRequest_Header{
+		RangeSize:  1,
+		CanDecline: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejectionMsg != storeFullMsg {
+		t.Fatalf("expected rejection message %q, got %q", storeFullMsg, rejectionMsg)
+	}
+	if cleanup != nil {
+		t.Fatalf("got unexpected non-nil cleanup method")
+	}
+	if n := s.metrics.RangeSnapshotsDeclinedFull.Count(); n != 1 {
+		t.Fatalf("expected 1 declined snapshot, but found %d", n)
+	}
+
+	// Raft snapshots can't be declined, since they're needed to catch up
+	// existing replicas.
+	cleanup, rejectionMsg, err = s.reserveSnapshot(ctx, &SnapshotRequest_Header{
+		RangeSize: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejectionMsg != "" {
+		t.Fatalf("expected no rejection message, got %q", rejectionMsg)
+	}
+	cleanup()
+
+	// Once the store has room again, preemptive snapshots are accepted.
+	desc.Capacity = roachpb.StoreCapacity{Capacity: 100, Available: 50}
+	s.updateDeclineNewReplicas(ctx, *desc)
+	cleanup, rejectionMsg, err = s.reserveSnapshot(ctx, &SnapshotRequest_Header{
+		RangeSize:  1,
+		CanDecline: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejectionMsg != "" {
+		t.Fatalf("expected no rejection message, got %q", rejectionMsg)
+	}
+	cleanup()
+}
+
 func TestSnapshotRateLimit(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
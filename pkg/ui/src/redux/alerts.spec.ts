@@ -13,6 +13,7 @@ import {
   staggeredVersionWarningSelector, staggeredVersionDismissedSetting,
   newVersionNotificationSelector, newVersionDismissedLocalSetting,
   disconnectedAlertSelector, disconnectedDismissedLocalSetting,
+  decliningStoresWarningSelector, decliningStoresDismissedLocalSetting,
 } from "./alerts";
 import { VERSION_DISMISSED_KEY, setUIDataKey, isInFlight } from "./uiData";
 import {
@@ -167,6 +168,54 @@ describe("alerts", function() {
       });
     });
 
+    describe("declining stores warning", function () {
+      const nodesWithDecliningStores = (declining: number) => [
+        {
+          store_statuses: [
+            { metrics: { "rebalancing.declining": declining } },
+            { metrics: { "rebalancing.declining": 0 } },
+          ],
+        },
+      ];
+
+      it("requires node statuses to be loaded before displaying", function () {
+        const alert = decliningStoresWarningSelector(state());
+        assert.isUndefined(alert);
+      });
+
+      it("does not display when no stores are declining replicas", function () {
+        dispatch(nodesReducerObj.receiveData(nodesWithDecliningStores(0)));
+        const alert = decliningStoresWarningSelector(state());
+        assert.isUndefined(alert);
+      });
+
+      it("displays when a store is declining replicas", function () {
+        dispatch(nodesReducerObj.receiveData(nodesWithDecliningStores(1)));
+        const alert = decliningStoresWarningSelector(state());
+        assert.isObject(alert);
+        assert.equal(alert.level, AlertLevel.WARNING);
+        assert.equal(alert.title, "Stores Nearly Full");
+      });
+
+      it("does not display if dismissed locally", function () {
+        dispatch(nodesReducerObj.receiveData(nodesWithDecliningStores(1)));
+        dispatch(decliningStoresDismissedLocalSetting.set(moment()));
+        const alert = decliningStoresWarningSelector(state());
+        assert.isUndefined(alert);
+      });
+
+      it("dismisses by setting local dismissal", function (done) {
+        dispatch(nodesReducerObj.receiveData(nodesWithDecliningStores(1)));
+        const alert = decliningStoresWarningSelector(state());
+        const beforeDismiss = moment();
+
+        dispatch(alert.dismiss).then(() => {
+          assert.isTrue(decliningStoresDismissedLocalSetting.selector(state()).isSameOrAfter(beforeDismiss));
+          done();
+        });
+      });
+    });
+
     describe("new version available notification", function () {
       it("displays nothing when versions have not yet been loaded", function () {
         dispatch(setUIDataKey(VERSION_DISMISSED_KEY, null));
@@ -9,6 +9,8 @@ import { createSelector } from "reselect";
 import { Store } from "redux";
 import { ThunkAction } from "redux-thunk";
 
+import { MetricConstants } from "src/util/proto";
+
 import { LocalSetting } from "./localsettings";
 import {
   saveUIData, VERSION_DISMISSED_KEY, loadUIData, isInFlight, UIDataState,
@@ -201,6 +203,47 @@ export const disconnectedAlertSelector = createSelector(
   },
 );
 
+export const decliningStoresDismissedLocalSetting = new LocalSetting(
+  "declining_stores_dismissed", localSettingsSelector, moment(0),
+);
+
+/**
+ * Warning when one or more stores are nearly full or so far behind on
+ * compactions that they are declining new replicas.
+ */
+export const decliningStoresWarningSelector = createSelector(
+  nodeStatusesSelector,
+  decliningStoresDismissedLocalSetting.selector,
+  (nodeStatuses, decliningStoresDismissed): Alert => {
+    const decliningStores = _.sumBy(nodeStatuses, (status) =>
+      _.filter(status.store_statuses, (ss) =>
+        ss.metrics && ss.metrics[MetricConstants.decliningReplicas] > 0,
+      ).length,
+    );
+    if (decliningStores === 0) {
+      return undefined;
+    }
+
+    // Allow local dismissal for one hour.
+    const dismissedMaxTime = moment().subtract(1, "h");
+    if (decliningStoresDismissed.isAfter(dismissedMaxTime)) {
+      return undefined;
+    }
+
+    return {
+      level: AlertLevel.WARNING,
+      title: "Stores Nearly Full",
+      text: `${decliningStores} ${decliningStores === 1 ? "store is" : "stores are"} nearly
+      full or falling behind on compactions and no longer accepting new replicas.
+      Add capacity to the cluster before foreground traffic is affected.`,
+      dismiss: (dispatch) => {
+        dispatch(decliningStoresDismissedLocalSetting.set(moment()));
+        return Promise.resolve();
+      },
+    };
+  },
+);
+
 /**
  * Selector which returns an array of all active alerts which should be
  * displayed in the alerts panel, which is embedded within the cluster overview
@@ -209,6 +252,7 @@ export const disconnectedAlertSelector = createSelector(
 export const panelAlertsSelector = createSelector(
   newVersionNotificationSelector,
   staggeredVersionWarningSelector,
+  decliningStoresWarningSelector,
   (...alerts: Alert[]): Alert[] => {
     return _.without(alerts, null, undefined);
   },
@@ -49,16 +49,22 @@ export const NODE_DECOMMISSIONED = "node_decommissioned";
 export const NODE_RECOMMISSIONED = "node_recommissioned";
 // Recorded when a cluster setting is changed.
 export const SET_CLUSTER_SETTING = "set_cluster_setting";
+// Recorded when a node started with a settings file changes a cluster setting
+// whose value differs from the file.
+export const CLUSTER_SETTING_DRIFT = "cluster_setting_drift";
+// Recorded when a SQL connection is rejected by the host-based authentication
+// configuration.
+export const REJECT_CONNECTION = "reject_connection";
 
 // Node Event Types
-export const nodeEvents = [NODE_JOIN, NODE_RESTART, NODE_DECOMMISSIONED, NODE_RECOMMISSIONED];
+export const nodeEvents = [NODE_JOIN, NODE_RESTART, NODE_DECOMMISSIONED, NODE_RECOMMISSIONED, REJECT_CONNECTION];
 export const databaseEvents = [CREATE_DATABASE, DROP_DATABASE];
 export const tableEvents = [
   CREATE_TABLE, DROP_TABLE, ALTER_TABLE, CREATE_INDEX, ALTER_INDEX,
   DROP_INDEX, CREATE_VIEW, DROP_VIEW, REVERSE_SCHEMA_CHANGE, FINISH_SCHEMA_CHANGE,
   FINISH_SCHEMA_CHANGE_ROLLBACK,
 ];
-export const settingsEvents = [SET_CLUSTER_SETTING];
+export const settingsEvents = [SET_CLUSTER_SETTING, CLUSTER_SETTING_DRIFT];
 export const allEvents = [...nodeEvents, ...databaseEvents, ...tableEvents, ...settingsEvents];
 
 const nodeEventSet = _.invert(nodeEvents);
@@ -20,6 +20,11 @@ export function getEventDescription(e: Event$Properties): string {
     SequenceName: string,
     SettingName: string,
     Value: string,
+    PreviousValue: string,
+    SettingsFile: string,
+    Database: string,
+    ClientAddr: string,
+    Reason: string,
   } = protobuf.util.isset(e, "info") ? JSON.parse(e.info) : {};
   const targetId: number = e.target_id ? e.target_id.toNumber() : null;
 
@@ -73,6 +78,10 @@ export function getEventDescription(e: Event$Properties): string {
       return `Node Rejoined: Node ${targetId} rejoined the cluster`;
     case eventTypes.SET_CLUSTER_SETTING:
       return `Cluster Setting Changed: User ${info.User} set ${info.SettingName} to ${info.Value}`;
+    case eventTypes.CLUSTER_SETTING_DRIFT:
+      return `Cluster Setting Drift Corrected: ${info.SettingName} was changed from ${info.PreviousValue} to ${info.Value} by settings file ${info.SettingsFile}`;
+    case eventTypes.REJECT_CONNECTION:
+      return `Connection Rejected: Node ${targetId} rejected a connection from ${info.ClientAddr} for user ${info.User}: ${info.Reason}`;
     default:
       return `Unknown Event Type: ${e.event_type}, content: ${JSON.stringify(info, null, 2)}`;
   }
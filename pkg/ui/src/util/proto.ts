@@ -62,6 +62,7 @@ export namespace MetricConstants {
   export const usedCapacity: string = "capacity.used";
   export const sysBytes: string = "sysbytes";
   export const sysCount: string = "syscount";
+  export const decliningReplicas: string = "rebalancing.declining";
 
   // Node level metrics.
   export const userCPUPercent: string = "sys.cpu.user.percent";
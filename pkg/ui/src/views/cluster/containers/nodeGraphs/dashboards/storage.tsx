@@ -94,6 +94,21 @@ export default function (props: GraphDashboardProps) {
     >
       <Axis label="sstables">
         <Metric name="cr.store.rocksdb.num-sstables" title="SSTables" />
+        <Metric name="cr.store.rocksdb.num-l0-sstables" title="L0 SSTables" />
+      </Axis>
+    </LineGraph>,
+
+    <LineGraph
+      title="RocksDB Compaction Debt"
+      sources={storeSources}
+      tooltip={
+        `The estimated number of bytes RocksDB must compact to bring its
+          levels back into shape ${tooltipSelection}. Stores that fall too far
+          behind decline new replicas.`
+      }
+    >
+      <Axis units={AxisUnits.Bytes} label="bytes">
+        <Metric name="cr.store.rocksdb.estimated-pending-compaction" title="Pending Compaction" />
       </Axis>
     </LineGraph>,
 
@@ -37,6 +37,10 @@ const connectionTableColumns: ConnectionTableColumn[] = [
     title: "Underreplicated (or slow)",
     extract: (problem) => problem.underreplicated_range_ids.length,
   },
+  {
+    title: "Constraint Violation",
+    extract: (problem) => problem.constraint_violating_range_ids.length,
+  },
   {
     title: "Total",
     extract: (problem) => {
@@ -44,7 +48,8 @@ const connectionTableColumns: ConnectionTableColumn[] = [
         problem.no_raft_leader_range_ids.length +
         problem.no_lease_range_ids.length +
         problem.raft_leader_not_lease_holder_range_ids.length +
-        problem.underreplicated_range_ids.length;
+        problem.underreplicated_range_ids.length +
+        problem.constraint_violating_range_ids.length;
     },
   },
   { title: "Error", extract: (problem) => problem.error_message },
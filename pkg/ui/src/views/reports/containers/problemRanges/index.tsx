@@ -171,6 +171,11 @@ class ProblemRanges extends React.Component<ProblemRangesProps, {}> {
           problems={problems}
           extract={(problem) => problem.underreplicated_range_ids}
         />
+        <ProblemRangeList
+          name="Constraint Violation"
+          problems={problems}
+          extract={(problem) => problem.constraint_violating_range_ids}
+        />
         <ConnectionsTable problemRanges={problemRanges} />
       </div>
     );
@@ -577,6 +577,8 @@ func init() {
 	logging.setVState(0, nil, false)
 	logging.exitFunc = os.Exit
 	logging.gcNotify = make(chan struct{}, 1)
+	logging.fileMaxSize = &LogFileMaxSize
+	logging.combinedMaxSize = &LogFilesCombinedMaxSize
 
 	go logging.flushDaemon()
 }
@@ -597,6 +599,7 @@ func StartGCDaemon() {
 // Flush flushes all pending log I/O.
 func Flush() {
 	logging.lockAndFlushAll()
+	secondaryLogRegistry.flushAll()
 }
 
 // SetSync configures whether logging synchronizes all writes.
@@ -651,6 +654,14 @@ type loggingT struct {
 	exitFunc  func(int)     // func that will be called on fatal errors
 	gcNotify  chan struct{} // notify GC daemon that a new log file was created
 
+	// prefix is added to the program name in the names of the log files.
+	// It is empty for the main logger, and only set for secondary loggers.
+	prefix string
+	// fileMaxSize and combinedMaxSize point to the maximum size of each
+	// log file and of all the log files of this logger, respectively.
+	// They are accessed atomically.
+	fileMaxSize, combinedMaxSize *int64
+
 	interceptor atomic.Value // InterceptorFn
 }
 
@@ -923,7 +934,7 @@ func (sb *syncBuffer) Sync() error {
 }
 
 func (sb *syncBuffer) Write(p []byte) (n int, err error) {
-	if sb.nbytes+int64(len(p)) >= atomic.LoadInt64(&LogFileMaxSize) {
+	if sb.nbytes+int64(len(p)) >= atomic.LoadInt64(sb.logger.fileMaxSize) {
 		if err := sb.rotateFile(timeutil.Now()); err != nil {
 			sb.logger.exitLocked(err)
 		}
@@ -947,7 +958,7 @@ func (sb *syncBuffer) rotateFile(now time.Time) error {
 		}
 	}
 	var err error
-	sb.file, sb.lastRotation, _, err = create(now, sb.lastRotation)
+	sb.file, sb.lastRotation, _, err = create(sb.logger.prefix, now, sb.lastRotation)
 	sb.nbytes = 0
 	if err != nil {
 		return err
@@ -956,8 +967,10 @@ func (sb *syncBuffer) rotateFile(now time.Time) error {
 	// Redirect stderr to the current INFO log file in order to capture panic
 	// stack traces that are written by the Go runtime to stderr. Note that if
 	// --logtostderr is true we'll never enter this code path and panic stack
-	// traces will go to the original stderr as you would expect.
-	if logging.stderrThreshold > Severity_INFO && !logging.noStderrRedirect {
+	// traces will go to the original stderr as you would expect. Only
+	// the main logger captures stderr.
+	if sb.logger == &logging &&
+		logging.stderrThreshold > Severity_INFO && !logging.noStderrRedirect {
 		// NB: any concurrent output to stderr may straddle the old and new
 		// files. This doesn't apply to log messages as we won't reach this code
 		// unless we're not logging to stderr.
@@ -992,11 +1005,11 @@ func (sb *syncBuffer) rotateFile(now time.Time) error {
 		if err != nil {
 			return err
 		}
-		logging.putBuffer(buf)
+		sb.logger.putBuffer(buf)
 	}
 
 	select {
-	case logging.gcNotify <- struct{}{}:
+	case sb.logger.gcNotify <- struct{}{}:
 	default:
 	}
 	return nil
@@ -1016,6 +1029,9 @@ func (l *loggingT) closeFileLocked() error {
 		}
 		l.file = nil
 	}
+	if l != &logging {
+		return nil
+	}
 	return restoreStderr()
 }
 
@@ -1046,6 +1062,7 @@ func (l *loggingT) flushDaemon() {
 			l.flushAll()
 		}
 		l.mu.Unlock()
+		secondaryLogRegistry.flushAll()
 	}
 }
 
@@ -1072,14 +1089,22 @@ func (l *loggingT) flushAll() {
 	}
 }
 
+// gcDaemon removes old log files. The secondary loggers share the
+// gcNotify channel of the main logger, so their files are collected
+// here as well.
 func (l *loggingT) gcDaemon() {
 	l.gcOldFiles()
+	secondaryLogRegistry.gcOldFiles()
 	for range l.gcNotify {
 		l.mu.Lock()
-		if !l.disableDaemons {
+		disabled := l.disableDaemons
+		if !disabled {
 			l.gcOldFiles()
 		}
 		l.mu.Unlock()
+		if !disabled {
+			secondaryLogRegistry.gcOldFiles()
+		}
 	}
 }
 
@@ -1096,8 +1121,17 @@ func (l *loggingT) gcOldFiles() {
 		return
 	}
 
-	logFilesCombinedMaxSize := atomic.LoadInt64(&LogFilesCombinedMaxSize)
-	files := selectFiles(allFiles, math.MaxInt64)
+	// Only consider the files written by this logger: each secondary
+	// logger is responsible for the retention of its own files.
+	ownFiles := allFiles[:0]
+	for _, f := range allFiles {
+		if l.ownsFile(f.Details) {
+			ownFiles = append(ownFiles, f)
+		}
+	}
+
+	logFilesCombinedMaxSize := atomic.LoadInt64(l.combinedMaxSize)
+	files := selectFiles(ownFiles, math.MaxInt64)
 	if len(files) == 0 {
 		return
 	}
@@ -1116,6 +1150,17 @@ func (l *loggingT) gcOldFiles() {
 	}
 }
 
+// ownsFile returns whether the log file with the given details was
+// written by this logger.
+func (l *loggingT) ownsFile(details FileDetails) bool {
+	if l.prefix != "" {
+		return details.Program == logProgramName(l.prefix)
+	}
+	// The main logger owns all the log files except those written by
+	// secondary loggers, whose names extend the program name.
+	return !strings.HasPrefix(details.Program, logProgramName("")+"-")
+}
+
 // copyStandardLogTo arranges for messages written to the Go "log"
 // package's default logs to also appear in the CockroachDB logs with
 // the specified severity.  Subsequent changes to the standard log's
@@ -159,25 +159,38 @@ func removePeriods(s string) string {
 	return strings.Replace(s, ".", "", -1)
 }
 
-// logName returns a new log file name with start time t, and the name
-// for the symlink.
-func logName(t time.Time) (name, link string) {
+// logProgramName returns the program name used in the names of the log
+// files with the given prefix. The main log files use an empty prefix;
+// secondary log files are named after the program and their prefix.
+func logProgramName(prefix string) string {
+	if prefix == "" {
+		return removePeriods(program)
+	}
+	return removePeriods(program) + "-" + removePeriods(prefix)
+}
+
+// logName returns a new log file name with the given prefix and start
+// time t, and the name for the symlink.
+func logName(prefix string, t time.Time) (name, link string) {
 	// Replace the ':'s in the time format with '_'s to allow for log files in
 	// Windows.
 	tFormatted := strings.Replace(t.Format(time.RFC3339), ":", "_", -1)
 
 	name = fmt.Sprintf("%s.%s.%s.%s.%06d.log",
-		removePeriods(program),
+		logProgramName(prefix),
 		removePeriods(host),
 		removePeriods(userName),
 		tFormatted,
 		pid)
-	return name, removePeriods(program) + ".log"
+	return name, logProgramName(prefix) + ".log"
 }
 
 var errMalformedName = errors.New("malformed log filename")
 
-func parseLogFilename(filename string) (FileDetails, error) {
+// ParseLogFilename parses the details of a log file from its name. It
+// returns an error if the name is not the name of a log file, as is the
+// case of the symlinks to the latest log files.
+func ParseLogFilename(filename string) (FileDetails, error) {
 	matches := logFileRE.FindStringSubmatch(filename)
 	if matches == nil || len(matches) != 6 {
 		return FileDetails{}, errMalformedName
@@ -206,11 +219,11 @@ func parseLogFilename(filename string) (FileDetails, error) {
 
 var errDirectoryNotSet = errors.New("log: log directory not set")
 
-// create creates a new log file and returns the file and its
-// filename. If the file is created successfully, create also attempts
-// to update the symlink for that tag, ignoring errors.
+// create creates a new log file with the given prefix and returns the
+// file and its filename. If the file is created successfully, create
+// also attempts to update the symlink for that tag, ignoring errors.
 func create(
-	t time.Time, lastRotation int64,
+	prefix string, t time.Time, lastRotation int64,
 ) (f *os.File, updatedRotation int64, filename string, err error) {
 	dir, err := logDir.get()
 	if err != nil {
@@ -227,7 +240,7 @@ func create(
 	t = timeutil.Unix(unix, 0)
 
 	// Generate the file name.
-	name, link := logName(t)
+	name, link := logName(prefix, t)
 	fname := filepath.Join(dir, name)
 	// Open the file os.O_APPEND|os.O_CREATE rather than use os.Create.
 	// Append is almost always more efficient than O_RDRW on most modern file systems.
@@ -268,7 +281,7 @@ func ListLogFiles() ([]FileInfo, error) {
 	}
 	for _, info := range infos {
 		if info.Mode().IsRegular() {
-			details, err := parseLogFilename(info.Name())
+			details, err := ParseLogFilename(info.Name())
 			if err == nil {
 				results = append(results, FileInfo{
 					Name:         info.Name(),
@@ -349,7 +362,7 @@ func GetLogReader(filename string, restricted bool) (io.ReadCloser, error) {
 	}
 
 	// Check that the file name is valid.
-	if _, err := parseLogFilename(filepath.Base(filename)); err != nil {
+	if _, err := ParseLogFilename(filepath.Base(filename)); err != nil {
 		return nil, err
 	}
 
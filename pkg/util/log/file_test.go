@@ -24,7 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
-// TestLogFilenameParsing ensures that logName and parseLogFilename work as
+// TestLogFilenameParsing ensures that logName and ParseLogFilename work as
 // advertised.
 func TestLogFilenameParsing(t *testing.T) {
 	testCases := []time.Time{
@@ -34,8 +34,8 @@ func TestLogFilenameParsing(t *testing.T) {
 	}
 
 	for i, testCase := range testCases {
-		filename, _ := logName(testCase)
-		details, err := parseLogFilename(filename)
+		filename, _ := logName("" /* prefix */, testCase)
+		details, err := ParseLogFilename(filename)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -54,7 +54,7 @@ func TestSelectFiles(t *testing.T) {
 	year2200 := time.Date(2200, time.January, 1, 1, 0, 0, 0, time.UTC)
 	for i := 0; i < 100; i++ {
 		fileTime := year2000.AddDate(i, 0, 0)
-		name, _ := logName(fileTime)
+		name, _ := logName("" /* prefix */, fileTime)
 		testfile := FileInfo{
 			Name: name,
 			Details: FileDetails{
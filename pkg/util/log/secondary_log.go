@@ -0,0 +1,120 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"context"
+	"os"
+
+	"github.com/cockroachdb/cockroach/pkg/util/caller"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// SecondaryLogger represents a secondary logging channel whose events
+// go to dedicated files in the log directory instead of the main log
+// files. The files of a secondary logger are named after the program
+// and the logger's prefix (e.g. cockroach-sql-audit.*.log), and are
+// rotated and garbage collected according to their own size limits.
+type SecondaryLogger struct {
+	logger loggingT
+}
+
+// secondaryLogRegistry keeps track of all the secondary loggers, so
+// that they can be flushed and garbage collected along with the main
+// logger.
+var secondaryLogRegistry secondaryLogs
+
+type secondaryLogs struct {
+	mu      syncutil.Mutex
+	loggers []*SecondaryLogger
+}
+
+// NewSecondaryLogger creates a secondary logger writing to files with
+// the given prefix. The maximum size of each file and the maximum
+// combined size of all the files of the logger are read atomically
+// from fileMaxSize and combinedMaxSize, so that they can be adjusted
+// at run time. If forceSyncWrites is set, every entry is synced to
+// disk before Logf returns.
+//
+// The secondary logger only writes to files: when no log directory is
+// configured, its entries are discarded.
+func NewSecondaryLogger(
+	prefix string, fileMaxSize, combinedMaxSize *int64, forceSyncWrites bool,
+) *SecondaryLogger {
+	l := &SecondaryLogger{
+		logger: loggingT{
+			stderrThreshold:  Severity_NONE,
+			fileThreshold:    Severity_INFO,
+			noStderrRedirect: true,
+			syncWrites:       forceSyncWrites,
+			exitFunc:         os.Exit,
+			// The main GC daemon takes care of the files of all the
+			// secondary loggers.
+			gcNotify:        logging.gcNotify,
+			prefix:          prefix,
+			fileMaxSize:     fileMaxSize,
+			combinedMaxSize: combinedMaxSize,
+		},
+	}
+	secondaryLogRegistry.mu.Lock()
+	defer secondaryLogRegistry.mu.Unlock()
+	secondaryLogRegistry.loggers = append(secondaryLogRegistry.loggers, l)
+	return l
+}
+
+// Logf logs an event to the secondary logger. The log tags of ctx are
+// included in the entry.
+func (l *SecondaryLogger) Logf(ctx context.Context, format string, args ...interface{}) {
+	file, line, _ := caller.Lookup(1)
+	msg := MakeMessage(ctx, format, args)
+	l.logger.outputLogEntry(Severity_INFO, file, line, msg)
+}
+
+// flushAll flushes the files of all the secondary loggers.
+func (r *secondaryLogs) flushAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.loggers {
+		l.logger.lockAndFlushAll()
+	}
+}
+
+// gcOldFiles removes the old files of all the secondary loggers.
+func (r *secondaryLogs) gcOldFiles() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.loggers {
+		l.logger.mu.Lock()
+		l.logger.gcOldFiles()
+		l.logger.mu.Unlock()
+	}
+}
+
+// closeFiles closes the current files of all the secondary loggers, so
+// that the next entry creates a new file. This is used when the log
+// directory changes.
+func (r *secondaryLogs) closeFiles() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.loggers {
+		l.logger.mu.Lock()
+		err := l.logger.closeFileLocked()
+		l.logger.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSecondaryLog(t *testing.T) {
+	s := ScopeWithoutShowLogs(t)
+	defer s.Close(t)
+
+	fileMaxSize := int64(1) // rotate on every entry
+	combinedMaxSize := int64(1 << 20)
+	l := NewSecondaryLogger("test-secondary", &fileMaxSize, &combinedMaxSize, true /* forceSyncWrites */)
+
+	ctx := context.Background()
+	Infof(ctx, "main message")
+	for i := 0; i < 3; i++ {
+		l.Logf(ctx, "secondary message %d", i)
+	}
+	Flush()
+
+	// readFiles returns the contents of the main and secondary log files,
+	// and the number of secondary log files.
+	readFiles := func() (mainContents, secondaryContents string, numSecondary int) {
+		files, err := ListLogFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			b, err := ioutil.ReadFile(filepath.Join(s.logDir, f.Name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Details.Program == logProgramName("test-secondary") {
+				numSecondary++
+				secondaryContents += string(b)
+			} else {
+				mainContents += string(b)
+			}
+		}
+		return mainContents, secondaryContents, numSecondary
+	}
+
+	mainContents, secondaryContents, numSecondary := readFiles()
+	if numSecondary < 3 {
+		t.Errorf("expected at least 3 secondary log files, found %d", numSecondary)
+	}
+	for i := 0; i < 3; i++ {
+		if msg := fmt.Sprintf("secondary message %d", i); !strings.Contains(secondaryContents, msg) {
+			t.Errorf("expected %q in the secondary log files", msg)
+		}
+	}
+	if strings.Contains(secondaryContents, "main message") {
+		t.Error("unexpected main message in the secondary log files")
+	}
+	if !strings.Contains(mainContents, "main message") {
+		t.Error("expected main message in the main log files")
+	}
+	if strings.Contains(mainContents, "secondary message") {
+		t.Error("unexpected secondary message in the main log files")
+	}
+
+	// The GC of the secondary logger only removes its own files, and
+	// always keeps the most recent one.
+	atomic.StoreInt64(&combinedMaxSize, 1)
+	secondaryLogRegistry.gcOldFiles()
+	newMainContents, _, numSecondary := readFiles()
+	if numSecondary != 1 {
+		t.Errorf("expected 1 secondary log file after GC, found %d", numSecondary)
+	}
+	if !strings.Contains(newMainContents, "main message") {
+		t.Error("expected main message in the main log files after GC")
+	}
+
+	// The main logger doesn't remove the files of the secondary loggers.
+	if logging.ownsFile(FileDetails{Program: logProgramName("test-secondary")}) {
+		t.Error("expected the secondary log files not to be owned by the main logger")
+	}
+	if !logging.ownsFile(FileDetails{Program: logProgramName("")}) {
+		t.Error("expected the main log files to be owned by the main logger")
+	}
+}
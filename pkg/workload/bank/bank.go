@@ -26,9 +26,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 
-	"github.com/cockroachdb/cockroach/pkg/testutils/workload"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/workload"
 )
 
 const (
@@ -120,8 +120,8 @@ func (b *bank) Tables() []workload.Table {
 			// Minus 2 for the single quotes
 			bytes = bytes[:b.payloadBytes-len(initialPrefix)-2]
 			return []string{
-				strconv.Itoa(rowIdx), // id
-				`0`,                  // balance
+				strconv.Itoa(rowIdx),              // id
+				`0`,                               // balance
 				`'` + initialPrefix + bytes + `'`, // payload
 			}
 		},
@@ -134,7 +134,7 @@ func (b *bank) Ops() []workload.Operation {
 	// TODO(dan): Move the various queries in the backup/restore tests here.
 	op := workload.Operation{
 		Name: `balance transfers`,
-		Fn: func(sqlDB *gosql.DB) (func(context.Context) error, error) {
+		Fn: func(sqlDB *gosql.DB, hists *workload.Histograms) (func(context.Context) error, error) {
 			rng := rand.New(rand.NewSource(b.seed))
 			updateStmt, err := sqlDB.Prepare(`
 				UPDATE bank
@@ -145,6 +145,8 @@ func (b *bank) Ops() []workload.Operation {
 				return nil, err
 			}
 
+			hist := hists.Get(`transfer`)
+
 			return func(ctx context.Context) error {
 				from := rng.Intn(b.rows)
 				to := rng.Intn(b.rows - 1)
@@ -152,7 +154,9 @@ func (b *bank) Ops() []workload.Operation {
 					to = rng.Intn(b.rows - 1)
 				}
 				amount := rand.Intn(maxTransfer)
+				start := timeutil.Now()
 				_, err := updateStmt.ExecContext(ctx, from, to, amount)
+				hist.Record(timeutil.Since(start))
 				return err
 			}, nil
 		},
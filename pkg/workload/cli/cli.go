@@ -0,0 +1,100 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package cli implements the commands of the workload tools, which are
+// available both in the `workload` binary and as `cockroach workload`.
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/cockroachdb/cockroach/pkg/workload"
+)
+
+// WorkloadCmd returns the `workload` command, whose `init`, `run` and
+// `check` subcommands have a subcommand for every registered Generator.
+// It must be called after the Generators are registered.
+func WorkloadCmd() *cobra.Command {
+	workloadCmd := &cobra.Command{
+		Use:   `workload`,
+		Short: `generate data and query loads`,
+	}
+	initCmd := &cobra.Command{
+		Use:   `init`,
+		Short: `set up the tables of a workload and load their initial data`,
+	}
+	runCmd := &cobra.Command{
+		Use:   `run`,
+		Short: `run a workload's operations against a cluster`,
+	}
+	checkCmd := &cobra.Command{
+		Use:   `check`,
+		Short: `check the consistency of the data of a workload`,
+	}
+
+	for _, meta := range workload.Registered() {
+		initCmd.AddCommand(genCmd(meta, runInit, dropFlags))
+		runCmd.AddCommand(genCmd(meta, runRun, runFlags, dropFlags))
+		checkCmd.AddCommand(genCmd(meta, runCheck))
+	}
+	workloadCmd.AddCommand(initCmd, runCmd, checkCmd)
+	return workloadCmd
+}
+
+// genCmd returns the subcommand for the Generator of the given Meta,
+// which configures the Generator with its flags and calls fn with the
+// URLs of the cluster. The subcommand also has the given flags.
+func genCmd(
+	meta workload.Meta,
+	fn func(gen workload.Generator, urls []string) error,
+	cmdFlags ...*pflag.FlagSet,
+) *cobra.Command {
+	gen := meta.New()
+	genFlags := gen.Flags()
+
+	cmd := &cobra.Command{
+		Use:   meta.Name + ` [CRDB URI...]`,
+		Short: meta.Description,
+	}
+	for _, f := range cmdFlags {
+		cmd.Flags().AddFlagSet(f)
+	}
+	cmd.Flags().AddFlagSet(genFlags)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		// The is a little awkward, but grab the strings back from the
+		// flags so we can use them to configure the Generator.
+		var flags []string
+		cmd.Flags().Visit(func(f *pflag.Flag) {
+			if genFlags.Lookup(f.Name) == nil {
+				// This flag is not in the Generator's set, so it must
+				// be one of the flags of the command.
+				return
+			}
+			flags = append(flags, fmt.Sprintf(`--%s=%s`, f.Name, f.Value))
+		})
+		if err := gen.Configure(flags); err != nil {
+			return err
+		}
+
+		urls := []string{CRDBDefaultURI}
+		if len(args) >= 1 {
+			urls = args
+		}
+		return fn(gen, urls)
+	}
+	return cmd
+}
@@ -0,0 +1,410 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package cli
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/codahale/hdrhistogram"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/tylertreat/hdrhistogram-writer"
+
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/workload"
+)
+
+// CRDBDefaultURI is the URI of the cluster used by the workload commands
+// when none is given.
+const CRDBDefaultURI = `postgres://root@localhost:26257/test?sslmode=disable`
+
+// dbName is the database in which the tables of the workloads are
+// created.
+const dbName = `test`
+
+var dropFlags = pflag.NewFlagSet(`drop`, pflag.ContinueOnError)
+var drop = dropFlags.Bool("drop", false, "Clear the existing data before starting.")
+
+var runFlags = pflag.NewFlagSet(`run`, pflag.ContinueOnError)
+var concurrency = runFlags.Int(
+	"concurrency", 2*runtime.NumCPU(), "Number of concurrent workers")
+var tolerateErrors = runFlags.Bool("tolerate-errors", false, "Keep running on error")
+var maxRate = runFlags.Float64(
+	"max-rate", 0, "Maximum frequency of operations (reads/writes). If 0, no limit.")
+var maxOps = runFlags.Uint64("max-ops", 0, "Maximum number of operations to run")
+var duration = runFlags.Duration("duration", 0,
+	"The duration to run, after the ramp-up period. If 0, run forever.")
+var ramp = runFlags.Duration("ramp", 0,
+	"The duration over which the workers are started. "+
+		"The latencies recorded during this period are discarded.")
+var runInitFirst = runFlags.Bool("init", false,
+	"Set up the tables and load their initial data before running.")
+var checkAfter = runFlags.Bool("check", false,
+	"Check the consistency of the data after running, if the generator supports it.")
+
+// Output in HdrHistogram Plotter format. See
+// https://hdrhistogram.github.io/HdrHistogram/plotFiles.html
+var histFile = runFlags.String(
+	"hist-file", "",
+	"Write histogram data to file for HdrHistogram Plotter, or stdout if - is specified.")
+
+// numOps keeps a global count of successful operations.
+var numOps uint64
+
+// workerRun is an infinite loop in which the worker continuously performs
+// the work of the operation, until the context is canceled or the maximum
+// number of operations is reached.
+func workerRun(
+	ctx context.Context, errCh chan<- error, limiter *rate.Limiter, fn func(context.Context) error,
+) {
+	for {
+		// Limit how quickly the load generator sends requests based on --max-rate.
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return
+			}
+		}
+
+		if err := fn(ctx); err != nil {
+			select {
+			case errCh <- err:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		v := atomic.AddUint64(&numOps, 1)
+		if *maxOps > 0 && v >= *maxOps {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// setupDatabase opens a connection pool to the workload database of the
+// cluster, which is created if needed. The connections are balanced
+// across the given URLs.
+func setupDatabase(dbURLs []string, maxConns int) (*gosql.DB, error) {
+	urls := make([]string, len(dbURLs))
+	for i, dbURL := range dbURLs {
+		parsedURL, err := url.Parse(dbURL)
+		if err != nil {
+			return nil, err
+		}
+		switch parsedURL.Scheme {
+		case "postgres", "postgresql":
+		default:
+			return nil, fmt.Errorf("unsupported database: %s", parsedURL.Scheme)
+		}
+		// Every connection of the pool uses the workload database.
+		parsedURL.Path = dbName
+		urls[i] = parsedURL.String()
+	}
+
+	db, err := gosql.Open("cockroach", strings.Join(urls, " "))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(maxConns)
+	db.SetMaxIdleConns(maxConns)
+
+	if *drop {
+		if _, err := db.Exec(`DROP DATABASE IF EXISTS ` + dbName); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := db.Exec(`CREATE DATABASE IF NOT EXISTS ` + dbName); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func hooks(gen workload.Generator) workload.Hooks {
+	if h, ok := gen.(workload.Hookser); ok {
+		return h.Hooks()
+	}
+	return workload.Hooks{}
+}
+
+// setupTables creates the tables of the generator and loads their
+// initial data.
+func setupTables(db *gosql.DB, gen workload.Generator) error {
+	start := timeutil.Now()
+	const batchSize = -1
+	size, err := workload.Setup(db, gen.Tables(), batchSize)
+	if err != nil {
+		return err
+	}
+	if postLoad := hooks(gen).PostLoad; postLoad != nil {
+		if err := postLoad(db); err != nil {
+			return err
+		}
+	}
+	log.Infof(context.Background(), "loaded %s (%d bytes) in %s",
+		gen.Meta().Name, size, timeutil.Since(start))
+	return nil
+}
+
+func runInit(gen workload.Generator, urls []string) error {
+	db, err := setupDatabase(urls, 1 /* maxConns */)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return setupTables(db, gen)
+}
+
+func runCheck(gen workload.Generator, urls []string) error {
+	check := hooks(gen).CheckConsistency
+	if check == nil {
+		return errors.Errorf(`%s does not support consistency checks`, gen.Meta().Name)
+	}
+	db, err := setupDatabase(urls, 1 /* maxConns */)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return check(context.Background(), db)
+}
+
+func runRun(gen workload.Generator, urls []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *concurrency < 1 {
+		return errors.Errorf(
+			"Value of 'concurrency' flag (%d) must be greater than or equal to 1", *concurrency)
+	}
+
+	var db *gosql.DB
+	for {
+		var err error
+		db, err = setupDatabase(urls, *concurrency+1)
+		if err == nil {
+			break
+		}
+		if !*tolerateErrors {
+			return err
+		}
+		log.Warningf(ctx, "retrying after error while setting up the database: %v", err)
+		time.Sleep(time.Second)
+	}
+	defer db.Close()
+
+	if *runInitFirst {
+		if err := setupTables(db, gen); err != nil {
+			return err
+		}
+	}
+
+	var limiter *rate.Limiter
+	if *maxRate > 0 {
+		// Create a limiter using maxRate specified on the command line and
+		// with allowed burst of 1 at the maximum allowed rate.
+		limiter = rate.NewLimiter(rate.Limit(*maxRate), 1)
+	}
+
+	ops := gen.Ops()
+	if len(ops) != 1 {
+		return errors.Errorf(`generators with more than one operation are not yet supported`)
+	}
+	op := ops[0]
+
+	hists := workload.NewHistograms(timeutil.Now())
+	fns := make([]func(context.Context) error, *concurrency)
+	for i := range fns {
+		var err error
+		if fns[i], err = op.Fn(db, hists); err != nil {
+			return err
+		}
+	}
+
+	start := timeutil.Now()
+	rampDone := start.Add(*ramp)
+	ramping := *ramp > 0
+	errCh := make(chan error)
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func(i int, fn func(context.Context) error) {
+			defer wg.Done()
+			// The workers are started at regular intervals during the ramp.
+			if ramping {
+				select {
+				case <-time.After(*ramp * time.Duration(i) / time.Duration(len(fns))):
+				case <-ctx.Done():
+					return
+				}
+			}
+			workerRun(ctx, errCh, limiter, fn)
+		}(i, fn)
+	}
+
+	var numErr int
+	tick := time.Tick(time.Second)
+	done := make(chan os.Signal, 3)
+	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(done)
+
+	go func() {
+		wg.Wait()
+		done <- syscall.Signal(0)
+	}()
+
+	if *duration > 0 {
+		go func() {
+			time.Sleep(*ramp + *duration)
+			done <- syscall.Signal(0)
+		}()
+	}
+
+	var rampOps uint64
+	for i := 0; ; {
+		select {
+		case err := <-errCh:
+			numErr++
+			if *tolerateErrors {
+				log.Error(ctx, err)
+				continue
+			}
+			return err
+
+		case <-tick:
+			now := timeutil.Now()
+			if ramping && !now.Before(rampDone) {
+				// The ramp is over: the run starts now.
+				ramping = false
+				hists.Reset(now)
+				start = now
+				rampOps = atomic.LoadUint64(&numOps)
+				fmt.Println("ramp-up complete")
+				i = 0
+				continue
+			}
+			hists.Tick(now, func(t workload.HistogramTick) {
+				if i%20 == 0 {
+					fmt.Println("_elapsed___errors__ops/sec(inst)___ops/sec(cum)__p50(ms)__p95(ms)__p99(ms)_pMax(ms)")
+				}
+				i++
+				fmt.Printf("%8s %8d %14.1f %14.1f %8.1f %8.1f %8.1f %8.1f %s\n",
+					time.Duration(now.Sub(start).Seconds()+0.5)*time.Second,
+					numErr,
+					float64(t.Hist.TotalCount())/t.Elapsed.Seconds(),
+					float64(t.Cumulative.TotalCount())/now.Sub(start).Seconds(),
+					time.Duration(t.Hist.ValueAtQuantile(50)).Seconds()*1000,
+					time.Duration(t.Hist.ValueAtQuantile(95)).Seconds()*1000,
+					time.Duration(t.Hist.ValueAtQuantile(99)).Seconds()*1000,
+					time.Duration(t.Hist.ValueAtQuantile(100)).Seconds()*1000,
+					t.Name,
+				)
+			})
+
+		case <-done:
+			cancel()
+			now := timeutil.Now()
+			elapsed := now.Sub(start)
+			total := hdrhistogram.New(
+				workload.MinLatency.Nanoseconds(), workload.MaxLatency.Nanoseconds(), 1)
+			fmt.Println("\n_elapsed___errors_____ops(total)___ops/sec(cum)__avg(ms)__p50(ms)__p95(ms)__p99(ms)_pMax(ms)")
+			hists.Tick(now, func(t workload.HistogramTick) {
+				total.Merge(t.Cumulative)
+				printTotal(elapsed, numErr, t.Cumulative, t.Name)
+			})
+			printTotal(elapsed, numErr, total, `total`)
+			fmt.Println()
+
+			// Output results that mimic Go's built-in benchmark format.
+			benchmarkName := strings.Join([]string{
+				"BenchmarkWorkload",
+				fmt.Sprintf("generator=%s", gen.Meta().Name),
+				fmt.Sprintf("concurrency=%d", *concurrency),
+				fmt.Sprintf("duration=%s", *duration),
+			}, "/")
+			// NB: This visits in a deterministic order.
+			gen.Flags().Visit(func(f *pflag.Flag) {
+				benchmarkName += fmt.Sprintf(`/%s=%s`, f.Name, f.Value)
+			})
+			result := testing.BenchmarkResult{
+				N: int(atomic.LoadUint64(&numOps) - rampOps),
+				T: elapsed,
+			}
+			fmt.Printf("%s\t%s\n", benchmarkName, result)
+
+			if *histFile == "-" {
+				if err := histwriter.WriteDistribution(total, nil, 1, os.Stdout); err != nil {
+					fmt.Printf("failed to write histogram to stdout: %v\n", err)
+				}
+			} else if *histFile != "" {
+				if err := histwriter.WriteDistributionFile(
+					total, nil, 1, *histFile,
+				); err != nil {
+					fmt.Printf("failed to write histogram file: %v\n", err)
+				}
+			}
+
+			h := hooks(gen)
+			if h.PostRun != nil {
+				if err := h.PostRun(elapsed, hists.Cumulative()); err != nil {
+					return err
+				}
+			}
+			if *checkAfter {
+				if h.CheckConsistency == nil {
+					return errors.Errorf(`%s does not support consistency checks`, gen.Meta().Name)
+				}
+				// Wait for the workers to be done before checking.
+				wg.Wait()
+				if err := h.CheckConsistency(context.Background(), db); err != nil {
+					return err
+				}
+				fmt.Println("consistency checks passed")
+			}
+			return nil
+		}
+	}
+}
+
+// printTotal prints the summary of the latencies recorded in a histogram
+// during a run.
+func printTotal(elapsed time.Duration, numErr int, h *hdrhistogram.Histogram, name string) {
+	fmt.Printf("%7.1fs %8d %14d %14.1f %8.1f %8.1f %8.1f %8.1f %8.1f %s\n",
+		elapsed.Seconds(), numErr,
+		h.TotalCount(), float64(h.TotalCount())/elapsed.Seconds(),
+		time.Duration(h.Mean()).Seconds()*1000,
+		time.Duration(h.ValueAtQuantile(50)).Seconds()*1000,
+		time.Duration(h.ValueAtQuantile(95)).Seconds()*1000,
+		time.Duration(h.ValueAtQuantile(99)).Seconds()*1000,
+		time.Duration(h.ValueAtQuantile(100)).Seconds()*1000,
+		name,
+	)
+}
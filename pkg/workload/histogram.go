@@ -0,0 +1,161 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package workload
+
+import (
+	"sort"
+	"time"
+
+	"github.com/codahale/hdrhistogram"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+const (
+	// MinLatency and MaxLatency bound the latencies recorded in the
+	// histograms. Latencies out of these bounds are clamped.
+	MinLatency = 100 * time.Microsecond
+	MaxLatency = 10 * time.Second
+
+	sigFigs = 1
+)
+
+func newHistogram() *hdrhistogram.Histogram {
+	return hdrhistogram.New(MinLatency.Nanoseconds(), MaxLatency.Nanoseconds(), sigFigs)
+}
+
+// NamedHistogram records the latencies of one kind of operation of a
+// workload, such as one type of transaction. It is safe for concurrent
+// use.
+type NamedHistogram struct {
+	name string
+	mu   struct {
+		syncutil.Mutex
+		current *hdrhistogram.Histogram
+	}
+}
+
+// Record records the latency of an operation.
+func (h *NamedHistogram) Record(elapsed time.Duration) {
+	if elapsed < MinLatency {
+		elapsed = MinLatency
+	} else if elapsed > MaxLatency {
+		elapsed = MaxLatency
+	}
+	h.mu.Lock()
+	// The latency is clamped to the range of the histogram, so it cannot
+	// be rejected.
+	_ = h.mu.current.RecordValue(elapsed.Nanoseconds())
+	h.mu.Unlock()
+}
+
+// tick returns the latencies recorded since the last tick.
+func (h *NamedHistogram) tick() *hdrhistogram.Histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cur := h.mu.current
+	h.mu.current = newHistogram()
+	return cur
+}
+
+// HistogramTick is the state of a histogram at a tick of a workload run.
+type HistogramTick struct {
+	// Name is the name of the histogram.
+	Name string
+	// Hist holds the latencies recorded since the previous tick, and
+	// Cumulative the latencies recorded since the start of the run.
+	Hist, Cumulative *hdrhistogram.Histogram
+	// Elapsed is the time since the previous tick.
+	Elapsed time.Duration
+}
+
+// Histograms is a registry of the named histograms of a workload run. It
+// is safe for concurrent use.
+type Histograms struct {
+	mu struct {
+		syncutil.Mutex
+		hists      map[string]*NamedHistogram
+		cumulative map[string]*hdrhistogram.Histogram
+		lastTick   time.Time
+	}
+}
+
+// NewHistograms returns an empty registry of histograms, whose first tick
+// covers the latencies recorded from now.
+func NewHistograms(now time.Time) *Histograms {
+	h := &Histograms{}
+	h.mu.hists = make(map[string]*NamedHistogram)
+	h.mu.cumulative = make(map[string]*hdrhistogram.Histogram)
+	h.mu.lastTick = now
+	return h
+}
+
+// Get returns the histogram with the given name, creating it if needed.
+func (h *Histograms) Get(name string) *NamedHistogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hist, ok := h.mu.hists[name]; ok {
+		return hist
+	}
+	hist := &NamedHistogram{name: name}
+	hist.mu.current = newHistogram()
+	h.mu.hists[name] = hist
+	h.mu.cumulative[name] = newHistogram()
+	return hist
+}
+
+// Tick collects the latencies recorded since the previous tick and adds
+// them to the cumulative histograms. fn is called for every histogram,
+// in the order of their names.
+func (h *Histograms) Tick(now time.Time, fn func(HistogramTick)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	names := make([]string, 0, len(h.mu.hists))
+	for name := range h.mu.hists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	elapsed := now.Sub(h.mu.lastTick)
+	h.mu.lastTick = now
+	for _, name := range names {
+		hist := h.mu.hists[name].tick()
+		cum := h.mu.cumulative[name]
+		cum.Merge(hist)
+		fn(HistogramTick{Name: name, Hist: hist, Cumulative: cum, Elapsed: elapsed})
+	}
+}
+
+// Reset discards the latencies recorded so far, e.g. at the end of the
+// ramp-up period of a run.
+func (h *Histograms) Reset(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, hist := range h.mu.hists {
+		hist.tick()
+		h.mu.cumulative[name] = newHistogram()
+	}
+	h.mu.lastTick = now
+}
+
+// Cumulative returns a copy of the cumulative histograms, by name.
+func (h *Histograms) Cumulative() map[string]*hdrhistogram.Histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	res := make(map[string]*hdrhistogram.Histogram, len(h.mu.cumulative))
+	for name, cum := range h.mu.cumulative {
+		res[name] = hdrhistogram.Import(cum.Export())
+	}
+	return res
+}
@@ -27,10 +27,11 @@ import (
 	"math/rand"
 	"sync/atomic"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 
-	"github.com/cockroachdb/cockroach/pkg/testutils/workload"
-	"github.com/pkg/errors"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/workload"
 )
 
 const (
@@ -111,7 +112,7 @@ func (*kv) Tables() []workload.Table {
 
 // Ops implements the Generator interface.
 func (w *kv) Ops() []workload.Operation {
-	opFn := func(db *gosql.DB) (func(context.Context) error, error) {
+	opFn := func(db *gosql.DB, hists *workload.Histograms) (func(context.Context) error, error) {
 		var buf bytes.Buffer
 		buf.WriteString(`SELECT k, v FROM test.kv WHERE k IN (`)
 		for i := 0; i < w.batchSize; i++ {
@@ -147,6 +148,8 @@ func (w *kv) Ops() []workload.Operation {
 			db:        db,
 			readStmt:  readStmt,
 			writeStmt: writeStmt,
+			readHist:  hists.Get(`read`),
+			writeHist: hists.Get(`write`),
 		}
 		seq := &sequence{config: w, val: w.writeSeq}
 		if w.sequential {
@@ -168,6 +171,8 @@ type kvOp struct {
 	db        *gosql.DB
 	readStmt  *gosql.Stmt
 	writeStmt *gosql.Stmt
+	readHist  *workload.NamedHistogram
+	writeHist *workload.NamedHistogram
 	g         keyGenerator
 }
 
@@ -177,12 +182,14 @@ func (o *kvOp) run(ctx context.Context) error {
 		for i := 0; i < o.config.batchSize; i++ {
 			args[i] = o.g.readKey()
 		}
+		start := timeutil.Now()
 		rows, err := o.readStmt.Query(args...)
 		if err != nil {
 			return err
 		}
 		for rows.Next() {
 		}
+		o.readHist.Record(timeutil.Since(start))
 		return rows.Err()
 	}
 	const argCount = 2
@@ -192,7 +199,9 @@ func (o *kvOp) run(ctx context.Context) error {
 		args[j+0] = o.g.writeKey()
 		args[j+1] = randomBlock(o.config, o.g.rand())
 	}
+	start := timeutil.Now()
 	_, err := o.writeStmt.Exec(args...)
+	o.writeHist.Record(timeutil.Since(start))
 	return err
 }
 
@@ -12,7 +12,7 @@
 // implied. See the License for the specific language governing
 // permissions and limitations under the License.
 
-package bank
+package workload
 
 import (
 	"os"
@@ -34,4 +34,4 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-//go:generate ../../../util/leaktest/add-leaktest.sh *_test.go
+//go:generate ../util/leaktest/add-leaktest.sh *_test.go
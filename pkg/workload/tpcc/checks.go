@@ -0,0 +1,101 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	gosql "database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// consistencyChecks are the consistency conditions of section 3.3.2. Every
+// query counts the warehouses or districts which violate the condition.
+var consistencyChecks = []struct {
+	name  string
+	query string
+}{
+	{
+		// The year-to-date amount of a warehouse is the sum of the amounts
+		// of its districts.
+		name: `3.3.2.1`,
+		query: `
+			SELECT count(*)
+			FROM warehouse
+			JOIN (SELECT d_w_id, sum(d_ytd) AS sum_d_ytd FROM district GROUP BY d_w_id) AS d
+			ON w_id = d_w_id
+			WHERE w_ytd != sum_d_ytd`,
+	},
+	{
+		// The next order ID of a district follows its last order and its
+		// last new order.
+		name: `3.3.2.2`,
+		query: `
+			SELECT count(*)
+			FROM district
+			JOIN (
+				SELECT o_w_id, o_d_id, max(o_id) AS max_o_id FROM "order" GROUP BY o_w_id, o_d_id
+			) AS o ON d_w_id = o_w_id AND d_id = o_d_id
+			JOIN (
+				SELECT no_w_id, no_d_id, max(no_o_id) AS max_no_o_id FROM new_order
+				GROUP BY no_w_id, no_d_id
+			) AS n ON d_w_id = no_w_id AND d_id = no_d_id
+			WHERE d_next_o_id - 1 != max_o_id OR d_next_o_id - 1 != max_no_o_id`,
+	},
+	{
+		// The new orders of a district are contiguous.
+		name: `3.3.2.3`,
+		query: `
+			SELECT count(*)
+			FROM (
+				SELECT max(no_o_id) - min(no_o_id) + 1 AS no_range, count(*) AS no_count
+				FROM new_order
+				GROUP BY no_w_id, no_d_id
+			)
+			WHERE no_range != no_count`,
+	},
+	{
+		// The number of order lines of a district is the sum of the number
+		// of order lines of its orders.
+		name: `3.3.2.4`,
+		query: `
+			SELECT count(*)
+			FROM (
+				SELECT o_w_id, o_d_id, sum(o_ol_cnt) AS sum_o_ol_cnt FROM "order"
+				GROUP BY o_w_id, o_d_id
+			) AS o
+			JOIN (
+				SELECT ol_w_id, ol_d_id, count(*) AS count_ol FROM order_line
+				GROUP BY ol_w_id, ol_d_id
+			) AS ol ON o_w_id = ol_w_id AND o_d_id = ol_d_id
+			WHERE sum_o_ol_cnt != count_ol`,
+	},
+}
+
+// checkConsistency runs the consistency checks of the spec, and returns an
+// error describing the first check which failed.
+func checkConsistency(ctx context.Context, db *gosql.DB) error {
+	for _, check := range consistencyChecks {
+		var violations int
+		if err := db.QueryRowContext(ctx, check.query).Scan(&violations); err != nil {
+			return errors.Wrapf(err, "running consistency check %s", check.name)
+		}
+		if violations != 0 {
+			return errors.Errorf(
+				"consistency check %s failed: %d violations", check.name, violations)
+		}
+	}
+	return nil
+}
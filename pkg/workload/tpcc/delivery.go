@@ -0,0 +1,93 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	gosql "database/sql"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+// delivery delivers the oldest undelivered order of every district of the
+// warehouse, see section 2.7. The spec allows the deliveries to be
+// deferred, but they are run synchronously here.
+func delivery(ctx context.Context, w *worker) error {
+	wID := w.warehouse
+	oCarrierID := randInt(w.rng, 1, 10)
+
+	return crdb.ExecuteTx(ctx, w.db, nil, func(tx *gosql.Tx) error {
+		for dID := 1; dID <= numDistrictsPerWarehouse; dID++ {
+			var oID int
+			if err := tx.QueryRowContext(ctx, `
+				SELECT no_o_id FROM new_order
+				WHERE no_w_id = $1 AND no_d_id = $2
+				ORDER BY no_o_id ASC
+				LIMIT 1`,
+				wID, dID,
+			).Scan(&oID); err != nil {
+				if err == gosql.ErrNoRows {
+					// All the orders of the district are delivered.
+					continue
+				}
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `
+				DELETE FROM new_order WHERE no_w_id = $1 AND no_d_id = $2 AND no_o_id = $3`,
+				wID, dID, oID,
+			); err != nil {
+				return err
+			}
+
+			var cID int
+			if err := tx.QueryRowContext(ctx, `
+				UPDATE "order" SET o_carrier_id = $1
+				WHERE o_w_id = $2 AND o_d_id = $3 AND o_id = $4
+				RETURNING o_c_id`,
+				oCarrierID, wID, dID, oID,
+			).Scan(&cID); err != nil {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE order_line SET ol_delivery_d = now()
+				WHERE ol_w_id = $1 AND ol_d_id = $2 AND ol_o_id = $3`,
+				wID, dID, oID,
+			); err != nil {
+				return err
+			}
+			// The amount is kept as a string, so that it is not rounded.
+			var olTotal string
+			if err := tx.QueryRowContext(ctx, `
+				SELECT sum(ol_amount) FROM order_line
+				WHERE ol_w_id = $1 AND ol_d_id = $2 AND ol_o_id = $3`,
+				wID, dID, oID,
+			).Scan(&olTotal); err != nil {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE customer SET
+					c_balance = c_balance + $1,
+					c_delivery_cnt = c_delivery_cnt + 1
+				WHERE c_w_id = $2 AND c_d_id = $3 AND c_id = $4`,
+				olTotal, wID, dID, cID,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,256 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// The cardinalities of the tables, see section 1.2.1 of the spec. Every
+// order has numOrderLinesPerOrder order lines, which is the average number
+// of order lines of the spec, so that the number of rows of the order_line
+// table is known in advance.
+const (
+	numItems                  = 100000
+	numDistrictsPerWarehouse  = 10
+	numStockPerWarehouse      = 100000
+	numCustomersPerDistrict   = 3000
+	numCustomersPerWarehouse  = numCustomersPerDistrict * numDistrictsPerWarehouse
+	numHistoryPerWarehouse    = numCustomersPerWarehouse
+	numOrdersPerDistrict      = numCustomersPerDistrict
+	numOrdersPerWarehouse     = numOrdersPerDistrict * numDistrictsPerWarehouse
+	numNewOrdersPerDistrict   = 900
+	numNewOrdersPerWarehouse  = numNewOrdersPerDistrict * numDistrictsPerWarehouse
+	numOrderLinesPerOrder     = 10
+	numOrderLinesPerWarehouse = numOrderLinesPerOrder * numOrdersPerWarehouse
+
+	// firstNewOrderID is the ID of the first order of every district which
+	// is not delivered yet, and so is in the new_order table.
+	firstNewOrderID = numOrdersPerDistrict - numNewOrdersPerDistrict + 1
+)
+
+// initialTimestamp is used for the timestamps of the initial data, so that
+// the data only depends on the flags of the generator.
+const initialTimestamp = `'2006-01-02 15:04:05'`
+
+// str formats a string for SQL. The strings of the initial data are
+// alphanumeric, so they never need to be escaped.
+func str(s string) string {
+	return `'` + s + `'`
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
+
+func (w *tpcc) tpccItemInitialRow(rng *rand.Rand, rowIdx int) []string {
+	iID := rowIdx + 1
+	return []string{
+		itoa(iID),
+		itoa(randInt(rng, 1, 10000)),    // i_im_id
+		str(randAString(rng, 14, 24)),   // i_name
+		randDecimal(rng, 100, 10000, 2), // i_price
+		str(randOriginalString(rng)),    // i_data
+	}
+}
+
+func (w *tpcc) tpccWarehouseInitialRow(rng *rand.Rand, rowIdx int) []string {
+	wID := rowIdx
+	return []string{
+		itoa(wID),
+		str(randAString(rng, 6, 10)),  // w_name
+		str(randAString(rng, 10, 20)), // w_street_1
+		str(randAString(rng, 10, 20)), // w_street_2
+		str(randAString(rng, 10, 20)), // w_city
+		str(randState(rng)),           // w_state
+		str(randZip(rng)),             // w_zip
+		randTax(rng),                  // w_tax
+		`300000.00`,                   // w_ytd
+	}
+}
+
+func (w *tpcc) tpccStockInitialRow(rng *rand.Rand, rowIdx int) []string {
+	sID := (rowIdx % numStockPerWarehouse) + 1
+	wID := rowIdx / numStockPerWarehouse
+	row := []string{
+		itoa(sID),
+		itoa(wID),
+		itoa(randInt(rng, 10, 100)), // s_quantity
+	}
+	for i := 0; i < numDistrictsPerWarehouse; i++ {
+		row = append(row, str(randAString(rng, 24, 24))) // s_dist_XX
+	}
+	return append(row,
+		`0`,                          // s_ytd
+		`0`,                          // s_order_cnt
+		`0`,                          // s_remote_cnt
+		str(randOriginalString(rng)), // s_data
+	)
+}
+
+func (w *tpcc) tpccDistrictInitialRow(rng *rand.Rand, rowIdx int) []string {
+	dID := (rowIdx % numDistrictsPerWarehouse) + 1
+	wID := rowIdx / numDistrictsPerWarehouse
+	return []string{
+		itoa(dID),
+		itoa(wID),
+		str(randAString(rng, 6, 10)),   // d_name
+		str(randAString(rng, 10, 20)),  // d_street_1
+		str(randAString(rng, 10, 20)),  // d_street_2
+		str(randAString(rng, 10, 20)),  // d_city
+		str(randState(rng)),            // d_state
+		str(randZip(rng)),              // d_zip
+		randTax(rng),                   // d_tax
+		`30000.00`,                     // d_ytd
+		itoa(numOrdersPerDistrict + 1), // d_next_o_id
+	}
+}
+
+func (w *tpcc) tpccCustomerInitialRow(rng *rand.Rand, rowIdx int) []string {
+	cID := (rowIdx % numCustomersPerDistrict) + 1
+	dID := ((rowIdx / numCustomersPerDistrict) % numDistrictsPerWarehouse) + 1
+	wID := rowIdx / numCustomersPerWarehouse
+
+	// The first 1000 customers of every district have distinct last names.
+	var last string
+	if cID <= 1000 {
+		last = lastName(cID - 1)
+	} else {
+		last = randCLast(rng, cLastLoad)
+	}
+	credit := `GC`
+	if rng.Intn(10) == 0 {
+		credit = `BC`
+	}
+	return []string{
+		itoa(cID),
+		itoa(dID),
+		itoa(wID),
+		str(randAString(rng, 8, 16)),    // c_first
+		`'OE'`,                          // c_middle
+		str(last),                       // c_last
+		str(randAString(rng, 10, 20)),   // c_street_1
+		str(randAString(rng, 10, 20)),   // c_street_2
+		str(randAString(rng, 10, 20)),   // c_city
+		str(randState(rng)),             // c_state
+		str(randZip(rng)),               // c_zip
+		str(randNString(rng, 16, 16)),   // c_phone
+		initialTimestamp,                // c_since
+		str(credit),                     // c_credit
+		`50000.00`,                      // c_credit_lim
+		randDecimal(rng, 0, 5000, 4),    // c_discount
+		`-10.00`,                        // c_balance
+		`10.00`,                         // c_ytd_payment
+		`1`,                             // c_payment_cnt
+		`0`,                             // c_delivery_cnt
+		str(randAString(rng, 300, 500)), // c_data
+	}
+}
+
+func (w *tpcc) tpccHistoryInitialRow(rng *rand.Rand, rowIdx int) []string {
+	cID := (rowIdx % numCustomersPerDistrict) + 1
+	dID := ((rowIdx / numCustomersPerDistrict) % numDistrictsPerWarehouse) + 1
+	wID := rowIdx / numCustomersPerWarehouse
+	return []string{
+		itoa(cID),                     // h_c_id
+		itoa(dID),                     // h_c_d_id
+		itoa(wID),                     // h_c_w_id
+		itoa(dID),                     // h_d_id
+		itoa(wID),                     // h_w_id
+		initialTimestamp,              // h_date
+		`10.00`,                       // h_amount
+		str(randAString(rng, 12, 24)), // h_data
+	}
+}
+
+// orderCustomers returns the customers of the orders of a district, which
+// are a random permutation of the customers of the district. The
+// permutation of the last district is cached, since the rows of the order
+// table are generated in order.
+type orderCustomers struct {
+	seed     int64
+	district int
+	perm     []int
+}
+
+func (c *orderCustomers) customer(wID, dID, oID int) int {
+	district := wID*numDistrictsPerWarehouse + dID
+	if c.perm == nil || c.district != district {
+		rng := rand.New(rand.NewSource(c.seed + int64(district)))
+		c.perm = rng.Perm(numCustomersPerDistrict)
+		c.district = district
+	}
+	return c.perm[oID-1] + 1
+}
+
+func (w *tpcc) tpccOrderInitialRow(
+	rng *rand.Rand, customers *orderCustomers, rowIdx int,
+) []string {
+	oID := (rowIdx % numOrdersPerDistrict) + 1
+	dID := ((rowIdx / numOrdersPerDistrict) % numDistrictsPerWarehouse) + 1
+	wID := rowIdx / numOrdersPerWarehouse
+
+	carrierID := `NULL`
+	if oID < firstNewOrderID {
+		carrierID = itoa(randInt(rng, 1, 10))
+	}
+	return []string{
+		itoa(oID),
+		itoa(dID),
+		itoa(wID),
+		itoa(customers.customer(wID, dID, oID)), // o_c_id
+		initialTimestamp,                        // o_entry_d
+		carrierID,                               // o_carrier_id
+		itoa(numOrderLinesPerOrder),             // o_ol_cnt
+		`1`,                                     // o_all_local
+	}
+}
+
+func (w *tpcc) tpccNewOrderInitialRow(rowIdx int) []string {
+	oID := (rowIdx % numNewOrdersPerDistrict) + firstNewOrderID
+	dID := ((rowIdx / numNewOrdersPerDistrict) % numDistrictsPerWarehouse) + 1
+	wID := rowIdx / numNewOrdersPerWarehouse
+	return []string{
+		itoa(oID),
+		itoa(dID),
+		itoa(wID),
+	}
+}
+
+func (w *tpcc) tpccOrderLineInitialRow(rng *rand.Rand, rowIdx int) []string {
+	number := (rowIdx % numOrderLinesPerOrder) + 1
+	orderIdx := rowIdx / numOrderLinesPerOrder
+	oID := (orderIdx % numOrdersPerDistrict) + 1
+	dID := ((orderIdx / numOrdersPerDistrict) % numDistrictsPerWarehouse) + 1
+	wID := orderIdx / numOrdersPerWarehouse
+
+	deliveryD, amount := initialTimestamp, `0.00`
+	if oID >= firstNewOrderID {
+		deliveryD, amount = `NULL`, randDecimal(rng, 1, 999999, 2)
+	}
+	return []string{
+		itoa(oID),
+		itoa(dID),
+		itoa(wID),
+		itoa(number),
+		itoa(randInt(rng, 1, numItems)), // ol_i_id
+		itoa(wID),                       // ol_supply_w_id
+		deliveryD,                       // ol_delivery_d
+		`5`,                             // ol_quantity
+		amount,                          // ol_amount
+		str(randAString(rng, 24, 24)),   // ol_dist_info
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+	"github.com/pkg/errors"
+)
+
+// errSimulated is returned by the new order transactions which order an
+// unused item, which must be rolled back, see section 2.4.2.3.
+var errSimulated = errors.New("simulated user error")
+
+type orderItem struct {
+	olIID       int
+	olSupplyWID int
+	olQuantity  int
+}
+
+// newOrder enters a complete order, see section 2.4.
+func newOrder(ctx context.Context, w *worker) error {
+	rng := w.rng
+	wID := w.warehouse
+	dID := randInt(rng, 1, numDistrictsPerWarehouse)
+	cID := randCustomerID(rng)
+	rollback := rng.Intn(100) == 0
+
+	items := make([]orderItem, randInt(rng, 5, 15))
+	allLocal := 1
+	for i := range items {
+		item := &items[i]
+		item.olIID = randItemID(rng)
+		if rollback && i == len(items)-1 {
+			item.olIID = numItems + 1
+		}
+		// 1% of the items are supplied by another warehouse.
+		item.olSupplyWID = wID
+		if rng.Intn(100) == 0 {
+			item.olSupplyWID = w.otherWarehouse()
+		}
+		if item.olSupplyWID != wID {
+			allLocal = 0
+		}
+		item.olQuantity = randInt(rng, 1, 10)
+	}
+	// The stock rows are updated in a consistent order, which reduces the
+	// contention between the concurrent new orders.
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].olSupplyWID != items[j].olSupplyWID {
+			return items[i].olSupplyWID < items[j].olSupplyWID
+		}
+		return items[i].olIID < items[j].olIID
+	})
+
+	err := crdb.ExecuteTx(ctx, w.db, nil, func(tx *gosql.Tx) error {
+		// The taxes and the discount are read as per the spec, but the total
+		// amount of the order which is computed from them is only displayed
+		// by the terminals of the spec.
+		var wTax, dTax, cDiscount float64
+		var cLast, cCredit string
+		if err := tx.QueryRowContext(ctx,
+			`SELECT w_tax FROM warehouse WHERE w_id = $1`, wID,
+		).Scan(&wTax); err != nil {
+			return err
+		}
+
+		// The order gets the next order ID of the district.
+		var oID int
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE district SET d_next_o_id = d_next_o_id + 1
+			WHERE d_w_id = $1 AND d_id = $2
+			RETURNING d_tax, d_next_o_id - 1`,
+			wID, dID,
+		).Scan(&dTax, &oID); err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			SELECT c_discount, c_last, c_credit FROM customer
+			WHERE c_w_id = $1 AND c_d_id = $2 AND c_id = $3`,
+			wID, dID, cID,
+		).Scan(&cDiscount, &cLast, &cCredit); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO "order" (o_id, o_d_id, o_w_id, o_c_id, o_entry_d, o_ol_cnt, o_all_local)
+			VALUES ($1, $2, $3, $4, now(), $5, $6)`,
+			oID, dID, wID, cID, len(items), allLocal,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO new_order (no_o_id, no_d_id, no_w_id) VALUES ($1, $2, $3)`,
+			oID, dID, wID,
+		); err != nil {
+			return err
+		}
+
+		for i, item := range items {
+			var iPrice float64
+			var iName, iData string
+			if err := tx.QueryRowContext(ctx,
+				`SELECT i_price, i_name, i_data FROM item WHERE i_id = $1`, item.olIID,
+			).Scan(&iPrice, &iName, &iData); err != nil {
+				if err == gosql.ErrNoRows {
+					return errSimulated
+				}
+				return err
+			}
+
+			var sQuantity int
+			var sDistInfo, sData string
+			if err := tx.QueryRowContext(ctx, fmt.Sprintf(`
+				SELECT s_quantity, s_dist_%02d, s_data FROM stock
+				WHERE s_i_id = $1 AND s_w_id = $2`, dID),
+				item.olIID, item.olSupplyWID,
+			).Scan(&sQuantity, &sDistInfo, &sData); err != nil {
+				return err
+			}
+			if sQuantity >= item.olQuantity+10 {
+				sQuantity -= item.olQuantity
+			} else {
+				sQuantity += 91 - item.olQuantity
+			}
+			remoteCnt := 0
+			if item.olSupplyWID != wID {
+				remoteCnt = 1
+			}
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE stock SET
+					s_quantity = $1,
+					s_ytd = s_ytd + $2,
+					s_order_cnt = s_order_cnt + 1,
+					s_remote_cnt = s_remote_cnt + $3
+				WHERE s_i_id = $4 AND s_w_id = $5`,
+				sQuantity, item.olQuantity, remoteCnt, item.olIID, item.olSupplyWID,
+			); err != nil {
+				return err
+			}
+
+			olAmount := float64(item.olQuantity) * iPrice
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO order_line (
+					ol_o_id, ol_d_id, ol_w_id, ol_number, ol_i_id, ol_supply_w_id,
+					ol_quantity, ol_amount, ol_dist_info
+				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+				oID, dID, wID, i+1, item.olIID, item.olSupplyWID,
+				item.olQuantity, fmt.Sprintf("%.2f", olAmount), sDistInfo,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == errSimulated {
+		// The rolled back new orders are part of the mix.
+		return nil
+	}
+	return err
+}
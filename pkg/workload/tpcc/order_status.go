@@ -0,0 +1,86 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	gosql "database/sql"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+// orderStatus queries the status of the last order of a customer, see
+// section 2.6.
+func orderStatus(ctx context.Context, w *worker) error {
+	rng := w.rng
+	wID := w.warehouse
+	dID := randInt(rng, 1, numDistrictsPerWarehouse)
+
+	// 60% of the customers are selected by last name.
+	var cID int
+	var cLast string
+	byLastName := rng.Intn(100) < 60
+	if byLastName {
+		cLast = randCLast(rng, cLastRun)
+	} else {
+		cID = randCustomerID(rng)
+	}
+
+	return crdb.ExecuteTx(ctx, w.db, nil, func(tx *gosql.Tx) error {
+		id := cID
+		if byLastName {
+			var err error
+			if id, err = customerByLastName(ctx, tx, wID, dID, cLast); err != nil {
+				return err
+			}
+		}
+
+		var cBalance float64
+		var cFirst, cMiddle, cLastName string
+		if err := tx.QueryRowContext(ctx, `
+			SELECT c_balance, c_first, c_middle, c_last FROM customer
+			WHERE c_w_id = $1 AND c_d_id = $2 AND c_id = $3`,
+			wID, dID, id,
+		).Scan(&cBalance, &cFirst, &cMiddle, &cLastName); err != nil {
+			return err
+		}
+
+		var oID int
+		var oEntryD gosql.RawBytes
+		var oCarrierID gosql.NullInt64
+		if err := tx.QueryRowContext(ctx, `
+			SELECT o_id, o_entry_d, o_carrier_id FROM "order"
+			WHERE o_w_id = $1 AND o_d_id = $2 AND o_c_id = $3
+			ORDER BY o_id DESC
+			LIMIT 1`,
+			wID, dID, id,
+		).Scan(&oID, &oEntryD, &oCarrierID); err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT ol_i_id, ol_supply_w_id, ol_quantity, ol_amount, ol_delivery_d
+			FROM order_line
+			WHERE ol_w_id = $1 AND ol_d_id = $2 AND ol_o_id = $3`,
+			wID, dID, oID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+		}
+		return rows.Err()
+	})
+}
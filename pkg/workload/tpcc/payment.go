@@ -0,0 +1,98 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+// payment updates the balance of a customer and the sales statistics of
+// the warehouse and the district, see section 2.5.
+func payment(ctx context.Context, w *worker) error {
+	rng := w.rng
+	wID := w.warehouse
+	dID := randInt(rng, 1, numDistrictsPerWarehouse)
+
+	// 15% of the customers belong to a remote warehouse.
+	cWID, cDID := wID, dID
+	if rng.Intn(100) < 15 {
+		cWID = w.otherWarehouse()
+		if cWID != wID {
+			cDID = randInt(rng, 1, numDistrictsPerWarehouse)
+		}
+	}
+	// 60% of the customers are selected by last name.
+	var cID int
+	var cLast string
+	byLastName := rng.Intn(100) < 60
+	if byLastName {
+		cLast = randCLast(rng, cLastRun)
+	} else {
+		cID = randCustomerID(rng)
+	}
+	hAmount := randDecimal(rng, 100, 500000, 2)
+
+	return crdb.ExecuteTx(ctx, w.db, nil, func(tx *gosql.Tx) error {
+		var wName, dName string
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE warehouse SET w_ytd = w_ytd + $1 WHERE w_id = $2
+			RETURNING w_name`,
+			hAmount, wID,
+		).Scan(&wName); err != nil {
+			return err
+		}
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE district SET d_ytd = d_ytd + $1 WHERE d_w_id = $2 AND d_id = $3
+			RETURNING d_name`,
+			hAmount, wID, dID,
+		).Scan(&dName); err != nil {
+			return err
+		}
+
+		id := cID
+		if byLastName {
+			var err error
+			if id, err = customerByLastName(ctx, tx, cWID, cDID, cLast); err != nil {
+				return err
+			}
+		}
+
+		// The data of the customers with a bad credit is prefixed with the
+		// details of the payment.
+		data := fmt.Sprintf("%d %d %d %d %d %s | ", id, cDID, cWID, dID, wID, hAmount)
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE customer SET
+				c_balance = c_balance - $1,
+				c_ytd_payment = c_ytd_payment + $1,
+				c_payment_cnt = c_payment_cnt + 1,
+				c_data = CASE c_credit WHEN 'BC' THEN substr($2 || c_data, 1, 500) ELSE c_data END
+			WHERE c_w_id = $3 AND c_d_id = $4 AND c_id = $5`,
+			hAmount, data, cWID, cDID, id,
+		); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO history (h_c_id, h_c_d_id, h_c_w_id, h_d_id, h_w_id, h_amount, h_date, h_data)
+			VALUES ($1, $2, $3, $4, $5, $6, now(), $7)`,
+			id, cDID, cWID, dID, wID, hAmount, wName+"    "+dName,
+		)
+		return err
+	})
+}
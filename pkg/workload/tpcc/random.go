@@ -0,0 +1,133 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// The constants of the non-uniform random number generators, see section
+// 2.1.6 of the spec. The difference between cLastRun and cLastLoad must be
+// in [65, 119], and neither 96 nor 112.
+const (
+	cLastLoad   = 157
+	cLastRun    = 223
+	cCustomerID = 259
+	cItemID     = 7911
+)
+
+const (
+	alphanumerics = `0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz`
+	numbers       = `0123456789`
+	letters       = `ABCDEFGHIJKLMNOPQRSTUVWXYZ`
+)
+
+// randInt returns a uniformly distributed number in [min, max].
+func randInt(rng *rand.Rand, min, max int) int {
+	return rng.Intn(max-min+1) + min
+}
+
+// nuRand returns a non-uniformly distributed number in [x, y], as defined
+// in section 2.1.6 of the spec.
+func nuRand(rng *rand.Rand, a, c, x, y int) int {
+	return (((randInt(rng, 0, a) | randInt(rng, x, y)) + c) % (y - x + 1)) + x
+}
+
+func randStringFrom(rng *rand.Rand, chars string, min, max int) string {
+	b := make([]byte, randInt(rng, min, max))
+	for i := range b {
+		b[i] = chars[rng.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// randAString returns a random alphanumeric string whose length is in
+// [min, max].
+func randAString(rng *rand.Rand, min, max int) string {
+	return randStringFrom(rng, alphanumerics, min, max)
+}
+
+// randNString returns a random numeric string whose length is in
+// [min, max].
+func randNString(rng *rand.Rand, min, max int) string {
+	return randStringFrom(rng, numbers, min, max)
+}
+
+// randState returns a random two-letter state.
+func randState(rng *rand.Rand) string {
+	return randStringFrom(rng, letters, 2, 2)
+}
+
+// randZip returns a random zip code, see section 4.3.2.7 of the spec.
+func randZip(rng *rand.Rand) string {
+	return randNString(rng, 4, 4) + `11111`
+}
+
+// randOriginalString returns the data of an item or a stock, of which 10%
+// contain the string ORIGINAL at a random position.
+func randOriginalString(rng *rand.Rand) string {
+	s := randAString(rng, 26, 50)
+	if rng.Intn(10) != 0 {
+		return s
+	}
+	const original = `ORIGINAL`
+	pos := rng.Intn(len(s) - len(original) + 1)
+	return s[:pos] + original + s[pos+len(original):]
+}
+
+// randTax returns a random tax rate in [0.0000, 0.2000].
+func randTax(rng *rand.Rand) string {
+	return randDecimal(rng, 0, 2000, 4)
+}
+
+var lastNameSyllables = []string{
+	`BAR`, `OUGHT`, `ABLE`, `PRI`, `PRES`, `ESE`, `ANTI`, `CALLY`, `ATION`, `EING`,
+}
+
+// lastName returns the last name of a customer for a number in [0, 999],
+// which is the concatenation of the syllables of its three digits.
+func lastName(num int) string {
+	return lastNameSyllables[num/100] + lastNameSyllables[(num/10)%10] + lastNameSyllables[num%10]
+}
+
+// randCLast returns the last name of a random customer, using
+// the given constant of the non-uniform distribution.
+func randCLast(rng *rand.Rand, c int) string {
+	return lastName(nuRand(rng, 255, c, 0, 999))
+}
+
+// randCustomerID returns the ID of a random customer of a district.
+func randCustomerID(rng *rand.Rand) int {
+	return nuRand(rng, 1023, cCustomerID, 1, numCustomersPerDistrict)
+}
+
+// randItemID returns the ID of a random item.
+func randItemID(rng *rand.Rand) int {
+	return nuRand(rng, 8191, cItemID, 1, numItems)
+}
+
+// randDecimal returns a random number with the given number of decimals,
+// formatted for SQL. min and max are expressed in units of the last
+// decimal, e.g. randDecimal(rng, 100, 10000, 2) returns a number in
+// [1.00, 100.00].
+func randDecimal(rng *rand.Rand, min, max int, decimals int) string {
+	scale := 1
+	for i := 0; i < decimals; i++ {
+		scale *= 10
+	}
+	v := randInt(rng, min, max)
+	return fmt.Sprintf(`%d.%0*d`, v/scale, decimals, v%scale)
+}
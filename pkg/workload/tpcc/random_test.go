@@ -0,0 +1,96 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestLastName(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The examples of section 4.3.2.3 of the spec.
+	for num, expected := range map[int]string{
+		371: `PRICALLYOUGHT`,
+		40:  `BARPRESBAR`,
+		999: `EINGEINGEING`,
+	} {
+		if actual := lastName(num); actual != expected {
+			t.Errorf("%d: expected %s, got %s", num, expected, actual)
+		}
+	}
+}
+
+func TestRandomHelpers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := randutil.NewPseudoRand()
+	zipRE := regexp.MustCompile(`^[0-9]{4}11111$`)
+	decimalRE := regexp.MustCompile(`^[0-9]+\.[0-9]{2}$`)
+	for i := 0; i < 1000; i++ {
+		if v := nuRand(rng, 1023, cCustomerID, 1, numCustomersPerDistrict); v < 1 || v > numCustomersPerDistrict {
+			t.Fatalf("customer ID %d out of range", v)
+		}
+		if v := randItemID(rng); v < 1 || v > numItems {
+			t.Fatalf("item ID %d out of range", v)
+		}
+		if s := randAString(rng, 14, 24); len(s) < 14 || len(s) > 24 {
+			t.Fatalf("string %q has an invalid length", s)
+		}
+		if s := randZip(rng); !zipRE.MatchString(s) {
+			t.Fatalf("invalid zip code %q", s)
+		}
+		if s := randDecimal(rng, 100, 10000, 2); !decimalRE.MatchString(s) {
+			t.Fatalf("invalid price %s", s)
+		}
+		if s := randOriginalString(rng); len(s) < 26 || len(s) > 50 {
+			t.Fatalf("string %q has an invalid length", s)
+		}
+	}
+
+	// About 10% of the data contains ORIGINAL.
+	var original int
+	for i := 0; i < 10000; i++ {
+		if strings.Contains(randOriginalString(rng), `ORIGINAL`) {
+			original++
+		}
+	}
+	if original < 800 || original > 1200 {
+		t.Errorf("expected about 1000 strings containing ORIGINAL, got %d", original)
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	txs, err := parseMix(`newOrder=10,payment=10,orderStatus=1,delivery=1,stockLevel=1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 5 || txs[0].name != newOrderName || txs[0].weight != 10 {
+		t.Errorf("unexpected mix %+v", txs)
+	}
+
+	for _, mix := range []string{`newOrder`, `newOrder=x`, `unknown=1`, `newOrder=0`} {
+		if _, err := parseMix(mix); err == nil {
+			t.Errorf("%s: expected an error", mix)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	gosql "database/sql"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+// stockLevel counts the recently sold items of a district whose stock is
+// below a threshold, see section 2.8.
+func stockLevel(ctx context.Context, w *worker) error {
+	wID := w.warehouse
+	dID := randInt(w.rng, 1, numDistrictsPerWarehouse)
+	threshold := randInt(w.rng, 10, 20)
+
+	return crdb.ExecuteTx(ctx, w.db, nil, func(tx *gosql.Tx) error {
+		var dNextOID int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT d_next_o_id FROM district WHERE d_w_id = $1 AND d_id = $2`,
+			wID, dID,
+		).Scan(&dNextOID); err != nil {
+			return err
+		}
+
+		// The items of the last 20 orders of the district are examined.
+		var lowStock int
+		return tx.QueryRowContext(ctx, `
+			SELECT count(DISTINCT s_i_id)
+			FROM order_line JOIN stock ON s_i_id = ol_i_id AND s_w_id = ol_w_id
+			WHERE ol_w_id = $1 AND ol_d_id = $2 AND ol_o_id >= $3 AND ol_o_id < $4
+				AND s_quantity < $5`,
+			wID, dID, dNextOID-20, dNextOID, threshold,
+		).Scan(&lowStock)
+	})
+}
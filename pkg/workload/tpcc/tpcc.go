@@ -0,0 +1,276 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package tpcc implements the TPC-C benchmark as a workload Generator. See
+// http://www.tpc.org/tpc_documents_current_versions/pdf/tpc-c_v5.11.0.pdf
+// for the spec, whose sections are referenced in the comments.
+package tpcc
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/codahale/hdrhistogram"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/cockroachdb/cockroach/pkg/workload"
+)
+
+type tpcc struct {
+	flags *pflag.FlagSet
+
+	seed       int64
+	warehouses int
+	doWaits    bool
+	mix        string
+
+	// txs are the transactions of the mix, with their weights.
+	txs []tx
+	// nextWorker is used to assign the warehouses to the workers.
+	nextWorker uint32
+}
+
+func init() {
+	workload.Register(tpccMeta)
+}
+
+var tpccMeta = workload.Meta{
+	Name: `tpcc`,
+	Description: `TPC-C simulates a transaction processing workload` +
+		` using a rich schema of multiple tables`,
+	Version: `1.0.0`,
+	New: func() workload.Generator {
+		g := &tpcc{flags: pflag.NewFlagSet(`tpcc`, pflag.ContinueOnError)}
+		g.flags.Int64Var(&g.seed, `seed`, 1, `Random number generator seed`)
+		g.flags.IntVar(&g.warehouses, `warehouses`, 1, `Number of warehouses for loading`)
+		g.flags.BoolVar(&g.doWaits, `wait`, true,
+			`Run in wait mode, with the keying and think times of the spec.`+
+				` A compliant run has 10 workers per warehouse.`)
+		g.flags.StringVar(&g.mix, `mix`,
+			`newOrder=10,payment=10,orderStatus=1,delivery=1,stockLevel=1`,
+			`Weights for the transaction mix. The default matches the TPC-C spec.`)
+		return g
+	},
+}
+
+// Meta implements the Generator interface.
+func (*tpcc) Meta() workload.Meta { return tpccMeta }
+
+// Flags implements the Generator interface.
+func (w *tpcc) Flags() *pflag.FlagSet {
+	return w.flags
+}
+
+// Configure implements the Generator interface.
+func (w *tpcc) Configure(flags []string) error {
+	if w.flags.Parsed() {
+		return errors.New("Configure was already called")
+	}
+	if err := w.flags.Parse(flags); err != nil {
+		return err
+	}
+	if w.warehouses < 1 {
+		return errors.Errorf(
+			"Value of 'warehouses' (%d) must be greater than or equal to 1", w.warehouses)
+	}
+	txs, err := parseMix(w.mix)
+	if err != nil {
+		return err
+	}
+	w.txs = txs
+	return nil
+}
+
+// parseMix parses the weights of the transactions of the --mix flag.
+func parseMix(mix string) ([]tx, error) {
+	var txs []tx
+	var total int
+	for _, weight := range strings.Split(mix, `,`) {
+		parts := strings.SplitN(weight, `=`, 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf(`invalid weight %q in mix: expected <tx>=<weight>`, weight)
+		}
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n < 0 {
+			return nil, errors.Errorf(
+				`invalid weight %q in mix: expected a non-negative integer`, weight)
+		}
+		t, ok := txByName(parts[0])
+		if !ok {
+			return nil, errors.Errorf(`unknown transaction %q in mix`, parts[0])
+		}
+		t.weight = n
+		total += n
+		txs = append(txs, t)
+	}
+	if total == 0 {
+		return nil, errors.New(`the mix has no transaction`)
+	}
+	return txs, nil
+}
+
+// Tables implements the Generator interface.
+func (w *tpcc) Tables() []workload.Table {
+	// Every table has its own random number generator, so that its data
+	// does not depend on the other tables.
+	rng := func(table int64) *rand.Rand {
+		return rand.New(rand.NewSource(w.seed + table))
+	}
+	warehouseRNG, districtRNG, customerRNG := rng(0), rng(1), rng(2)
+	historyRNG, orderRNG, itemRNG, stockRNG, orderLineRNG := rng(3), rng(4), rng(5), rng(6), rng(7)
+	customers := &orderCustomers{seed: w.seed}
+
+	return []workload.Table{
+		{
+			Name:            `warehouse`,
+			Schema:          tpccWarehouseSchema,
+			InitialRowCount: w.warehouses,
+			InitialRowFn: func(rowIdx int) []string {
+				return w.tpccWarehouseInitialRow(warehouseRNG, rowIdx)
+			},
+		},
+		{
+			Name:            `district`,
+			Schema:          tpccDistrictSchema,
+			InitialRowCount: numDistrictsPerWarehouse * w.warehouses,
+			InitialRowFn: func(rowIdx int) []string {
+				return w.tpccDistrictInitialRow(districtRNG, rowIdx)
+			},
+		},
+		{
+			Name:            `customer`,
+			Schema:          tpccCustomerSchema,
+			InitialRowCount: numCustomersPerWarehouse * w.warehouses,
+			InitialRowFn: func(rowIdx int) []string {
+				return w.tpccCustomerInitialRow(customerRNG, rowIdx)
+			},
+		},
+		{
+			Name:            `history`,
+			Schema:          tpccHistorySchema,
+			InitialRowCount: numHistoryPerWarehouse * w.warehouses,
+			InitialRowFn: func(rowIdx int) []string {
+				return w.tpccHistoryInitialRow(historyRNG, rowIdx)
+			},
+		},
+		{
+			Name:            `"order"`,
+			Schema:          tpccOrderSchema,
+			InitialRowCount: numOrdersPerWarehouse * w.warehouses,
+			InitialRowFn: func(rowIdx int) []string {
+				return w.tpccOrderInitialRow(orderRNG, customers, rowIdx)
+			},
+		},
+		{
+			Name:            `new_order`,
+			Schema:          tpccNewOrderSchema,
+			InitialRowCount: numNewOrdersPerWarehouse * w.warehouses,
+			InitialRowFn:    w.tpccNewOrderInitialRow,
+		},
+		{
+			Name:            `item`,
+			Schema:          tpccItemSchema,
+			InitialRowCount: numItems,
+			InitialRowFn: func(rowIdx int) []string {
+				return w.tpccItemInitialRow(itemRNG, rowIdx)
+			},
+		},
+		{
+			Name:            `stock`,
+			Schema:          tpccStockSchema,
+			InitialRowCount: numStockPerWarehouse * w.warehouses,
+			InitialRowFn: func(rowIdx int) []string {
+				return w.tpccStockInitialRow(stockRNG, rowIdx)
+			},
+		},
+		{
+			Name:            `order_line`,
+			Schema:          tpccOrderLineSchema,
+			InitialRowCount: numOrderLinesPerWarehouse * w.warehouses,
+			InitialRowFn: func(rowIdx int) []string {
+				return w.tpccOrderLineInitialRow(orderLineRNG, rowIdx)
+			},
+		},
+	}
+}
+
+// Ops implements the Generator interface.
+func (w *tpcc) Ops() []workload.Operation {
+	op := workload.Operation{
+		Name: `tpcc`,
+		Fn: func(db *gosql.DB, hists *workload.Histograms) (func(context.Context) error, error) {
+			// The workers are assigned to the warehouses in a round-robin
+			// fashion, as the terminals of section 4.2.2.
+			idx := atomic.AddUint32(&w.nextWorker, 1) - 1
+			return newWorker(w, db, hists, int(idx)).run, nil
+		},
+	}
+	return []workload.Operation{op}
+}
+
+// Hooks implements the Hookser interface.
+func (w *tpcc) Hooks() workload.Hooks {
+	return workload.Hooks{
+		CheckConsistency: checkConsistency,
+		PostRun:          w.postRun,
+	}
+}
+
+// maxTpmCPerWarehouse is the maximum throughput of new orders of a
+// warehouse, given the keying and think times of the spec.
+const maxTpmCPerWarehouse = 12.86
+
+// postRun reports the throughput of new orders (tpmC) and the efficiency
+// of the run, which is the fraction of the maximum throughput of the
+// warehouses which was achieved, as well as the latencies of each
+// transaction.
+func (w *tpcc) postRun(elapsed time.Duration, hists map[string]*hdrhistogram.Histogram) error {
+	var newOrders int64
+	if h, ok := hists[newOrderName]; ok {
+		newOrders = h.TotalCount()
+	}
+	tpmC := float64(newOrders) / elapsed.Minutes()
+	efficiency := 100 * tpmC / (maxTpmCPerWarehouse * float64(w.warehouses))
+
+	fmt.Println("_elapsed_______tpmC____efc__avg(ms)__p50(ms)__p90(ms)__p95(ms)__p99(ms)_pMax(ms)")
+	for _, t := range w.txs {
+		h, ok := hists[t.name]
+		if !ok {
+			continue
+		}
+		var tpm, efc string
+		if t.name == newOrderName {
+			tpm = fmt.Sprintf("%.1f", tpmC)
+			efc = fmt.Sprintf("%.1f%%", efficiency)
+		}
+		fmt.Printf("%7.1fs %10s %6s %8.1f %8.1f %8.1f %8.1f %8.1f %8.1f %s\n",
+			elapsed.Seconds(), tpm, efc,
+			time.Duration(h.Mean()).Seconds()*1000,
+			time.Duration(h.ValueAtQuantile(50)).Seconds()*1000,
+			time.Duration(h.ValueAtQuantile(90)).Seconds()*1000,
+			time.Duration(h.ValueAtQuantile(95)).Seconds()*1000,
+			time.Duration(h.ValueAtQuantile(99)).Seconds()*1000,
+			time.Duration(h.ValueAtQuantile(100)).Seconds()*1000,
+			t.name,
+		)
+	}
+	return nil
+}
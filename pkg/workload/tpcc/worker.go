@@ -0,0 +1,208 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tpcc
+
+import (
+	"context"
+	gosql "database/sql"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/workload"
+)
+
+const (
+	newOrderName    = `newOrder`
+	paymentName     = `payment`
+	orderStatusName = `orderStatus`
+	deliveryName    = `delivery`
+	stockLevelName  = `stockLevel`
+)
+
+// tx is one of the five transactions of the benchmark.
+type tx struct {
+	name string
+	run  func(ctx context.Context, w *worker) error
+	// keyingTime is the time spent by the user to enter the input of the
+	// transaction, and thinkTime the mean of the time spent by the user
+	// before starting the next transaction, see section 5.2.5.
+	keyingTime, thinkTime time.Duration
+	// weight is the weight of the transaction in the mix.
+	weight int
+}
+
+var allTxs = []tx{
+	{
+		name: newOrderName, run: newOrder,
+		keyingTime: 18 * time.Second, thinkTime: 12 * time.Second,
+	},
+	{
+		name: paymentName, run: payment,
+		keyingTime: 3 * time.Second, thinkTime: 12 * time.Second,
+	},
+	{
+		name: orderStatusName, run: orderStatus,
+		keyingTime: 2 * time.Second, thinkTime: 10 * time.Second,
+	},
+	{
+		name: deliveryName, run: delivery,
+		keyingTime: 2 * time.Second, thinkTime: 5 * time.Second,
+	},
+	{
+		name: stockLevelName, run: stockLevel,
+		keyingTime: 2 * time.Second, thinkTime: 5 * time.Second,
+	},
+}
+
+func txByName(name string) (tx, bool) {
+	for _, t := range allTxs {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return tx{}, false
+}
+
+// worker emulates a terminal of a warehouse, which runs the transactions
+// of the mix.
+type worker struct {
+	config    *tpcc
+	db        *gosql.DB
+	hists     []*workload.NamedHistogram
+	rng       *rand.Rand
+	warehouse int
+	// deck holds the indexes of the transactions left to run in the
+	// current shuffled deck of the mix, see section 5.2.4.2.
+	deck []int
+}
+
+func newWorker(config *tpcc, db *gosql.DB, hists *workload.Histograms, idx int) *worker {
+	w := &worker{
+		config:    config,
+		db:        db,
+		rng:       rand.New(rand.NewSource(config.seed + int64(idx))),
+		warehouse: idx % config.warehouses,
+	}
+	for _, t := range config.txs {
+		w.hists = append(w.hists, hists.Get(t.name))
+	}
+	return w
+}
+
+// nextTx returns the index of the next transaction to run.
+func (w *worker) nextTx() int {
+	if len(w.deck) == 0 {
+		var cards []int
+		for i, t := range w.config.txs {
+			for j := 0; j < t.weight; j++ {
+				cards = append(cards, i)
+			}
+		}
+		for _, j := range w.rng.Perm(len(cards)) {
+			w.deck = append(w.deck, cards[j])
+		}
+	}
+	i := w.deck[0]
+	w.deck = w.deck[1:]
+	return i
+}
+
+func (w *worker) run(ctx context.Context) error {
+	i := w.nextTx()
+	t := w.config.txs[i]
+
+	if w.config.doWaits {
+		if err := sleep(ctx, t.keyingTime); err != nil {
+			return err
+		}
+	}
+
+	start := timeutil.Now()
+	if err := t.run(ctx, w); err != nil {
+		return errors.Wrapf(err, "error in %s", t.name)
+	}
+	w.hists[i].Record(timeutil.Since(start))
+
+	if w.config.doWaits {
+		// The think times have a negative exponential distribution, whose
+		// maximum is at least 10 times the mean, see section 5.2.5.4.
+		thinkTime := time.Duration(-math.Log(1-w.rng.Float64()) * float64(t.thinkTime))
+		if max := 10 * t.thinkTime; thinkTime > max {
+			thinkTime = max
+		}
+		return sleep(ctx, thinkTime)
+	}
+	return nil
+}
+
+// sleep waits for the given duration, unless the context is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// otherWarehouse returns a random warehouse other than the warehouse of
+// the worker, if there is more than one warehouse.
+func (w *worker) otherWarehouse() int {
+	if w.config.warehouses == 1 {
+		return w.warehouse
+	}
+	other := w.rng.Intn(w.config.warehouses - 1)
+	if other >= w.warehouse {
+		other++
+	}
+	return other
+}
+
+// customerByLastName returns the ID of the customer of a district with the
+// given last name who is in the middle of the customers with this name,
+// ordered by first name, see section 2.5.2.2.
+func customerByLastName(
+	ctx context.Context, tx *gosql.Tx, wID, dID int, last string,
+) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT c_id FROM customer
+		WHERE c_w_id = $1 AND c_d_id = $2 AND c_last = $3
+		ORDER BY c_first ASC`,
+		wID, dID, last)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, errors.Errorf(
+			"no customer with last name %s in district %d of warehouse %d", last, dID, wID)
+	}
+	return ids[(len(ids)-1)/2], nil
+}
@@ -24,7 +24,9 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/codahale/hdrhistogram"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 )
@@ -92,15 +94,38 @@ type Table struct {
 
 // Operation represents some SQL query workload performable on a database
 // initialized with the requisite tables.
-//
-// TODO(dan): Finish nailing down the invariants of Operation as more workloads
-// are ported to this framework. TPCC in particular should be informative.
 type Operation struct {
 	// Name is a name for the work performed by this Operation.
 	Name string
 	// Fn returns a function to be called once per unit of work to be done.
-	// Various generator tools use this to track progress.
-	Fn func(*gosql.DB) (func(context.Context) error, error)
+	// Various generator tools use this to track progress. Fn is called once
+	// per concurrent worker, so that the returned functions can keep
+	// per-worker state. The returned functions record the latencies of the
+	// work they perform in the given histograms.
+	Fn func(*gosql.DB, *Histograms) (func(context.Context) error, error)
+}
+
+// Hookser is implemented by the Generators which need to hook into the
+// workload tools.
+type Hookser interface {
+	// Hooks returns the hooks of the Generator.
+	Hooks() Hooks
+}
+
+// Hooks are optional functions called by the workload tools at various
+// stages. Any of them may be nil.
+type Hooks struct {
+	// PostLoad is called after the initial data is loaded into the tables,
+	// e.g. to add the constraints and indexes which would slow down the
+	// load.
+	PostLoad func(*gosql.DB) error
+	// CheckConsistency checks the invariants of the data, either after it
+	// is loaded or after the workload ran.
+	CheckConsistency func(context.Context, *gosql.DB) error
+	// PostRun is called at the end of a run with its duration, excluding
+	// the ramp-up period, and the cumulative latencies recorded in each
+	// histogram, to report the results specific to the Generator.
+	PostRun func(elapsed time.Duration, hists map[string]*hdrhistogram.Histogram) error
 }
 
 var registered = make(map[string]Meta)
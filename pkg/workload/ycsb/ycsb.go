@@ -0,0 +1,359 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ycsb implements the core workloads A to F of the Yahoo! Cloud
+// Serving Benchmark as a workload Generator. See
+// https://github.com/brianfrankcooper/YCSB/wiki/Core-Workloads.
+package ycsb
+
+import (
+	"bytes"
+	"context"
+	gosql "database/sql"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/workload"
+)
+
+const (
+	numFields   = 10
+	fieldLength = 100
+	// maxScanLength is the maximum number of rows of the scans of workload
+	// E, whose length is uniformly distributed.
+	maxScanLength = 100
+
+	readName            = `read`
+	updateName          = `update`
+	insertName          = `insert`
+	scanName            = `scan`
+	readModifyWriteName = `readModifyWrite`
+)
+
+// workloadMix is the mix of operations of a core workload, in percents,
+// and the distribution of the keys of the requests.
+type workloadMix struct {
+	read, update, insert, scan, readModifyWrite int
+	// latest is set when the requests favor the most recently inserted
+	// rows rather than a fixed set of popular rows.
+	latest bool
+}
+
+var workloads = map[string]workloadMix{
+	// Update heavy.
+	`A`: {read: 50, update: 50},
+	// Read mostly.
+	`B`: {read: 95, update: 5},
+	// Read only.
+	`C`: {read: 100},
+	// Read latest.
+	`D`: {read: 95, insert: 5, latest: true},
+	// Short ranges.
+	`E`: {scan: 95, insert: 5},
+	// Read-modify-write.
+	`F`: {read: 50, readModifyWrite: 50},
+}
+
+type ycsb struct {
+	flags *pflag.FlagSet
+
+	seed        int64
+	initialRows int
+	workload    string
+
+	mix workloadMix
+	// nextInsert is the index of the next row to insert.
+	nextInsert uint64
+}
+
+func init() {
+	workload.Register(ycsbMeta)
+}
+
+var ycsbMeta = workload.Meta{
+	Name:        `ycsb`,
+	Description: `YCSB is the Yahoo! Cloud Serving Benchmark`,
+	Version:     `1.0.0`,
+	New: func() workload.Generator {
+		g := &ycsb{flags: pflag.NewFlagSet(`ycsb`, pflag.ContinueOnError)}
+		g.flags.Int64Var(&g.seed, `seed`, 1, `Key hash seed.`)
+		g.flags.IntVar(&g.initialRows, `initial-rows`, 10000, `Initial number of rows to load.`)
+		g.flags.StringVar(&g.workload, `workload`, `B`, `Core workload to run, from A to F.`)
+		return g
+	},
+}
+
+// Meta implements the Generator interface.
+func (*ycsb) Meta() workload.Meta { return ycsbMeta }
+
+// Flags implements the Generator interface.
+func (g *ycsb) Flags() *pflag.FlagSet {
+	return g.flags
+}
+
+// Configure implements the Generator interface.
+func (g *ycsb) Configure(flags []string) error {
+	if g.flags.Parsed() {
+		return errors.New("Configure was already called")
+	}
+	if err := g.flags.Parse(flags); err != nil {
+		return err
+	}
+	mix, ok := workloads[strings.ToUpper(g.workload)]
+	if !ok {
+		return errors.Errorf("Unknown workload: %q", g.workload)
+	}
+	if g.initialRows < 1 {
+		return errors.Errorf(
+			"Value of 'initial-rows' (%d) must be greater than or equal to 1", g.initialRows)
+	}
+	g.mix = mix
+	g.nextInsert = uint64(g.initialRows)
+	return nil
+}
+
+// keyName returns the key of the row of the given index. The indexes are
+// hashed, so that the popular rows are spread across the table.
+func (g *ycsb) keyName(idx uint64) string {
+	h := fnv.New64()
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], idx)
+	binary.BigEndian.PutUint64(buf[8:], uint64(g.seed))
+	_, _ = h.Write(buf[:])
+	return fmt.Sprintf(`user%d`, h.Sum64())
+}
+
+func randField(rng *rand.Rand) string {
+	const letters = `abcdefghijklmnopqrstuvwxyz`
+	b := make([]byte, fieldLength)
+	for i := range b {
+		b[i] = letters[rng.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// Tables implements the Generator interface.
+func (g *ycsb) Tables() []workload.Table {
+	var schema bytes.Buffer
+	schema.WriteString(`(ycsb_key VARCHAR(255) PRIMARY KEY NOT NULL`)
+	for i := 0; i < numFields; i++ {
+		fmt.Fprintf(&schema, `, field%d TEXT`, i)
+	}
+	schema.WriteString(`)`)
+
+	rng := rand.New(rand.NewSource(g.seed))
+	table := workload.Table{
+		Name:            `usertable`,
+		Schema:          schema.String(),
+		InitialRowCount: g.initialRows,
+		InitialRowFn: func(rowIdx int) []string {
+			row := []string{`'` + g.keyName(uint64(rowIdx)) + `'`}
+			for i := 0; i < numFields; i++ {
+				row = append(row, `'`+randField(rng)+`'`)
+			}
+			return row
+		},
+	}
+	return []workload.Table{table}
+}
+
+// Ops implements the Generator interface.
+func (g *ycsb) Ops() []workload.Operation {
+	var workerIdx int64
+	opFn := func(db *gosql.DB, hists *workload.Histograms) (func(context.Context) error, error) {
+		var buf bytes.Buffer
+		buf.WriteString(`INSERT INTO usertable VALUES ($1`)
+		for i := 0; i < numFields; i++ {
+			fmt.Fprintf(&buf, `, $%d`, i+2)
+		}
+		buf.WriteString(`)`)
+		insertStmt, err := db.Prepare(buf.String())
+		if err != nil {
+			return nil, err
+		}
+		readStmt, err := db.Prepare(`SELECT * FROM usertable WHERE ycsb_key = $1`)
+		if err != nil {
+			return nil, err
+		}
+		scanStmt, err := db.Prepare(`SELECT * FROM usertable WHERE ycsb_key >= $1 LIMIT $2`)
+		if err != nil {
+			return nil, err
+		}
+		updateStmts := make([]*gosql.Stmt, numFields)
+		for i := range updateStmts {
+			if updateStmts[i], err = db.Prepare(fmt.Sprintf(
+				`UPDATE usertable SET field%d = $1 WHERE ycsb_key = $2`, i,
+			)); err != nil {
+				return nil, err
+			}
+		}
+
+		rng := rand.New(rand.NewSource(g.seed + atomic.AddInt64(&workerIdx, 1)))
+		zipf, err := newZipfGenerator(rng, atomic.LoadUint64(&g.nextInsert), defaultTheta)
+		if err != nil {
+			return nil, err
+		}
+		// Only the histograms of the operations of the mix are reported.
+		hist := func(name string, percent int) *workload.NamedHistogram {
+			if percent == 0 {
+				return nil
+			}
+			return hists.Get(name)
+		}
+		mix := g.mix
+		w := &ycsbWorker{
+			config:              g,
+			rng:                 rng,
+			zipf:                zipf,
+			insertStmt:          insertStmt,
+			readStmt:            readStmt,
+			scanStmt:            scanStmt,
+			updateStmts:         updateStmts,
+			readHist:            hist(readName, mix.read),
+			updateHist:          hist(updateName, mix.update),
+			insertHist:          hist(insertName, mix.insert),
+			scanHist:            hist(scanName, mix.scan),
+			readModifyWriteHist: hist(readModifyWriteName, mix.readModifyWrite),
+		}
+		return w.run, nil
+	}
+	return []workload.Operation{{
+		Name: fmt.Sprintf(`workload%s`, strings.ToUpper(g.workload)),
+		Fn:   opFn,
+	}}
+}
+
+type ycsbWorker struct {
+	config *ycsb
+	rng    *rand.Rand
+	zipf   *zipfGenerator
+
+	insertStmt, readStmt, scanStmt *gosql.Stmt
+	// updateStmts update each of the fields.
+	updateStmts []*gosql.Stmt
+
+	readHist, updateHist, insertHist, scanHist *workload.NamedHistogram
+	readModifyWriteHist                        *workload.NamedHistogram
+}
+
+// nextReadKey returns the key of a row to read or update. The rows
+// inserted by other workers may not be visible yet, so the row may not
+// exist.
+func (w *ycsbWorker) nextReadKey() string {
+	rows := atomic.LoadUint64(&w.config.nextInsert)
+	w.zipf.setItems(rows)
+	idx := w.zipf.next()
+	if w.config.mix.latest {
+		// The most popular rows are the most recently inserted ones.
+		idx = rows - 1 - idx
+	}
+	return w.config.keyName(idx)
+}
+
+func (w *ycsbWorker) run(ctx context.Context) error {
+	mix := w.config.mix
+	p := w.rng.Intn(100)
+	switch {
+	case p < mix.read:
+		return w.readRow(ctx, w.readHist)
+	case p < mix.read+mix.update:
+		return w.updateRow(ctx, w.updateHist)
+	case p < mix.read+mix.update+mix.insert:
+		return w.insertRow(ctx)
+	case p < mix.read+mix.update+mix.insert+mix.scan:
+		return w.scanRows(ctx)
+	default:
+		start := timeutil.Now()
+		if err := w.readRow(ctx, nil); err != nil {
+			return err
+		}
+		if err := w.updateRow(ctx, nil); err != nil {
+			return err
+		}
+		w.readModifyWriteHist.Record(timeutil.Since(start))
+		return nil
+	}
+}
+
+// readRow reads a row, and records its latency in the given histogram, if
+// any.
+func (w *ycsbWorker) readRow(ctx context.Context, hist *workload.NamedHistogram) error {
+	start := timeutil.Now()
+	rows, err := w.readStmt.QueryContext(ctx, w.nextReadKey())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if hist != nil {
+		hist.Record(timeutil.Since(start))
+	}
+	return nil
+}
+
+// updateRow updates a random field of a row, and records its latency in
+// the given histogram, if any.
+func (w *ycsbWorker) updateRow(ctx context.Context, hist *workload.NamedHistogram) error {
+	start := timeutil.Now()
+	stmt := w.updateStmts[w.rng.Intn(numFields)]
+	if _, err := stmt.ExecContext(ctx, randField(w.rng), w.nextReadKey()); err != nil {
+		return err
+	}
+	if hist != nil {
+		hist.Record(timeutil.Since(start))
+	}
+	return nil
+}
+
+func (w *ycsbWorker) insertRow(ctx context.Context) error {
+	idx := atomic.AddUint64(&w.config.nextInsert, 1) - 1
+	args := []interface{}{w.config.keyName(idx)}
+	for i := 0; i < numFields; i++ {
+		args = append(args, randField(w.rng))
+	}
+	start := timeutil.Now()
+	if _, err := w.insertStmt.ExecContext(ctx, args...); err != nil {
+		return err
+	}
+	w.insertHist.Record(timeutil.Since(start))
+	return nil
+}
+
+func (w *ycsbWorker) scanRows(ctx context.Context) error {
+	start := timeutil.Now()
+	rows, err := w.scanStmt.QueryContext(ctx, w.nextReadKey(), w.rng.Intn(maxScanLength)+1)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	w.scanHist.Record(timeutil.Since(start))
+	return nil
+}
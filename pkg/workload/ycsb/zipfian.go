@@ -0,0 +1,95 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ycsb
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/pkg/errors"
+)
+
+// defaultTheta is the skew of the zipfian distributions of YCSB.
+const defaultTheta = 0.99
+
+// zipfGenerator generates numbers in [0, n) with a zipfian distribution,
+// in which 0 is the most popular number, using the algorithm of "Quickly
+// Generating Billion-Record Synthetic Databases" by Gray et al. The number
+// of items n can grow, as the rows inserted by the workloads are added,
+// in which case the zeta constant is updated incrementally.
+type zipfGenerator struct {
+	rng   *rand.Rand
+	theta float64
+	alpha float64
+	zeta2 float64
+
+	n     uint64
+	zetaN float64
+	eta   float64
+}
+
+func newZipfGenerator(rng *rand.Rand, n uint64, theta float64) (*zipfGenerator, error) {
+	if n == 0 {
+		return nil, errors.New("zipfian distributions need at least one item")
+	}
+	if theta <= 0 || theta >= 1 {
+		return nil, errors.Errorf("the zipfian theta (%f) must be in (0, 1)", theta)
+	}
+	z := &zipfGenerator{
+		rng:   rng,
+		theta: theta,
+		alpha: 1 / (1 - theta),
+		zeta2: zeta(0, 2, theta, 0),
+	}
+	z.setItems(n)
+	return z, nil
+}
+
+// zeta returns the zeta constant of n items, given the constant of the
+// first from items.
+func zeta(from, n uint64, theta, initial float64) float64 {
+	sum := initial
+	for i := from; i < n; i++ {
+		sum += 1 / math.Pow(float64(i+1), theta)
+	}
+	return sum
+}
+
+// setItems sets the number of items, which can only grow.
+func (z *zipfGenerator) setItems(n uint64) {
+	if n <= z.n {
+		return
+	}
+	z.zetaN = zeta(z.n, n, z.theta, z.zetaN)
+	z.n = n
+	z.eta = (1 - math.Pow(2/float64(n), 1-z.theta)) / (1 - z.zeta2/z.zetaN)
+}
+
+// next returns a random number in [0, n).
+func (z *zipfGenerator) next() uint64 {
+	u := z.rng.Float64()
+	uz := u * z.zetaN
+	if uz < 1 {
+		return 0
+	}
+	if uz < 1+math.Pow(0.5, z.theta) {
+		return 1
+	}
+	v := uint64(float64(z.n) * math.Pow(z.eta*u-z.eta+1, z.alpha))
+	if v >= z.n {
+		v = z.n - 1
+	}
+	return v
+}
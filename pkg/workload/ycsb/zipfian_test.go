@@ -0,0 +1,81 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ycsb
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestZipfGenerator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := randutil.NewPseudoRand()
+	for _, n := range []uint64{1, 2, 10, 1000, 100000} {
+		t.Run(fmt.Sprintf("n=%d", n), func(t *testing.T) {
+			z, err := newZipfGenerator(rng, n, defaultTheta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			const samples = 100000
+			counts := make(map[uint64]int)
+			for i := 0; i < samples; i++ {
+				v := z.next()
+				if v >= n {
+					t.Fatalf("%d is out of range", v)
+				}
+				counts[v]++
+			}
+			if n < 10 {
+				return
+			}
+			// The probability of an item is proportional to 1/(rank^theta),
+			// so the first item is about twice as popular as the second.
+			ratio := float64(counts[0]) / float64(counts[1])
+			if expected := math.Pow(2, defaultTheta); math.Abs(ratio-expected) > 0.3 {
+				t.Errorf("expected the ratio of the first two items to be about %.2f, got %.2f",
+					expected, ratio)
+			}
+		})
+	}
+}
+
+func TestZipfGeneratorGrowth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := randutil.NewPseudoRand()
+	z, err := newZipfGenerator(rng, 100, defaultTheta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z.setItems(1000)
+	fresh, err := newZipfGenerator(rng, 1000, defaultTheta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(z.zetaN-fresh.zetaN) > 1e-9 || math.Abs(z.eta-fresh.eta) > 1e-9 {
+		t.Errorf("expected the incremental constants (%f, %f) to be (%f, %f)",
+			z.zetaN, z.eta, fresh.zetaN, fresh.eta)
+	}
+	// The number of items never shrinks.
+	z.setItems(10)
+	if z.n != 1000 {
+		t.Errorf("expected 1000 items, got %d", z.n)
+	}
+}